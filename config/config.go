@@ -1,12 +1,15 @@
 package config
 
 import (
+	"net/url"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
 
+	"github.com/realsangil/apimonitor/models"
 	"github.com/realsangil/apimonitor/pkg/rserrors"
 )
 
@@ -17,18 +20,70 @@ var (
 )
 
 type configure struct {
-	Environment string       `mapstructure:"environment"`
-	DB          dbConfigure  `mapstructure:"db"`
-	Logger      logConfigure `mapstructure:"logger"`
+	Environment  string                `mapstructure:"environment"`
+	Server       serverConfigure       `mapstructure:"server"`
+	DB           dbConfigure           `mapstructure:"db"`
+	Logger       logConfigure          `mapstructure:"logger"`
+	ResultWriter resultWriterConfigure `mapstructure:"resultWriter"`
+	Coordinator  coordinatorConfigure  `mapstructure:"coordinator"`
+	Scheduler    schedulerConfigure    `mapstructure:"scheduler"`
+	SMTP         smtpConfigure         `mapstructure:"smtp"`
+	Escalation   escalationConfigure   `mapstructure:"escalation"`
+	Rollup       rollupConfigure       `mapstructure:"rollup"`
+	Retention    retentionConfigure    `mapstructure:"retention"`
+	Tracing      tracingConfigure      `mapstructure:"tracing"`
+	Stream       streamConfigure       `mapstructure:"stream"`
+	StatusPage   statusPageConfigure   `mapstructure:"statusPage"`
+	Badge        badgeConfigure        `mapstructure:"badge"`
+	Report       reportConfigure       `mapstructure:"report"`
 }
 
 func (c *configure) Validate() error {
+	if err := c.Server.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
 	if err := c.Logger.Validate(); err != nil {
 		return errors.WithStack(err)
 	}
 	if err := c.DB.Validate(); err != nil {
 		return errors.WithStack(err)
 	}
+	if err := c.ResultWriter.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := c.Coordinator.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := c.Scheduler.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := c.SMTP.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := c.Escalation.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := c.Rollup.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := c.Retention.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := c.Tracing.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := c.Stream.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := c.StatusPage.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := c.Badge.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := c.Report.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
 	return nil
 }
 
@@ -41,6 +96,47 @@ func Init(configFilePath string) error {
 	viper.SetConfigName("server_config")
 	viper.SetDefault("environment", "development")
 	viper.SetDefault("logger.filepath", "./server.log")
+	viper.SetDefault("server.port", 1323)
+	viper.SetDefault("server.shutdownTimeout", 30)
+	viper.SetDefault("resultWriter.queueSize", 1000)
+	viper.SetDefault("resultWriter.batchSize", 100)
+	viper.SetDefault("resultWriter.flushInterval", "1s")
+	viper.SetDefault("resultWriter.maxRetries", 3)
+	viper.SetDefault("resultWriter.retryInterval", "500ms")
+	viper.SetDefault("coordinator.mode", "")
+	viper.SetDefault("coordinator.heartbeatInterval", "5s")
+	viper.SetDefault("coordinator.replicaTimeout", "15s")
+	viper.SetDefault("scheduler.catchUpPolicy", "once")
+	viper.SetDefault("smtp.port", 587)
+	viper.SetDefault("smtp.startTLS", "auto")
+	viper.SetDefault("smtp.timeout", "10s")
+	viper.SetDefault("escalation.pollInterval", "30s")
+	viper.SetDefault("rollup.interval", "5m")
+	viper.SetDefault("rollup.delay", "5m")
+	viper.SetDefault("retention.interval", "1h")
+	viper.SetDefault("retention.rawDays", 0)
+	viper.SetDefault("retention.failureDays", 0)
+	viper.SetDefault("retention.batchSize", 1000)
+	viper.SetDefault("retention.batchPause", "100ms")
+	viper.SetDefault("tracing.endpoint", "")
+	viper.SetDefault("tracing.serviceName", "apimonitor")
+	viper.SetDefault("tracing.batchSize", 100)
+	viper.SetDefault("tracing.queueSize", 2048)
+	viper.SetDefault("tracing.interval", "5s")
+	viper.SetDefault("tracing.timeout", "10s")
+	viper.SetDefault("stream.bufferSize", 64)
+	viper.SetDefault("stream.heartbeatInterval", "15s")
+	viper.SetDefault("statusPage.enabled", false)
+	viper.SetDefault("statusPage.title", "Status")
+	viper.SetDefault("statusPage.days", 90)
+	viper.SetDefault("statusPage.recentIncidentDays", 7)
+	viper.SetDefault("statusPage.cacheMaxAge", "1m")
+	viper.SetDefault("badge.cacheMaxAge", "5m")
+	viper.SetDefault("report.daily", false)
+	viper.SetDefault("report.weekly", false)
+	viper.SetDefault("report.interval", "15m")
+	viper.SetDefault("report.delay", "15m")
+	viper.SetDefault("report.limit", 5)
 	if err := viper.ReadInConfig(); err != nil {
 		return errors.WithStack(err)
 	}
@@ -57,6 +153,41 @@ func Init(configFilePath string) error {
 	return nil
 }
 
+type serverConfigure struct {
+	Port            uint   `mapstructure:"port"`
+	ShutdownTimeout int    `mapstructure:"shutdownTimeout"`
+	PublicURL       string `mapstructure:"publicUrl"`
+}
+
+func (c *serverConfigure) GetPort() uint {
+	return c.Port
+}
+
+// GetShutdownTimeout returns how long running tests and requests are waited for on shutdown.
+func (c *serverConfigure) GetShutdownTimeout() time.Duration {
+	return time.Duration(c.ShutdownTimeout) * time.Second
+}
+
+// GetPublicURL returns the address the users open API Monitor at, which the links in alerts start with.
+func (c *serverConfigure) GetPublicURL() string {
+	return c.PublicURL
+}
+
+func (c *serverConfigure) Validate() error {
+	if c.Port == 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "server.port")
+	}
+	if c.ShutdownTimeout < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "server.shutdownTimeout")
+	}
+	if c.PublicURL != "" {
+		if u, err := url.Parse(c.PublicURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return errors.Wrap(rserrors.ErrInvalidParameter, "server.publicUrl")
+		}
+	}
+	return nil
+}
+
 type dbConfigure struct {
 	Host     string `mapstructure:"host"`
 	Name     string `mapstructure:"name"`
@@ -154,6 +285,478 @@ func (c *logConfigure) Validate() error {
 	return nil
 }
 
+type resultWriterConfigure struct {
+	QueueSize     int           `mapstructure:"queueSize"`
+	BatchSize     int           `mapstructure:"batchSize"`
+	FlushInterval time.Duration `mapstructure:"flushInterval"`
+	MaxRetries    int           `mapstructure:"maxRetries"`
+	RetryInterval time.Duration `mapstructure:"retryInterval"`
+	JournalPath   string        `mapstructure:"journalPath"`
+}
+
+func (c *resultWriterConfigure) GetQueueSize() int {
+	return c.QueueSize
+}
+
+func (c *resultWriterConfigure) GetBatchSize() int {
+	return c.BatchSize
+}
+
+func (c *resultWriterConfigure) GetFlushInterval() time.Duration {
+	return c.FlushInterval
+}
+
+func (c *resultWriterConfigure) GetMaxRetries() int {
+	return c.MaxRetries
+}
+
+func (c *resultWriterConfigure) GetRetryInterval() time.Duration {
+	return c.RetryInterval
+}
+
+func (c *resultWriterConfigure) GetJournalPath() string {
+	return c.JournalPath
+}
+
+func (c *resultWriterConfigure) Validate() error {
+	if c.QueueSize < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "resultWriter.queueSize")
+	}
+	if c.BatchSize < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "resultWriter.batchSize")
+	}
+	if c.MaxRetries < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "resultWriter.maxRetries")
+	}
+	return nil
+}
+
+type coordinatorConfigure struct {
+	Mode              string        `mapstructure:"mode"`
+	ReplicaId         string        `mapstructure:"replicaId"`
+	HeartbeatInterval time.Duration `mapstructure:"heartbeatInterval"`
+	ReplicaTimeout    time.Duration `mapstructure:"replicaTimeout"`
+}
+
+func (c *coordinatorConfigure) GetMode() string {
+	return c.Mode
+}
+
+func (c *coordinatorConfigure) GetReplicaId() string {
+	return c.ReplicaId
+}
+
+func (c *coordinatorConfigure) GetHeartbeatInterval() time.Duration {
+	return c.HeartbeatInterval
+}
+
+func (c *coordinatorConfigure) GetReplicaTimeout() time.Duration {
+	return c.ReplicaTimeout
+}
+
+func (c *coordinatorConfigure) Validate() error {
+	switch c.Mode {
+	case "", "leader", "shard":
+	default:
+		return errors.Wrap(rserrors.ErrInvalidParameter, "coordinator.mode")
+	}
+	if c.HeartbeatInterval < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "coordinator.heartbeatInterval")
+	}
+	if c.ReplicaTimeout < 0 || (c.ReplicaTimeout > 0 && c.ReplicaTimeout <= c.HeartbeatInterval) {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "coordinator.replicaTimeout")
+	}
+	return nil
+}
+
+type schedulerConfigure struct {
+	CatchUpPolicy string `mapstructure:"catchUpPolicy"`
+}
+
+func (c *schedulerConfigure) GetCatchUpPolicy() string {
+	return c.CatchUpPolicy
+}
+
+func (c *schedulerConfigure) Validate() error {
+	switch c.CatchUpPolicy {
+	case "", "once", "skip":
+	default:
+		return errors.Wrap(rserrors.ErrInvalidParameter, "scheduler.catchUpPolicy")
+	}
+	return nil
+}
+
+type smtpConfigure struct {
+	Host               string        `mapstructure:"host"`
+	Port               int           `mapstructure:"port"`
+	Username           string        `mapstructure:"username"`
+	Password           string        `mapstructure:"password"`
+	From               string        `mapstructure:"from"`
+	StartTLS           string        `mapstructure:"startTLS"`
+	InsecureSkipVerify bool          `mapstructure:"insecureSkipVerify"`
+	Timeout            time.Duration `mapstructure:"timeout"`
+}
+
+func (c *smtpConfigure) GetHost() string {
+	return c.Host
+}
+
+func (c *smtpConfigure) GetPort() int {
+	return c.Port
+}
+
+func (c *smtpConfigure) GetUsername() string {
+	return c.Username
+}
+
+func (c *smtpConfigure) GetPassword() string {
+	return c.Password
+}
+
+func (c *smtpConfigure) GetFrom() string {
+	return c.From
+}
+
+func (c *smtpConfigure) GetStartTLS() string {
+	return c.StartTLS
+}
+
+func (c *smtpConfigure) GetInsecureSkipVerify() bool {
+	return c.InsecureSkipVerify
+}
+
+func (c *smtpConfigure) GetTimeout() time.Duration {
+	return c.Timeout
+}
+
+func (c *smtpConfigure) Validate() error {
+	if c.Host == "" {
+		return nil
+	}
+	if c.Port <= 0 || c.Port > 65535 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "smtp.port")
+	}
+	if c.From == "" {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "smtp.from")
+	}
+	switch c.StartTLS {
+	case "", "auto", "always", "never":
+	default:
+		return errors.Wrap(rserrors.ErrInvalidParameter, "smtp.startTLS")
+	}
+	if c.Timeout < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "smtp.timeout")
+	}
+	return nil
+}
+
 func GetServerConfig() configure {
 	return c
 }
+
+type escalationConfigure struct {
+	PollInterval time.Duration `mapstructure:"pollInterval"`
+}
+
+func (c *escalationConfigure) GetPollInterval() time.Duration {
+	return c.PollInterval
+}
+
+func (c *escalationConfigure) Validate() error {
+	if c.PollInterval < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "escalation.pollInterval")
+	}
+	return nil
+}
+
+type rollupConfigure struct {
+	Interval time.Duration `mapstructure:"interval"`
+	Delay    time.Duration `mapstructure:"delay"`
+}
+
+func (c *rollupConfigure) GetInterval() time.Duration {
+	return c.Interval
+}
+
+// GetDelay returns how long a period is waited for the results still being written before it is rolled up.
+func (c *rollupConfigure) GetDelay() time.Duration {
+	return c.Delay
+}
+
+func (c *rollupConfigure) Validate() error {
+	if c.Interval < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "rollup.interval")
+	}
+	if c.Delay < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "rollup.delay")
+	}
+	return nil
+}
+
+type retentionConfigure struct {
+	Interval    time.Duration `mapstructure:"interval"`
+	RawDays     int           `mapstructure:"rawDays"`
+	FailureDays int           `mapstructure:"failureDays"`
+	BatchSize   int           `mapstructure:"batchSize"`
+	BatchPause  time.Duration `mapstructure:"batchPause"`
+}
+
+func (c *retentionConfigure) GetInterval() time.Duration {
+	return c.Interval
+}
+
+// GetRawDays and GetFailureDays return the retention of the web services without their own. Zero keeps the results forever.
+func (c *retentionConfigure) GetRawDays() int {
+	return c.RawDays
+}
+
+func (c *retentionConfigure) GetFailureDays() int {
+	return c.FailureDays
+}
+
+func (c *retentionConfigure) GetBatchSize() int {
+	return c.BatchSize
+}
+
+// GetBatchPause returns how long the purge waits between two batches, to let the writes to test_results through.
+func (c *retentionConfigure) GetBatchPause() time.Duration {
+	return c.BatchPause
+}
+
+func (c *retentionConfigure) Validate() error {
+	if c.Interval < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "retention.interval")
+	}
+	if c.RawDays < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "retention.rawDays")
+	}
+	if c.FailureDays < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "retention.failureDays")
+	}
+	if c.BatchSize < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "retention.batchSize")
+	}
+	if c.BatchPause < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "retention.batchPause")
+	}
+	return nil
+}
+
+type tracingConfigure struct {
+	Endpoint    string            `mapstructure:"endpoint"`
+	ServiceName string            `mapstructure:"serviceName"`
+	Headers     map[string]string `mapstructure:"headers"`
+	BatchSize   int               `mapstructure:"batchSize"`
+	QueueSize   int               `mapstructure:"queueSize"`
+	Interval    time.Duration     `mapstructure:"interval"`
+	Timeout     time.Duration     `mapstructure:"timeout"`
+}
+
+// GetEndpoint returns the OTLP/HTTP traces endpoint of the collector, or empty to disable the tracing.
+func (c *tracingConfigure) GetEndpoint() string {
+	return c.Endpoint
+}
+
+func (c *tracingConfigure) GetServiceName() string {
+	return c.ServiceName
+}
+
+func (c *tracingConfigure) GetHeaders() map[string]string {
+	return c.Headers
+}
+
+func (c *tracingConfigure) GetBatchSize() int {
+	return c.BatchSize
+}
+
+// GetQueueSize returns how many spans wait to be exported before the new ones are dropped.
+func (c *tracingConfigure) GetQueueSize() int {
+	return c.QueueSize
+}
+
+func (c *tracingConfigure) GetInterval() time.Duration {
+	return c.Interval
+}
+
+func (c *tracingConfigure) GetTimeout() time.Duration {
+	return c.Timeout
+}
+
+func (c *tracingConfigure) Validate() error {
+	if c.Endpoint != "" {
+		endpoint, err := url.Parse(c.Endpoint)
+		if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			return errors.Wrap(rserrors.ErrInvalidParameter, "tracing.endpoint")
+		}
+	}
+	if c.BatchSize < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "tracing.batchSize")
+	}
+	if c.QueueSize < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "tracing.queueSize")
+	}
+	if c.Interval < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "tracing.interval")
+	}
+	if c.Timeout < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "tracing.timeout")
+	}
+	return nil
+}
+
+type streamConfigure struct {
+	BufferSize        int           `mapstructure:"bufferSize"`
+	HeartbeatInterval time.Duration `mapstructure:"heartbeatInterval"`
+}
+
+// GetBufferSize returns how many events may wait for a client of the live stream before it is dropped.
+func (c *streamConfigure) GetBufferSize() int {
+	return c.BufferSize
+}
+
+func (c *streamConfigure) GetHeartbeatInterval() time.Duration {
+	return c.HeartbeatInterval
+}
+
+func (c *streamConfigure) Validate() error {
+	if c.BufferSize < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "stream.bufferSize")
+	}
+	if c.HeartbeatInterval < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "stream.heartbeatInterval")
+	}
+	return nil
+}
+
+type statusPageComponentConfigure struct {
+	Name          string   `mapstructure:"name"`
+	WebServiceIds []string `mapstructure:"webServiceIds"`
+}
+
+type statusPageConfigure struct {
+	Enabled            bool                           `mapstructure:"enabled"`
+	Title              string                         `mapstructure:"title"`
+	Days               int                            `mapstructure:"days"`
+	RecentIncidentDays int                            `mapstructure:"recentIncidentDays"`
+	CacheMaxAge        time.Duration                  `mapstructure:"cacheMaxAge"`
+	Components         []statusPageComponentConfigure `mapstructure:"components"`
+}
+
+// IsEnabled returns whether /status and /status.json are served.
+func (c *statusPageConfigure) IsEnabled() bool {
+	return c.Enabled
+}
+
+func (c *statusPageConfigure) GetTitle() string {
+	return c.Title
+}
+
+// GetDays returns how many days of uptime bars are shown, today included.
+func (c *statusPageConfigure) GetDays() int {
+	return c.Days
+}
+
+// GetRecentIncidentDays returns how long the resolved incidents are listed.
+func (c *statusPageConfigure) GetRecentIncidentDays() int {
+	return c.RecentIncidentDays
+}
+
+// GetCacheMaxAge returns how long the status page is reused, by the server and by the clients.
+func (c *statusPageConfigure) GetCacheMaxAge() time.Duration {
+	return c.CacheMaxAge
+}
+
+func (c *statusPageConfigure) GetComponents() []models.StatusComponentDefinition {
+	components := make([]models.StatusComponentDefinition, 0, len(c.Components))
+	for _, component := range c.Components {
+		components = append(components, models.StatusComponentDefinition{
+			Name:          component.Name,
+			WebServiceIds: component.WebServiceIds,
+		})
+	}
+	return components
+}
+
+func (c *statusPageConfigure) Validate() error {
+	if c.Days < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "statusPage.days")
+	}
+	if c.RecentIncidentDays < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "statusPage.recentIncidentDays")
+	}
+	if c.CacheMaxAge < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "statusPage.cacheMaxAge")
+	}
+	for _, component := range c.Components {
+		if component.Name == "" || len(component.WebServiceIds) == 0 {
+			return errors.Wrap(rserrors.ErrInvalidParameter, "statusPage.components")
+		}
+	}
+	return nil
+}
+
+type badgeConfigure struct {
+	CacheMaxAge time.Duration `mapstructure:"cacheMaxAge"`
+}
+
+// GetCacheMaxAge returns how long the clients and the proxies may cache a badge.
+func (c *badgeConfigure) GetCacheMaxAge() time.Duration {
+	return c.CacheMaxAge
+}
+
+func (c *badgeConfigure) Validate() error {
+	if c.CacheMaxAge < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "badge.cacheMaxAge")
+	}
+	return nil
+}
+
+type reportConfigure struct {
+	Daily    bool          `mapstructure:"daily"`
+	Weekly   bool          `mapstructure:"weekly"`
+	Interval time.Duration `mapstructure:"interval"`
+	Delay    time.Duration `mapstructure:"delay"`
+	Limit    int           `mapstructure:"limit"`
+}
+
+// IsEnabled reports whether any report is generated.
+func (c *reportConfigure) IsEnabled() bool {
+	return c.Daily || c.Weekly
+}
+
+func (c *reportConfigure) GetPeriods() []models.ReportPeriod {
+	periods := make([]models.ReportPeriod, 0, 2)
+	if c.Daily {
+		periods = append(periods, models.ReportDaily)
+	}
+	if c.Weekly {
+		periods = append(periods, models.ReportWeekly)
+	}
+	return periods
+}
+
+func (c *reportConfigure) GetInterval() time.Duration {
+	return c.Interval
+}
+
+// GetDelay returns how long a period is waited for its results and rollups before it is reported.
+func (c *reportConfigure) GetDelay() time.Duration {
+	return c.Delay
+}
+
+func (c *reportConfigure) GetLimit() int {
+	return c.Limit
+}
+
+func (c *reportConfigure) Validate() error {
+	if c.Interval < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "report.interval")
+	}
+	if c.Delay < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "report.delay")
+	}
+	if c.Limit < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "report.limit")
+	}
+	return nil
+}
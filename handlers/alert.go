@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/middlewares"
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/services"
+)
+
+const (
+	AlertDeliveryIdParam = "deliveryId"
+)
+
+var _ AlertHandler = &AlertHandlerImpl{}
+
+type AlertHandler interface {
+	SendTestAlert(c echo.Context) error
+	GetDelivery(c echo.Context) error
+	GetDeliveryList(c echo.Context) error
+	ResendDelivery(c echo.Context) error
+}
+
+type AlertHandlerImpl struct {
+	alertService services.AlertService
+}
+
+func (handler *AlertHandlerImpl) SendTestAlert(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	var alert models.Alert
+	if err := ctx.Bind(&alert); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	if err := handler.alertService.SendTestAlert(&alert); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, nil)
+}
+
+func (handler *AlertHandlerImpl) GetDelivery(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	delivery := &models.AlertDelivery{Id: ctx.Param(AlertDeliveryIdParam)}
+	if err := handler.alertService.GetDelivery(delivery); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, delivery)
+}
+
+// GetDeliveryList lists the deliveries of the test of the path, or of the test_id query if it has none.
+func (handler *AlertHandlerImpl) GetDeliveryList(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	page, err := ctx.QueryParamInt64("page", 1)
+	if err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	numItem, err := ctx.QueryParamInt64("num_item", 20)
+	if err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	testId := ctx.Param(TestIdParam)
+	if testId == "" {
+		testId = ctx.QueryParam("test_id")
+	}
+
+	list, aerr := handler.alertService.GetDeliveryList(models.AlertDeliveryListRequest{
+		Page:      int(page),
+		NumItem:   int(numItem),
+		TestId:    testId,
+		IsSuccess: models.IsSuccess(ctx.QueryParam("is_success")),
+	})
+	if aerr != nil {
+		return aerr.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, list)
+}
+
+func (handler *AlertHandlerImpl) ResendDelivery(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	delivery := &models.AlertDelivery{Id: ctx.Param(AlertDeliveryIdParam)}
+	resend, aerr := handler.alertService.ResendDelivery(delivery)
+	if aerr != nil {
+		return aerr.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, resend)
+}
+
+func NewAlertHandler(alertService services.AlertService) (AlertHandler, error) {
+	if rsvalid.IsZero(alertService) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "AlertHandler")
+	}
+	return &AlertHandlerImpl{
+		alertService: alertService,
+	}, nil
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/middlewares"
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rsbadge"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/services"
+)
+
+const (
+	// BadgeFileParam is the file name of a badge, the id of its test or web service followed by badgeExtension.
+	BadgeFileParam = "file"
+
+	badgeExtension = ".svg"
+)
+
+var _ BadgeHandler = &BadgeHandlerImpl{}
+
+type BadgeHandler interface {
+	GetTestBadge(c echo.Context) error
+	GetWebServiceBadge(c echo.Context) error
+}
+
+type BadgeHandlerImpl struct {
+	webServiceService services.WebServiceService
+	testService       services.TestService
+	badgeService      services.BadgeService
+}
+
+// GetTestBadge renders the badge of the test of the type query, status, uptime or latency,
+// over the window query for the uptime and the latency. The label query, up to 64 characters, replaces the label.
+func (handler *BadgeHandlerImpl) GetTestBadge(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	testId, ok := badgeId(ctx)
+	if !ok {
+		return amerr.GetErrorsFromCode(amerr.ErrTestNotFound).GetErrFromLanguage(lang)
+	}
+	request, err := newBadgeRequest(ctx)
+	if err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	test := &models.Test{Id: testId}
+	if err := handler.testService.GetTestById(test); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	badge, aerr := handler.badgeService.GetTestBadge(test, request)
+	if aerr != nil {
+		return aerr.GetErrFromLanguage(lang)
+	}
+
+	return handler.render(ctx, badge)
+}
+
+// GetWebServiceBadge renders the badge of the web service, like GetTestBadge.
+func (handler *BadgeHandlerImpl) GetWebServiceBadge(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	webServiceId, ok := badgeId(ctx)
+	if !ok {
+		return amerr.GetErrorsFromCode(amerr.ErrWebServiceNotFound).GetErrFromLanguage(lang)
+	}
+	request, err := newBadgeRequest(ctx)
+	if err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	webService := &models.WebService{Id: webServiceId}
+	if err := handler.webServiceService.GetWebServiceById(webService); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	badge, aerr := handler.badgeService.GetWebServiceBadge(webService, request)
+	if aerr != nil {
+		return aerr.GetErrFromLanguage(lang)
+	}
+
+	return handler.render(ctx, badge)
+}
+
+// render writes the SVG of the badge with a strong ETag of its content,
+// or Not Modified if the client already has it.
+func (handler *BadgeHandlerImpl) render(ctx echo.Context, badge *models.Badge) error {
+	svg := badge.SVG()
+	sum := sha1.Sum(svg)
+	etag := fmt.Sprintf(`"%s"`, hex.EncodeToString(sum[:]))
+
+	header := ctx.Response().Header()
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(handler.badgeService.CacheMaxAge()/time.Second)))
+	if matchesETag(ctx.Request().Header.Get("If-None-Match"), etag) {
+		return ctx.NoContent(http.StatusNotModified)
+	}
+	return ctx.Blob(http.StatusOK, rsbadge.ContentType, svg)
+}
+
+func badgeId(ctx echo.Context) (string, bool) {
+	file := ctx.Param(BadgeFileParam)
+	if !strings.HasSuffix(file, badgeExtension) {
+		return "", false
+	}
+	return strings.TrimSuffix(file, badgeExtension), true
+}
+
+func newBadgeRequest(ctx echo.Context) (models.BadgeRequest, error) {
+	return models.NewBadgeRequest(ctx.QueryParam("type"), ctx.QueryParam("window"), ctx.QueryParam("label"), time.Now())
+}
+
+// matchesETag reports whether the If-None-Match header lists the ETag, or is '*'.
+// Like for any If-None-Match, a weak tag matches too.
+func matchesETag(ifNoneMatch, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func NewBadgeHandler(webServiceService services.WebServiceService, testService services.TestService, badgeService services.BadgeService) (BadgeHandler, error) {
+	if rsvalid.IsZero(webServiceService, testService, badgeService) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "BadgeHandler")
+	}
+	return &BadgeHandlerImpl{
+		webServiceService: webServiceService,
+		testService:       testService,
+		badgeService:      badgeService,
+	}, nil
+}
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/middlewares"
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/services"
+)
+
+const (
+	EscalationPolicyIdParam = "policyId"
+	EscalationIdParam       = "escalationId"
+)
+
+var _ EscalationHandler = &EscalationHandlerImpl{}
+
+type EscalationHandler interface {
+	CreatePolicy(c echo.Context) error
+	GetPolicy(c echo.Context) error
+	GetPolicyList(c echo.Context) error
+	UpdatePolicy(c echo.Context) error
+	DeletePolicy(c echo.Context) error
+	GetEscalation(c echo.Context) error
+	GetEscalationList(c echo.Context) error
+	Acknowledge(c echo.Context) error
+	AcknowledgeTest(c echo.Context) error
+}
+
+type EscalationHandlerImpl struct {
+	testService       services.TestService
+	escalationService services.EscalationService
+}
+
+func (handler *EscalationHandlerImpl) CreatePolicy(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	var request models.EscalationPolicyRequest
+	if err := ctx.Bind(&request); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	policy, aerr := handler.escalationService.CreatePolicy(request)
+	if aerr != nil {
+		return aerr.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, policy)
+}
+
+func (handler *EscalationHandlerImpl) GetPolicy(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	policy := &models.EscalationPolicy{Id: ctx.Param(EscalationPolicyIdParam)}
+	if err := handler.escalationService.GetPolicy(policy); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, policy)
+}
+
+func (handler *EscalationHandlerImpl) GetPolicyList(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	page, err := ctx.QueryParamInt64("page", 1)
+	if err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	numItem, err := ctx.QueryParamInt64("num_item", 20)
+	if err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	list, aerr := handler.escalationService.GetPolicyList(models.EscalationPolicyListRequest{
+		Page:    int(page),
+		NumItem: int(numItem),
+	})
+	if aerr != nil {
+		return aerr.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, list)
+}
+
+func (handler *EscalationHandlerImpl) UpdatePolicy(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	policy := &models.EscalationPolicy{Id: ctx.Param(EscalationPolicyIdParam)}
+
+	var request models.EscalationPolicyRequest
+	if err := ctx.Bind(&request); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	if err := handler.escalationService.UpdatePolicy(policy, request); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, policy)
+}
+
+func (handler *EscalationHandlerImpl) DeletePolicy(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	policy := &models.EscalationPolicy{Id: ctx.Param(EscalationPolicyIdParam)}
+	if err := handler.escalationService.DeletePolicy(policy); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, nil)
+}
+
+func (handler *EscalationHandlerImpl) GetEscalation(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	escalation := &models.Escalation{Id: ctx.Param(EscalationIdParam)}
+	if err := handler.escalationService.GetEscalation(escalation); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, escalation)
+}
+
+func (handler *EscalationHandlerImpl) GetEscalationList(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	page, err := ctx.QueryParamInt64("page", 1)
+	if err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	numItem, err := ctx.QueryParamInt64("num_item", 20)
+	if err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	testId := ctx.Param(TestIdParam)
+	if testId == "" {
+		testId = ctx.QueryParam("test_id")
+	}
+
+	list, aerr := handler.escalationService.GetEscalationList(models.EscalationListRequest{
+		Page:    int(page),
+		NumItem: int(numItem),
+		TestId:  testId,
+		IsOpen:  ctx.QueryParam("open") == "true",
+	})
+	if aerr != nil {
+		return aerr.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, list)
+}
+
+func (handler *EscalationHandlerImpl) Acknowledge(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	var request models.EscalationAcknowledgeRequest
+	if err := ctx.Bind(&request); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	escalation := &models.Escalation{Id: ctx.Param(EscalationIdParam)}
+	if err := handler.escalationService.Acknowledge(escalation, request); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, escalation)
+}
+
+// AcknowledgeTest acknowledges the current outage of the test, without looking up its escalation.
+func (handler *EscalationHandlerImpl) AcknowledgeTest(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	test := &models.Test{Id: ctx.Param(TestIdParam)}
+	if err := handler.testService.GetTestById(test); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	var request models.EscalationAcknowledgeRequest
+	if err := ctx.Bind(&request); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	escalation, aerr := handler.escalationService.AcknowledgeTest(test, request)
+	if aerr != nil {
+		return aerr.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, escalation)
+}
+
+func NewEscalationHandler(testService services.TestService, escalationService services.EscalationService) (EscalationHandler, error) {
+	if rsvalid.IsZero(testService, escalationService) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "EscalationHandler")
+	}
+	return &EscalationHandlerImpl{
+		testService:       testService,
+		escalationService: escalationService,
+	}, nil
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/middlewares"
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/services"
+)
+
+const (
+	IncidentIdParam = "incidentId"
+)
+
+var _ IncidentHandler = &IncidentHandlerImpl{}
+
+type IncidentHandler interface {
+	GetIncident(c echo.Context) error
+	GetIncidentList(c echo.Context) error
+	Acknowledge(c echo.Context) error
+	CreateNote(c echo.Context) error
+}
+
+type IncidentHandlerImpl struct {
+	incidentService services.IncidentService
+}
+
+func (handler *IncidentHandlerImpl) GetIncident(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	incident := &models.Incident{Id: ctx.Param(IncidentIdParam)}
+	if err := handler.incidentService.GetIncident(incident); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, incident)
+}
+
+func (handler *IncidentHandlerImpl) GetIncidentList(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	page, err := ctx.QueryParamInt64("page", 1)
+	if err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	numItem, err := ctx.QueryParamInt64("num_item", 20)
+	if err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	webServiceId := ctx.Param(WebServiceIdParam)
+	if webServiceId == "" {
+		webServiceId = ctx.QueryParam("web_service_id")
+	}
+
+	list, aerr := handler.incidentService.GetIncidentList(models.IncidentListRequest{
+		Page:         int(page),
+		NumItem:      int(numItem),
+		WebServiceId: webServiceId,
+		IsOpen:       ctx.QueryParam("open") == "true",
+	})
+	if aerr != nil {
+		return aerr.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, list)
+}
+
+func (handler *IncidentHandlerImpl) Acknowledge(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	var request models.IncidentAcknowledgeRequest
+	if err := ctx.Bind(&request); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	incident := &models.Incident{Id: ctx.Param(IncidentIdParam)}
+	if err := handler.incidentService.Acknowledge(incident, request); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, incident)
+}
+
+func (handler *IncidentHandlerImpl) CreateNote(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	var request models.IncidentNoteRequest
+	if err := ctx.Bind(&request); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	incident := &models.Incident{Id: ctx.Param(IncidentIdParam)}
+	note, aerr := handler.incidentService.CreateNote(incident, request)
+	if aerr != nil {
+		return aerr.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, note)
+}
+
+func NewIncidentHandler(incidentService services.IncidentService) (IncidentHandler, error) {
+	if rsvalid.IsZero(incidentService) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "IncidentHandler")
+	}
+	return &IncidentHandlerImpl{
+		incidentService: incidentService,
+	}, nil
+}
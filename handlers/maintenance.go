@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/middlewares"
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/services"
+)
+
+const (
+	MaintenanceIdParam = "maintenanceId"
+)
+
+var _ MaintenanceHandler = &MaintenanceHandlerImpl{}
+
+type MaintenanceHandler interface {
+	CreateMaintenance(c echo.Context) error
+	GetMaintenance(c echo.Context) error
+	GetMaintenanceList(c echo.Context) error
+	UpdateMaintenance(c echo.Context) error
+	DeleteMaintenance(c echo.Context) error
+}
+
+type MaintenanceHandlerImpl struct {
+	webServiceService  services.WebServiceService
+	maintenanceService services.MaintenanceService
+}
+
+func (handler *MaintenanceHandlerImpl) CreateMaintenance(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	webService := &models.WebService{Id: ctx.Param(WebServiceIdParam)}
+	if err := handler.webServiceService.GetWebServiceById(webService); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	var request models.MaintenanceRequest
+	if err := ctx.Bind(&request); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	maintenance, aerr := handler.maintenanceService.CreateMaintenance(webService, request)
+	if aerr != nil {
+		return aerr.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, maintenance)
+}
+
+func (handler *MaintenanceHandlerImpl) GetMaintenance(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	maintenance := &models.Maintenance{
+		Id:           ctx.Param(MaintenanceIdParam),
+		WebServiceId: ctx.Param(WebServiceIdParam),
+	}
+	if err := handler.maintenanceService.GetMaintenance(maintenance); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, maintenance)
+}
+
+func (handler *MaintenanceHandlerImpl) GetMaintenanceList(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	page, err := ctx.QueryParamInt64("page", 1)
+	if err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	numItem, err := ctx.QueryParamInt64("num_item", 20)
+	if err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	list, aerr := handler.maintenanceService.GetMaintenanceList(models.MaintenanceListRequest{
+		Page:         int(page),
+		NumItem:      int(numItem),
+		WebServiceId: ctx.Param(WebServiceIdParam),
+	})
+	if aerr != nil {
+		return aerr.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, list)
+}
+
+func (handler *MaintenanceHandlerImpl) UpdateMaintenance(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	maintenance := &models.Maintenance{
+		Id:           ctx.Param(MaintenanceIdParam),
+		WebServiceId: ctx.Param(WebServiceIdParam),
+	}
+
+	var request models.MaintenanceRequest
+	if err := ctx.Bind(&request); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	if err := handler.maintenanceService.UpdateMaintenance(maintenance, request); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, maintenance)
+}
+
+func (handler *MaintenanceHandlerImpl) DeleteMaintenance(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	maintenance := &models.Maintenance{
+		Id:           ctx.Param(MaintenanceIdParam),
+		WebServiceId: ctx.Param(WebServiceIdParam),
+	}
+	if err := handler.maintenanceService.DeleteMaintenance(maintenance); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, nil)
+}
+
+func NewMaintenanceHandler(webServiceService services.WebServiceService, maintenanceService services.MaintenanceService) (MaintenanceHandler, error) {
+	if rsvalid.IsZero(webServiceService, maintenanceService) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "MaintenanceHandler")
+	}
+	return &MaintenanceHandlerImpl{
+		webServiceService:  webServiceService,
+		maintenanceService: maintenanceService,
+	}, nil
+}
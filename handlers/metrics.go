@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/middlewares"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsmetrics"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/services"
+)
+
+type MetricsHandler interface {
+	GetMetrics(c echo.Context) error
+}
+
+type MetricsHandlerImpl struct {
+	metricsService services.MetricsService
+}
+
+// GetMetrics writes the metrics in the Prometheus text format, to be scraped.
+func (handler *MetricsHandlerImpl) GetMetrics(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	buf := &bytes.Buffer{}
+	if err := handler.metricsService.WriteMetrics(buf); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrInternalServer().GetErrFromLanguage(lang)
+	}
+
+	return ctx.Blob(http.StatusOK, rsmetrics.ContentType, buf.Bytes())
+}
+
+func NewMetricsHandler(metricsService services.MetricsService) (MetricsHandler, error) {
+	if rsvalid.IsZero(metricsService) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "MetricsHandler")
+	}
+	return &MetricsHandlerImpl{
+		metricsService: metricsService,
+	}, nil
+}
@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/middlewares"
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/services"
+)
+
+const (
+	NotificationChannelIdParam        = "channelId"
+	NotificationChannelBindingIdParam = "bindingId"
+)
+
+var _ NotificationChannelHandler = &NotificationChannelHandlerImpl{}
+
+type NotificationChannelHandler interface {
+	CreateChannel(c echo.Context) error
+	GetChannel(c echo.Context) error
+	GetChannelList(c echo.Context) error
+	UpdateChannel(c echo.Context) error
+	DeleteChannel(c echo.Context) error
+	CreateBinding(c echo.Context) error
+	GetBindingList(c echo.Context) error
+	UpdateBinding(c echo.Context) error
+	DeleteBinding(c echo.Context) error
+	GetTestChannels(c echo.Context) error
+}
+
+type NotificationChannelHandlerImpl struct {
+	webServiceService          services.WebServiceService
+	testService                services.TestService
+	notificationChannelService services.NotificationChannelService
+}
+
+func (handler *NotificationChannelHandlerImpl) CreateChannel(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	var request models.NotificationChannelRequest
+	if err := ctx.Bind(&request); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	channel, aerr := handler.notificationChannelService.CreateChannel(request)
+	if aerr != nil {
+		return aerr.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, channel)
+}
+
+func (handler *NotificationChannelHandlerImpl) GetChannel(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	channel := &models.NotificationChannel{Id: ctx.Param(NotificationChannelIdParam)}
+	if err := handler.notificationChannelService.GetChannel(channel); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, channel)
+}
+
+func (handler *NotificationChannelHandlerImpl) GetChannelList(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	page, err := ctx.QueryParamInt64("page", 1)
+	if err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	numItem, err := ctx.QueryParamInt64("num_item", 20)
+	if err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	list, aerr := handler.notificationChannelService.GetChannelList(models.NotificationChannelListRequest{
+		Page:    int(page),
+		NumItem: int(numItem),
+	})
+	if aerr != nil {
+		return aerr.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, list)
+}
+
+func (handler *NotificationChannelHandlerImpl) UpdateChannel(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	channel := &models.NotificationChannel{Id: ctx.Param(NotificationChannelIdParam)}
+
+	var request models.NotificationChannelRequest
+	if err := ctx.Bind(&request); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	if err := handler.notificationChannelService.UpdateChannel(channel, request); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, channel)
+}
+
+func (handler *NotificationChannelHandlerImpl) DeleteChannel(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	channel := &models.NotificationChannel{Id: ctx.Param(NotificationChannelIdParam)}
+	if err := handler.notificationChannelService.DeleteChannel(channel); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, nil)
+}
+
+// CreateBinding binds the channel globally, to a WebService or to a test, after checking that it exists.
+func (handler *NotificationChannelHandlerImpl) CreateBinding(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	channel := &models.NotificationChannel{Id: ctx.Param(NotificationChannelIdParam)}
+	if err := handler.notificationChannelService.GetChannel(channel); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	var request models.NotificationChannelBindingRequest
+	if err := ctx.Bind(&request); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	switch request.Scope {
+	case models.ChannelScopeWebService:
+		if err := handler.webServiceService.GetWebServiceById(&models.WebService{Id: request.ScopeId}); err != nil {
+			return err.GetErrFromLanguage(lang)
+		}
+	case models.ChannelScopeTest:
+		if err := handler.testService.GetTestById(&models.Test{Id: request.ScopeId}); err != nil {
+			return err.GetErrFromLanguage(lang)
+		}
+	}
+
+	binding, aerr := handler.notificationChannelService.CreateBinding(channel, request)
+	if aerr != nil {
+		return aerr.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, binding)
+}
+
+func (handler *NotificationChannelHandlerImpl) GetBindingList(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	page, err := ctx.QueryParamInt64("page", 1)
+	if err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	numItem, err := ctx.QueryParamInt64("num_item", 20)
+	if err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	list, aerr := handler.notificationChannelService.GetBindingList(models.NotificationChannelBindingListRequest{
+		Page:      int(page),
+		NumItem:   int(numItem),
+		ChannelId: ctx.Param(NotificationChannelIdParam),
+	})
+	if aerr != nil {
+		return aerr.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, list)
+}
+
+func (handler *NotificationChannelHandlerImpl) UpdateBinding(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	binding := &models.NotificationChannelBinding{
+		Id:        ctx.Param(NotificationChannelBindingIdParam),
+		ChannelId: ctx.Param(NotificationChannelIdParam),
+	}
+
+	var request models.NotificationChannelBindingRequest
+	if err := ctx.Bind(&request); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	if err := handler.notificationChannelService.UpdateBinding(binding, request); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, binding)
+}
+
+func (handler *NotificationChannelHandlerImpl) DeleteBinding(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	binding := &models.NotificationChannelBinding{
+		Id:        ctx.Param(NotificationChannelBindingIdParam),
+		ChannelId: ctx.Param(NotificationChannelIdParam),
+	}
+	if err := handler.notificationChannelService.DeleteBinding(binding); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, nil)
+}
+
+// GetTestChannels returns the bindings, with their channels, the test is alerted through.
+func (handler *NotificationChannelHandlerImpl) GetTestChannels(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	test := &models.Test{Id: ctx.Param(TestIdParam)}
+	if err := handler.testService.GetTestById(test); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	bindings, aerr := handler.notificationChannelService.GetEffectiveBindings(test)
+	if aerr != nil {
+		return aerr.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, bindings)
+}
+
+func NewNotificationChannelHandler(
+	webServiceService services.WebServiceService,
+	testService services.TestService,
+	notificationChannelService services.NotificationChannelService,
+) (NotificationChannelHandler, error) {
+	if rsvalid.IsZero(webServiceService, testService, notificationChannelService) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "NotificationChannelHandler")
+	}
+	return &NotificationChannelHandlerImpl{
+		webServiceService:          webServiceService,
+		testService:                testService,
+		notificationChannelService: notificationChannelService,
+	}, nil
+}
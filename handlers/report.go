@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/middlewares"
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/services"
+)
+
+const (
+	ReportIdParam = "reportId"
+)
+
+var _ ReportHandler = &ReportHandlerImpl{}
+
+type ReportHandler interface {
+	GetReport(c echo.Context) error
+	GetReportHTML(c echo.Context) error
+	GetReportList(c echo.Context) error
+}
+
+type ReportHandlerImpl struct {
+	reportService services.ReportService
+}
+
+func (handler *ReportHandlerImpl) GetReport(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	report := &models.Report{Id: ctx.Param(ReportIdParam)}
+	if err := handler.reportService.GetReport(report); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, report)
+}
+
+// GetReportHTML renders the report as it was sent by email.
+func (handler *ReportHandlerImpl) GetReportHTML(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	report := &models.Report{Id: ctx.Param(ReportIdParam)}
+	if err := handler.reportService.GetReport(report); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	html, err := report.HTML("")
+	if err != nil {
+		rslog.Error(err)
+		return amerr.GetErrInternalServer().GetErrFromLanguage(lang)
+	}
+
+	return ctx.HTML(http.StatusOK, html)
+}
+
+func (handler *ReportHandlerImpl) GetReportList(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	page, err := ctx.QueryParamInt64("page", 1)
+	if err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	numItem, err := ctx.QueryParamInt64("num_item", 20)
+	if err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	period := models.ReportPeriod(ctx.QueryParam("period"))
+	if period != "" {
+		if err := period.Validate(); err != nil {
+			rslog.Error(err)
+			return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+		}
+	}
+
+	webServiceId := ctx.Param(WebServiceIdParam)
+	if webServiceId == "" {
+		webServiceId = ctx.QueryParam("web_service_id")
+	}
+
+	list, aerr := handler.reportService.GetReportList(models.ReportListRequest{
+		Page:         int(page),
+		NumItem:      int(numItem),
+		WebServiceId: webServiceId,
+		Period:       period,
+	})
+	if aerr != nil {
+		return aerr.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, list)
+}
+
+func NewReportHandler(reportService services.ReportService) (ReportHandler, error) {
+	if rsvalid.IsZero(reportService) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "ReportHandler")
+	}
+	return &ReportHandlerImpl{
+		reportService: reportService,
+	}, nil
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/middlewares"
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/services"
+)
+
+type RetentionHandler interface {
+	DryRunPurge(c echo.Context) error
+}
+
+type RetentionHandlerImpl struct {
+	webServiceService services.WebServiceService
+	resultPurger      services.ResultPurger
+}
+
+// DryRunPurge reports what the purge would delete, of the web service in the path or the web_service_id query,
+// or of every web service.
+func (handler *RetentionHandlerImpl) DryRunPurge(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	webServiceId := ctx.Param(WebServiceIdParam)
+	if webServiceId == "" {
+		webServiceId = ctx.QueryParam("web_service_id")
+	}
+
+	var webService *models.WebService
+	if webServiceId != "" {
+		webService = &models.WebService{Id: webServiceId}
+		if err := handler.webServiceService.GetWebServiceById(webService); err != nil {
+			return err.GetErrFromLanguage(lang)
+		}
+	}
+
+	report, aerr := handler.resultPurger.DryRun(webService)
+	if aerr != nil {
+		return aerr.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, report)
+}
+
+func NewRetentionHandler(webServiceService services.WebServiceService, resultPurger services.ResultPurger) (RetentionHandler, error) {
+	if rsvalid.IsZero(webServiceService, resultPurger) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "RetentionHandler")
+	}
+	return &RetentionHandlerImpl{
+		webServiceService: webServiceService,
+		resultPurger:      resultPurger,
+	}, nil
+}
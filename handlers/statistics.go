@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/middlewares"
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/services"
+)
+
+var _ StatisticsHandler = &StatisticsHandlerImpl{}
+
+type StatisticsHandler interface {
+	GetTestLatency(c echo.Context) error
+}
+
+type StatisticsHandlerImpl struct {
+	testService       services.TestService
+	statisticsService services.StatisticsService
+}
+
+func (handler *StatisticsHandlerImpl) GetTestLatency(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	request, err := models.NewLatencyStatisticsRequest(
+		ctx.QueryParam("bucket"),
+		ctx.QueryParam("window"),
+		ctx.QueryParam("from"),
+		ctx.QueryParam("to"),
+		time.Now(),
+	)
+	if err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	test := &models.Test{Id: ctx.Param(TestIdParam)}
+	if err := handler.testService.GetTestById(test); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	statistics, aerr := handler.statisticsService.GetLatencyStatistics(test, request)
+	if aerr != nil {
+		return aerr.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, statistics)
+}
+
+func NewStatisticsHandler(testService services.TestService, statisticsService services.StatisticsService) (StatisticsHandler, error) {
+	if rsvalid.IsZero(testService, statisticsService) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "StatisticsHandler")
+	}
+	return &StatisticsHandlerImpl{
+		testService:       testService,
+		statisticsService: statisticsService,
+	}, nil
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/middlewares"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/services"
+)
+
+var statusPageTemplate = template.Must(template.New("status").Funcs(template.FuncMap{
+	"percent": func(ratio *float64) string {
+		if ratio == nil {
+			return "No data"
+		}
+		return fmt.Sprintf("%.2f%%", *ratio*100)
+	},
+	"barClass": func(ratio *float64) string {
+		switch {
+		case ratio == nil:
+			return "none"
+		case *ratio >= 0.999:
+			return "up"
+		case *ratio >= 0.95:
+			return "partial"
+		}
+		return "down"
+	},
+	"time": func(t time.Time) string {
+		return t.UTC().Format("2006-01-02 15:04 MST")
+	},
+	"duration": func(seconds int64) string {
+		return (time.Duration(seconds) * time.Second).String()
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; color: #24292e; max-width: 860px; margin: 0 auto; padding: 24px; }
+.overall { padding: 16px; border-radius: 6px; color: #fff; font-size: 1.2em; margin-bottom: 24px; }
+.component { border: 1px solid #e1e4e8; border-radius: 6px; padding: 16px; margin-bottom: 12px; }
+.component header { display: flex; justify-content: space-between; margin-bottom: 8px; }
+.bars { display: flex; gap: 2px; height: 32px; }
+.bars span { flex: 1; border-radius: 2px; }
+.legend { display: flex; justify-content: space-between; color: #6a737d; font-size: 0.8em; margin-top: 4px; }
+.operational, .up { background: #2ea44f; } .maintenance { background: #0366d6; }
+.degraded, .partial { background: #f9c513; } .outage, .down { background: #d73a49; } .none { background: #e1e4e8; }
+.status-operational { color: #2ea44f; } .status-maintenance { color: #0366d6; }
+.status-degraded { color: #b08800; } .status-outage { color: #d73a49; }
+li { margin-bottom: 8px; } footer { color: #6a737d; font-size: 0.8em; margin-top: 24px; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="overall {{.Status}}">{{if eq (print .Status) "operational"}}All systems operational{{else if eq (print .Status) "maintenance"}}Under maintenance{{else if eq (print .Status) "degraded"}}Degraded performance{{else}}Major outage{{end}}</div>
+{{range .Components}}
+<section class="component">
+<header><strong>{{.Name}}</strong><span class="status-{{.Status}}">{{.Status}}</span></header>
+<div class="bars">{{range .Days}}<span class="{{barClass .Uptime}}" title="{{.Date}}: {{percent .Uptime}}"></span>{{end}}</div>
+<div class="legend"><span>{{len .Days}} days ago</span><span>{{percent .Uptime}} uptime</span><span>Today</span></div>
+</section>
+{{end}}
+<h2>Ongoing incidents</h2>
+{{if .OngoingIncidents}}<ul>{{range .OngoingIncidents}}<li><strong>{{.Component}}</strong> since {{time .StartedAt}}{{if .Acknowledged}}, investigating{{end}}</li>{{end}}</ul>{{else}}<p>No ongoing incidents.</p>{{end}}
+<h2>Recent incidents</h2>
+{{if .RecentIncidents}}<ul>{{range .RecentIncidents}}<li><strong>{{.Component}}</strong> from {{time .StartedAt}}, resolved after {{duration .Duration}}</li>{{end}}</ul>{{else}}<p>No recent incidents.</p>{{end}}
+<footer>Updated {{time .GeneratedAt}}</footer>
+</body>
+</html>
+`))
+
+var _ StatusPageHandler = &StatusPageHandlerImpl{}
+
+type StatusPageHandler interface {
+	GetStatusPage(c echo.Context) error
+	GetStatusPageJson(c echo.Context) error
+}
+
+type StatusPageHandlerImpl struct {
+	statusPageService services.StatusPageService
+}
+
+// GetStatusPage renders the public status page.
+func (handler *StatusPageHandlerImpl) GetStatusPage(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	page, aerr := handler.statusPageService.GetStatusPage()
+	if aerr != nil {
+		return aerr.GetErrFromLanguage(lang)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := statusPageTemplate.Execute(buf, page); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrInternalServer().GetErrFromLanguage(lang)
+	}
+
+	handler.setCacheControl(ctx)
+	return ctx.HTMLBlob(http.StatusOK, buf.Bytes())
+}
+
+// GetStatusPageJson returns the public status page as a models.StatusPage.
+func (handler *StatusPageHandlerImpl) GetStatusPageJson(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	page, aerr := handler.statusPageService.GetStatusPage()
+	if aerr != nil {
+		return aerr.GetErrFromLanguage(lang)
+	}
+
+	handler.setCacheControl(ctx)
+	return ctx.JSON(http.StatusOK, page)
+}
+
+func (handler *StatusPageHandlerImpl) setCacheControl(ctx echo.Context) {
+	maxAge := int(handler.statusPageService.CacheMaxAge() / time.Second)
+	ctx.Response().Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", maxAge))
+}
+
+func NewStatusPageHandler(statusPageService services.StatusPageService) (StatusPageHandler, error) {
+	if rsvalid.IsZero(statusPageService) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "StatusPageHandler")
+	}
+	return &StatusPageHandlerImpl{
+		statusPageService: statusPageService,
+	}, nil
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/middlewares"
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/services"
+)
+
+const (
+	streamRetryMillis = 3000
+	// streamLaggedEvent tells a client it was dropped for lagging behind and should catch up before reconnecting.
+	streamLaggedEvent = "lagged"
+)
+
+var _ StreamHandler = &StreamHandlerImpl{}
+
+type StreamHandler interface {
+	StreamResults(c echo.Context) error
+}
+
+type StreamHandlerImpl struct {
+	webServiceService services.WebServiceService
+	testService       services.TestService
+	resultHub         services.ResultHub
+}
+
+// StreamResults pushes the new results and alert state changes as Server-Sent Events,
+// of the web service of the web_service_id query and of the test of the test_id query if given.
+// Each event is named after its type, with the JSON of the models.StreamEvent as data.
+//
+// The events are those of the tests executed by the replica serving the stream, see services.ResultHub:
+// with several replicas, the results of the tests owned by the others are not streamed.
+func (handler *StreamHandlerImpl) StreamResults(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	filter := models.StreamFilter{
+		WebServiceId: ctx.QueryParam("web_service_id"),
+		TestId:       ctx.QueryParam("test_id"),
+	}
+	if filter.WebServiceId != "" {
+		if err := handler.webServiceService.GetWebServiceById(&models.WebService{Id: filter.WebServiceId}); err != nil {
+			return err.GetErrFromLanguage(lang)
+		}
+	}
+	if filter.TestId != "" {
+		if err := handler.testService.GetTestById(&models.Test{Id: filter.TestId}); err != nil {
+			return err.GetErrFromLanguage(lang)
+		}
+	}
+
+	subscription := handler.resultHub.Subscribe(filter)
+	defer subscription.Close()
+
+	res := ctx.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	// Disables the buffering of nginx, which would hold the events back.
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	if _, err := fmt.Fprintf(res, "retry: %d\n\n", streamRetryMillis); err != nil {
+		return nil
+	}
+	res.Flush()
+
+	heartbeat := time.NewTicker(handler.resultHub.HeartbeatInterval())
+	defer heartbeat.Stop()
+	for {
+		select {
+		case event, ok := <-subscription.Events():
+			if !ok {
+				if subscription.Lagged() {
+					_, _ = fmt.Fprintf(res, "event: %s\ndata: {}\n\n", streamLaggedEvent)
+					res.Flush()
+				}
+				return nil
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				rslog.Errorf("failed to marshal stream event: testId='%s', error='%v'", event.TestId, err)
+				continue
+			}
+			if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return nil
+			}
+			res.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case <-ctx.Request().Context().Done():
+			return nil
+		}
+	}
+}
+
+func NewStreamHandler(webServiceService services.WebServiceService, testService services.TestService, resultHub services.ResultHub) (StreamHandler, error) {
+	if rsvalid.IsZero(webServiceService, testService, resultHub) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "StreamHandler")
+	}
+	return &StreamHandlerImpl{
+		webServiceService: webServiceService,
+		testService:       testService,
+		resultHub:         resultHub,
+	}, nil
+}
@@ -28,6 +28,8 @@ type TestHandler interface {
 	GetTestList(c echo.Context) error
 	UpdateTest(c echo.Context) error
 	ExecuteTest(c echo.Context) error
+	RunTest(c echo.Context) error
+	DryRunTest(c echo.Context) error
 }
 
 type TestHandlerImpl struct {
@@ -176,6 +178,59 @@ func (handler *TestHandlerImpl) ExecuteTest(c echo.Context) error {
 	return ctx.JSON(http.StatusOK, nil)
 }
 
+func (handler *TestHandlerImpl) RunTest(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	test := &models.Test{Id: ctx.Param(TestIdParam)}
+	result, aerr := handler.testService.RunTest(test)
+	if aerr != nil {
+		return aerr.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (handler *TestHandlerImpl) DryRunTest(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	var request models.TestDryRunRequest
+	if err := ctx.Bind(&request); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	if err := request.Validate(); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	if rsvalid.IsZero(request.WebServiceId) {
+		return amerr.GetErrorsFromCode(amerr.ErrWebServiceNotFound).GetErrFromLanguage(lang)
+	}
+
+	webService := &models.WebService{Id: request.WebServiceId}
+	if err := handler.webServiceService.GetWebServiceById(webService); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	result, aerr := handler.testService.DryRunTest(webService, request.TestRequest)
+	if aerr != nil {
+		return aerr.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
 func NewTestHandler(webServiceService services.WebServiceService, testService services.TestService) (TestHandler, error) {
 	if rsvalid.IsZero(
 		webServiceService,
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
@@ -18,10 +20,13 @@ import (
 type TestResultHandler interface {
 	GetListByWebService(c echo.Context) error
 	GetListByTest(c echo.Context) error
+	ExportResults(c echo.Context) error
 }
 
 type TestResultHandlerImpl struct {
 	TestResultService services.TestResultService
+	webServiceService services.WebServiceService
+	testService       services.TestService
 }
 
 func (handler *TestResultHandlerImpl) GetListByWebService(c echo.Context) error {
@@ -86,9 +91,87 @@ func (handler *TestResultHandlerImpl) GetListByTest(c echo.Context) error {
 	return ctx.JSON(http.StatusOK, list)
 }
 
-func NewTestResultHandler(testResultService services.TestResultService) (TestResultHandler, error) {
-	if rsvalid.IsZero(testResultService) {
+// ExportResults downloads the results of the format query, csv or ndjson, filtered by the test_id, web_service_id
+// and is_success queries, and tested in the range of the window or the from and to queries like the uptime.
+// The results are written as they are read, so the export of a quarter is not held in memory.
+// An unknown test or web service is not found, instead of exporting nothing.
+func (handler *TestResultHandlerImpl) ExportResults(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	request, err := models.NewTestResultExportRequest(
+		ctx.QueryParam("format"),
+		ctx.QueryParam("test_id"),
+		ctx.QueryParam("web_service_id"),
+		ctx.QueryParam("is_success"),
+		ctx.QueryParam("window"),
+		ctx.QueryParam("from"),
+		ctx.QueryParam("to"),
+		time.Now(),
+	)
+	if err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	if request.TestId != "" {
+		if err := handler.testService.GetTestById(&models.Test{Id: request.TestId}); err != nil {
+			return err.GetErrFromLanguage(lang)
+		}
+	}
+	if request.WebServiceId != "" {
+		if err := handler.webServiceService.GetWebServiceById(&models.WebService{Id: request.WebServiceId}); err != nil {
+			return err.GetErrFromLanguage(lang)
+		}
+	}
+
+	res := ctx.Response()
+	w := &exportWriter{response: res, request: request}
+	if aerr := handler.TestResultService.ExportResults(request, w); aerr != nil {
+		if !res.Committed {
+			return aerr.GetErrFromLanguage(lang)
+		}
+		// The status is already sent, so a failure while streaming only cuts the export short.
+		rslog.Errorf("failed to export results: request='%+v'", request)
+		return nil
+	}
+	w.writeHeader()
+	return nil
+}
+
+// exportWriter sends the headers of an export with its first bytes, which are written once the results are read,
+// so that an export failing before, e.g. on a lost connection, is still answered with an error.
+type exportWriter struct {
+	response *echo.Response
+	request  models.TestResultExportRequest
+}
+
+func (w *exportWriter) Write(p []byte) (int, error) {
+	w.writeHeader()
+	return w.response.Write(p)
+}
+
+func (w *exportWriter) writeHeader() {
+	if w.response.Committed {
+		return
+	}
+	w.response.Header().Set(echo.HeaderContentType, w.request.Format.ContentType())
+	w.response.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", w.request.FileName()))
+	w.response.Header().Set("X-Accel-Buffering", "no")
+	w.response.WriteHeader(http.StatusOK)
+}
+
+func NewTestResultHandler(testResultService services.TestResultService, webServiceService services.WebServiceService, testService services.TestService) (TestResultHandler, error) {
+	if rsvalid.IsZero(testResultService, webServiceService, testService) {
 		return nil, rserrors.ErrInvalidParameter
 	}
-	return &TestResultHandlerImpl{TestResultService: testResultService}, nil
+	return &TestResultHandlerImpl{
+		TestResultService: testResultService,
+		webServiceService: webServiceService,
+		testService:       testService,
+	}, nil
 }
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/realsangil/apimonitor/middlewares"
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	repositoryMocks "github.com/realsangil/apimonitor/repositories/mocks"
+	"github.com/realsangil/apimonitor/services"
+	serviceMocks "github.com/realsangil/apimonitor/services/mocks"
+)
+
+func TestTestResultHandlerImpl_ExportResults(t *testing.T) {
+	tests := []struct {
+		name            string
+		query           string
+		testErr         *amerr.ErrorWithLanguage
+		scanErr         error
+		wantCode        int
+		wantDisposition bool
+		wantBody        string
+	}{
+		{
+			name:            "exported",
+			query:           "test_id=test&window=24h",
+			wantCode:        http.StatusOK,
+			wantDisposition: true,
+			wantBody:        "id,web_service_id,test_id,tested_at,is_success,status_code,response_time,in_maintenance,failed_assertions\nresult,web-service,test,2020-03-01T00:00:00Z,true,200,10,false,\n",
+		},
+		{
+			name:     "unknown test",
+			query:    "test_id=unknown&window=24h",
+			testErr:  amerr.GetErrorsFromCode(amerr.ErrTestNotFound),
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "query failed",
+			query:    "test_id=test&window=24h",
+			scanErr:  errors.New("connection lost"),
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testService := &serviceMocks.TestService{}
+			testService.On("GetTestById", mock.Anything).Return(tt.testErr)
+			testResultRepository := &repositoryMocks.TestResultRepository{}
+			testResultRepository.On("ScanExport", mock.Anything, mock.Anything, mock.Anything).Return(tt.scanErr).Run(func(args mock.Arguments) {
+				if tt.scanErr != nil {
+					return
+				}
+				fn := args.Get(2).(func(*models.TestResultExport) error)
+				_ = fn(&models.TestResultExport{
+					Id:           "result",
+					WebServiceId: "web-service",
+					TestId:       "test",
+					TestedAt:     time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC),
+					IsSuccess:    true,
+					StatusCode:   200,
+					ResponseTime: 10,
+				})
+			})
+
+			testResultService, err := services.NewTestResultService(testResultRepository)
+			if err != nil {
+				t.Fatal(err)
+			}
+			handler, err := NewTestResultHandler(testResultService, &serviceMocks.WebServiceService{}, testService)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			e := echo.New()
+			e.HTTPErrorHandler = middlewares.ErrorHandleMiddleware
+			e.GET("/v1/results/export", handler.ExportResults, middlewares.ReplaceContextMiddleware)
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/results/export?"+tt.query, nil))
+
+			assert.Equal(t, tt.wantCode, rec.Code, rec.Body.String())
+			assert.Equal(t, tt.wantDisposition, rec.Header().Get(echo.HeaderContentDisposition) != "")
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, rec.Body.String())
+			}
+			if tt.testErr != nil {
+				testResultRepository.AssertNotCalled(t, "ScanExport", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
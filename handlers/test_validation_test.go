@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/realsangil/apimonitor/middlewares"
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	repositoryMocks "github.com/realsangil/apimonitor/repositories/mocks"
+	"github.com/realsangil/apimonitor/services"
+	serviceMocks "github.com/realsangil/apimonitor/services/mocks"
+)
+
+type fakeScheduleManager struct {
+	services.ScheduleManager
+}
+
+func (fakeScheduleManager) AddSchedule(*models.Test) error { return nil }
+
+func TestTestHandlerImpl_CreateTest_Validation(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{
+			name:     "valid alert",
+			body:     `{"name": "health", "path": "/health", "method": "GET", "contentType": "application/json", "schedule": "1m", "alerts": [{"type": "webhook", "config": {"url": "https://hooks.example.com"}, "template": "{{.Status}}"}]}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "invalid alert template",
+			body:     `{"name": "health", "path": "/health", "method": "GET", "contentType": "application/json", "schedule": "1m", "alerts": [{"type": "webhook", "config": {"url": "https://hooks.example.com"}, "template": "{{.Status"}]}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid alert policy",
+			body:     `{"name": "health", "path": "/health", "method": "GET", "contentType": "application/json", "schedule": "1m", "alertPolicy": {"failureThreshold": -1}}`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webServiceService := &serviceMocks.WebServiceService{}
+			webServiceService.On("GetWebServiceById", mock.Anything).Return((*amerr.ErrorWithLanguage)(nil))
+			testRepository := &repositoryMocks.TestRepository{}
+			testRepository.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+			testService, err := services.NewTestService(testRepository, &fakeScheduleManager{}, &serviceMocks.IncidentRecorder{}, &repositoryMocks.NotificationChannelBindingRepository{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			handler, err := NewTestHandler(webServiceService, testService)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			e := echo.New()
+			e.HTTPErrorHandler = middlewares.ErrorHandleMiddleware
+			e.POST("/v1/web-services/:"+WebServiceIdParam+"/tests", handler.CreateTest, middlewares.ReplaceContextMiddleware)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/web-services/ws/tests", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantCode, rec.Code, rec.Body.String())
+			if tt.wantCode != http.StatusOK {
+				testRepository.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/middlewares"
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/services"
+)
+
+var _ UptimeHandler = &UptimeHandlerImpl{}
+
+type UptimeHandler interface {
+	GetWebServiceUptime(c echo.Context) error
+	GetTestUptime(c echo.Context) error
+}
+
+type UptimeHandlerImpl struct {
+	webServiceService services.WebServiceService
+	testService       services.TestService
+	uptimeService     services.UptimeService
+}
+
+func (handler *UptimeHandlerImpl) GetWebServiceUptime(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	request, err := models.NewUptimeRequest(ctx.QueryParam("window"), ctx.QueryParam("from"), ctx.QueryParam("to"), time.Now())
+	if err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	webService := &models.WebService{Id: ctx.Param(WebServiceIdParam)}
+	if err := handler.webServiceService.GetWebServiceById(webService); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	uptime, aerr := handler.uptimeService.GetWebServiceUptime(webService, request)
+	if aerr != nil {
+		return aerr.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, uptime)
+}
+
+func (handler *UptimeHandlerImpl) GetTestUptime(c echo.Context) error {
+	ctx, err := middlewares.ConvertToCustomContext(c)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	lang := ctx.Language()
+
+	request, err := models.NewUptimeRequest(ctx.QueryParam("window"), ctx.QueryParam("from"), ctx.QueryParam("to"), time.Now())
+	if err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest).GetErrFromLanguage(lang)
+	}
+
+	test := &models.Test{Id: ctx.Param(TestIdParam)}
+	if err := handler.testService.GetTestById(test); err != nil {
+		return err.GetErrFromLanguage(lang)
+	}
+
+	uptime, aerr := handler.uptimeService.GetTestUptime(test, request)
+	if aerr != nil {
+		return aerr.GetErrFromLanguage(lang)
+	}
+
+	return ctx.JSON(http.StatusOK, uptime)
+}
+
+func NewUptimeHandler(
+	webServiceService services.WebServiceService,
+	testService services.TestService,
+	uptimeService services.UptimeService,
+) (UptimeHandler, error) {
+	if rsvalid.IsZero(webServiceService, testService, uptimeService) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "UptimeHandler")
+	}
+	return &UptimeHandlerImpl{
+		webServiceService: webServiceService,
+		testService:       testService,
+		uptimeService:     uptimeService,
+	}, nil
+}
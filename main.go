@@ -1,7 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -12,6 +17,8 @@ import (
 	"github.com/realsangil/apimonitor/middlewares"
 	"github.com/realsangil/apimonitor/pkg/rsdb"
 	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsnotify"
+	"github.com/realsangil/apimonitor/pkg/rstrace"
 	"github.com/realsangil/apimonitor/repositories"
 	"github.com/realsangil/apimonitor/services"
 )
@@ -31,6 +38,13 @@ func main() {
 		rslog.Fatal(err)
 	}
 
+	if err := rsnotify.InitSMTP(&serverConfig.SMTP); err != nil {
+		rslog.Fatal(err)
+	}
+
+	tracer := services.NewTracer(&serverConfig.Tracing)
+	rstrace.SetDefaultTracer(tracer)
+
 	e := echo.New()
 	e.Use(
 		middlewares.ReplaceContextMiddleware,
@@ -46,16 +60,151 @@ func main() {
 	webServiceRepository := repositories.NewWebServiceRepository()
 	testRepository := repositories.NewTestRepository()
 	testResultRepository := repositories.NewTestResultRepository()
+	maintenanceRepository := repositories.NewMaintenanceRepository()
+	replicaRepository := repositories.NewReplicaRepository()
+	alertStateRepository := repositories.NewAlertStateRepository()
+	alertDeliveryRepository := repositories.NewAlertDeliveryRepository()
+	notificationChannelRepository := repositories.NewNotificationChannelRepository()
+	notificationChannelBindingRepository := repositories.NewNotificationChannelBindingRepository()
+	escalationPolicyRepository := repositories.NewEscalationPolicyRepository()
+	escalationRepository := repositories.NewEscalationRepository()
+	incidentRepository := repositories.NewIncidentRepository()
+	incidentTestRepository := repositories.NewIncidentTestRepository()
+	incidentNoteRepository := repositories.NewIncidentNoteRepository()
+	testResultRollupRepository := repositories.NewTestResultRollupRepository()
+	reportRepository := repositories.NewReportRepository()
 
 	if err := rsdb.CreateTables(
 		webServiceRepository,
 		testRepository,
 		testResultRepository,
+		maintenanceRepository,
+		replicaRepository,
+		alertStateRepository,
+		alertDeliveryRepository,
+		notificationChannelRepository,
+		notificationChannelBindingRepository,
+		escalationPolicyRepository,
+		escalationRepository,
+		incidentRepository,
+		incidentTestRepository,
+		incidentNoteRepository,
+		testResultRollupRepository,
+		reportRepository,
 	); err != nil {
 		rslog.Fatal(err)
 	}
 
-	testSchedulerManager, err := services.NewTestScheduleManager(testRepository, testResultRepository)
+	maintenanceService, err := services.NewMaintenanceService(maintenanceRepository)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+
+	resultWriter, err := services.NewTestResultWriter(testResultRepository, &serverConfig.ResultWriter)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+	go func() {
+		if err := resultWriter.Run(); err != nil {
+			rslog.Error(err)
+		}
+	}()
+
+	coordinator, err := services.NewReplicaCoordinator(replicaRepository, &serverConfig.Coordinator)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+	go func() {
+		if err := coordinator.Run(); err != nil {
+			rslog.Error(err)
+		}
+	}()
+
+	notificationChannelService, err := services.NewNotificationChannelService(notificationChannelRepository, notificationChannelBindingRepository)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+
+	escalator, err := services.NewTestEscalator(
+		escalationRepository,
+		escalationPolicyRepository,
+		notificationChannelRepository,
+		testRepository,
+		testResultRepository,
+		alertStateRepository,
+		alertDeliveryRepository,
+		coordinator,
+		&serverConfig.Server,
+		&serverConfig.Escalation,
+	)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+	go func() {
+		if err := escalator.Run(); err != nil {
+			rslog.Error(err)
+		}
+	}()
+
+	resultRoller, err := services.NewTestResultRoller(testResultRepository, testResultRollupRepository, coordinator, &serverConfig.Rollup)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+	go func() {
+		if err := resultRoller.Run(); err != nil {
+			rslog.Error(err)
+		}
+	}()
+
+	resultPurger, err := services.NewTestResultPurger(
+		webServiceRepository,
+		testResultRepository,
+		testResultRollupRepository,
+		coordinator,
+		&serverConfig.Retention,
+	)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+	go func() {
+		if err := resultPurger.Run(); err != nil {
+			rslog.Error(err)
+		}
+	}()
+
+	incidentService, err := services.NewIncidentService(incidentRepository, incidentTestRepository, incidentNoteRepository)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+
+	resultHub, err := services.NewResultStreamHub(&serverConfig.Stream)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+
+	alertManager, err := services.NewTestAlertManager(
+		alertStateRepository,
+		alertDeliveryRepository,
+		notificationChannelService,
+		escalator,
+		incidentService,
+		resultHub,
+		&serverConfig.Server,
+	)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+
+	testSchedulerManager, err := services.NewTestScheduleManager(
+		testRepository,
+		testResultRepository,
+		maintenanceService,
+		alertManager,
+		resultWriter,
+		resultHub,
+		coordinator,
+		&serverConfig.Scheduler,
+	)
 	if err != nil {
 		rslog.Fatal(err)
 	}
@@ -65,12 +214,12 @@ func main() {
 		}
 	}()
 
-	webServiceService, err := services.NewWebServiceService(webServiceRepository, testRepository, testSchedulerManager)
+	webServiceService, err := services.NewWebServiceService(webServiceRepository, testRepository, testSchedulerManager, notificationChannelBindingRepository)
 	if err != nil {
 		rslog.Fatal(err)
 	}
 
-	testService, err := services.NewTestService(testRepository, testSchedulerManager)
+	testService, err := services.NewTestService(testRepository, testSchedulerManager, incidentService, notificationChannelBindingRepository)
 	if err != nil {
 		rslog.Fatal(err)
 	}
@@ -90,11 +239,153 @@ func main() {
 		rslog.Fatal(err)
 	}
 
-	testResultHandler, err := handlers.NewTestResultHandler(testResultService)
+	testResultHandler, err := handlers.NewTestResultHandler(testResultService, webServiceService, testService)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+
+	maintenanceHandler, err := handlers.NewMaintenanceHandler(webServiceService, maintenanceService)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+
+	alertService, err := services.NewAlertService(alertDeliveryRepository, testRepository, notificationChannelService)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+
+	alertHandler, err := handlers.NewAlertHandler(alertService)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+
+	notificationChannelHandler, err := handlers.NewNotificationChannelHandler(webServiceService, testService, notificationChannelService)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+
+	escalationService, err := services.NewEscalationService(escalationRepository, escalationPolicyRepository, notificationChannelRepository)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+
+	escalationHandler, err := handlers.NewEscalationHandler(testService, escalationService)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+
+	incidentHandler, err := handlers.NewIncidentHandler(incidentService)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+
+	uptimeService, err := services.NewUptimeService(testResultRepository, testResultRollupRepository, incidentRepository, incidentTestRepository, maintenanceService)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+
+	uptimeHandler, err := handlers.NewUptimeHandler(webServiceService, testService, uptimeService)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+
+	statisticsService, err := services.NewStatisticsService(testResultRepository, testResultRollupRepository)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+
+	statisticsHandler, err := handlers.NewStatisticsHandler(testService, statisticsService)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+
+	retentionHandler, err := handlers.NewRetentionHandler(webServiceService, resultPurger)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+
+	streamHandler, err := handlers.NewStreamHandler(webServiceService, testService, resultHub)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+
+	badgeService, err := services.NewBadgeService(testRepository, alertStateRepository, maintenanceService, uptimeService, statisticsService, &serverConfig.Badge)
 	if err != nil {
 		rslog.Fatal(err)
 	}
 
+	badgeHandler, err := handlers.NewBadgeHandler(webServiceService, testService, badgeService)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+
+	reportGenerator, err := services.NewWebServiceReportGenerator(
+		webServiceRepository,
+		testRepository,
+		testResultRepository,
+		incidentRepository,
+		reportRepository,
+		notificationChannelBindingRepository,
+		uptimeService,
+		statisticsService,
+		coordinator,
+		&serverConfig.Server,
+		&serverConfig.Report,
+	)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+	go func() {
+		if err := reportGenerator.Run(); err != nil {
+			rslog.Error(err)
+		}
+	}()
+
+	reportService, err := services.NewReportService(reportRepository)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+
+	reportHandler, err := handlers.NewReportHandler(reportService)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+
+	metricsService, err := services.NewMetricsService(resultWriter)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+
+	metricsHandler, err := handlers.NewMetricsHandler(metricsService)
+	if err != nil {
+		rslog.Fatal(err)
+	}
+
+	e.GET("/metrics", metricsHandler.GetMetrics)
+
+	if serverConfig.StatusPage.IsEnabled() {
+		statusPageService, err := services.NewStatusPageService(
+			webServiceRepository,
+			testResultRepository,
+			testResultRollupRepository,
+			alertStateRepository,
+			incidentRepository,
+			maintenanceService,
+			&serverConfig.StatusPage,
+		)
+		if err != nil {
+			rslog.Fatal(err)
+		}
+
+		statusPageHandler, err := handlers.NewStatusPageHandler(statusPageService)
+		if err != nil {
+			rslog.Fatal(err)
+		}
+
+		e.GET("/status", statusPageHandler.GetStatusPage)
+		e.GET("/status.json", statusPageHandler.GetStatusPageJson)
+	}
+
 	v1 := e.Group("/v1")
 	{
 		v1WebService := v1.Group("/webservices")
@@ -109,24 +400,140 @@ func main() {
 				v1OneWebService.PUT("", webServiceHandler.UpdateWebServiceById)
 				v1OneWebService.GET("/results", testResultHandler.GetListByWebService)
 				v1OneWebService.GET("/execute", webServiceHandler.ExecuteTests)
+				v1OneWebService.GET("/incidents", incidentHandler.GetIncidentList)
+				v1OneWebService.GET("/reports", reportHandler.GetReportList)
+				v1OneWebService.GET("/uptime", uptimeHandler.GetWebServiceUptime)
+				v1OneWebService.GET("/retention/dry-run", retentionHandler.DryRunPurge)
 
 				v1Test := v1OneWebService.Group("/tests")
 				{
 					v1Test.POST("", testHandler.CreateTest)
 					v1Test.GET("", testHandler.GetTestList)
 				}
+
+				v1Maintenance := v1OneWebService.Group("/maintenances")
+				{
+					v1Maintenance.POST("", maintenanceHandler.CreateMaintenance)
+					v1Maintenance.GET("", maintenanceHandler.GetMaintenanceList)
+
+					v1OneMaintenance := v1Maintenance.Group(fmt.Sprintf("/:%s", handlers.MaintenanceIdParam))
+					{
+						v1OneMaintenance.GET("", maintenanceHandler.GetMaintenance)
+						v1OneMaintenance.PUT("", maintenanceHandler.UpdateMaintenance)
+						v1OneMaintenance.DELETE("", maintenanceHandler.DeleteMaintenance)
+					}
+				}
 			}
 		}
 
+		v1Channel := v1.Group("/channels")
+		{
+			v1Channel.POST("", notificationChannelHandler.CreateChannel)
+			v1Channel.GET("", notificationChannelHandler.GetChannelList)
+
+			v1OneChannel := v1Channel.Group(fmt.Sprintf("/:%s", handlers.NotificationChannelIdParam))
+			{
+				v1OneChannel.GET("", notificationChannelHandler.GetChannel)
+				v1OneChannel.PUT("", notificationChannelHandler.UpdateChannel)
+				v1OneChannel.DELETE("", notificationChannelHandler.DeleteChannel)
+				v1OneChannel.POST("/bindings", notificationChannelHandler.CreateBinding)
+				v1OneChannel.GET("/bindings", notificationChannelHandler.GetBindingList)
+				v1OneChannel.PUT(fmt.Sprintf("/bindings/:%s", handlers.NotificationChannelBindingIdParam), notificationChannelHandler.UpdateBinding)
+				v1OneChannel.DELETE(fmt.Sprintf("/bindings/:%s", handlers.NotificationChannelBindingIdParam), notificationChannelHandler.DeleteBinding)
+			}
+		}
+
+		v1EscalationPolicy := v1.Group("/escalation-policies")
+		{
+			v1EscalationPolicy.POST("", escalationHandler.CreatePolicy)
+			v1EscalationPolicy.GET("", escalationHandler.GetPolicyList)
+
+			v1OneEscalationPolicy := v1EscalationPolicy.Group(fmt.Sprintf("/:%s", handlers.EscalationPolicyIdParam))
+			{
+				v1OneEscalationPolicy.GET("", escalationHandler.GetPolicy)
+				v1OneEscalationPolicy.PUT("", escalationHandler.UpdatePolicy)
+				v1OneEscalationPolicy.DELETE("", escalationHandler.DeletePolicy)
+			}
+		}
+
+		v1.GET("/escalations", escalationHandler.GetEscalationList)
+		v1.GET(fmt.Sprintf("/escalations/:%s", handlers.EscalationIdParam), escalationHandler.GetEscalation)
+		v1.POST(fmt.Sprintf("/escalations/:%s/acknowledge", handlers.EscalationIdParam), escalationHandler.Acknowledge)
+
+		v1.GET("/incidents", incidentHandler.GetIncidentList)
+		v1.GET(fmt.Sprintf("/incidents/:%s", handlers.IncidentIdParam), incidentHandler.GetIncident)
+		v1.POST(fmt.Sprintf("/incidents/:%s/acknowledge", handlers.IncidentIdParam), incidentHandler.Acknowledge)
+		v1.POST(fmt.Sprintf("/incidents/:%s/notes", handlers.IncidentIdParam), incidentHandler.CreateNote)
+
+		v1.GET("/reports", reportHandler.GetReportList)
+		v1.GET(fmt.Sprintf("/reports/:%s", handlers.ReportIdParam), reportHandler.GetReport)
+		v1.GET(fmt.Sprintf("/reports/:%s/html", handlers.ReportIdParam), reportHandler.GetReportHTML)
+
+		v1.GET("/retention/dry-run", retentionHandler.DryRunPurge)
+
+		v1.GET("/stream/results", streamHandler.StreamResults)
+		v1.GET("/results/export", testResultHandler.ExportResults)
+
+		v1.GET(fmt.Sprintf("/badges/tests/:%s", handlers.BadgeFileParam), badgeHandler.GetTestBadge)
+		v1.GET(fmt.Sprintf("/badges/webservices/:%s", handlers.BadgeFileParam), badgeHandler.GetWebServiceBadge)
+
+		v1.POST("/tests/dry-run", testHandler.DryRunTest)
+		v1.POST("/alerts/test", alertHandler.SendTestAlert)
+		v1.GET("/alerts/deliveries", alertHandler.GetDeliveryList)
+		v1.GET(fmt.Sprintf("/alerts/deliveries/:%s", handlers.AlertDeliveryIdParam), alertHandler.GetDelivery)
+		v1.POST(fmt.Sprintf("/alerts/deliveries/:%s/resend", handlers.AlertDeliveryIdParam), alertHandler.ResendDelivery)
+
 		v1OneTest := v1.Group(fmt.Sprintf("/tests/:%s", handlers.TestIdParam))
 		{
 			v1OneTest.GET("", testHandler.GetTest)
 			v1OneTest.DELETE("", testHandler.DeleteTest)
 			v1OneTest.PUT("", testHandler.UpdateTest)
 			v1OneTest.GET("/execute", testHandler.ExecuteTest)
+			v1OneTest.POST("/run", testHandler.RunTest)
 			v1OneTest.GET("/results", testResultHandler.GetListByTest)
+			v1OneTest.GET("/deliveries", alertHandler.GetDeliveryList)
+			v1OneTest.GET("/channels", notificationChannelHandler.GetTestChannels)
+			v1OneTest.GET("/escalations", escalationHandler.GetEscalationList)
+			v1OneTest.POST("/acknowledge", escalationHandler.AcknowledgeTest)
+			v1OneTest.GET("/uptime", uptimeHandler.GetTestUptime)
+			v1OneTest.GET("/statistics/latency", statisticsHandler.GetTestLatency)
+		}
+	}
+
+	go func() {
+		if err := e.Start(fmt.Sprintf(":%d", serverConfig.Server.GetPort())); err != nil && err != http.ErrServerClosed {
+			rslog.Fatal(err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-quit
+	rslog.Infof("Received signal: '%v'", sig)
+
+	// every step gets its own timeout, so that a slow step does not leave the next ones no time to finish.
+	shutdown := func(name string, fn func(ctx context.Context) error) {
+		ctx, cancel := context.WithTimeout(context.Background(), serverConfig.Server.GetShutdownTimeout())
+		defer cancel()
+		if err := fn(ctx); err != nil {
+			rslog.Errorf("failed to shut down %s: error='%v'", name, err)
 		}
 	}
 
-	e.Logger.Fatal(e.Start(":1323"))
+	shutdown("test scheduler", testSchedulerManager.Shutdown)
+	shutdown("alert manager", alertManager.Shutdown)
+	shutdown("escalator", escalator.Shutdown)
+	shutdown("result roller", resultRoller.Shutdown)
+	shutdown("result purger", resultPurger.Shutdown)
+	shutdown("report generator", reportGenerator.Shutdown)
+	shutdown("tracer", tracer.Shutdown)
+	shutdown("coordinator", coordinator.Shutdown)
+	// The streams never end by themselves, so they are closed before the server waits for its connections.
+	resultHub.Close()
+	shutdown("server", e.Shutdown)
+	shutdown("result writer", resultWriter.Shutdown)
+	if err := rsdb.Close(); err != nil {
+		rslog.Error(err)
+	}
+	rslog.Info("Server stopped")
 }
@@ -1,41 +1,246 @@
 package models
 
 import (
+	"context"
 	"database/sql/driver"
+	"encoding/json"
+	"net/mail"
+	"reflect"
+	"strings"
 
-	"github.com/imroc/req"
-	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/pkg/rsdb"
 	"github.com/realsangil/apimonitor/pkg/rserrors"
-	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsnotify"
 )
 
-type Alerter interface {
-	Alert(msg string) error
+type Alerts []*Alert
+
+func (alerts *Alerts) Scan(src interface{}) error {
+	return rsdb.ScanJson(alerts, src)
+}
+
+// Value stores the alerts with their credentials, which are masked when they are marshalled.
+func (alerts Alerts) Value() (driver.Value, error) {
+	stored := make([]*storedAlert, 0, len(alerts))
+	for _, alert := range alerts {
+		stored = append(stored, (*storedAlert)(alert))
+	}
+	return rsdb.JsonValue(stored)
+}
+
+// keepCredentials returns the alerts with the credentials of the previous alerts sent to the same place,
+// so that alerts read back from the API keep their credentials, see rsnotify.KeepCredentials.
+// Each previous alert is matched once, whatever the order of the alerts is.
+func (alerts Alerts) keepCredentials(previous Alerts) (Alerts, error) {
+	matched := make([]bool, len(previous))
+	kept := make(Alerts, 0, len(alerts))
+	for _, alert := range alerts {
+		if alert == nil {
+			kept = append(kept, alert)
+			continue
+		}
+		var previousConfig []byte
+		for i, previousAlert := range previous {
+			if !matched[i] && previousAlert != nil && previousAlert.Type == alert.Type &&
+				rsnotify.SameReceiver(previousAlert.Config, alert.Config) {
+				matched[i] = true
+				previousConfig = previousAlert.Config
+				break
+			}
+		}
+		config, err := rsnotify.KeepCredentials(previousConfig, alert.Config)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		copied := *alert
+		copied.Config = config
+		kept = append(kept, &copied)
+	}
+	return kept, nil
+}
+
+func (alerts Alerts) Validate() error {
+	for _, alert := range alerts {
+		if alert == nil {
+			return errors.Wrap(rserrors.ErrInvalidParameter, "alert")
+		}
+		if err := alert.Validate(); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// Alert is a notification channel of a test.
+// Config depends on Type and is validated by the notifier registered for it in rsnotify.
+//
+// Template replaces the default message of the alert. See AlertMessageData for what it may use.
+type Alert struct {
+	Type     rsnotify.Type   `json:"type"`
+	Disabled bool            `json:"disabled"`
+	Config   json.RawMessage `json:"config"`
+	Template AlertTemplate   `json:"template,omitempty"`
+}
+
+// storedAlert is an Alert marshalled with its credentials.
+type storedAlert Alert
+
+// MarshalJSON masks the credentials of the config, so that it is never shown once configured.
+func (alert Alert) MarshalJSON() ([]byte, error) {
+	return json.Marshal(storedAlert(alert.Redacted()))
+}
+
+// Redacted is the alert with the credentials of its config masked.
+func (alert Alert) Redacted() Alert {
+	alert.Config = rsnotify.RedactConfig(alert.Config)
+	return alert
+}
+
+// IsSameNotifier reports whether both alerts are sent to the same place, whatever their credentials are.
+func (alert Alert) IsSameNotifier(other Alert) bool {
+	if alert.Type != other.Type {
+		return false
+	}
+	var config, otherConfig interface{}
+	if err := json.Unmarshal(rsnotify.RedactConfig(alert.Config), &config); err != nil {
+		return false
+	}
+	if err := json.Unmarshal(rsnotify.RedactConfig(other.Config), &otherConfig); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(config, otherConfig)
+}
+
+// UnmarshalJSON also accepts the legacy {"url": "...", "disable": false} alerts as webhooks.
+// The legacy disable flag was never honored, so those alerts stay enabled.
+func (alert *Alert) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Type     rsnotify.Type   `json:"type"`
+		Disabled bool            `json:"disabled"`
+		Config   json.RawMessage `json:"config"`
+		Template AlertTemplate   `json:"template"`
+		URL      string          `json:"url"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return errors.WithStack(err)
+	}
+
+	alert.Type = raw.Type
+	alert.Disabled = raw.Disabled
+	alert.Config = raw.Config
+	alert.Template = raw.Template
+	if raw.Type == "" && raw.URL != "" {
+		config, err := json.Marshal(rsnotify.WebhookConfig{HTTPConfig: rsnotify.HTTPConfig{URL: raw.URL}})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		alert.Type = rsnotify.TypeWebhook
+		alert.Config = config
+	}
+	return nil
 }
 
-type WebHookAlerts []*WebHookAlerter
+func (alert Alert) Validate() error {
+	if _, err := rsnotify.New(alert.Type, alert.Config); err != nil {
+		return errors.Wrap(rserrors.ErrInvalidParameter, err.Error())
+	}
+	if err := alert.Template.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
 
-func (alerts *WebHookAlerts) Scan(src interface{}) error {
-	data, ok := src.([]byte)
-	if !ok {
-		return rserrors.Error("invalid data")
+// Message renders data with the template of the alert, or with the default templates if it has none.
+func (alert Alert) Message(data AlertMessageData) (rsnotify.Message, error) {
+	if alert.Template == "" {
+		return data.Message()
 	}
-	return jsoniter.Unmarshal(data, alerts)
+	text, err := alert.Template.render(data)
+	if err != nil {
+		return rsnotify.Message{}, errors.WithStack(err)
+	}
+	return rsnotify.NewMessage(text), nil
 }
 
-func (alerts WebHookAlerts) Value() (driver.Value, error) {
-	return jsoniter.Marshal(alerts)
+func (alert *Alert) Scan(src interface{}) error {
+	return rsdb.ScanJson(alert, src)
 }
 
-type WebHookAlerter struct {
-	URL    string `json:"url"`
-	Enable bool   `json:"disable"`
+func (alert Alert) Value() (driver.Value, error) {
+	return rsdb.JsonValue(storedAlert(alert))
 }
 
-func (alerter WebHookAlerter) Alert(msg string) error {
-	resp, err := req.Post(alerter.URL, req.BodyJSON(map[string]string{
-		"text": msg,
-	}))
-	rslog.Debug(resp)
+// Alert sends the message with retries. A disabled alert sends nothing.
+func (alert Alert) Alert(message rsnotify.Message) error {
+	if alert.Disabled {
+		return nil
+	}
+	_, err := alert.Send(context.Background(), message)
 	return err
 }
+
+// Send sends the message with retries even if the alert is disabled, and returns every attempt.
+func (alert Alert) Send(ctx context.Context, message rsnotify.Message) ([]rsnotify.Attempt, error) {
+	notifier, err := rsnotify.New(alert.Type, alert.Config)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	attempts, err := rsnotify.Send(ctx, notifier, message, rsnotify.DefaultRetry)
+	return attempts, errors.WithStack(err)
+}
+
+// recipients returns the addresses an enabled email alert is sent to.
+func (alert Alert) recipients() []string {
+	if alert.Type != rsnotify.TypeEmail || alert.Disabled {
+		return nil
+	}
+	config := rsnotify.EmailConfig{}
+	if err := json.Unmarshal(alert.Config, &config); err != nil {
+		return nil
+	}
+	return config.To
+}
+
+func NewEmailAlert(to []string) (*Alert, error) {
+	config, err := json.Marshal(rsnotify.EmailConfig{To: to})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &Alert{Type: rsnotify.TypeEmail, Config: config}, nil
+}
+
+// EmailAddresses are the recipients of the email alerts of every test of a WebService.
+type EmailAddresses []string
+
+func (addresses *EmailAddresses) Scan(src interface{}) error {
+	return rsdb.ScanJson(addresses, src)
+}
+
+func (addresses EmailAddresses) Value() (driver.Value, error) {
+	return rsdb.JsonValue(addresses)
+}
+
+func (addresses EmailAddresses) Validate() error {
+	for _, address := range addresses {
+		if _, err := mail.ParseAddress(address); err != nil {
+			return errors.Wrapf(rserrors.ErrInvalidParameter, "email: '%s'", address)
+		}
+	}
+	return nil
+}
+
+// contains compares the addresses only, without the display names.
+func (addresses EmailAddresses) contains(address string) bool {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return false
+	}
+	for _, a := range addresses {
+		if p, err := mail.ParseAddress(a); err == nil && strings.EqualFold(p.Address, parsed.Address) {
+			return true
+		}
+	}
+	return false
+}
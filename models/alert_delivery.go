@@ -0,0 +1,123 @@
+package models
+
+import (
+	"context"
+	"database/sql/driver"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rsmodels"
+	"github.com/realsangil/apimonitor/pkg/rsnotify"
+	"github.com/realsangil/apimonitor/pkg/rsstr"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+)
+
+type AlertDeliveryAttempts []rsnotify.Attempt
+
+func (attempts *AlertDeliveryAttempts) Scan(src interface{}) error {
+	return rsdb.ScanJson(attempts, src)
+}
+
+func (attempts AlertDeliveryAttempts) Value() (driver.Value, error) {
+	return rsdb.JsonValue(attempts)
+}
+
+// AlertDelivery is a message sent by an alert of a test, with every attempt to deliver it.
+// The alert is copied, so that a delivery can be sent again as it was even after the test changed,
+// but without its credentials, which stay with the test only.
+type AlertDelivery struct {
+	rsmodels.DefaultValidateChecker
+	Id      string `json:"id" gorm:"primary_key;Size:36"`
+	TestId  string `json:"testId" gorm:"Size:36;NOT NULL;index"`
+	Alert   Alert  `json:"alert" gorm:"Type:JSON"`
+	Subject string `json:"subject" gorm:"Type:TEXT"`
+	Text    string `json:"text" gorm:"Type:TEXT"`
+	HTML    string `json:"-" gorm:"Column:html;Type:MEDIUMTEXT"`
+	// ResendOf is the delivery this one sent again.
+	ResendOf   string                `json:"resendOf,omitempty" gorm:"Size:36"`
+	Success    bool                  `json:"success"`
+	StatusCode int                   `json:"statusCode"`
+	Latency    int64                 `json:"latency"`
+	Error      string                `json:"error" gorm:"Type:TEXT"`
+	Attempts   AlertDeliveryAttempts `json:"attempts" gorm:"Type:JSON"`
+	CreatedAt  time.Time             `json:"createdAt" gorm:"index"`
+
+	// alert is the alert the delivery is sent by, with its credentials.
+	alert Alert
+}
+
+func (delivery *AlertDelivery) Validate() error {
+	if rsvalid.IsZero(delivery.Id, delivery.TestId, delivery.Alert.Type, delivery.CreatedAt) {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "alertDelivery")
+	}
+	delivery.SetValidated()
+	return nil
+}
+
+func (delivery AlertDelivery) TableName() string {
+	return "alert_deliveries"
+}
+
+func (delivery AlertDelivery) Message() rsnotify.Message {
+	return rsnotify.Message{
+		Subject: delivery.Subject,
+		Text:    delivery.Text,
+		HTML:    delivery.HTML,
+		SentAt:  delivery.CreatedAt,
+	}
+}
+
+// SetAttempts records the attempts, the last of which is the outcome of the delivery.
+func (delivery *AlertDelivery) SetAttempts(attempts []rsnotify.Attempt, err error) {
+	delivery.Attempts = attempts
+	delivery.Success = err == nil
+	delivery.StatusCode = 0
+	delivery.Latency = 0
+	delivery.Error = ""
+	if len(attempts) > 0 {
+		last := attempts[len(attempts)-1]
+		delivery.StatusCode = last.StatusCode
+		delivery.Latency = last.Latency
+	}
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+}
+
+// Send sends the message with the alert, even if it is disabled, and returns every attempt.
+func (delivery AlertDelivery) Send(ctx context.Context) ([]rsnotify.Attempt, error) {
+	return delivery.alert.Send(ctx, delivery.Message())
+}
+
+// Resend is a new delivery of the same message by alert, which is the alert of the delivery with its credentials.
+// See Alert.IsSameNotifier.
+func (delivery AlertDelivery) Resend(alert Alert) *AlertDelivery {
+	resend := NewAlertDelivery(delivery.TestId, alert, delivery.Message())
+	resend.ResendOf = delivery.Id
+	return resend
+}
+
+func NewAlertDelivery(testId string, alert Alert, message rsnotify.Message) *AlertDelivery {
+	return &AlertDelivery{
+		DefaultValidateChecker: rsmodels.ValidatedDefaultValidateChecker,
+		Id:                     rsstr.NewUUID(),
+		TestId:                 testId,
+		Alert:                  alert.Redacted(),
+		alert:                  alert,
+		Subject:                message.Subject,
+		Text:                   message.Text,
+		HTML:                   message.HTML,
+		Attempts:               AlertDeliveryAttempts{},
+		CreatedAt:              time.Now(),
+	}
+}
+
+type AlertDeliveryListRequest struct {
+	Page      int
+	NumItem   int
+	TestId    string
+	IsSuccess IsSuccess
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/realsangil/apimonitor/pkg/rsnotify"
+)
+
+func TestAlertDelivery_SetAttempts(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name           string
+		attempts       []rsnotify.Attempt
+		err            error
+		wantSuccess    bool
+		wantStatusCode int
+		wantLatency    int64
+		wantError      string
+	}{
+		{
+			name: "delivered after a retry",
+			attempts: []rsnotify.Attempt{
+				{StatusCode: 503, Latency: 30, Error: "unexpected status", AttemptedAt: now},
+				{StatusCode: 200, Latency: 20, AttemptedAt: now.Add(time.Second)},
+			},
+			wantSuccess:    true,
+			wantStatusCode: 200,
+			wantLatency:    20,
+		},
+		{
+			name:           "failed",
+			attempts:       []rsnotify.Attempt{{StatusCode: 400, Latency: 10, Error: "unexpected status", AttemptedAt: now}},
+			err:            errors.New("unexpected status"),
+			wantStatusCode: 400,
+			wantLatency:    10,
+			wantError:      "unexpected status",
+		},
+		{
+			name:      "invalid config",
+			err:       errors.New("unknown notifier type"),
+			wantError: "unknown notifier type",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delivery := NewAlertDelivery("test", Alert{Type: rsnotify.TypeWebhook}, rsnotify.NewMessage("text"))
+			delivery.SetAttempts(tt.attempts, tt.err)
+			assert.Equal(t, tt.wantSuccess, delivery.Success)
+			assert.Equal(t, tt.wantStatusCode, delivery.StatusCode)
+			assert.Equal(t, tt.wantLatency, delivery.Latency)
+			assert.Equal(t, tt.wantError, delivery.Error)
+			assert.Len(t, delivery.Attempts, len(tt.attempts))
+		})
+	}
+}
+
+func TestAlertDelivery_Resend(t *testing.T) {
+	message := rsnotify.Message{Subject: "[DOWN] health", Text: "[DOWN] health\nfailed", HTML: "<p>failed</p>"}
+	delivery := NewAlertDelivery("test", Alert{Type: rsnotify.TypeEmail}, message)
+	delivery.SetAttempts([]rsnotify.Attempt{{StatusCode: 421}}, errors.New("try again later"))
+
+	resend := delivery.Resend(Alert{Type: rsnotify.TypeEmail})
+	assert.NotEqual(t, delivery.Id, resend.Id)
+	assert.Equal(t, delivery.Id, resend.ResendOf)
+	assert.Equal(t, delivery.TestId, resend.TestId)
+	assert.Equal(t, delivery.Alert, resend.Alert)
+	assert.Equal(t, message.HTML, resend.Message().HTML)
+	assert.False(t, resend.Success)
+	assert.Empty(t, resend.Attempts)
+	assert.NoError(t, resend.Validate())
+}
+
+func TestNewAlertDelivery_Secret(t *testing.T) {
+	alert := Alert{Type: rsnotify.TypeWebhook, Config: []byte(`{"url": "https://hooks.example.com", "secret": "s3cr3t"}`)}
+	delivery := NewAlertDelivery("test", alert, rsnotify.NewMessage("text"))
+
+	assert.JSONEq(t, `{"url": "https://hooks.example.com", "secret": "********"}`, string(delivery.Alert.Config))
+	value, err := delivery.Alert.Value()
+	assert.NoError(t, err)
+	assert.NotContains(t, string(value.([]byte)), "s3cr3t")
+	assert.True(t, alert.IsSameNotifier(delivery.Alert))
+}
@@ -0,0 +1,271 @@
+package models
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"net/url"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rsnotify"
+)
+
+const (
+	AlertMessageStatusDown      = "DOWN"
+	AlertMessageStatusRecovered = "RECOVERED"
+
+	alertResponseExcerptLength = 500
+)
+
+var alertTemplateFuncs = map[string]interface{}{
+	"datetime": func(t interface{}) string {
+		switch t := t.(type) {
+		case time.Time:
+			return t.Format(time.RFC3339)
+		case *time.Time:
+			if t != nil {
+				return t.Format(time.RFC3339)
+			}
+		}
+		return "-"
+	},
+}
+
+var alertTextTemplate = texttemplate.Must(newAlertTemplate("alert.txt").Parse(
+	`[{{.Status}}] {{.Test.Name}}
+{{.Test.Method}} {{.URL}}
+{{if .IsDown}}Failed {{.ConsecutiveFailures}} times in a row since {{datetime .FailingSince}}{{else}}Back after {{.Outage}} of outage{{end}}
+Status code: {{.Result.StatusCode}}
+Latency: {{.Latency}}
+Tested at: {{datetime .Result.TestedAt}}
+{{- if .FailedAssertions}}
+Failed assertions:
+{{- range .FailedAssertions}}
+- {{.Field}}: expected '{{.Expected}}', got '{{.Actual}}'
+{{- end}}
+{{- end}}
+{{- if .ResponseExcerpt}}
+Response:
+{{.ResponseExcerpt}}
+{{- end}}
+{{- if .Link}}
+{{.Link}}
+{{- end}}`))
+
+var alertHTMLTemplate = htmltemplate.Must(htmltemplate.New("alert.html").Funcs(alertTemplateFuncs).Parse(
+	`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; font-size: 14px;">
+<h2 style="color: {{if .IsDown}}#c0392b{{else}}#27ae60{{end}};">[{{.Status}}] {{.Test.Name}}</h2>
+<p><code>{{.Test.Method}} {{.URL}}</code></p>
+<p>{{if .IsDown}}Failed {{.ConsecutiveFailures}} times in a row since {{datetime .FailingSince}}{{else}}Back after {{.Outage}} of outage{{end}}</p>
+<table cellpadding="4">
+<tr><th align="left">Status code</th><td>{{.Result.StatusCode}}</td></tr>
+<tr><th align="left">Latency</th><td>{{.Latency}}</td></tr>
+<tr><th align="left">Tested at</th><td>{{datetime .Result.TestedAt}}</td></tr>
+</table>
+{{- if .FailedAssertions}}
+<h3>Failed assertions</h3>
+<table cellpadding="4" border="1" style="border-collapse: collapse;">
+<tr><th>Field</th><th>Expected</th><th>Actual</th></tr>
+{{- range .FailedAssertions}}
+<tr><td>{{.Field}}</td><td>{{.Expected}}</td><td>{{.Actual}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .ResponseExcerpt}}
+<h3>Response</h3>
+<pre style="background: #f4f4f4; padding: 8px; white-space: pre-wrap;">{{.ResponseExcerpt}}</pre>
+{{- end}}
+{{- if .Link}}
+<p><a href="{{.Link}}">Open in API Monitor</a></p>
+{{- end}}
+</body>
+</html>`))
+
+// AlertMessageData is what the alert templates are rendered from.
+// A custom template of an Alert is a text/template which may use:
+//
+//	{{.Status}}               "DOWN" or "RECOVERED", {{if .IsDown}} tells them apart
+//	{{.Test}}                 the test, e.g. {{.Test.Name}}, {{.Test.Method}}, {{.Test.Path}}, {{.Test.Description}}, see AlertTest
+//	{{.WebService}}           the WebService of the test, e.g. {{.WebService.Host}}, {{.WebService.Description}}
+//	{{.URL}}                  the full URL the test requests
+//	{{.Result}}               the TestResult, e.g. {{.Result.StatusCode}}, {{.Result.ResponseTime}} (ms), {{.Result.TestedAt}}
+//	{{.Latency}}              the response time as a duration, e.g. 1.2s
+//	{{.FailedAssertions}}     the failed assertions, each with {{.Field}}, {{.Expected}} and {{.Actual}}
+//	{{.ResponseExcerpt}}      the first 500 characters of the response
+//	{{.ConsecutiveFailures}}  how many times the test failed in a row
+//	{{.FailingSince}}         when the test started to fail
+//	{{.Outage}}               how long the test was down, only when it recovered
+//	{{.Link}}                 the page of the test, empty unless server.publicUrl is configured
+//
+// {{datetime .FailingSince}} formats a time as RFC 3339.
+// The first line of the rendered text is the subject of emails.
+type AlertMessageData struct {
+	Status              string
+	Test                AlertTest
+	WebService          AlertWebService
+	URL                 string
+	Result              *TestResult
+	Latency             time.Duration
+	FailedAssertions    AssertionResults
+	ResponseExcerpt     string
+	ConsecutiveFailures int
+	FailingSince        *time.Time
+	Outage              time.Duration
+	Link                string
+}
+
+// AlertTest is the test an alert is about, as templates see it.
+// Its parameters and alerts are left out, because they may hold credentials.
+type AlertTest struct {
+	Id          string
+	Name        string
+	Description string
+	Method      string
+	Path        string
+	Host        string
+}
+
+// AlertWebService is the WebService an alert is about, as templates see it.
+type AlertWebService struct {
+	Id          string
+	Host        string
+	Description string
+}
+
+func newAlertTest(test *Test, webService *WebService) AlertTest {
+	return AlertTest{
+		Id:          test.Id,
+		Name:        test.Name,
+		Description: test.Description,
+		Method:      string(test.Method),
+		Path:        string(test.Path),
+		Host:        webService.Host,
+	}
+}
+
+func newAlertWebService(webService *WebService) AlertWebService {
+	return AlertWebService{Id: webService.Id, Host: webService.Host, Description: webService.Description}
+}
+
+func (data AlertMessageData) IsDown() bool {
+	return data.Status == AlertMessageStatusDown
+}
+
+// Message renders the default plain text and HTML alert.
+// Chat channels send the text, email sends both as a multipart mail.
+func (data AlertMessageData) Message() (rsnotify.Message, error) {
+	var text, html bytes.Buffer
+	if err := alertTextTemplate.Execute(&text, data); err != nil {
+		return rsnotify.Message{}, errors.WithStack(err)
+	}
+	if err := alertHTMLTemplate.Execute(&html, data); err != nil {
+		return rsnotify.Message{}, errors.WithStack(err)
+	}
+
+	message := rsnotify.NewMessage(text.String())
+	message.HTML = html.String()
+	return message, nil
+}
+
+// NewAlertMessageData collects what is alerted about event. link is the page of the test, which may be empty.
+func NewAlertMessageData(test *Test, result *TestResult, state *AlertState, event AlertEvent, link string) AlertMessageData {
+	status := AlertMessageStatusDown
+	if event == AlertEventRecovered {
+		status = AlertMessageStatusRecovered
+	}
+	webService := test.WebService
+	if webService == nil {
+		webService = &WebService{}
+	}
+	return AlertMessageData{
+		Status:              status,
+		Test:                newAlertTest(test, webService),
+		WebService:          newAlertWebService(webService),
+		URL:                 test.URL(),
+		Result:              result,
+		Latency:             time.Duration(result.ResponseTime) * time.Millisecond,
+		FailedAssertions:    result.Assertions.Failures(),
+		ResponseExcerpt:     excerpt(result.Response, alertResponseExcerptLength),
+		ConsecutiveFailures: state.ConsecutiveFailures,
+		FailingSince:        state.FailingSince,
+		Outage:              state.OutageDuration().Round(time.Second),
+		Link:                link,
+	}
+}
+
+// sampleAlertMessageData is what custom templates are checked against when they are saved.
+func sampleAlertMessageData() AlertMessageData {
+	failingSince := time.Now().Add(-5 * time.Minute)
+	webService := &WebService{Id: "sample", Schema: "https", Host: "api.example.com"}
+	test := &Test{Id: "sample", Name: "sample", WebService: webService, Path: "/health", Method: "GET"}
+	result := &TestResult{
+		Id:           "sample",
+		TestId:       test.Id,
+		StatusCode:   500,
+		Response:     "sample",
+		ResponseTime: 1000,
+		Assertions:   AssertionResults{{Field: "statusCode", Expected: 200, Actual: 500}},
+		TestedAt:     time.Now(),
+	}
+	state := &AlertState{TestId: test.Id, ConsecutiveFailures: 3, FailingSince: &failingSince}
+	return NewAlertMessageData(test, result, state, AlertEventDown, "https://apimonitor.example.com/tests/sample")
+}
+
+func newAlertTemplate(name string) *texttemplate.Template {
+	return texttemplate.New(name).Funcs(alertTemplateFuncs).Option("missingkey=error")
+}
+
+// AlertTemplate is a custom text/template of an alert, rendered from AlertMessageData.
+type AlertTemplate string
+
+// Validate parses the template and renders it with sample data,
+// so that a misspelled field is rejected when the alert is saved rather than when it fires.
+func (t AlertTemplate) Validate() error {
+	if t == "" {
+		return nil
+	}
+	if _, err := t.render(sampleAlertMessageData()); err != nil {
+		return errors.Wrapf(rserrors.ErrInvalidParameter, "template: %v", err)
+	}
+	return nil
+}
+
+func (t AlertTemplate) render(data AlertMessageData) (string, error) {
+	tmpl, err := newAlertTemplate("custom").Parse(string(t))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// URL is the address the test requests, or only its path if the WebService is not loaded.
+func (test Test) URL() string {
+	if test.WebService == nil {
+		return test.Path.String()
+	}
+	u := url.URL{
+		Scheme: test.WebService.Schema,
+		Host:   test.WebService.Host,
+		Path:   test.Path.String(),
+	}
+	return u.String()
+}
+
+func excerpt(s string, length int) string {
+	s = strings.TrimSpace(s)
+	runes := []rune(s)
+	if len(runes) <= length {
+		return s
+	}
+	return string(runes[:length]) + "…"
+}
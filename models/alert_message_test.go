@@ -0,0 +1,163 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlertMessageData_Message(t *testing.T) {
+	failingSince := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	recoveringSince := failingSince.Add(4 * time.Minute)
+	test := &Test{
+		Name:       "health <api>",
+		Method:     "GET",
+		Path:       "/health",
+		WebService: &WebService{Schema: "https", Host: "api.example.com"},
+	}
+
+	tests := []struct {
+		name        string
+		result      *TestResult
+		state       *AlertState
+		event       AlertEvent
+		wantSubject string
+		wantText    []string
+		wantHTML    []string
+		wantNotText []string
+	}{
+		{
+			name: "down",
+			result: &TestResult{
+				StatusCode:   500,
+				ResponseTime: 1200,
+				Response:     `{"error": "<db> is down"}`,
+				Assertions:   AssertionResults{{Field: "statusCode", Expected: 200, Actual: 500, Passed: false}},
+				TestedAt:     failingSince,
+			},
+			state:       &AlertState{ConsecutiveFailures: 3, FailingSince: &failingSince},
+			event:       AlertEventDown,
+			wantSubject: "[DOWN] health <api>",
+			wantText: []string{
+				"GET https://api.example.com/health",
+				"Failed 3 times in a row since 2020-01-01T00:00:00Z",
+				"Status code: 500",
+				"Latency: 1.2s",
+				"- statusCode: expected '200', got '500'",
+				`{"error": "<db> is down"}`,
+			},
+			wantHTML: []string{
+				"[DOWN] health &lt;api&gt;",
+				"<td>statusCode</td><td>200</td><td>500</td>",
+				"&lt;db&gt; is down",
+			},
+		},
+		{
+			name:        "recovered",
+			result:      &TestResult{IsSuccess: true, StatusCode: 200, ResponseTime: 80, TestedAt: recoveringSince},
+			state:       &AlertState{FailingSince: &failingSince, RecoveringSince: &recoveringSince},
+			event:       AlertEventRecovered,
+			wantSubject: "[RECOVERED] health <api>",
+			wantText:    []string{"Back after 4m0s of outage", "Status code: 200"},
+			wantHTML:    []string{"[RECOVERED] health &lt;api&gt;", "Back after 4m0s of outage"},
+			wantNotText: []string{"Failed assertions", "Response:"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, err := NewAlertMessageData(test, tt.result, tt.state, tt.event, "").Message()
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.wantSubject, message.Subject)
+			for _, s := range tt.wantText {
+				assert.Contains(t, message.Text, s)
+			}
+			for _, s := range tt.wantHTML {
+				assert.Contains(t, message.HTML, s)
+			}
+			for _, s := range tt.wantNotText {
+				assert.NotContains(t, message.Text, s)
+			}
+		})
+	}
+}
+
+func TestAlertMessageData_Message_ResponseExcerpt(t *testing.T) {
+	test := &Test{Name: "health", Method: "GET", Path: "/health"}
+	result := &TestResult{Response: strings.Repeat("a", 1000)}
+	message, err := NewAlertMessageData(test, result, &AlertState{}, AlertEventDown, "").Message()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, message.Text, "GET /health")
+	assert.Contains(t, message.Text, strings.Repeat("a", alertResponseExcerptLength)+"…")
+	assert.NotContains(t, message.Text, strings.Repeat("a", alertResponseExcerptLength+1))
+}
+
+func TestAlert_Message(t *testing.T) {
+	failingSince := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	test := &Test{Id: "test", Name: "health", Method: "GET", Path: "/health"}
+	result := &TestResult{StatusCode: 503, Assertions: AssertionResults{{Field: "statusCode", Expected: 200, Actual: 503}}}
+	state := &AlertState{ConsecutiveFailures: 2, FailingSince: &failingSince}
+	data := NewAlertMessageData(test, result, state, AlertEventDown, "https://apimonitor.example.com/tests/test")
+
+	tests := []struct {
+		name        string
+		template    AlertTemplate
+		wantSubject string
+		wantText    string
+		wantHTML    bool
+	}{
+		{
+			name:        "default",
+			wantSubject: "[DOWN] health",
+			wantHTML:    true,
+		},
+		{
+			name:        "custom",
+			template:    "{{.Status}} {{.Test.Name}} ({{.Result.StatusCode}})\n{{range .FailedAssertions}}{{.Field}} {{end}}since {{datetime .FailingSince}}\n{{.Link}}",
+			wantSubject: "DOWN health (503)",
+			wantText:    "DOWN health (503)\nstatusCode since 2020-01-01T00:00:00Z\nhttps://apimonitor.example.com/tests/test",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := Alert{Type: "webhook", Template: tt.template}
+			message, err := alert.Message(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.wantSubject, message.Subject)
+			if tt.wantText != "" {
+				assert.Equal(t, tt.wantText, message.Text)
+			}
+			assert.Equal(t, tt.wantHTML, message.HTML != "")
+		})
+	}
+}
+
+func TestAlertTemplate_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template AlertTemplate
+		wantErr  bool
+	}{
+		{name: "empty", template: ""},
+		{name: "valid", template: "{{.Status}} {{.WebService.Host}}{{.Test.Path}} {{.Outage}}"},
+		{name: "syntax error", template: "{{.Status", wantErr: true},
+		{name: "unknown field", template: "{{.Test.Nmae}}", wantErr: true},
+		{name: "unknown function", template: "{{upper .Status}}", wantErr: true},
+		{name: "test parameters", template: "{{.Test.Parameters.Header}}", wantErr: true},
+		{name: "test alerts", template: "{{range .Test.Alerts}}{{.Config}}{{end}}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.template.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
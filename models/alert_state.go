@@ -0,0 +1,172 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rsmodels"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+)
+
+const (
+	DefaultAlertFailureThreshold  = 1
+	DefaultAlertRecoveryThreshold = 1
+)
+
+// AlertPolicy decides when a failing test is alerted and when it is recovered.
+// A zero threshold means the default.
+//
+// EscalationPolicyId escalates an alert which is not acknowledged in time. See EscalationPolicy.
+type AlertPolicy struct {
+	FailureThreshold   int    `json:"failureThreshold"`
+	RecoveryThreshold  int    `json:"recoveryThreshold"`
+	EscalationPolicyId string `json:"escalationPolicyId,omitempty"`
+}
+
+func (policy AlertPolicy) Validate() error {
+	if policy.FailureThreshold < 0 || policy.RecoveryThreshold < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "alertPolicy")
+	}
+	return nil
+}
+
+func (policy AlertPolicy) GetFailureThreshold() int {
+	if policy.FailureThreshold <= 0 {
+		return DefaultAlertFailureThreshold
+	}
+	return policy.FailureThreshold
+}
+
+func (policy AlertPolicy) GetRecoveryThreshold() int {
+	if policy.RecoveryThreshold <= 0 {
+		return DefaultAlertRecoveryThreshold
+	}
+	return policy.RecoveryThreshold
+}
+
+func (policy *AlertPolicy) Scan(src interface{}) error {
+	return rsdb.ScanJson(policy, src)
+}
+
+func (policy AlertPolicy) Value() (driver.Value, error) {
+	return rsdb.JsonValue(policy)
+}
+
+type AlertStatus string
+
+const (
+	AlertStatusOk        AlertStatus = "ok"
+	AlertStatusFailing   AlertStatus = "failing"
+	AlertStatusAlerting  AlertStatus = "alerting"
+	AlertStatusRecovered AlertStatus = "recovered"
+)
+
+type AlertEvent int
+
+const (
+	AlertEventNone AlertEvent = iota
+	AlertEventDown
+	AlertEventRecovered
+)
+
+// AlertState is the alerting state of a test, kept in the database so that it survives restarts.
+//
+//	ok, recovered --failure--> failing --N failures--> alerting --M successes--> recovered
+//
+// A failing test which succeeds before it is alerted goes back to ok silently.
+type AlertState struct {
+	rsmodels.DefaultValidateChecker
+	TestId               string      `json:"testId" gorm:"primary_key;Size:36"`
+	Status               AlertStatus `json:"status" gorm:"Size:20"`
+	ConsecutiveFailures  int         `json:"consecutiveFailures"`
+	ConsecutiveSuccesses int         `json:"consecutiveSuccesses"`
+	FailingSince         *time.Time  `json:"failingSince"`
+	RecoveringSince      *time.Time  `json:"recoveringSince"`
+	AlertedAt            *time.Time  `json:"alertedAt"`
+	RecoveredAt          *time.Time  `json:"recoveredAt"`
+	ModifiedAt           time.Time   `json:"modifiedAt"`
+}
+
+func (state *AlertState) Validate() error {
+	if rsvalid.IsZero(state.TestId, state.Status) {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "alertState")
+	}
+	state.SetValidated()
+	return nil
+}
+
+func (state AlertState) TableName() string {
+	return "alert_states"
+}
+
+// Transition applies the result to the state and returns the notification to send, if any.
+// Results in maintenance don't change the state.
+func (state *AlertState) Transition(result *TestResult, policy AlertPolicy) AlertEvent {
+	if result.InMaintenance {
+		return AlertEventNone
+	}
+	state.ModifiedAt = time.Now()
+	testedAt := result.TestedAt
+
+	if !result.IsSuccess {
+		state.ConsecutiveSuccesses = 0
+		state.RecoveringSince = nil
+		state.ConsecutiveFailures++
+		switch state.Status {
+		case AlertStatusAlerting:
+			return AlertEventNone
+		case AlertStatusFailing:
+		default:
+			state.Status = AlertStatusFailing
+			state.FailingSince = &testedAt
+		}
+		if state.ConsecutiveFailures < policy.GetFailureThreshold() {
+			return AlertEventNone
+		}
+		state.Status = AlertStatusAlerting
+		state.AlertedAt = &testedAt
+		return AlertEventDown
+	}
+
+	state.ConsecutiveFailures = 0
+	state.ConsecutiveSuccesses++
+	switch state.Status {
+	case AlertStatusAlerting:
+		if state.RecoveringSince == nil {
+			state.RecoveringSince = &testedAt
+		}
+		if state.ConsecutiveSuccesses < policy.GetRecoveryThreshold() {
+			return AlertEventNone
+		}
+		state.Status = AlertStatusRecovered
+		state.RecoveredAt = &testedAt
+		return AlertEventRecovered
+	case AlertStatusFailing:
+		state.Status = AlertStatusOk
+		state.FailingSince = nil
+	case AlertStatusRecovered:
+		state.Status = AlertStatusOk
+	}
+	return AlertEventNone
+}
+
+// OutageDuration is the time from the first failure to the first success of the recovery.
+func (state AlertState) OutageDuration() time.Duration {
+	if state.FailingSince == nil || state.RecoveringSince == nil {
+		return 0
+	}
+	return state.RecoveringSince.Sub(*state.FailingSince)
+}
+
+func NewAlertState(testId string) *AlertState {
+	return &AlertState{
+		DefaultValidateChecker: rsmodels.ValidatedDefaultValidateChecker,
+		TestId:                 testId,
+		Status:                 AlertStatusOk,
+		ModifiedAt:             time.Now(),
+	}
+}
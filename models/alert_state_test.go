@@ -0,0 +1,66 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlertState_Transition(t *testing.T) {
+	startedAt := time.Date(2020, 2, 1, 9, 0, 0, 0, time.UTC)
+	policy := AlertPolicy{FailureThreshold: 3, RecoveryThreshold: 2}
+
+	tests := []struct {
+		name       string
+		results    []bool
+		wantEvents []AlertEvent
+		wantStatus AlertStatus
+	}{
+		{
+			name:       "flapping below the threshold",
+			results:    []bool{false, false, true, false},
+			wantEvents: []AlertEvent{AlertEventNone, AlertEventNone, AlertEventNone, AlertEventNone},
+			wantStatus: AlertStatusFailing,
+		},
+		{
+			name:       "alerted once",
+			results:    []bool{false, false, false, false, false},
+			wantEvents: []AlertEvent{AlertEventNone, AlertEventNone, AlertEventDown, AlertEventNone, AlertEventNone},
+			wantStatus: AlertStatusAlerting,
+		},
+		{
+			name:       "recovered after two successes",
+			results:    []bool{false, false, false, true, false, true, true, true},
+			wantEvents: []AlertEvent{AlertEventNone, AlertEventNone, AlertEventDown, AlertEventNone, AlertEventNone, AlertEventNone, AlertEventRecovered, AlertEventNone},
+			wantStatus: AlertStatusOk,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := NewAlertState("test")
+			events := make([]AlertEvent, 0, len(tt.results))
+			for i, isSuccess := range tt.results {
+				result := &TestResult{IsSuccess: isSuccess, TestedAt: startedAt.Add(time.Duration(i) * time.Minute)}
+				events = append(events, state.Transition(result, policy))
+			}
+			assert.Equal(t, tt.wantEvents, events)
+			assert.Equal(t, tt.wantStatus, state.Status)
+		})
+	}
+}
+
+func TestAlertState_OutageDuration(t *testing.T) {
+	startedAt := time.Date(2020, 2, 1, 9, 0, 0, 0, time.UTC)
+	state := NewAlertState("test")
+	policy := AlertPolicy{RecoveryThreshold: 2}
+
+	assert.Equal(t, AlertEventDown, state.Transition(&TestResult{TestedAt: startedAt}, policy))
+	assert.Equal(t, AlertEventNone, state.Transition(&TestResult{TestedAt: startedAt.Add(time.Minute)}, policy))
+	assert.Equal(t, AlertEventNone, state.Transition(&TestResult{InMaintenance: true, TestedAt: startedAt.Add(2 * time.Minute)}, policy))
+	assert.Equal(t, AlertEventNone, state.Transition(&TestResult{IsSuccess: true, TestedAt: startedAt.Add(5 * time.Minute)}, policy))
+	assert.Equal(t, AlertEventRecovered, state.Transition(&TestResult{IsSuccess: true, TestedAt: startedAt.Add(6 * time.Minute)}, policy))
+
+	assert.Equal(t, AlertStatusRecovered, state.Status)
+	assert.Equal(t, 5*time.Minute, state.OutageDuration())
+}
@@ -0,0 +1,192 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/realsangil/apimonitor/pkg/rsnotify"
+)
+
+func TestAlerts_Scan(t *testing.T) {
+	tests := []struct {
+		name         string
+		src          string
+		wantType     rsnotify.Type
+		wantDisabled bool
+		wantConfig   string
+	}{
+		{
+			name:       "legacy webhook",
+			src:        `[{"url": "https://hooks.example.com/alert", "disable": false}]`,
+			wantType:   rsnotify.TypeWebhook,
+			wantConfig: `{"url": "https://hooks.example.com/alert"}`,
+		},
+		{
+			name:       "legacy webhook with disable flag",
+			src:        `[{"url": "https://hooks.example.com/alert", "disable": true}]`,
+			wantType:   rsnotify.TypeWebhook,
+			wantConfig: `{"url": "https://hooks.example.com/alert"}`,
+		},
+		{
+			name:         "typed channel",
+			src:          `[{"type": "slack", "disabled": true, "config": {"url": "https://hooks.slack.com/services/x", "channel": "#ops"}}]`,
+			wantType:     rsnotify.TypeSlack,
+			wantDisabled: true,
+			wantConfig:   `{"url": "https://hooks.slack.com/services/x", "channel": "#ops"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var alerts Alerts
+			if err := alerts.Scan([]byte(tt.src)); err != nil {
+				t.Fatal(err)
+			}
+			if assert.Len(t, alerts, 1) {
+				assert.Equal(t, tt.wantType, alerts[0].Type)
+				assert.Equal(t, tt.wantDisabled, alerts[0].Disabled)
+				assert.JSONEq(t, tt.wantConfig, string(alerts[0].Config))
+				assert.NoError(t, alerts.Validate())
+			}
+		})
+	}
+}
+
+func TestAlert_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		alert   Alert
+		wantErr bool
+	}{
+		{
+			name:    "valid discord",
+			alert:   Alert{Type: rsnotify.TypeDiscord, Config: json.RawMessage(`{"url": "https://discord.com/api/webhooks/x"}`)},
+			wantErr: false,
+		},
+		{
+			name:    "unknown type",
+			alert:   Alert{Type: "pager", Config: json.RawMessage(`{"url": "https://example.com"}`)},
+			wantErr: true,
+		},
+		{
+			name: "invalid template",
+			alert: Alert{
+				Type:     rsnotify.TypeWebhook,
+				Config:   json.RawMessage(`{"url": "https://hooks.example.com/alert"}`),
+				Template: "{{.Result.Status}}",
+			},
+			wantErr: true,
+		},
+		{
+			name:    "teams without url",
+			alert:   Alert{Type: rsnotify.TypeTeams, Config: json.RawMessage(`{}`)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.alert.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTest_GetAlerts(t *testing.T) {
+	webhook := &Alert{Type: rsnotify.TypeWebhook, Config: json.RawMessage(`{"url": "https://hooks.example.com/alert"}`)}
+	email := &Alert{Type: rsnotify.TypeEmail, Config: json.RawMessage(`{"to": ["Ops <ops@example.com>"]}`)}
+
+	tests := []struct {
+		name       string
+		test       Test
+		wantLength int
+		wantTo     []string
+	}{
+		{
+			name:       "without web service recipients",
+			test:       Test{Alerts: Alerts{webhook}, WebService: &WebService{}},
+			wantLength: 1,
+		},
+		{
+			name:       "web service recipients",
+			test:       Test{Alerts: Alerts{webhook}, WebService: &WebService{AlertRecipients: EmailAddresses{"dev@example.com"}}},
+			wantLength: 2,
+			wantTo:     []string{"dev@example.com"},
+		},
+		{
+			name:       "recipients already mailed by the test",
+			test:       Test{Alerts: Alerts{email}, WebService: &WebService{AlertRecipients: EmailAddresses{"OPS@example.com", "dev@example.com"}}},
+			wantLength: 2,
+			wantTo:     []string{"dev@example.com"},
+		},
+		{
+			name:       "every recipient already mailed by the test",
+			test:       Test{Alerts: Alerts{email}, WebService: &WebService{AlertRecipients: EmailAddresses{"ops@example.com"}}},
+			wantLength: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alerts := tt.test.GetAlerts()
+			if assert.Len(t, alerts, tt.wantLength) && tt.wantTo != nil {
+				last := alerts[len(alerts)-1]
+				assert.Equal(t, rsnotify.TypeEmail, last.Type)
+				assert.Equal(t, tt.wantTo, last.recipients())
+			}
+		})
+	}
+}
+
+func TestEmailAddresses_Validate(t *testing.T) {
+	assert.NoError(t, EmailAddresses{"ops@example.com", "Dev <dev@example.com>"}.Validate())
+	assert.Error(t, EmailAddresses{"ops"}.Validate())
+}
+
+func TestAlerts_Secret(t *testing.T) {
+	alerts := Alerts{{Type: rsnotify.TypeWebhook, Config: []byte(`{"url": "https://hooks.example.com", "secret": "s3cr3t"}`)}}
+
+	data, err := json.Marshal(alerts)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "s3cr3t")
+
+	value, err := alerts.Value()
+	assert.NoError(t, err)
+	var stored Alerts
+	if err := stored.Scan(value); err != nil {
+		t.Fatal(err)
+	}
+	assert.JSONEq(t, string(alerts[0].Config), string(stored[0].Config))
+
+	// An alert read back from the API keeps its secret when the test is updated.
+	var request Alerts
+	if err := json.Unmarshal(data, &request); err != nil {
+		t.Fatal(err)
+	}
+	kept, err := request.keepCredentials(alerts)
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(alerts[0].Config), string(kept[0].Config))
+}
+
+func TestAlerts_KeepCredentials(t *testing.T) {
+	previous := Alerts{
+		{Type: rsnotify.TypeWebhook, Config: []byte(`{"url": "https://hooks.example.com/a", "secret": "a"}`)},
+		{Type: rsnotify.TypeWebhook, Config: []byte(`{"url": "https://hooks.example.com/b", "headers": {"Authorization": "Bearer b"}}`)},
+	}
+
+	// The alerts keep their credentials when they are reordered.
+	request := Alerts{
+		{Type: rsnotify.TypeWebhook, Config: []byte(`{"url": "https://hooks.example.com/b", "headers": {"Authorization": "********"}}`)},
+		{Type: rsnotify.TypeWebhook, Config: []byte(`{"url": "https://hooks.example.com/a", "secret": "********"}`)},
+	}
+	kept, err := request.keepCredentials(previous)
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(previous[1].Config), string(kept[0].Config))
+	assert.JSONEq(t, string(previous[0].Config), string(kept[1].Config))
+
+	// A masked secret is never sent to another URL.
+	request = Alerts{{Type: rsnotify.TypeWebhook, Config: []byte(`{"url": "https://evil.example.com", "secret": "********"}`)}}
+	_, err = request.keepCredentials(previous)
+	assert.Equal(t, rsnotify.ErrInvalidConfig, errors.Cause(err))
+}
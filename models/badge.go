@@ -0,0 +1,178 @@
+package models
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/pkg/rsbadge"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rsstats"
+)
+
+// BadgeType is what a badge shows.
+type BadgeType string
+
+const (
+	BadgeStatus  BadgeType = "status"
+	BadgeUptime  BadgeType = "uptime"
+	BadgeLatency BadgeType = "latency"
+)
+
+const badgeNoData = "no data"
+
+// MaxBadgeLabelLength bounds the label of a badge, which is rendered as given.
+const MaxBadgeLabelLength = 64
+
+// BadgeRequest is the badge of a type, over the window ending at To for the uptime and the latency.
+type BadgeRequest struct {
+	Type   BadgeType
+	Window string
+	Label  string
+	From   time.Time
+	To     time.Time
+}
+
+// NewBadgeRequest reads the type, status by default, and one of the windows like 24h, 7d and 30d.
+// The label replaces the default one, e.g. 'uptime 7d', and is at most MaxBadgeLabelLength characters.
+func NewBadgeRequest(badgeType, window, label string, now time.Time) (BadgeRequest, error) {
+	request := BadgeRequest{Type: BadgeType(badgeType), Window: window, Label: label, To: now}
+	if utf8.RuneCountInString(label) > MaxBadgeLabelLength {
+		return request, errors.Wrap(rserrors.ErrInvalidParameter, "label")
+	}
+	switch request.Type {
+	case "":
+		request.Type = BadgeStatus
+	case BadgeStatus, BadgeUptime, BadgeLatency:
+	default:
+		return request, errors.Wrap(rserrors.ErrInvalidParameter, "type")
+	}
+
+	if request.Window == "" {
+		request.Window = DefaultReportWindow
+	}
+	duration, ok := reportWindows[request.Window]
+	if !ok {
+		return request, errors.Wrap(rserrors.ErrInvalidParameter, "window")
+	}
+	request.From = now.Add(-duration)
+	return request, nil
+}
+
+func (request BadgeRequest) UptimeRequest() UptimeRequest {
+	return UptimeRequest{From: request.From, To: request.To}
+}
+
+// LatencyStatisticsRequest buckets the window by hour, or by day past a week, so that the rollups are read.
+func (request BadgeRequest) LatencyStatisticsRequest() LatencyStatisticsRequest {
+	bucket := "1h"
+	if request.To.Sub(request.From) > 7*24*time.Hour {
+		bucket = "1d"
+	}
+	return LatencyStatisticsRequest{From: request.From, To: request.To, Bucket: bucket}
+}
+
+// Badge is rendered as a shields.io-like SVG.
+type Badge struct {
+	Label   string
+	Message string
+	Color   string
+}
+
+func (badge Badge) SVG() []byte {
+	return rsbadge.Render(badge.Label, badge.Message, badge.Color)
+}
+
+// NewStatusBadge shows the current status of a test or a web service, see NewWebServiceStatus,
+// or unknown without a status, like that of a test never run.
+func NewStatusBadge(request BadgeRequest, status ComponentStatus) Badge {
+	badge := Badge{Label: "status"}
+	switch status {
+	case ComponentOperational:
+		badge.Message, badge.Color = "up", rsbadge.ColorBrightGreen
+	case ComponentMaintenance:
+		badge.Message, badge.Color = "maintenance", rsbadge.ColorBlue
+	case ComponentDegraded:
+		badge.Message, badge.Color = "degraded", rsbadge.ColorYellow
+	case ComponentOutage:
+		badge.Message, badge.Color = "down", rsbadge.ColorRed
+	default:
+		badge.Message, badge.Color = "unknown", rsbadge.ColorLightGrey
+	}
+	return badge.WithLabel(request.Label)
+}
+
+// NewUptimeBadge shows the availability, like the uptime endpoints.
+func NewUptimeBadge(request BadgeRequest, uptime Uptime) Badge {
+	badge := Badge{Label: fmt.Sprintf("uptime %s", request.Window)}
+	if uptime.TotalCount == 0 && uptime.Downtime == 0 {
+		badge.Message, badge.Color = badgeNoData, rsbadge.ColorLightGrey
+		return badge.WithLabel(request.Label)
+	}
+
+	percent := uptime.Availability * 100
+	badge.Message = fmt.Sprintf("%s%%", formatPercent(percent))
+	switch {
+	case percent >= 99.9:
+		badge.Color = rsbadge.ColorBrightGreen
+	case percent >= 99:
+		badge.Color = rsbadge.ColorGreen
+	case percent >= 97:
+		badge.Color = rsbadge.ColorYellowGreen
+	case percent >= 95:
+		badge.Color = rsbadge.ColorYellow
+	case percent >= 90:
+		badge.Color = rsbadge.ColorOrange
+	default:
+		badge.Color = rsbadge.ColorRed
+	}
+	return badge.WithLabel(request.Label)
+}
+
+// NewLatencyBadge shows the mean response time of the summary, in milliseconds.
+func NewLatencyBadge(request BadgeRequest, summary rsstats.Summary) Badge {
+	badge := Badge{Label: fmt.Sprintf("latency %s", request.Window)}
+	if summary.Count == 0 {
+		badge.Message, badge.Color = badgeNoData, rsbadge.ColorLightGrey
+		return badge.WithLabel(request.Label)
+	}
+
+	mean := summary.Mean
+	if mean < 1000 {
+		badge.Message = fmt.Sprintf("%.0fms", mean)
+	} else {
+		badge.Message = fmt.Sprintf("%.2fs", mean/1000)
+	}
+	switch {
+	case mean < 200:
+		badge.Color = rsbadge.ColorBrightGreen
+	case mean < 500:
+		badge.Color = rsbadge.ColorGreen
+	case mean < 1000:
+		badge.Color = rsbadge.ColorYellow
+	case mean < 2000:
+		badge.Color = rsbadge.ColorOrange
+	default:
+		badge.Color = rsbadge.ColorRed
+	}
+	return badge.WithLabel(request.Label)
+}
+
+// WithLabel is the badge with label instead of its default one, unless label is empty.
+func (badge Badge) WithLabel(label string) Badge {
+	if label != "" {
+		badge.Label = label
+	}
+	return badge
+}
+
+// formatPercent keeps two decimals, without rounding up to 100 what is not.
+func formatPercent(percent float64) string {
+	truncated := float64(int64(percent*100)) / 100
+	if truncated == 100 {
+		return "100"
+	}
+	return fmt.Sprintf("%.2f", truncated)
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/realsangil/apimonitor/pkg/rsbadge"
+	"github.com/realsangil/apimonitor/pkg/rsstats"
+)
+
+func TestNewBadgeRequest(t *testing.T) {
+	now := time.Date(2020, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	request, err := NewBadgeRequest("", "", "", now)
+	if assert.NoError(t, err) {
+		assert.Equal(t, BadgeStatus, request.Type)
+		assert.Equal(t, DefaultReportWindow, request.Window)
+		assert.Equal(t, now.Add(-24*time.Hour), request.From)
+		assert.Equal(t, "1h", request.LatencyStatisticsRequest().Bucket)
+	}
+
+	request, err = NewBadgeRequest("latency", "30d", "", now)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "1d", request.LatencyStatisticsRequest().Bucket)
+	}
+
+	_, err = NewBadgeRequest("coverage", "", "", now)
+	assert.Error(t, err)
+	_, err = NewBadgeRequest("uptime", "2w", "", now)
+	assert.Error(t, err)
+	_, err = NewBadgeRequest("uptime", "", strings.Repeat("a", MaxBadgeLabelLength+1), now)
+	assert.Error(t, err)
+}
+
+func TestNewUptimeBadge(t *testing.T) {
+	request := BadgeRequest{Type: BadgeUptime, Window: "7d"}
+	tests := []struct {
+		name        string
+		uptime      Uptime
+		wantMessage string
+		wantColor   string
+	}{
+		{name: "no data", uptime: Uptime{Availability: 1}, wantMessage: "no data", wantColor: rsbadge.ColorLightGrey},
+		{name: "all up", uptime: Uptime{TotalCount: 10, Availability: 1}, wantMessage: "100%", wantColor: rsbadge.ColorBrightGreen},
+		{name: "not rounded up", uptime: Uptime{TotalCount: 10, Availability: 0.99999}, wantMessage: "99.99%", wantColor: rsbadge.ColorBrightGreen},
+		{name: "some downtime", uptime: Uptime{TotalCount: 10, Availability: 0.985}, wantMessage: "98.50%", wantColor: rsbadge.ColorYellowGreen},
+		{name: "down", uptime: Uptime{TotalCount: 10, Downtime: 60, Availability: 0.5}, wantMessage: "50.00%", wantColor: rsbadge.ColorRed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			badge := NewUptimeBadge(request, tt.uptime)
+			assert.Equal(t, "uptime 7d", badge.Label)
+			assert.Equal(t, tt.wantMessage, badge.Message)
+			assert.Equal(t, tt.wantColor, badge.Color)
+		})
+	}
+}
+
+func TestNewLatencyBadge(t *testing.T) {
+	request := BadgeRequest{Type: BadgeLatency, Window: "24h", Label: "api"}
+	badge := NewLatencyBadge(request, rsstats.Summary{Count: 3, Mean: 123.4})
+	assert.Equal(t, Badge{Label: "api", Message: "123ms", Color: rsbadge.ColorBrightGreen}, badge)
+	badge = NewLatencyBadge(request, rsstats.Summary{Count: 3, Mean: 2500})
+	assert.Equal(t, Badge{Label: "api", Message: "2.50s", Color: rsbadge.ColorRed}, badge)
+	badge = NewLatencyBadge(request, rsstats.Summary{})
+	assert.Equal(t, "no data", badge.Message)
+}
+
+func TestNewStatusBadge(t *testing.T) {
+	request := BadgeRequest{Type: BadgeStatus}
+	assert.Equal(t, Badge{Label: "status", Message: "up", Color: rsbadge.ColorBrightGreen}, NewStatusBadge(request, ComponentOperational))
+	assert.Equal(t, Badge{Label: "status", Message: "down", Color: rsbadge.ColorRed}, NewStatusBadge(request, ComponentOutage))
+	assert.Equal(t, Badge{Label: "status", Message: "unknown", Color: rsbadge.ColorLightGrey}, NewStatusBadge(request, ""))
+}
@@ -0,0 +1,238 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rsmodels"
+	"github.com/realsangil/apimonitor/pkg/rsstr"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+)
+
+var ErrEscalationClosed = errors.New("escalation is already acknowledged or resolved")
+
+// EscalationStep notifies its channels when a down test is still unacknowledged AfterMinutes after it went down.
+type EscalationStep struct {
+	AfterMinutes int      `json:"afterMinutes"`
+	ChannelIds   []string `json:"channelIds"`
+}
+
+func (step EscalationStep) After() time.Duration {
+	return time.Duration(step.AfterMinutes) * time.Minute
+}
+
+type EscalationSteps []EscalationStep
+
+func (steps *EscalationSteps) Scan(src interface{}) error {
+	return rsdb.ScanJson(steps, src)
+}
+
+func (steps EscalationSteps) Value() (driver.Value, error) {
+	return rsdb.JsonValue(steps)
+}
+
+// Validate requires the steps in the order they fire, each with at least one channel.
+func (steps EscalationSteps) Validate() error {
+	if len(steps) == 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "escalation steps")
+	}
+	for i, step := range steps {
+		if step.AfterMinutes < 0 || (i > 0 && step.AfterMinutes < steps[i-1].AfterMinutes) {
+			return errors.Wrapf(rserrors.ErrInvalidParameter, "escalation step %d: afterMinutes", i)
+		}
+		if len(step.ChannelIds) == 0 {
+			return errors.Wrapf(rserrors.ErrInvalidParameter, "escalation step %d: channelIds", i)
+		}
+	}
+	return nil
+}
+
+// ChannelIds returns the channels of the steps, without duplicates.
+func (steps EscalationSteps) ChannelIds() []string {
+	channelIds := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, step := range steps {
+		for _, channelId := range step.ChannelIds {
+			if !seen[channelId] {
+				seen[channelId] = true
+				channelIds = append(channelIds, channelId)
+			}
+		}
+	}
+	return channelIds
+}
+
+// EscalationPolicy notifies more notification channels the longer a down test is left unacknowledged,
+// e.g. the on-call channel at once, the team after 15 minutes and the managers after an hour.
+// A test uses it by AlertPolicy.EscalationPolicyId.
+type EscalationPolicy struct {
+	rsmodels.DefaultValidateChecker
+	Id         string          `json:"id" gorm:"primary_key;Size:36"`
+	Name       string          `json:"name" gorm:"Size:100;unique"`
+	Steps      EscalationSteps `json:"steps" gorm:"Type:JSON"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	ModifiedAt time.Time       `json:"modifiedAt"`
+}
+
+func (policy *EscalationPolicy) Validate() error {
+	if rsvalid.IsZero(policy.Id, policy.Name, policy.CreatedAt, policy.ModifiedAt) {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "EscalationPolicy")
+	}
+	if err := policy.Steps.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+	policy.SetValidated()
+	return nil
+}
+
+func (policy EscalationPolicy) TableName() string {
+	return "escalation_policies"
+}
+
+func (policy *EscalationPolicy) UpdateFromRequest(request EscalationPolicyRequest) error {
+	policy.Name = request.Name
+	policy.Steps = request.Steps
+	policy.ModifiedAt = time.Now()
+	return policy.Validate()
+}
+
+func NewEscalationPolicy(request EscalationPolicyRequest) (*EscalationPolicy, error) {
+	policy := &EscalationPolicy{
+		Id:        rsstr.NewUUID(),
+		CreatedAt: time.Now(),
+	}
+	if err := policy.UpdateFromRequest(request); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return policy, nil
+}
+
+type EscalationPolicyRequest struct {
+	Name  string          `json:"name"`
+	Steps EscalationSteps `json:"steps"`
+}
+
+type EscalationPolicyListRequest struct {
+	Page    int
+	NumItem int
+}
+
+// Escalation is the progress of an escalation policy for one outage of a test.
+// It is kept in the database and polled, so that a step due while the server was down fires once it is back.
+//
+// Step is the number of steps notified so far, and NextAt is when the next one is due.
+// An escalation is closed once it is acknowledged or the test recovers, and NextAt is cleared.
+type Escalation struct {
+	rsmodels.DefaultValidateChecker
+	Id             string     `json:"id" gorm:"primary_key;Size:36"`
+	TestId         string     `json:"testId" gorm:"Size:36;NOT NULL;index"`
+	PolicyId       string     `json:"policyId" gorm:"Size:36;NOT NULL"`
+	ResultId       string     `json:"resultId" gorm:"Size:36"`
+	Step           int        `json:"step"`
+	StartedAt      time.Time  `json:"startedAt"`
+	NextAt         *time.Time `json:"nextAt" gorm:"index"`
+	AcknowledgedAt *time.Time `json:"acknowledgedAt"`
+	AcknowledgedBy string     `json:"acknowledgedBy" gorm:"Size:100"`
+	ResolvedAt     *time.Time `json:"resolvedAt"`
+	CreatedAt      time.Time  `json:"createdAt" gorm:"index"`
+	ModifiedAt     time.Time  `json:"modifiedAt"`
+}
+
+func (escalation *Escalation) Validate() error {
+	if rsvalid.IsZero(escalation.Id, escalation.TestId, escalation.PolicyId, escalation.StartedAt, escalation.CreatedAt) {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "Escalation")
+	}
+	escalation.SetValidated()
+	return nil
+}
+
+func (escalation Escalation) TableName() string {
+	return "escalations"
+}
+
+func (escalation Escalation) IsOpen() bool {
+	return escalation.AcknowledgedAt == nil && escalation.ResolvedAt == nil
+}
+
+// Advance moves past the steps which are due at now and schedules the next one.
+// Several steps are due at once when the server was down while they were.
+func (escalation *Escalation) Advance(policy *EscalationPolicy, now time.Time) []EscalationStep {
+	due := make([]EscalationStep, 0)
+	if !escalation.IsOpen() {
+		return due
+	}
+	for escalation.Step < len(policy.Steps) && !escalation.StartedAt.Add(policy.Steps[escalation.Step].After()).After(now) {
+		due = append(due, policy.Steps[escalation.Step])
+		escalation.Step++
+	}
+	escalation.NextAt = escalation.nextAt(policy)
+	escalation.ModifiedAt = now
+	return due
+}
+
+func (escalation Escalation) nextAt(policy *EscalationPolicy) *time.Time {
+	if escalation.Step >= len(policy.Steps) {
+		return nil
+	}
+	nextAt := escalation.StartedAt.Add(policy.Steps[escalation.Step].After())
+	return &nextAt
+}
+
+// NotifiedChannelIds returns the channels of the steps notified so far.
+func (escalation Escalation) NotifiedChannelIds(policy *EscalationPolicy) []string {
+	step := escalation.Step
+	if step > len(policy.Steps) {
+		step = len(policy.Steps)
+	}
+	return policy.Steps[:step].ChannelIds()
+}
+
+// Acknowledge stops the escalation. The channels already notified are still told when the test recovers.
+func (escalation *Escalation) Acknowledge(by string, at time.Time) error {
+	if !escalation.IsOpen() {
+		return errors.WithStack(ErrEscalationClosed)
+	}
+	escalation.AcknowledgedAt = &at
+	escalation.AcknowledgedBy = by
+	escalation.NextAt = nil
+	escalation.ModifiedAt = at
+	return nil
+}
+
+func (escalation *Escalation) Resolve(at time.Time) {
+	escalation.ResolvedAt = &at
+	escalation.NextAt = nil
+	escalation.ModifiedAt = at
+}
+
+// NewEscalation starts the policy from when the test went down, which is the result that alerted it.
+func NewEscalation(test *Test, policy *EscalationPolicy, result *TestResult) *Escalation {
+	now := time.Now()
+	escalation := &Escalation{
+		DefaultValidateChecker: rsmodels.ValidatedDefaultValidateChecker,
+		Id:                     rsstr.NewUUID(),
+		TestId:                 test.Id,
+		PolicyId:               policy.Id,
+		ResultId:               result.Id,
+		StartedAt:              result.TestedAt,
+		CreatedAt:              now,
+		ModifiedAt:             now,
+	}
+	escalation.NextAt = escalation.nextAt(policy)
+	return escalation
+}
+
+type EscalationAcknowledgeRequest struct {
+	By string `json:"by"`
+}
+
+type EscalationListRequest struct {
+	Page    int
+	NumItem int
+	TestId  string
+	IsOpen  bool
+}
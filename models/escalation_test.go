@@ -0,0 +1,138 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscalation_Advance(t *testing.T) {
+	policy := &EscalationPolicy{
+		Id: "policy",
+		Steps: EscalationSteps{
+			{AfterMinutes: 0, ChannelIds: []string{"a"}},
+			{AfterMinutes: 15, ChannelIds: []string{"b"}},
+			{AfterMinutes: 60, ChannelIds: []string{"c"}},
+		},
+	}
+	startedAt := time.Date(2020, 2, 1, 10, 0, 0, 0, time.UTC)
+	acknowledgedAt := startedAt.Add(time.Minute)
+
+	tests := []struct {
+		name           string
+		escalation     Escalation
+		now            time.Time
+		wantChannelIds []string
+		wantStep       int
+		wantNextAt     *time.Time
+	}{
+		{
+			name:           "immediately",
+			escalation:     Escalation{StartedAt: startedAt},
+			now:            startedAt,
+			wantChannelIds: []string{"a"},
+			wantStep:       1,
+			wantNextAt:     timePtr(startedAt.Add(15 * time.Minute)),
+		},
+		{
+			name:           "not acknowledged within 15 minutes",
+			escalation:     Escalation{StartedAt: startedAt, Step: 1},
+			now:            startedAt.Add(15 * time.Minute),
+			wantChannelIds: []string{"b"},
+			wantStep:       2,
+			wantNextAt:     timePtr(startedAt.Add(time.Hour)),
+		},
+		{
+			name:       "not due yet",
+			escalation: Escalation{StartedAt: startedAt, Step: 1},
+			now:        startedAt.Add(14 * time.Minute),
+			wantStep:   1,
+			wantNextAt: timePtr(startedAt.Add(15 * time.Minute)),
+		},
+		{
+			name:           "steps missed while the server was down",
+			escalation:     Escalation{StartedAt: startedAt, Step: 1},
+			now:            startedAt.Add(2 * time.Hour),
+			wantChannelIds: []string{"b", "c"},
+			wantStep:       3,
+		},
+		{
+			name:       "acknowledged",
+			escalation: Escalation{StartedAt: startedAt, Step: 1, AcknowledgedAt: &acknowledgedAt},
+			now:        startedAt.Add(2 * time.Hour),
+			wantStep:   1,
+		},
+		{
+			name:       "every step notified",
+			escalation: Escalation{StartedAt: startedAt, Step: 3},
+			now:        startedAt.Add(2 * time.Hour),
+			wantStep:   3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			escalation := tt.escalation
+			channelIds := make([]string, 0)
+			for _, step := range escalation.Advance(policy, tt.now) {
+				channelIds = append(channelIds, step.ChannelIds...)
+			}
+			if tt.wantChannelIds == nil {
+				tt.wantChannelIds = []string{}
+			}
+			assert.Equal(t, tt.wantChannelIds, channelIds)
+			assert.Equal(t, tt.wantStep, escalation.Step)
+			assert.Equal(t, tt.wantNextAt, escalation.NextAt)
+		})
+	}
+}
+
+func TestEscalation_Acknowledge(t *testing.T) {
+	policy := &EscalationPolicy{
+		Id: "policy",
+		Steps: EscalationSteps{
+			{AfterMinutes: 0, ChannelIds: []string{"a"}},
+			{AfterMinutes: 15, ChannelIds: []string{"a", "b"}},
+			{AfterMinutes: 60, ChannelIds: []string{"c"}},
+		},
+	}
+	startedAt := time.Now()
+	escalation := NewEscalation(&Test{Id: "test"}, policy, &TestResult{Id: "result", TestedAt: startedAt})
+	assert.Equal(t, startedAt, *escalation.NextAt)
+
+	escalation.Advance(policy, startedAt.Add(20*time.Minute))
+	assert.NoError(t, escalation.Acknowledge("alice", startedAt.Add(30*time.Minute)))
+	assert.False(t, escalation.IsOpen())
+	assert.Nil(t, escalation.NextAt)
+	assert.Empty(t, escalation.Advance(policy, startedAt.Add(2*time.Hour)))
+	assert.Equal(t, []string{"a", "b"}, escalation.NotifiedChannelIds(policy))
+
+	err := escalation.Acknowledge("bob", startedAt.Add(40*time.Minute))
+	assert.Equal(t, ErrEscalationClosed, errors.Cause(err))
+	assert.Equal(t, "alice", escalation.AcknowledgedBy)
+}
+
+func TestEscalationSteps_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		steps   EscalationSteps
+		wantErr bool
+	}{
+		{name: "escalating", steps: EscalationSteps{{AfterMinutes: 0, ChannelIds: []string{"a"}}, {AfterMinutes: 15, ChannelIds: []string{"b"}}}},
+		{name: "no steps", steps: EscalationSteps{}, wantErr: true},
+		{name: "out of order", steps: EscalationSteps{{AfterMinutes: 15, ChannelIds: []string{"a"}}, {AfterMinutes: 0, ChannelIds: []string{"b"}}}, wantErr: true},
+		{name: "negative", steps: EscalationSteps{{AfterMinutes: -1, ChannelIds: []string{"a"}}}, wantErr: true},
+		{name: "no channels", steps: EscalationSteps{{AfterMinutes: 0}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.steps.Validate()
+			assert.Equal(t, tt.wantErr, err != nil, "error: %v", err)
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
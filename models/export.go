@@ -0,0 +1,111 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+)
+
+// ExportFormat is the format the results are exported in.
+type ExportFormat string
+
+const (
+	ExportCSV    ExportFormat = "csv"
+	ExportNDJSON ExportFormat = "ndjson"
+)
+
+func (format ExportFormat) ContentType() string {
+	if format == ExportNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// TestResultExportRequest filters the results exported to those tested in [From, To),
+// of the test and of the web service if given. Both succeeded and failed results are exported if IsSuccess is nil.
+type TestResultExportRequest struct {
+	Format       ExportFormat
+	TestId       string
+	WebServiceId string
+	IsSuccess    *bool
+	From         time.Time
+	To           time.Time
+}
+
+// FileName is the name the export is downloaded as, e.g. results-20200301T000000Z-20200401T000000Z.csv.
+func (request TestResultExportRequest) FileName() string {
+	const layout = "20060102T150405Z"
+	return "results-" + request.From.UTC().Format(layout) + "-" + request.To.UTC().Format(layout) + "." + string(request.Format)
+}
+
+// NewTestResultExportRequest reads the format, csv by default, and the range like NewUptimeRequest.
+func NewTestResultExportRequest(format, testId, webServiceId, isSuccess, window, from, to string, now time.Time) (TestResultExportRequest, error) {
+	request := TestResultExportRequest{
+		Format:       ExportFormat(format),
+		TestId:       testId,
+		WebServiceId: webServiceId,
+	}
+	switch request.Format {
+	case "":
+		request.Format = ExportCSV
+	case ExportCSV, ExportNDJSON:
+	default:
+		return request, errors.Wrap(rserrors.ErrInvalidParameter, "format")
+	}
+	if isSuccess != "" {
+		parsed, err := strconv.ParseBool(isSuccess)
+		if err != nil {
+			return request, errors.Wrap(rserrors.ErrInvalidParameter, "is_success")
+		}
+		request.IsSuccess = &parsed
+	}
+
+	timeRange, err := parseTimeRange(window, from, to, now)
+	if err != nil {
+		return request, errors.WithStack(err)
+	}
+	request.From, request.To = timeRange.Start, timeRange.End
+	return request, nil
+}
+
+// TestResultExport is an exported result. The response bodies are left out.
+type TestResultExport struct {
+	Id            string           `json:"id"`
+	WebServiceId  string           `json:"webServiceId"`
+	TestId        string           `json:"testId"`
+	TestedAt      time.Time        `json:"testedAt"`
+	IsSuccess     bool             `json:"isSuccess"`
+	StatusCode    int              `json:"statusCode"`
+	ResponseTime  int64            `json:"responseTime"`
+	InMaintenance bool             `json:"inMaintenance"`
+	Assertions    AssertionResults `json:"assertions"`
+}
+
+// TestResultExportHeader is the header of the CSV export, in the order of TestResultExport.CSVRecord.
+var TestResultExportHeader = []string{
+	"id", "web_service_id", "test_id", "tested_at", "is_success", "status_code", "response_time", "in_maintenance", "failed_assertions",
+}
+
+// CSVRecord lists the failed assertions by field, separated by semicolons.
+func (export *TestResultExport) CSVRecord() []string {
+	failures := export.Assertions.Failures()
+	fields := make([]string, 0, len(failures))
+	for _, failure := range failures {
+		fields = append(fields, failure.Field)
+	}
+	return []string{
+		export.Id,
+		export.WebServiceId,
+		export.TestId,
+		export.TestedAt.UTC().Format(time.RFC3339Nano),
+		strconv.FormatBool(export.IsSuccess),
+		strconv.Itoa(export.StatusCode),
+		strconv.FormatInt(export.ResponseTime, 10),
+		strconv.FormatBool(export.InMaintenance),
+		strings.Join(fields, ";"),
+	}
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTestResultExportRequest(t *testing.T) {
+	now := time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	request, err := NewTestResultExportRequest("", "test", "", "false", "", "2020-01-01T00:00:00Z", "", now)
+	if assert.NoError(t, err) {
+		assert.Equal(t, ExportCSV, request.Format)
+		assert.Equal(t, "test", request.TestId)
+		if assert.NotNil(t, request.IsSuccess) {
+			assert.False(t, *request.IsSuccess)
+		}
+		assert.Equal(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), request.From)
+		assert.Equal(t, now, request.To)
+		assert.Equal(t, "results-20200101T000000Z-20200401T000000Z.csv", request.FileName())
+	}
+
+	request, err = NewTestResultExportRequest("ndjson", "", "web-service", "", "7d", "", "", now)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "application/x-ndjson", request.Format.ContentType())
+		assert.Equal(t, now.AddDate(0, 0, -7), request.From)
+		assert.Nil(t, request.IsSuccess)
+	}
+
+	request, err = NewTestResultExportRequest("", "", "web-service", "true", "7d", "", "", now)
+	if assert.NoError(t, err) && assert.NotNil(t, request.IsSuccess) {
+		assert.True(t, *request.IsSuccess)
+	}
+
+	_, err = NewTestResultExportRequest("xml", "", "", "", "", "", "", now)
+	assert.Error(t, err)
+	_, err = NewTestResultExportRequest("", "", "", "maybe", "", "", "", now)
+	assert.Error(t, err)
+	_, err = NewTestResultExportRequest("", "", "", "", "1y", "", "", now)
+	assert.Error(t, err)
+}
+
+func TestTestResultExport_CSVRecord(t *testing.T) {
+	export := &TestResultExport{
+		Id:           "result",
+		WebServiceId: "web-service",
+		TestId:       "test",
+		TestedAt:     time.Date(2020, 1, 1, 9, 0, 0, 0, time.FixedZone("KST", 9*60*60)),
+		StatusCode:   500,
+		ResponseTime: 120,
+		Assertions: AssertionResults{
+			{Field: "statusCode", Passed: false},
+			{Field: "body", Passed: true},
+			{Field: "header.Content-Type", Passed: false},
+		},
+	}
+	record := export.CSVRecord()
+	assert.Len(t, record, len(TestResultExportHeader))
+	assert.Equal(t, []string{
+		"result", "web-service", "test", "2020-01-01T00:00:00Z", "false", "500", "120", "false", "statusCode;header.Content-Type",
+	}, record)
+}
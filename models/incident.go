@@ -0,0 +1,198 @@
+package models
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rsmodels"
+	"github.com/realsangil/apimonitor/pkg/rsstr"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+)
+
+var ErrIncidentAcknowledged = errors.New("incident is already acknowledged")
+
+// Incident is an outage of a web service. It opens when the first of its tests is alerted down,
+// and closes when every test which went down during it recovered.
+// A test of the web service which goes down while an incident is open joins it instead of opening another one.
+//
+// OpenKey is the web service while the incident is open and nil once it is closed.
+// It is unique, so that two replicas alerting tests of the same web service at once open a single incident.
+type Incident struct {
+	rsmodels.DefaultValidateChecker
+	Id             string          `json:"id" gorm:"primary_key;Size:36"`
+	WebServiceId   string          `json:"webServiceId" gorm:"Size:36;NOT NULL;index"`
+	WebService     *WebService     `json:"webService,omitempty" gorm:"foreignkey:WebServiceId;association_autoupdate:false;association_autocreate:false"`
+	OpenKey        *string         `json:"-" gorm:"Size:36;unique"`
+	FirstResultId  string          `json:"firstResultId" gorm:"Size:36"`
+	StartedAt      time.Time       `json:"startedAt" gorm:"index"`
+	EndedAt        *time.Time      `json:"endedAt"`
+	Duration       int64           `json:"duration"`
+	AcknowledgedAt *time.Time      `json:"acknowledgedAt"`
+	AcknowledgedBy string          `json:"acknowledgedBy" gorm:"Size:100"`
+	Tests          []*IncidentTest `json:"tests,omitempty" gorm:"foreignkey:IncidentId;association_autoupdate:false;association_autocreate:false"`
+	Notes          []*IncidentNote `json:"notes,omitempty" gorm:"foreignkey:IncidentId;association_autoupdate:false;association_autocreate:false"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	ModifiedAt     time.Time       `json:"modifiedAt"`
+}
+
+func (incident *Incident) Validate() error {
+	if rsvalid.IsZero(incident.Id, incident.WebServiceId, incident.StartedAt, incident.CreatedAt) {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "Incident")
+	}
+	incident.SetValidated()
+	return nil
+}
+
+func (incident Incident) TableName() string {
+	return "incidents"
+}
+
+func (incident Incident) IsOpen() bool {
+	return incident.EndedAt == nil
+}
+
+// Close ends the incident at the recovery of its last test. Duration is in seconds.
+func (incident *Incident) Close(at time.Time) {
+	incident.EndedAt = &at
+	incident.Duration = int64(at.Sub(incident.StartedAt) / time.Second)
+	incident.OpenKey = nil
+	incident.ModifiedAt = time.Now()
+}
+
+// Window is the time the incident was open. The end of an open incident is zero.
+func (incident Incident) Window() TimeWindow {
+	window := TimeWindow{Start: incident.StartedAt}
+	if incident.EndedAt != nil {
+		window.End = *incident.EndedAt
+	}
+	return window
+}
+
+func (incident *Incident) Acknowledge(by string, at time.Time) error {
+	if incident.AcknowledgedAt != nil {
+		return errors.WithStack(ErrIncidentAcknowledged)
+	}
+	incident.AcknowledgedAt = &at
+	incident.AcknowledgedBy = by
+	incident.ModifiedAt = at
+	return nil
+}
+
+// NewIncident opens an incident from the result which alerted the first test down.
+func NewIncident(test *Test, result *TestResult) *Incident {
+	now := time.Now()
+	openKey := test.WebServiceId
+	return &Incident{
+		DefaultValidateChecker: rsmodels.ValidatedDefaultValidateChecker,
+		Id:                     rsstr.NewUUID(),
+		WebServiceId:           test.WebServiceId,
+		OpenKey:                &openKey,
+		FirstResultId:          result.Id,
+		StartedAt:              result.TestedAt,
+		CreatedAt:              now,
+		ModifiedAt:             now,
+	}
+}
+
+type IncidentAcknowledgeRequest struct {
+	By string `json:"by"`
+}
+
+type IncidentListRequest struct {
+	Page         int
+	NumItem      int
+	WebServiceId string
+	IsOpen       bool
+}
+
+// IncidentTest is a test which went down during an incident, from the result which alerted it until it recovered.
+// A test which flaps during an incident joins it once per outage.
+type IncidentTest struct {
+	rsmodels.DefaultValidateChecker
+	Id          string     `json:"id" gorm:"primary_key;Size:36"`
+	IncidentId  string     `json:"incidentId" gorm:"Size:36;NOT NULL;index"`
+	TestId      string     `json:"testId" gorm:"Size:36;NOT NULL;index"`
+	Test        *Test      `json:"test,omitempty" gorm:"foreignkey:TestId;association_autoupdate:false;association_autocreate:false"`
+	ResultId    string     `json:"resultId" gorm:"Size:36"`
+	StartedAt   time.Time  `json:"startedAt"`
+	RecoveredAt *time.Time `json:"recoveredAt"`
+}
+
+func (incidentTest *IncidentTest) Validate() error {
+	if rsvalid.IsZero(incidentTest.Id, incidentTest.IncidentId, incidentTest.TestId, incidentTest.StartedAt) {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "IncidentTest")
+	}
+	incidentTest.SetValidated()
+	return nil
+}
+
+func (incidentTest IncidentTest) TableName() string {
+	return "incident_tests"
+}
+
+func (incidentTest *IncidentTest) Recover(at time.Time) {
+	incidentTest.RecoveredAt = &at
+}
+
+// Window is the time the test was down. The end of a test still down is zero.
+func (incidentTest IncidentTest) Window() TimeWindow {
+	window := TimeWindow{Start: incidentTest.StartedAt}
+	if incidentTest.RecoveredAt != nil {
+		window.End = *incidentTest.RecoveredAt
+	}
+	return window
+}
+
+func NewIncidentTest(incident *Incident, test *Test, result *TestResult) *IncidentTest {
+	return &IncidentTest{
+		DefaultValidateChecker: rsmodels.ValidatedDefaultValidateChecker,
+		Id:                     rsstr.NewUUID(),
+		IncidentId:             incident.Id,
+		TestId:                 test.Id,
+		ResultId:               result.Id,
+		StartedAt:              result.TestedAt,
+	}
+}
+
+// IncidentNote is a note left on an incident while it is investigated or reviewed.
+type IncidentNote struct {
+	rsmodels.DefaultValidateChecker
+	Id         string    `json:"id" gorm:"primary_key;Size:36"`
+	IncidentId string    `json:"incidentId" gorm:"Size:36;NOT NULL;index"`
+	Author     string    `json:"author" gorm:"Size:100"`
+	Body       string    `json:"body" gorm:"Type:TEXT"`
+	CreatedAt  time.Time `json:"createdAt" gorm:"index"`
+}
+
+func (note *IncidentNote) Validate() error {
+	if rsvalid.IsZero(note.Id, note.IncidentId, note.Body, note.CreatedAt) {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "IncidentNote")
+	}
+	note.SetValidated()
+	return nil
+}
+
+func (note IncidentNote) TableName() string {
+	return "incident_notes"
+}
+
+func NewIncidentNote(incident *Incident, request IncidentNoteRequest) (*IncidentNote, error) {
+	note := &IncidentNote{
+		Id:         rsstr.NewUUID(),
+		IncidentId: incident.Id,
+		Author:     request.Author,
+		Body:       request.Body,
+		CreatedAt:  time.Now(),
+	}
+	if err := note.Validate(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return note, nil
+}
+
+type IncidentNoteRequest struct {
+	Author string `json:"author"`
+	Body   string `json:"body"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncident_Close(t *testing.T) {
+	startedAt := time.Date(2020, 2, 1, 10, 0, 0, 0, time.UTC)
+	incident := NewIncident(&Test{Id: "test", WebServiceId: "ws"}, &TestResult{Id: "result", TestedAt: startedAt})
+	assert.True(t, incident.IsOpen())
+	if assert.NotNil(t, incident.OpenKey) {
+		assert.Equal(t, "ws", *incident.OpenKey)
+	}
+	assert.Equal(t, "result", incident.FirstResultId)
+
+	incident.Close(startedAt.Add(12*time.Minute + 30*time.Second))
+	assert.False(t, incident.IsOpen())
+	assert.Nil(t, incident.OpenKey)
+	assert.Equal(t, int64(750), incident.Duration)
+}
+
+func TestIncident_Acknowledge(t *testing.T) {
+	incident := NewIncident(&Test{Id: "test", WebServiceId: "ws"}, &TestResult{Id: "result", TestedAt: time.Now()})
+	assert.NoError(t, incident.Acknowledge("alice", time.Now()))
+
+	err := incident.Acknowledge("bob", time.Now())
+	assert.Equal(t, ErrIncidentAcknowledged, errors.Cause(err))
+	assert.Equal(t, "alice", incident.AcknowledgedBy)
+}
@@ -0,0 +1,209 @@
+package models
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rsstats"
+)
+
+const (
+	DefaultLatencyBucket = "1h"
+
+	// MaxLatencyBuckets bounds the range of a request, e.g. a day by minute.
+	MaxLatencyBuckets = 1500
+)
+
+var (
+	latencyBuckets = map[string]time.Duration{
+		"1m": time.Minute,
+		"1h": time.Hour,
+		"1d": 24 * time.Hour,
+	}
+
+	// LatencyHistogramBounds are the upper bounds of the latency histogram, in milliseconds.
+	LatencyHistogramBounds = []int64{50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000}
+)
+
+// LatencyStatisticsRequest is the range [From, To) of the statistics, summarized by Bucket.
+type LatencyStatisticsRequest struct {
+	From   time.Time
+	To     time.Time
+	Bucket string
+}
+
+func (request LatencyStatisticsRequest) BucketDuration() time.Duration {
+	return latencyBuckets[request.Bucket]
+}
+
+// NewLatencyStatisticsRequest reads the range like NewUptimeRequest, and a bucket of 1m, 1h or 1d.
+func NewLatencyStatisticsRequest(bucket, window, from, to string, now time.Time) (LatencyStatisticsRequest, error) {
+	if bucket == "" {
+		bucket = DefaultLatencyBucket
+	}
+	duration, ok := latencyBuckets[bucket]
+	if !ok {
+		return LatencyStatisticsRequest{}, errors.Wrap(rserrors.ErrInvalidParameter, "bucket")
+	}
+
+	timeRange, err := parseTimeRange(window, from, to, now)
+	if err != nil {
+		return LatencyStatisticsRequest{}, errors.WithStack(err)
+	}
+	if timeRange.Duration()/duration > MaxLatencyBuckets {
+		return LatencyStatisticsRequest{}, errors.Wrap(rserrors.ErrInvalidParameter, "too many buckets")
+	}
+
+	return LatencyStatisticsRequest{
+		From:   timeRange.Start,
+		To:     timeRange.End,
+		Bucket: bucket,
+	}, nil
+}
+
+// LatencyBucket summarizes the response times of the results tested in [Start, Start+bucket).
+type LatencyBucket struct {
+	Start time.Time `json:"start"`
+	rsstats.Summary
+}
+
+// LatencyStatistics summarizes the response times of a test, in milliseconds.
+// The buckets start at multiples of the bucket in UTC, and those without results are left out.
+// The results in maintenance are not counted.
+//
+// When a part of the range is read from the rollups, the percentiles of the summary are estimated
+// from the histogram, since the response times themselves are gone, unless the range is a single rollup.
+// The buckets stay exact.
+type LatencyStatistics struct {
+	From      time.Time                 `json:"from"`
+	To        time.Time                 `json:"to"`
+	Bucket    string                    `json:"bucket"`
+	Summary   rsstats.Summary           `json:"summary"`
+	Buckets   []LatencyBucket           `json:"buckets"`
+	Histogram []rsstats.HistogramBucket `json:"histogram"`
+}
+
+// MaxBucketP95 is the highest p95 of the buckets, which are exact unlike the percentiles of the summary.
+func (statistics LatencyStatistics) MaxBucketP95() int64 {
+	var max int64
+	for _, bucket := range statistics.Buckets {
+		if bucket.P95 > max {
+			max = bucket.P95
+		}
+	}
+	return max
+}
+
+// LatencyStatisticsBuilder builds the statistics from the results and the rollups in the order they were tested,
+// holding the response times of one bucket and of the whole range.
+type LatencyStatisticsBuilder struct {
+	request   LatencyStatisticsRequest
+	buckets   []LatencyBucket
+	current   time.Time
+	samples   []int64
+	all       []int64
+	histogram *rsstats.Histogram
+	rollups   []rsstats.Summary
+}
+
+func (builder *LatencyStatisticsBuilder) Add(testedAt time.Time, responseTime int64) {
+	start := testedAt.Truncate(builder.request.BucketDuration())
+	if !start.Equal(builder.current) {
+		builder.flush()
+		builder.current = start
+	}
+	builder.samples = append(builder.samples, responseTime)
+	builder.all = append(builder.all, responseTime)
+	builder.histogram.Add(responseTime)
+}
+
+// AddRollup adds a rollup whose granularity is the bucket of the request.
+func (builder *LatencyStatisticsBuilder) AddRollup(rollup *TestResultRollup) {
+	if rollup.TotalCount == 0 {
+		return
+	}
+	builder.flush()
+	builder.current = rollup.Start
+	builder.buckets = append(builder.buckets, LatencyBucket{
+		Start:   rollup.Start,
+		Summary: rollup.Summary(),
+	})
+	builder.rollups = append(builder.rollups, rollup.Summary())
+	builder.histogram.Merge(rollup.Histogram)
+}
+
+func (builder *LatencyStatisticsBuilder) flush() {
+	if len(builder.samples) == 0 {
+		return
+	}
+	builder.buckets = append(builder.buckets, LatencyBucket{
+		Start:   builder.current,
+		Summary: rsstats.Summarize(builder.samples),
+	})
+	builder.samples = builder.samples[:0]
+}
+
+func (builder *LatencyStatisticsBuilder) Build() *LatencyStatistics {
+	builder.flush()
+	return &LatencyStatistics{
+		From:      builder.request.From,
+		To:        builder.request.To,
+		Bucket:    builder.request.Bucket,
+		Summary:   builder.summary(),
+		Buckets:   builder.buckets,
+		Histogram: builder.histogram.Buckets(),
+	}
+}
+
+func (builder *LatencyStatisticsBuilder) summary() rsstats.Summary {
+	summary := rsstats.Summarize(builder.all)
+	if len(builder.rollups) == 0 {
+		return summary
+	}
+	if len(builder.rollups) == 1 && summary.Count == 0 {
+		// The rollup is the whole range, so its percentiles are exact.
+		return builder.rollups[0]
+	}
+
+	sum := summary.Mean * float64(summary.Count)
+	for _, rollup := range builder.rollups {
+		if summary.Count == 0 || rollup.Min < summary.Min {
+			summary.Min = rollup.Min
+		}
+		if rollup.Max > summary.Max {
+			summary.Max = rollup.Max
+		}
+		summary.Count += rollup.Count
+		sum += rollup.Mean * float64(rollup.Count)
+	}
+	summary.Mean = sum / float64(summary.Count)
+	summary.P50 = builder.percentile(50, summary)
+	summary.P90 = builder.percentile(90, summary)
+	summary.P95 = builder.percentile(95, summary)
+	summary.P99 = builder.percentile(99, summary)
+	return summary
+}
+
+func (builder *LatencyStatisticsBuilder) percentile(p float64, summary rsstats.Summary) int64 {
+	percentile := builder.histogram.Percentile(p, summary.Max)
+	if percentile < summary.Min {
+		return summary.Min
+	}
+	if percentile > summary.Max {
+		return summary.Max
+	}
+	return percentile
+}
+
+func NewLatencyStatisticsBuilder(request LatencyStatisticsRequest) *LatencyStatisticsBuilder {
+	return &LatencyStatisticsBuilder{
+		request:   request,
+		buckets:   make([]LatencyBucket, 0),
+		samples:   make([]int64, 0),
+		all:       make([]int64, 0),
+		histogram: rsstats.NewHistogram(LatencyHistogramBounds...),
+		rollups:   make([]rsstats.Summary, 0),
+	}
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/realsangil/apimonitor/pkg/rsstats"
+)
+
+func TestNewLatencyStatisticsRequest(t *testing.T) {
+	now := time.Date(2020, 2, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		bucket     string
+		window     string
+		wantBucket string
+		wantErr    bool
+	}{
+		{name: "default", wantBucket: "1h"},
+		{name: "a day by minute", bucket: "1m", window: "24h", wantBucket: "1m"},
+		{name: "a week by minute", bucket: "1m", window: "7d", wantErr: true},
+		{name: "a month by day", bucket: "1d", window: "30d", wantBucket: "1d"},
+		{name: "unknown bucket", bucket: "5m", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := NewLatencyStatisticsRequest(tt.bucket, tt.window, "", "", now)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantBucket, request.Bucket)
+			assert.Equal(t, now, request.To)
+		})
+	}
+}
+
+func TestLatencyStatisticsBuilder(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2020, 2, 1, hour, minute, 0, 0, time.UTC)
+	}
+	request := LatencyStatisticsRequest{From: at(10, 0), To: at(13, 0), Bucket: "1h"}
+
+	builder := NewLatencyStatisticsBuilder(request)
+	for _, sample := range []struct {
+		testedAt     time.Time
+		responseTime int64
+	}{
+		{at(10, 0), 100},
+		{at(10, 20), 300},
+		{at(10, 40), 200},
+		{at(12, 10), 40},
+		{at(12, 50), 12000},
+	} {
+		builder.Add(sample.testedAt, sample.responseTime)
+	}
+	statistics := builder.Build()
+
+	assert.Equal(t, "1h", statistics.Bucket)
+	assert.Equal(t, rsstats.Summary{Count: 5, Min: 40, Max: 12000, Mean: 2528, P50: 200, P90: 12000, P95: 12000, P99: 12000}, statistics.Summary)
+	assert.Equal(t, []LatencyBucket{
+		{Start: at(10, 0), Summary: rsstats.Summary{Count: 3, Min: 100, Max: 300, Mean: 200, P50: 200, P90: 300, P95: 300, P99: 300}},
+		{Start: at(12, 0), Summary: rsstats.Summary{Count: 2, Min: 40, Max: 12000, Mean: 6020, P50: 40, P90: 12000, P95: 12000, P99: 12000}},
+	}, statistics.Buckets)
+
+	counts := make([]int, 0)
+	for _, bucket := range statistics.Histogram {
+		counts = append(counts, bucket.Count)
+	}
+	assert.Equal(t, []int{1, 1, 1, 1, 0, 0, 0, 0, 1, 0}, counts)
+}
+
+func TestLatencyStatisticsBuilder_AddRollup(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2020, 2, 1, hour, minute, 0, 0, time.UTC)
+	}
+	request := LatencyStatisticsRequest{From: at(9, 30), To: at(12, 0), Bucket: "1h"}
+
+	rollup := NewTestResultRollupBuilder("test", at(10, 0))
+	for _, responseTime := range []int64{100, 200, 300, 400} {
+		rollup.Add(&TestResult{TestId: "test", IsSuccess: true, StatusCode: 200, ResponseTime: responseTime})
+	}
+
+	builder := NewLatencyStatisticsBuilder(request)
+	builder.Add(at(9, 40), 50)
+	builder.AddRollup(rollup.Build())
+	builder.AddRollup(&TestResultRollup{TestId: "test", Start: at(11, 0)})
+	statistics := builder.Build()
+
+	assert.Equal(t, []LatencyBucket{
+		{Start: at(9, 0), Summary: rsstats.Summary{Count: 1, Min: 50, Max: 50, Mean: 50, P50: 50, P90: 50, P95: 50, P99: 50}},
+		{Start: at(10, 0), Summary: rsstats.Summary{Count: 4, Min: 100, Max: 400, Mean: 250, P50: 200, P90: 400, P95: 400, P99: 400}},
+	}, statistics.Buckets)
+	assert.Equal(t, rsstats.Summary{Count: 5, Min: 50, Max: 400, Mean: 210, P50: 250, P90: 400, P95: 400, P99: 400}, statistics.Summary)
+}
+
+func TestLatencyStatisticsBuilder_SingleRollup(t *testing.T) {
+	start := time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)
+	request := LatencyStatisticsRequest{From: start, To: start.Add(24 * time.Hour), Bucket: "1d"}
+
+	// Response times between the bounds of the histogram, whose estimated p95 would be off.
+	rollup := NewTestResultRollupBuilder("test", start)
+	for responseTime := int64(1); responseTime <= 20; responseTime++ {
+		rollup.Add(&TestResult{TestId: "test", IsSuccess: true, StatusCode: 200, ResponseTime: responseTime})
+	}
+	built := rollup.Build()
+
+	builder := NewLatencyStatisticsBuilder(request)
+	builder.AddRollup(built)
+	statistics := builder.Build()
+
+	assert.Equal(t, built.Summary(), statistics.Summary)
+	assert.Equal(t, built.P95ResponseTime, statistics.MaxBucketP95())
+}
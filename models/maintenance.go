@@ -0,0 +1,331 @@
+package models
+
+import (
+	"database/sql/driver"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rsmodels"
+	"github.com/realsangil/apimonitor/pkg/rsstr"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+)
+
+const (
+	MaintenanceTypeOnce   MaintenanceType = "once"
+	MaintenanceTypeDaily  MaintenanceType = "daily"
+	MaintenanceTypeWeekly MaintenanceType = "weekly"
+
+	maintenanceClockLayout = "15:04"
+)
+
+type MaintenanceType string
+
+func (maintenanceType MaintenanceType) Validate() error {
+	switch maintenanceType {
+	case MaintenanceTypeOnce, MaintenanceTypeDaily, MaintenanceTypeWeekly:
+		return nil
+	default:
+		return errors.Wrap(rserrors.ErrInvalidParameter, "MaintenanceType")
+	}
+}
+
+func (maintenanceType MaintenanceType) IsRecurring() bool {
+	return maintenanceType != MaintenanceTypeOnce
+}
+
+// Maintenance is a planned maintenance window of a WebService.
+// A one-off window is the range [StartAt, EndAt).
+// A recurring window repeats Recurrence every day or on the given weekdays,
+// and StartAt/EndAt optionally bound the period in which it is active.
+type Maintenance struct {
+	rsmodels.DefaultValidateChecker
+	Id           string                `json:"id" gorm:"Size:36"`
+	WebServiceId string                `json:"webServiceId" gorm:"Size:36;NOT NULL"`
+	Name         string                `json:"name"`
+	Description  string                `json:"description" gorm:"Type:TEXT"`
+	Type         MaintenanceType       `json:"type" gorm:"Size:10"`
+	StartAt      *time.Time            `json:"startAt"`
+	EndAt        *time.Time            `json:"endAt"`
+	Recurrence   MaintenanceRecurrence `json:"recurrence" gorm:"Type:JSON"`
+	CreatedAt    time.Time             `json:"createdAt"`
+	ModifiedAt   time.Time             `json:"modifiedAt"`
+}
+
+func (maintenance *Maintenance) Validate() error {
+	if rsvalid.IsZero(
+		maintenance.Id,
+		maintenance.WebServiceId,
+		maintenance.Name,
+		maintenance.Type,
+		maintenance.CreatedAt,
+		maintenance.ModifiedAt,
+	) {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "Maintenance")
+	}
+	if err := maintenance.Type.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+	if !maintenance.Type.IsRecurring() && (rsvalid.IsZero(maintenance.StartAt, maintenance.EndAt)) {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "Maintenance.StartAt/EndAt")
+	}
+	if !rsvalid.IsZero(maintenance.StartAt, maintenance.EndAt) && !maintenance.EndAt.After(*maintenance.StartAt) {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "Maintenance.EndAt")
+	}
+	if maintenance.Type.IsRecurring() {
+		if err := maintenance.Recurrence.Validate(maintenance.Type); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	maintenance.SetValidated()
+	return nil
+}
+
+func (maintenance *Maintenance) UpdateFromRequest(request MaintenanceRequest) error {
+	maintenance.Name = request.Name
+	maintenance.Description = request.Description
+	maintenance.Type = request.Type
+	maintenance.StartAt = request.StartAt
+	maintenance.EndAt = request.EndAt
+	maintenance.Recurrence = request.Recurrence
+	if !maintenance.Type.IsRecurring() {
+		maintenance.Recurrence = MaintenanceRecurrence{}
+	}
+	maintenance.ModifiedAt = time.Now()
+	return maintenance.Validate()
+}
+
+// Contains reports whether t falls inside the maintenance window.
+func (maintenance Maintenance) Contains(t time.Time) bool {
+	for _, window := range maintenance.Windows(t, t.Add(time.Nanosecond)) {
+		if window.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Windows returns the occurrences of the maintenance that overlap [from, to),
+// clipped to that range and sorted by start time.
+func (maintenance Maintenance) Windows(from, to time.Time) []TimeWindow {
+	windows := make([]TimeWindow, 0)
+	if !to.After(from) {
+		return windows
+	}
+
+	bound := TimeWindow{Start: from, End: to}
+	if maintenance.StartAt != nil && maintenance.StartAt.After(bound.Start) {
+		bound.Start = *maintenance.StartAt
+	}
+	if maintenance.EndAt != nil && maintenance.EndAt.Before(bound.End) {
+		bound.End = *maintenance.EndAt
+	}
+	if !bound.End.After(bound.Start) {
+		return windows
+	}
+
+	if !maintenance.Type.IsRecurring() {
+		return append(windows, bound)
+	}
+
+	for _, occurrence := range maintenance.Recurrence.occurrences(maintenance.Type, bound.Start, bound.End) {
+		if clipped, ok := occurrence.Intersect(bound); ok {
+			windows = append(windows, clipped)
+		}
+	}
+	return windows
+}
+
+func (maintenance Maintenance) TableName() string {
+	return "maintenances"
+}
+
+func NewMaintenance(webService *WebService, request MaintenanceRequest) (*Maintenance, error) {
+	if rsvalid.IsZero(webService, request) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "Maintenance")
+	}
+	maintenance := &Maintenance{
+		Id:           rsstr.NewUUID(),
+		WebServiceId: webService.Id,
+		CreatedAt:    time.Now(),
+	}
+	if err := maintenance.UpdateFromRequest(request); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return maintenance, nil
+}
+
+// MaintenanceRecurrence describes a daily or weekly window such as
+// "every Sunday 02:00-04:00 Asia/Seoul". An EndTime that is not after the
+// StartTime means the window ends on the following day.
+type MaintenanceRecurrence struct {
+	Weekdays  Weekdays `json:"weekdays,omitempty"`
+	StartTime string   `json:"startTime,omitempty"`
+	EndTime   string   `json:"endTime,omitempty"`
+	Timezone  string   `json:"timezone,omitempty"`
+}
+
+func (recurrence *MaintenanceRecurrence) Scan(src interface{}) error {
+	return rsdb.ScanJson(recurrence, src)
+}
+
+func (recurrence MaintenanceRecurrence) Value() (driver.Value, error) {
+	return rsdb.JsonValue(recurrence)
+}
+
+func (recurrence MaintenanceRecurrence) Validate(maintenanceType MaintenanceType) error {
+	if maintenanceType == MaintenanceTypeWeekly && rsvalid.IsZero(recurrence.Weekdays) {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "MaintenanceRecurrence.Weekdays")
+	}
+	if err := recurrence.Weekdays.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err := time.Parse(maintenanceClockLayout, recurrence.StartTime); err != nil {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "MaintenanceRecurrence.StartTime")
+	}
+	if _, err := time.Parse(maintenanceClockLayout, recurrence.EndTime); err != nil {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "MaintenanceRecurrence.EndTime")
+	}
+	if _, err := recurrence.location(); err != nil {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "MaintenanceRecurrence.Timezone")
+	}
+	return nil
+}
+
+func (recurrence MaintenanceRecurrence) location() (*time.Location, error) {
+	if recurrence.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(recurrence.Timezone)
+}
+
+func (recurrence MaintenanceRecurrence) occurrences(maintenanceType MaintenanceType, from, to time.Time) []TimeWindow {
+	windows := make([]TimeWindow, 0)
+
+	loc, err := recurrence.location()
+	if err != nil {
+		return windows
+	}
+	start, err := time.Parse(maintenanceClockLayout, recurrence.StartTime)
+	if err != nil {
+		return windows
+	}
+	end, err := time.Parse(maintenanceClockLayout, recurrence.EndTime)
+	if err != nil {
+		return windows
+	}
+
+	// A window that starts on the day before "from" may still be running.
+	localFrom := from.In(loc)
+	day := time.Date(localFrom.Year(), localFrom.Month(), localFrom.Day()-1, 0, 0, 0, 0, loc)
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		if maintenanceType == MaintenanceTypeWeekly && !recurrence.Weekdays.Has(day.Weekday()) {
+			continue
+		}
+		window := TimeWindow{
+			Start: time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, loc),
+			End:   time.Date(day.Year(), day.Month(), day.Day(), end.Hour(), end.Minute(), 0, 0, loc),
+		}
+		if !window.End.After(window.Start) {
+			window.End = window.End.AddDate(0, 0, 1)
+		}
+		windows = append(windows, window)
+	}
+	return windows
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// Weekdays is a list of lower-case three-letter weekday names such as "sun".
+type Weekdays []string
+
+func (weekdays Weekdays) Validate() error {
+	for _, weekday := range weekdays {
+		if _, exist := weekdayNames[strings.ToLower(weekday)]; !exist {
+			return errors.Wrap(rserrors.ErrInvalidParameter, "Weekdays")
+		}
+	}
+	return nil
+}
+
+func (weekdays Weekdays) Has(weekday time.Weekday) bool {
+	for _, w := range weekdays {
+		if d, exist := weekdayNames[strings.ToLower(w)]; exist && d == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+type TimeWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+func (window TimeWindow) Contains(t time.Time) bool {
+	return !t.Before(window.Start) && t.Before(window.End)
+}
+
+func (window TimeWindow) Duration() time.Duration {
+	return window.End.Sub(window.Start)
+}
+
+func (window TimeWindow) Intersect(other TimeWindow) (TimeWindow, bool) {
+	intersection := window
+	if other.Start.After(intersection.Start) {
+		intersection.Start = other.Start
+	}
+	if other.End.Before(intersection.End) {
+		intersection.End = other.End
+	}
+	return intersection, intersection.End.After(intersection.Start)
+}
+
+// MergeTimeWindows sorts the windows and merges the overlapping ones.
+func MergeTimeWindows(windows []TimeWindow) []TimeWindow {
+	sorted := make([]TimeWindow, len(windows))
+	copy(sorted, windows)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+
+	merged := make([]TimeWindow, 0, len(sorted))
+	for _, window := range sorted {
+		last := len(merged) - 1
+		if last >= 0 && !window.Start.After(merged[last].End) {
+			if window.End.After(merged[last].End) {
+				merged[last].End = window.End
+			}
+			continue
+		}
+		merged = append(merged, window)
+	}
+	return merged
+}
+
+type MaintenanceRequest struct {
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	Type        MaintenanceType       `json:"type"`
+	StartAt     *time.Time            `json:"startAt"`
+	EndAt       *time.Time            `json:"endAt"`
+	Recurrence  MaintenanceRecurrence `json:"recurrence"`
+}
+
+type MaintenanceListRequest struct {
+	Page         int
+	NumItem      int
+	WebServiceId string
+}
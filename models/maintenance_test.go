@@ -0,0 +1,189 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaintenance_Contains(t *testing.T) {
+	kst, err := time.LoadLocation("Asia/Seoul")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	startAt := time.Date(2020, 2, 1, 10, 0, 0, 0, time.UTC)
+	endAt := time.Date(2020, 2, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		maintenance Maintenance
+		t           time.Time
+		want        bool
+	}{
+		{
+			name: "once inside",
+			maintenance: Maintenance{
+				Type:    MaintenanceTypeOnce,
+				StartAt: &startAt,
+				EndAt:   &endAt,
+			},
+			t:    time.Date(2020, 2, 1, 11, 0, 0, 0, time.UTC),
+			want: true,
+		},
+		{
+			name: "once at end",
+			maintenance: Maintenance{
+				Type:    MaintenanceTypeOnce,
+				StartAt: &startAt,
+				EndAt:   &endAt,
+			},
+			t:    endAt,
+			want: false,
+		},
+		{
+			name: "weekly sunday in kst",
+			maintenance: Maintenance{
+				Type: MaintenanceTypeWeekly,
+				Recurrence: MaintenanceRecurrence{
+					Weekdays:  Weekdays{"sun"},
+					StartTime: "02:00",
+					EndTime:   "04:00",
+					Timezone:  "Asia/Seoul",
+				},
+			},
+			// 2020-02-02 is a Sunday; 03:00 KST is 18:00 UTC on Saturday.
+			t:    time.Date(2020, 2, 2, 3, 0, 0, 0, kst).UTC(),
+			want: true,
+		},
+		{
+			name: "weekly monday in kst",
+			maintenance: Maintenance{
+				Type: MaintenanceTypeWeekly,
+				Recurrence: MaintenanceRecurrence{
+					Weekdays:  Weekdays{"sun"},
+					StartTime: "02:00",
+					EndTime:   "04:00",
+					Timezone:  "Asia/Seoul",
+				},
+			},
+			t:    time.Date(2020, 2, 3, 3, 0, 0, 0, kst),
+			want: false,
+		},
+		{
+			name: "daily across midnight",
+			maintenance: Maintenance{
+				Type: MaintenanceTypeDaily,
+				Recurrence: MaintenanceRecurrence{
+					StartTime: "23:00",
+					EndTime:   "01:00",
+				},
+			},
+			t:    time.Date(2020, 2, 3, 0, 30, 0, 0, time.UTC),
+			want: true,
+		},
+		{
+			name: "daily before active period",
+			maintenance: Maintenance{
+				Type:    MaintenanceTypeDaily,
+				StartAt: &endAt,
+				Recurrence: MaintenanceRecurrence{
+					StartTime: "10:00",
+					EndTime:   "11:00",
+				},
+			},
+			t:    time.Date(2020, 2, 1, 10, 30, 0, 0, time.UTC),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.maintenance.Contains(tt.t))
+		})
+	}
+}
+
+func TestMaintenance_Windows(t *testing.T) {
+	maintenance := Maintenance{
+		Type: MaintenanceTypeDaily,
+		Recurrence: MaintenanceRecurrence{
+			StartTime: "23:00",
+			EndTime:   "01:00",
+		},
+	}
+	from := time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2020, 2, 2, 0, 0, 0, 0, time.UTC)
+
+	want := []TimeWindow{
+		{Start: from, End: time.Date(2020, 2, 1, 1, 0, 0, 0, time.UTC)},
+		{Start: time.Date(2020, 2, 1, 23, 0, 0, 0, time.UTC), End: to},
+	}
+	assert.Equal(t, want, maintenance.Windows(from, to))
+}
+
+func TestMaintenance_Validate(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+
+	tests := []struct {
+		name        string
+		maintenance Maintenance
+		wantErr     bool
+	}{
+		{
+			name: "pass once",
+			maintenance: Maintenance{
+				Id: "maintenance", WebServiceId: "webService", Name: "deploy",
+				Type: MaintenanceTypeOnce, StartAt: &now, EndAt: &later,
+				CreatedAt: now, ModifiedAt: now,
+			},
+			wantErr: false,
+		},
+		{
+			name: "once without range",
+			maintenance: Maintenance{
+				Id: "maintenance", WebServiceId: "webService", Name: "deploy",
+				Type:      MaintenanceTypeOnce,
+				CreatedAt: now, ModifiedAt: now,
+			},
+			wantErr: true,
+		},
+		{
+			name: "end before start",
+			maintenance: Maintenance{
+				Id: "maintenance", WebServiceId: "webService", Name: "deploy",
+				Type: MaintenanceTypeOnce, StartAt: &later, EndAt: &now,
+				CreatedAt: now, ModifiedAt: now,
+			},
+			wantErr: true,
+		},
+		{
+			name: "weekly without weekdays",
+			maintenance: Maintenance{
+				Id: "maintenance", WebServiceId: "webService", Name: "backup",
+				Type:       MaintenanceTypeWeekly,
+				Recurrence: MaintenanceRecurrence{StartTime: "02:00", EndTime: "04:00"},
+				CreatedAt:  now, ModifiedAt: now,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid timezone",
+			maintenance: Maintenance{
+				Id: "maintenance", WebServiceId: "webService", Name: "backup",
+				Type:       MaintenanceTypeDaily,
+				Recurrence: MaintenanceRecurrence{StartTime: "02:00", EndTime: "04:00", Timezone: "Mars/Olympus"},
+				CreatedAt:  now, ModifiedAt: now,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.maintenance.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
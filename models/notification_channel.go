@@ -0,0 +1,278 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rsmodels"
+	"github.com/realsangil/apimonitor/pkg/rsnotify"
+	"github.com/realsangil/apimonitor/pkg/rsstr"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+)
+
+// NotifierConfig is the JSON config of a notifier, kept as is.
+type NotifierConfig json.RawMessage
+
+// MarshalJSON masks the credentials of the config, which is stored with it, see Value.
+func (config NotifierConfig) MarshalJSON() ([]byte, error) {
+	if len(config) == 0 {
+		return []byte("null"), nil
+	}
+	return rsnotify.RedactConfig(config), nil
+}
+
+func (config *NotifierConfig) UnmarshalJSON(data []byte) error {
+	*config = append((*config)[:0], data...)
+	return nil
+}
+
+func (config *NotifierConfig) Scan(src interface{}) error {
+	if src == nil {
+		*config = nil
+		return nil
+	}
+	data, ok := src.([]byte)
+	if !ok {
+		return errors.New("Invalid Scan Source")
+	}
+	// The driver reuses its buffer, so the bytes are copied.
+	*config = append((*config)[:0], data...)
+	return nil
+}
+
+func (config NotifierConfig) Value() (driver.Value, error) {
+	if len(config) == 0 {
+		return nil, nil
+	}
+	return []byte(config), nil
+}
+
+// NotificationChannel is a notifier shared by tests, like the Slack channel of a team.
+// It alerts the tests it is bound to by NotificationChannelBindings.
+type NotificationChannel struct {
+	rsmodels.DefaultValidateChecker
+	Id         string         `json:"id" gorm:"primary_key;Size:36"`
+	Name       string         `json:"name" gorm:"Size:100;unique"`
+	Type       rsnotify.Type  `json:"type" gorm:"Size:20"`
+	Disabled   bool           `json:"disabled"`
+	Config     NotifierConfig `json:"config" gorm:"Type:JSON"`
+	Template   AlertTemplate  `json:"template" gorm:"Type:TEXT"`
+	CreatedAt  time.Time      `json:"createdAt"`
+	ModifiedAt time.Time      `json:"modifiedAt"`
+}
+
+func (channel *NotificationChannel) Validate() error {
+	if rsvalid.IsZero(channel.Id, channel.Name, channel.Type, channel.CreatedAt, channel.ModifiedAt) {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "NotificationChannel")
+	}
+	if err := channel.Alert().Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+	channel.SetValidated()
+	return nil
+}
+
+func (channel NotificationChannel) TableName() string {
+	return "notification_channels"
+}
+
+func (channel NotificationChannel) Alert() *Alert {
+	return &Alert{
+		Type:     channel.Type,
+		Disabled: channel.Disabled,
+		Config:   json.RawMessage(channel.Config),
+		Template: channel.Template,
+	}
+}
+
+func (channel *NotificationChannel) UpdateFromRequest(request NotificationChannelRequest) error {
+	channel.Name = request.Name
+	channel.Type = request.Type
+	channel.Disabled = request.Disabled
+	config, err := rsnotify.KeepCredentials(channel.Config, request.Config)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	channel.Config = NotifierConfig(config)
+	channel.Template = request.Template
+	channel.ModifiedAt = time.Now()
+	return channel.Validate()
+}
+
+func NewNotificationChannel(request NotificationChannelRequest) (*NotificationChannel, error) {
+	channel := &NotificationChannel{
+		Id:        rsstr.NewUUID(),
+		CreatedAt: time.Now(),
+	}
+	if err := channel.UpdateFromRequest(request); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return channel, nil
+}
+
+type NotificationChannelRequest struct {
+	Name     string         `json:"name"`
+	Type     rsnotify.Type  `json:"type"`
+	Disabled bool           `json:"disabled"`
+	Config   NotifierConfig `json:"config"`
+	Template AlertTemplate  `json:"template"`
+}
+
+type NotificationChannelListRequest struct {
+	Page    int
+	NumItem int
+}
+
+// ChannelScope is the level a channel is bound at. A more specific level overrides the binding of
+// the same channel at a broader one: test over WebService over global.
+type ChannelScope string
+
+const (
+	ChannelScopeGlobal     ChannelScope = "global"
+	ChannelScopeWebService ChannelScope = "webService"
+	ChannelScopeTest       ChannelScope = "test"
+)
+
+func (scope ChannelScope) Validate() error {
+	switch scope {
+	case ChannelScopeGlobal, ChannelScopeWebService, ChannelScopeTest:
+		return nil
+	default:
+		return errors.Wrap(rserrors.ErrInvalidParameter, "ChannelScope")
+	}
+}
+
+func (scope ChannelScope) level() int {
+	switch scope {
+	case ChannelScopeWebService:
+		return 1
+	case ChannelScopeTest:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// NotificationChannelBinding attaches a channel to every test, the tests of a WebService or a test.
+// Disabled mutes a channel inherited from a broader level, and Template replaces its template.
+type NotificationChannelBinding struct {
+	rsmodels.DefaultValidateChecker
+	Id        string               `json:"id" gorm:"primary_key;Size:36"`
+	ChannelId string               `json:"channelId" gorm:"Size:36;NOT NULL;unique_index:idx_channel_scope"`
+	Channel   *NotificationChannel `json:"channel,omitempty" gorm:"foreignkey:ChannelId;association_autoupdate:false;association_autocreate:false"`
+	Scope     ChannelScope         `json:"scope" gorm:"Size:20;unique_index:idx_channel_scope"`
+	// ScopeId is the id of the WebService or the test, and empty for the global scope.
+	ScopeId    string        `json:"scopeId" gorm:"Size:36;unique_index:idx_channel_scope;index"`
+	Disabled   bool          `json:"disabled"`
+	Template   AlertTemplate `json:"template" gorm:"Type:TEXT"`
+	CreatedAt  time.Time     `json:"createdAt"`
+	ModifiedAt time.Time     `json:"modifiedAt"`
+}
+
+func (binding *NotificationChannelBinding) Validate() error {
+	if rsvalid.IsZero(binding.Id, binding.ChannelId, binding.Scope, binding.CreatedAt, binding.ModifiedAt) {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "NotificationChannelBinding")
+	}
+	if err := binding.Scope.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+	if (binding.Scope == ChannelScopeGlobal) != (binding.ScopeId == "") {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "NotificationChannelBinding.ScopeId")
+	}
+	if err := binding.Template.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+	binding.SetValidated()
+	return nil
+}
+
+func (binding NotificationChannelBinding) TableName() string {
+	return "notification_channel_bindings"
+}
+
+func (binding *NotificationChannelBinding) UpdateFromRequest(request NotificationChannelBindingRequest) error {
+	binding.Disabled = request.Disabled
+	binding.Template = request.Template
+	binding.ModifiedAt = time.Now()
+	return binding.Validate()
+}
+
+func NewNotificationChannelBinding(channel *NotificationChannel, request NotificationChannelBindingRequest) (*NotificationChannelBinding, error) {
+	if rsvalid.IsZero(channel) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "NotificationChannelBinding")
+	}
+	binding := &NotificationChannelBinding{
+		Id:        rsstr.NewUUID(),
+		ChannelId: channel.Id,
+		Scope:     request.Scope,
+		ScopeId:   request.ScopeId,
+		CreatedAt: time.Now(),
+	}
+	if err := binding.UpdateFromRequest(request); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return binding, nil
+}
+
+type NotificationChannelBindingRequest struct {
+	Scope    ChannelScope  `json:"scope"`
+	ScopeId  string        `json:"scopeId"`
+	Disabled bool          `json:"disabled"`
+	Template AlertTemplate `json:"template"`
+}
+
+// Alert is the alert of the channel with the template of the binding, if it overrides it.
+func (binding NotificationChannelBinding) Alert() *Alert {
+	alert := binding.Channel.Alert()
+	if binding.Template != "" {
+		alert.Template = binding.Template
+	}
+	return alert
+}
+
+type NotificationChannelBindingListRequest struct {
+	Page      int
+	NumItem   int
+	ChannelId string
+}
+
+// NotificationChannelBindings are the bindings of the channels at every level a test inherits from.
+type NotificationChannelBindings []*NotificationChannelBinding
+
+// Effective returns the bindings the test is alerted through, sorted by channel name.
+// The binding at the most specific level of each channel wins, and it is dropped if it or its channel is disabled.
+func (bindings NotificationChannelBindings) Effective() NotificationChannelBindings {
+	winners := make(map[string]*NotificationChannelBinding)
+	for _, binding := range bindings {
+		if binding.Channel == nil {
+			continue
+		}
+		if winner, exist := winners[binding.ChannelId]; !exist || binding.Scope.level() > winner.Scope.level() {
+			winners[binding.ChannelId] = binding
+		}
+	}
+
+	effective := make(NotificationChannelBindings, 0, len(winners))
+	for _, binding := range winners {
+		if !binding.Disabled && !binding.Channel.Disabled {
+			effective = append(effective, binding)
+		}
+	}
+	sort.Slice(effective, func(i, j int) bool {
+		return effective[i].Channel.Name < effective[j].Channel.Name
+	})
+	return effective
+}
+
+func (bindings NotificationChannelBindings) Alerts() Alerts {
+	alerts := make(Alerts, 0, len(bindings))
+	for _, binding := range bindings {
+		alerts = append(alerts, binding.Alert())
+	}
+	return alerts
+}
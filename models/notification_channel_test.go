@@ -0,0 +1,114 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/realsangil/apimonitor/pkg/rsnotify"
+)
+
+func TestNotificationChannelBindings_Effective(t *testing.T) {
+	ops := &NotificationChannel{Id: "ops", Name: "ops", Type: rsnotify.TypeWebhook, Config: NotifierConfig(`{"url": "https://ops.example.com"}`)}
+	dev := &NotificationChannel{Id: "dev", Name: "dev", Type: rsnotify.TypeWebhook, Config: NotifierConfig(`{"url": "https://dev.example.com"}`)}
+	off := &NotificationChannel{Id: "off", Name: "off", Type: rsnotify.TypeWebhook, Disabled: true}
+
+	tests := []struct {
+		name         string
+		bindings     NotificationChannelBindings
+		wantChannels []string
+		wantTemplate []AlertTemplate
+	}{
+		{
+			name:     "nothing bound",
+			bindings: NotificationChannelBindings{},
+		},
+		{
+			name: "inherited from every level",
+			bindings: NotificationChannelBindings{
+				{ChannelId: "ops", Channel: ops, Scope: ChannelScopeGlobal},
+				{ChannelId: "dev", Channel: dev, Scope: ChannelScopeWebService, ScopeId: "ws"},
+			},
+			wantChannels: []string{"dev", "ops"},
+			wantTemplate: []AlertTemplate{"", ""},
+		},
+		{
+			name: "muted at the test level",
+			bindings: NotificationChannelBindings{
+				{ChannelId: "ops", Channel: ops, Scope: ChannelScopeGlobal},
+				{ChannelId: "ops", Channel: ops, Scope: ChannelScopeTest, ScopeId: "test", Disabled: true},
+				{ChannelId: "dev", Channel: dev, Scope: ChannelScopeGlobal},
+			},
+			wantChannels: []string{"dev"},
+			wantTemplate: []AlertTemplate{""},
+		},
+		{
+			name: "unmuted at a more specific level",
+			bindings: NotificationChannelBindings{
+				{ChannelId: "ops", Channel: ops, Scope: ChannelScopeTest, ScopeId: "test"},
+				{ChannelId: "ops", Channel: ops, Scope: ChannelScopeWebService, ScopeId: "ws", Disabled: true},
+			},
+			wantChannels: []string{"ops"},
+			wantTemplate: []AlertTemplate{""},
+		},
+		{
+			name: "template overridden by the WebService",
+			bindings: NotificationChannelBindings{
+				{ChannelId: "ops", Channel: ops, Scope: ChannelScopeGlobal, Template: "global"},
+				{ChannelId: "ops", Channel: ops, Scope: ChannelScopeWebService, ScopeId: "ws", Template: "{{.Test.Name}}"},
+			},
+			wantChannels: []string{"ops"},
+			wantTemplate: []AlertTemplate{"{{.Test.Name}}"},
+		},
+		{
+			name: "disabled channel",
+			bindings: NotificationChannelBindings{
+				{ChannelId: "off", Channel: off, Scope: ChannelScopeTest, ScopeId: "test"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			effective := tt.bindings.Effective()
+			alerts := effective.Alerts()
+			if assert.Len(t, effective, len(tt.wantChannels)) && assert.Len(t, alerts, len(tt.wantChannels)) {
+				for i, binding := range effective {
+					assert.Equal(t, tt.wantChannels[i], binding.Channel.Name)
+					assert.Equal(t, tt.wantTemplate[i], alerts[i].Template)
+				}
+			}
+		})
+	}
+}
+
+func TestNotificationChannelBinding_Validate(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		scope   ChannelScope
+		scopeId string
+		wantErr bool
+	}{
+		{name: "global", scope: ChannelScopeGlobal},
+		{name: "global with id", scope: ChannelScopeGlobal, scopeId: "ws", wantErr: true},
+		{name: "web service", scope: ChannelScopeWebService, scopeId: "ws"},
+		{name: "web service without id", scope: ChannelScopeWebService, wantErr: true},
+		{name: "test", scope: ChannelScopeTest, scopeId: "test"},
+		{name: "unknown scope", scope: "team", scopeId: "team", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			binding := &NotificationChannelBinding{
+				Id:         "binding",
+				ChannelId:  "ops",
+				Scope:      tt.scope,
+				ScopeId:    tt.scopeId,
+				CreatedAt:  now,
+				ModifiedAt: now,
+			}
+			err := binding.Validate()
+			assert.Equal(t, tt.wantErr, err != nil, "error: %v", err)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rsmodels"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+)
+
+// Replica is an apimonitor process which shares the database with other processes.
+// HeartbeatAt is set by the database clock, so the replicas need not agree on time.
+type Replica struct {
+	rsmodels.DefaultValidateChecker
+	Id          string    `json:"id" gorm:"primary_key;Size:64"`
+	Hostname    string    `json:"hostname" gorm:"Size:255"`
+	StartedAt   time.Time `json:"startedAt" gorm:"Type:DATETIME(3)"`
+	HeartbeatAt time.Time `json:"heartbeatAt" gorm:"Type:DATETIME(3);index"`
+}
+
+func (replica *Replica) Validate() error {
+	if rsvalid.IsZero(replica.Id, replica.StartedAt) {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "replica")
+	}
+	replica.SetValidated()
+	return nil
+}
+
+func (replica Replica) TableName() string {
+	return "replicas"
+}
+
+func NewReplica(id, hostname string) (*Replica, error) {
+	replica := &Replica{
+		Id:        id,
+		Hostname:  hostname,
+		StartedAt: time.Now(),
+	}
+	if err := replica.Validate(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return replica, nil
+}
@@ -0,0 +1,483 @@
+package models
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	htmltemplate "html/template"
+	"sort"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rsmodels"
+	"github.com/realsangil/apimonitor/pkg/rsnotify"
+	"github.com/realsangil/apimonitor/pkg/rsstats"
+	"github.com/realsangil/apimonitor/pkg/rsstr"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+)
+
+// ReportPeriod is how often a digest report is generated, always over the last complete period in UTC.
+type ReportPeriod string
+
+const (
+	ReportDaily  ReportPeriod = "daily"
+	ReportWeekly ReportPeriod = "weekly"
+)
+
+// reportMinRegression is how much slower than in the previous period a test must get to be a regression.
+const reportMinRegression = 0.1
+
+func (period ReportPeriod) Validate() error {
+	switch period {
+	case ReportDaily, ReportWeekly:
+		return nil
+	default:
+		return errors.Wrap(rserrors.ErrInvalidParameter, "ReportPeriod")
+	}
+}
+
+func (period ReportPeriod) Duration() time.Duration {
+	if period == ReportWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// Window is the last period completed at now: yesterday, or last week from Monday to Monday.
+func (period ReportPeriod) Window(now time.Time) TimeWindow {
+	end := now.UTC().Truncate(24 * time.Hour)
+	if period == ReportWeekly {
+		end = end.AddDate(0, 0, -((int(end.Weekday()) + 6) % 7))
+	}
+	return TimeWindow{Start: end.Add(-period.Duration()), End: end}
+}
+
+// Previous is the period before the window, which the latencies are compared with.
+func (period ReportPeriod) Previous(window TimeWindow) TimeWindow {
+	return TimeWindow{Start: window.Start.Add(-period.Duration()), End: window.Start}
+}
+
+// ReportIncident is an incident of the web service open at some time in the period.
+type ReportIncident struct {
+	Id        string     `json:"id"`
+	StartedAt time.Time  `json:"startedAt"`
+	EndedAt   *time.Time `json:"endedAt"`
+	Duration  int64      `json:"duration"`
+}
+
+// ReportTest is a test a report is about.
+type ReportTest struct {
+	TestId string `json:"testId"`
+	Name   string `json:"name"`
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+func newReportTest(test *Test) ReportTest {
+	return ReportTest{TestId: test.Id, Name: test.Name, Method: string(test.Method), Path: string(test.Path)}
+}
+
+// ReportLatency is the latency of a test in the period. Mean and P95 are in milliseconds.
+// P95 is the highest p95 of the days of the period, which is the p95 of the day for a daily report.
+type ReportLatency struct {
+	ReportTest
+	Count int     `json:"count"`
+	Mean  float64 `json:"mean"`
+	P95   int64   `json:"p95"`
+}
+
+// ReportRegression is a test whose mean latency grew since the previous period.
+// Change is the growth as a ratio, e.g. 0.5 for 50% slower.
+type ReportRegression struct {
+	ReportTest
+	Mean         float64 `json:"mean"`
+	PreviousMean float64 `json:"previousMean"`
+	Change       float64 `json:"change"`
+}
+
+// ReportAssertionFailure is how many results of a test failed an assertion on a field.
+type ReportAssertionFailure struct {
+	ReportTest
+	Field string `json:"field"`
+	Count int    `json:"count"`
+}
+
+// ReportTestLatency is the latency of a test in a period and in the previous one.
+// MaxDailyP95 is the highest p95 of the days of the period: the daily p95 is exact even when it is
+// read from the rollups, whereas the p95 of a longer period read from them is estimated.
+type ReportTestLatency struct {
+	Test        *Test
+	Current     rsstats.Summary
+	Previous    rsstats.Summary
+	MaxDailyP95 int64
+}
+
+// ReportSummary is what a report tells about the web service in the period.
+type ReportSummary struct {
+	Uptime            Uptime                   `json:"uptime"`
+	Incidents         []ReportIncident         `json:"incidents"`
+	SlowestTests      []ReportLatency          `json:"slowestTests"`
+	Regressions       []ReportRegression       `json:"regressions"`
+	FailingAssertions []ReportAssertionFailure `json:"failingAssertions"`
+}
+
+func (summary *ReportSummary) Scan(src interface{}) error {
+	return rsdb.ScanJson(summary, src)
+}
+
+func (summary ReportSummary) Value() (driver.Value, error) {
+	return rsdb.JsonValue(summary)
+}
+
+// NewReportSummary keeps at most limit of the slowest tests by their highest daily p95, of the biggest regressions
+// and of the most failed assertions.
+func NewReportSummary(uptime Uptime, incidents []*Incident, latencies []ReportTestLatency, failures []ReportAssertionFailure, limit int) ReportSummary {
+	summary := ReportSummary{
+		Uptime:            uptime,
+		Incidents:         make([]ReportIncident, 0, len(incidents)),
+		SlowestTests:      make([]ReportLatency, 0),
+		Regressions:       make([]ReportRegression, 0),
+		FailingAssertions: failures,
+	}
+	for _, incident := range incidents {
+		summary.Incidents = append(summary.Incidents, ReportIncident{
+			Id:        incident.Id,
+			StartedAt: incident.StartedAt,
+			EndedAt:   incident.EndedAt,
+			Duration:  incident.Duration,
+		})
+	}
+
+	for _, latency := range latencies {
+		if latency.Current.Count == 0 {
+			continue
+		}
+		test := newReportTest(latency.Test)
+		summary.SlowestTests = append(summary.SlowestTests, ReportLatency{
+			ReportTest: test,
+			Count:      latency.Current.Count,
+			Mean:       latency.Current.Mean,
+			P95:        latency.MaxDailyP95,
+		})
+		if latency.Previous.Count == 0 || latency.Previous.Mean == 0 {
+			continue
+		}
+		change := (latency.Current.Mean - latency.Previous.Mean) / latency.Previous.Mean
+		if change >= reportMinRegression {
+			summary.Regressions = append(summary.Regressions, ReportRegression{
+				ReportTest:   test,
+				Mean:         latency.Current.Mean,
+				PreviousMean: latency.Previous.Mean,
+				Change:       change,
+			})
+		}
+	}
+
+	sort.SliceStable(summary.SlowestTests, func(i, j int) bool {
+		return summary.SlowestTests[i].P95 > summary.SlowestTests[j].P95
+	})
+	sort.SliceStable(summary.Regressions, func(i, j int) bool {
+		return summary.Regressions[i].Change > summary.Regressions[j].Change
+	})
+	sort.SliceStable(summary.FailingAssertions, func(i, j int) bool {
+		return summary.FailingAssertions[i].Count > summary.FailingAssertions[j].Count
+	})
+	if len(summary.SlowestTests) > limit {
+		summary.SlowestTests = summary.SlowestTests[:limit]
+	}
+	if len(summary.Regressions) > limit {
+		summary.Regressions = summary.Regressions[:limit]
+	}
+	if len(summary.FailingAssertions) > limit {
+		summary.FailingAssertions = summary.FailingAssertions[:limit]
+	}
+	return summary
+}
+
+// ReportAssertionCounter counts the failed assertions of the failed results by test and field.
+type ReportAssertionCounter struct {
+	counts map[ReportTest]map[string]int
+}
+
+func NewReportAssertionCounter() *ReportAssertionCounter {
+	return &ReportAssertionCounter{counts: make(map[ReportTest]map[string]int)}
+}
+
+// Add counts the failures of the result. A result of a test not in tests is skipped.
+func (counter *ReportAssertionCounter) Add(tests map[string]*Test, export *TestResultExport) {
+	test, exist := tests[export.TestId]
+	if !exist {
+		return
+	}
+	key := newReportTest(test)
+	for _, failure := range export.Assertions.Failures() {
+		fields, exist := counter.counts[key]
+		if !exist {
+			fields = make(map[string]int)
+			counter.counts[key] = fields
+		}
+		fields[failure.Field]++
+	}
+}
+
+// Failures are sorted by test name and field, NewReportSummary sorts them by count.
+func (counter *ReportAssertionCounter) Failures() []ReportAssertionFailure {
+	failures := make([]ReportAssertionFailure, 0)
+	for test, fields := range counter.counts {
+		for field, count := range fields {
+			failures = append(failures, ReportAssertionFailure{ReportTest: test, Field: field, Count: count})
+		}
+	}
+	sort.Slice(failures, func(i, j int) bool {
+		if failures[i].Name != failures[j].Name {
+			return failures[i].Name < failures[j].Name
+		}
+		if failures[i].TestId != failures[j].TestId {
+			return failures[i].TestId < failures[j].TestId
+		}
+		return failures[i].Field < failures[j].Field
+	})
+	return failures
+}
+
+// ReportDelivery is the outcome of sending a report through a notification channel.
+type ReportDelivery struct {
+	ChannelId   string        `json:"channelId"`
+	ChannelName string        `json:"channelName"`
+	Type        rsnotify.Type `json:"type"`
+	Success     bool          `json:"success"`
+	Error       string        `json:"error,omitempty"`
+	SentAt      time.Time     `json:"sentAt"`
+}
+
+type ReportDeliveries []ReportDelivery
+
+func (deliveries *ReportDeliveries) Scan(src interface{}) error {
+	return rsdb.ScanJson(deliveries, src)
+}
+
+func (deliveries ReportDeliveries) Value() (driver.Value, error) {
+	return rsdb.JsonValue(deliveries)
+}
+
+// Report is a digest of a web service over a period, generated once the period is over
+// and kept so that it can be read again. A period of a web service has a single report.
+type Report struct {
+	rsmodels.DefaultValidateChecker
+	Id           string           `json:"id" gorm:"primary_key;Size:36"`
+	WebServiceId string           `json:"webServiceId" gorm:"Size:36;NOT NULL;unique_index:idx_web_service_period_start"`
+	WebService   *WebService      `json:"webService,omitempty" gorm:"foreignkey:WebServiceId;association_autoupdate:false;association_autocreate:false"`
+	Period       ReportPeriod     `json:"period" gorm:"Size:10;unique_index:idx_web_service_period_start"`
+	Start        time.Time        `json:"start" gorm:"unique_index:idx_web_service_period_start;index"`
+	End          time.Time        `json:"end"`
+	Summary      ReportSummary    `json:"summary" gorm:"Type:JSON"`
+	Deliveries   ReportDeliveries `json:"deliveries" gorm:"Type:JSON"`
+	CreatedAt    time.Time        `json:"createdAt"`
+}
+
+func (report *Report) Validate() error {
+	if rsvalid.IsZero(report.Id, report.WebServiceId, report.Period, report.Start, report.End, report.CreatedAt) {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "Report")
+	}
+	if err := report.Period.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+	report.SetValidated()
+	return nil
+}
+
+func (report Report) TableName() string {
+	return "reports"
+}
+
+func NewReport(webService *WebService, period ReportPeriod, window TimeWindow, summary ReportSummary) (*Report, error) {
+	if rsvalid.IsZero(webService) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "Report")
+	}
+	report := &Report{
+		Id:           rsstr.NewUUID(),
+		WebServiceId: webService.Id,
+		WebService:   webService,
+		Period:       period,
+		Start:        window.Start,
+		End:          window.End,
+		Summary:      summary,
+		Deliveries:   make(ReportDeliveries, 0),
+		CreatedAt:    time.Now(),
+	}
+	if err := report.Validate(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return report, nil
+}
+
+// AddDelivery records the outcome of sending the report through the channel.
+func (report *Report) AddDelivery(channel *NotificationChannel, err error, at time.Time) {
+	delivery := ReportDelivery{
+		ChannelId:   channel.Id,
+		ChannelName: channel.Name,
+		Type:        channel.Type,
+		Success:     err == nil,
+		SentAt:      at,
+	}
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+	report.Deliveries = append(report.Deliveries, delivery)
+}
+
+type ReportListRequest struct {
+	Page         int
+	NumItem      int
+	WebServiceId string
+	Period       ReportPeriod
+}
+
+var reportTemplateFuncs = map[string]interface{}{
+	"date": func(t time.Time) string {
+		return t.UTC().Format("2006-01-02")
+	},
+	"datetime": alertTemplateFuncs["datetime"],
+	"percent": func(ratio float64) string {
+		return formatPercent(ratio * 100)
+	},
+	"seconds": func(seconds int64) string {
+		return (time.Duration(seconds) * time.Second).String()
+	},
+	"ms": func(ms float64) string {
+		return fmt.Sprintf("%.0fms", ms)
+	},
+}
+
+var reportTextTemplate = texttemplate.Must(texttemplate.New("report.txt").Funcs(reportTemplateFuncs).Parse(
+	`[{{.Period}} report] {{.Host}} {{date .Start}} - {{date .End}}
+Availability: {{percent .Summary.Uptime.Availability}}% ({{.Summary.Uptime.SuccessCount}}/{{.Summary.Uptime.TotalCount}} results succeeded)
+Downtime: {{seconds .Summary.Uptime.Downtime}}
+Incidents: {{len .Summary.Incidents}}
+{{- range .Summary.Incidents}}
+- {{datetime .StartedAt}} to {{datetime .EndedAt}}
+{{- end}}
+{{- if .Summary.SlowestTests}}
+Slowest endpoints (highest daily p95):
+{{- range .Summary.SlowestTests}}
+- {{.Method}} {{.Path}} ({{.Name}}): p95 {{.P95}}ms, mean {{ms .Mean}}
+{{- end}}
+{{- end}}
+{{- if .Summary.Regressions}}
+Latency regressions:
+{{- range .Summary.Regressions}}
+- {{.Method}} {{.Path}} ({{.Name}}): mean {{ms .PreviousMean}} -> {{ms .Mean}} (+{{percent .Change}}%)
+{{- end}}
+{{- end}}
+{{- if .Summary.FailingAssertions}}
+Failing assertions:
+{{- range .Summary.FailingAssertions}}
+- {{.Method}} {{.Path}} ({{.Name}}): {{.Field}} failed {{.Count}} times
+{{- end}}
+{{- end}}
+{{- if .Link}}
+{{.Link}}
+{{- end}}`))
+
+var reportHTMLTemplate = htmltemplate.Must(htmltemplate.New("report.html").Funcs(reportTemplateFuncs).Parse(
+	`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>[{{.Period}} report] {{.Host}}</title>
+</head>
+<body style="font-family: sans-serif; font-size: 14px;">
+<h2>[{{.Period}} report] {{.Host}}</h2>
+<p>{{date .Start}} - {{date .End}} (UTC)</p>
+<table cellpadding="4">
+<tr><th align="left">Availability</th><td>{{percent .Summary.Uptime.Availability}}%</td></tr>
+<tr><th align="left">Results</th><td>{{.Summary.Uptime.SuccessCount}} / {{.Summary.Uptime.TotalCount}} succeeded</td></tr>
+<tr><th align="left">Downtime</th><td>{{seconds .Summary.Uptime.Downtime}}</td></tr>
+<tr><th align="left">Incidents</th><td>{{len .Summary.Incidents}}</td></tr>
+</table>
+{{- if .Summary.Incidents}}
+<h3>Incidents</h3>
+<table cellpadding="4" border="1" style="border-collapse: collapse;">
+<tr><th>Started at</th><th>Ended at</th></tr>
+{{- range .Summary.Incidents}}
+<tr><td>{{datetime .StartedAt}}</td><td>{{datetime .EndedAt}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Summary.SlowestTests}}
+<h3>Slowest endpoints</h3>
+<table cellpadding="4" border="1" style="border-collapse: collapse;">
+<tr><th>Test</th><th>Endpoint</th><th>p95</th><th>Mean</th></tr>
+{{- range .Summary.SlowestTests}}
+<tr><td>{{.Name}}</td><td><code>{{.Method}} {{.Path}}</code></td><td>{{.P95}}ms</td><td>{{ms .Mean}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Summary.Regressions}}
+<h3>Latency regressions</h3>
+<table cellpadding="4" border="1" style="border-collapse: collapse;">
+<tr><th>Test</th><th>Endpoint</th><th>Previous mean</th><th>Mean</th><th>Change</th></tr>
+{{- range .Summary.Regressions}}
+<tr><td>{{.Name}}</td><td><code>{{.Method}} {{.Path}}</code></td><td>{{ms .PreviousMean}}</td><td>{{ms .Mean}}</td><td>+{{percent .Change}}%</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Summary.FailingAssertions}}
+<h3>Failing assertions</h3>
+<table cellpadding="4" border="1" style="border-collapse: collapse;">
+<tr><th>Test</th><th>Endpoint</th><th>Field</th><th>Failures</th></tr>
+{{- range .Summary.FailingAssertions}}
+<tr><td>{{.Name}}</td><td><code>{{.Method}} {{.Path}}</code></td><td>{{.Field}}</td><td>{{.Count}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Link}}
+<p><a href="{{.Link}}">Open in API Monitor</a></p>
+{{- end}}
+</body>
+</html>`))
+
+// reportMessageData is what the report templates are rendered from.
+type reportMessageData struct {
+	*Report
+	Host string
+	Link string
+}
+
+func (report *Report) messageData(link string) reportMessageData {
+	data := reportMessageData{Report: report, Link: link}
+	if report.WebService != nil {
+		data.Host = report.WebService.Host
+	}
+	return data
+}
+
+// HTML renders the report as a page. link may be empty.
+func (report *Report) HTML(link string) (string, error) {
+	var html bytes.Buffer
+	if err := reportHTMLTemplate.Execute(&html, report.messageData(link)); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return html.String(), nil
+}
+
+// Message renders the report like an alert, as plain text and HTML.
+// link is the page of the report, which may be empty.
+func (report *Report) Message(link string) (rsnotify.Message, error) {
+	var text bytes.Buffer
+	if err := reportTextTemplate.Execute(&text, report.messageData(link)); err != nil {
+		return rsnotify.Message{}, errors.WithStack(err)
+	}
+	html, err := report.HTML(link)
+	if err != nil {
+		return rsnotify.Message{}, errors.WithStack(err)
+	}
+
+	message := rsnotify.NewMessage(text.String())
+	message.HTML = html
+	return message, nil
+}
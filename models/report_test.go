@@ -0,0 +1,140 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/realsangil/apimonitor/pkg/rsstats"
+)
+
+func TestReportPeriod_Window(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2020, 3, d, 0, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name   string
+		period ReportPeriod
+		now    time.Time
+		want   TimeWindow
+	}{
+		{
+			name:   "daily is yesterday",
+			period: ReportDaily,
+			now:    day(4).Add(15 * time.Hour),
+			want:   TimeWindow{Start: day(3), End: day(4)},
+		},
+		{
+			name:   "daily at midnight",
+			period: ReportDaily,
+			now:    day(4),
+			want:   TimeWindow{Start: day(3), End: day(4)},
+		},
+		{
+			name:   "weekly on wednesday is last week",
+			period: ReportWeekly,
+			now:    day(4).Add(15 * time.Hour),
+			want:   TimeWindow{Start: day(2).AddDate(0, 0, -7), End: day(2)},
+		},
+		{
+			name:   "weekly on monday",
+			period: ReportWeekly,
+			now:    day(9).Add(time.Minute),
+			want:   TimeWindow{Start: day(2), End: day(9)},
+		},
+		{
+			name:   "weekly on sunday",
+			period: ReportWeekly,
+			now:    day(8).Add(23 * time.Hour),
+			want:   TimeWindow{Start: day(2).AddDate(0, 0, -7), End: day(2)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.period.Window(tt.now)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, TimeWindow{Start: tt.want.Start.Add(-tt.period.Duration()), End: tt.want.Start}, tt.period.Previous(got))
+		})
+	}
+}
+
+func TestNewReportSummary(t *testing.T) {
+	fast := &Test{Id: "fast", Name: "fast", Method: "GET", Path: "/fast"}
+	slow := &Test{Id: "slow", Name: "slow", Method: "GET", Path: "/slow"}
+	slower := &Test{Id: "slower", Name: "slower", Method: "POST", Path: "/slower"}
+	idle := &Test{Id: "idle", Name: "idle", Method: "GET", Path: "/idle"}
+
+	latencies := []ReportTestLatency{
+		{Test: fast, Current: rsstats.Summary{Count: 10, Mean: 105, P95: 120}, MaxDailyP95: 120, Previous: rsstats.Summary{Count: 10, Mean: 100}},
+		{Test: slow, Current: rsstats.Summary{Count: 10, Mean: 300, P95: 500}, MaxDailyP95: 500, Previous: rsstats.Summary{Count: 10, Mean: 200}},
+		{Test: slower, Current: rsstats.Summary{Count: 10, Mean: 800, P95: 700}, MaxDailyP95: 900, Previous: rsstats.Summary{Count: 10, Mean: 400}},
+		{Test: idle, Previous: rsstats.Summary{Count: 10, Mean: 100}},
+	}
+	failures := []ReportAssertionFailure{
+		{ReportTest: newReportTest(fast), Field: "body", Count: 1},
+		{ReportTest: newReportTest(slow), Field: "statusCode", Count: 3},
+	}
+	endedAt := time.Date(2020, 3, 1, 1, 0, 0, 0, time.UTC)
+	incidents := []*Incident{{Id: "incident", StartedAt: endedAt.Add(-time.Hour), EndedAt: &endedAt, Duration: 3600}}
+
+	summary := NewReportSummary(Uptime{Availability: 0.99}, incidents, latencies, failures, 2)
+
+	assert.Equal(t, []ReportIncident{{Id: "incident", StartedAt: endedAt.Add(-time.Hour), EndedAt: &endedAt, Duration: 3600}}, summary.Incidents)
+	assert.Equal(t, []ReportLatency{
+		{ReportTest: newReportTest(slower), Count: 10, Mean: 800, P95: 900},
+		{ReportTest: newReportTest(slow), Count: 10, Mean: 300, P95: 500},
+	}, summary.SlowestTests)
+	assert.Equal(t, []ReportRegression{
+		{ReportTest: newReportTest(slower), Mean: 800, PreviousMean: 400, Change: 1},
+		{ReportTest: newReportTest(slow), Mean: 300, PreviousMean: 200, Change: 0.5},
+	}, summary.Regressions)
+	assert.Equal(t, "statusCode", summary.FailingAssertions[0].Field)
+	assert.Len(t, summary.FailingAssertions, 2)
+}
+
+func TestReportAssertionCounter(t *testing.T) {
+	test := &Test{Id: "test", Name: "test", Method: "GET", Path: "/"}
+	tests := map[string]*Test{test.Id: test}
+	counter := NewReportAssertionCounter()
+	counter.Add(tests, &TestResultExport{TestId: test.Id, Assertions: AssertionResults{
+		{Field: "statusCode", Passed: false},
+		{Field: "body", Passed: true},
+	}})
+	counter.Add(tests, &TestResultExport{TestId: test.Id, Assertions: AssertionResults{
+		{Field: "statusCode", Passed: false},
+		{Field: "body", Passed: false},
+	}})
+	counter.Add(tests, &TestResultExport{TestId: "deleted", Assertions: AssertionResults{{Field: "statusCode"}}})
+
+	assert.Equal(t, []ReportAssertionFailure{
+		{ReportTest: newReportTest(test), Field: "body", Count: 1},
+		{ReportTest: newReportTest(test), Field: "statusCode", Count: 2},
+	}, counter.Failures())
+}
+
+func TestReport_Message(t *testing.T) {
+	webService := &WebService{Id: "ws", Host: "api.example.com"}
+	window := ReportDaily.Window(time.Date(2020, 3, 4, 1, 0, 0, 0, time.UTC))
+	summary := NewReportSummary(Uptime{TotalCount: 100, SuccessCount: 99, Availability: 0.995}, nil, []ReportTestLatency{
+		{
+			Test:        &Test{Id: "test", Name: "users", Method: "GET", Path: "/users"},
+			Current:     rsstats.Summary{Count: 10, Mean: 300, P95: 500},
+			Previous:    rsstats.Summary{Count: 10, Mean: 200},
+			MaxDailyP95: 500,
+		},
+	}, nil, 5)
+	report, err := NewReport(webService, ReportDaily, window, summary)
+	assert.NoError(t, err)
+
+	message, err := report.Message("https://apimonitor.example.com/v1/reports/" + report.Id + "/html")
+	assert.NoError(t, err)
+	assert.Equal(t, "[daily report] api.example.com 2020-03-03 - 2020-03-04", message.Subject)
+	assert.Contains(t, message.Text, "Availability: 99.50% (99/100 results succeeded)")
+	assert.Contains(t, message.Text, "- GET /users (users): p95 500ms, mean 300ms")
+	assert.Contains(t, message.Text, "- GET /users (users): mean 200ms -> 300ms (+50.00%)")
+	assert.False(t, strings.Contains(message.Text, "Failing assertions"))
+	assert.Contains(t, message.HTML, "<h3>Latency regressions</h3>")
+	assert.Contains(t, message.HTML, `href="https://apimonitor.example.com/v1/reports/`+report.Id+`/html"`)
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+)
+
+// RetentionPolicy is how many days the results are kept. RawDays applies to every result,
+// and FailureDays keeps the failures longer. Zero days fall back to the global policy,
+// and zero days there keep the results forever.
+type RetentionPolicy struct {
+	RawDays     int `json:"rawDays"`
+	FailureDays int `json:"failureDays"`
+}
+
+func (policy *RetentionPolicy) Scan(src interface{}) error {
+	return rsdb.ScanJson(policy, src)
+}
+
+func (policy RetentionPolicy) Value() (driver.Value, error) {
+	return rsdb.JsonValue(policy)
+}
+
+func (policy RetentionPolicy) Validate() error {
+	if policy.RawDays < 0 || policy.FailureDays < 0 {
+		return errors.Wrap(rserrors.ErrInvalidParameter, "retention")
+	}
+	return nil
+}
+
+// Or fills the days not set with those of the global policy.
+func (policy RetentionPolicy) Or(global RetentionPolicy) RetentionPolicy {
+	if policy.RawDays == 0 {
+		policy.RawDays = global.RawDays
+	}
+	if policy.FailureDays == 0 {
+		policy.FailureDays = global.FailureDays
+	}
+	return policy
+}
+
+// Cutoffs returns when the successes and the failures older than are purged, or the zero time to keep them.
+// A failure is never purged before the successes.
+func (policy RetentionPolicy) Cutoffs(now time.Time) (success, failure time.Time) {
+	if policy.RawDays > 0 {
+		success = now.AddDate(0, 0, -policy.RawDays)
+	}
+	failure = success
+	if policy.FailureDays > policy.RawDays && policy.RawDays > 0 {
+		failure = now.AddDate(0, 0, -policy.FailureDays)
+	}
+	return success, failure
+}
+
+// PurgeTarget is what the purge deletes of a web service: the successes tested before SuccessBefore,
+// and the failures tested before FailureBefore. A nil time keeps them.
+type PurgeTarget struct {
+	WebServiceId  string          `json:"webServiceId"`
+	Host          string          `json:"host"`
+	Policy        RetentionPolicy `json:"policy"`
+	SuccessBefore *time.Time      `json:"successBefore"`
+	FailureBefore *time.Time      `json:"failureBefore"`
+	SuccessCount  int64           `json:"successCount"`
+	FailureCount  int64           `json:"failureCount"`
+}
+
+// NewPurgeTarget applies the policy of the web service, or the global one, at now.
+// The results not rolled up yet, tested after rolledUpTo, are kept whatever the policy.
+func NewPurgeTarget(webService *WebService, global RetentionPolicy, now, rolledUpTo time.Time) PurgeTarget {
+	policy := webService.Retention.Or(global)
+	target := PurgeTarget{
+		WebServiceId: webService.Id,
+		Host:         webService.Host,
+		Policy:       policy,
+	}
+
+	success, failure := policy.Cutoffs(now)
+	target.SuccessBefore = purgeCutoff(success, rolledUpTo)
+	target.FailureBefore = purgeCutoff(failure, rolledUpTo)
+	return target
+}
+
+func purgeCutoff(cutoff, rolledUpTo time.Time) *time.Time {
+	if cutoff.IsZero() || rolledUpTo.IsZero() {
+		return nil
+	}
+	if rolledUpTo.Before(cutoff) {
+		cutoff = rolledUpTo
+	}
+	return &cutoff
+}
+
+// PurgeReport is what a purge deleted, or for a dry run would delete, per web service.
+type PurgeReport struct {
+	DryRun     bool          `json:"dryRun"`
+	Targets    []PurgeTarget `json:"targets"`
+	TotalCount int64         `json:"totalCount"`
+}
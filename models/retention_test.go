@@ -0,0 +1,82 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPurgeTarget(t *testing.T) {
+	now := time.Date(2020, 7, 1, 12, 0, 0, 0, time.UTC)
+	rolledUpTo := time.Date(2020, 6, 30, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) *time.Time {
+		t := now.AddDate(0, 0, -days)
+		return &t
+	}
+	global := RetentionPolicy{RawDays: 30, FailureDays: 180}
+
+	tests := []struct {
+		name              string
+		retention         RetentionPolicy
+		global            RetentionPolicy
+		rolledUpTo        time.Time
+		wantPolicy        RetentionPolicy
+		wantSuccessBefore *time.Time
+		wantFailureBefore *time.Time
+	}{
+		{
+			name:              "global",
+			global:            global,
+			rolledUpTo:        rolledUpTo,
+			wantPolicy:        global,
+			wantSuccessBefore: daysAgo(30),
+			wantFailureBefore: daysAgo(180),
+		},
+		{
+			name:              "own raw days",
+			retention:         RetentionPolicy{RawDays: 7},
+			global:            global,
+			rolledUpTo:        rolledUpTo,
+			wantPolicy:        RetentionPolicy{RawDays: 7, FailureDays: 180},
+			wantSuccessBefore: daysAgo(7),
+			wantFailureBefore: daysAgo(180),
+		},
+		{
+			name:              "failures not kept shorter",
+			retention:         RetentionPolicy{RawDays: 30, FailureDays: 7},
+			rolledUpTo:        rolledUpTo,
+			wantPolicy:        RetentionPolicy{RawDays: 30, FailureDays: 7},
+			wantSuccessBefore: daysAgo(30),
+			wantFailureBefore: daysAgo(30),
+		},
+		{
+			name:       "kept forever",
+			retention:  RetentionPolicy{FailureDays: 180},
+			rolledUpTo: rolledUpTo,
+			wantPolicy: RetentionPolicy{FailureDays: 180},
+		},
+		{
+			name:              "not rolled up yet",
+			retention:         RetentionPolicy{RawDays: 1},
+			rolledUpTo:        rolledUpTo,
+			wantPolicy:        RetentionPolicy{RawDays: 1},
+			wantSuccessBefore: &rolledUpTo,
+			wantFailureBefore: &rolledUpTo,
+		},
+		{
+			name:       "never rolled up",
+			global:     global,
+			wantPolicy: global,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webService := &WebService{Id: "webService", Retention: tt.retention}
+			target := NewPurgeTarget(webService, tt.global, now, tt.rolledUpTo)
+			assert.Equal(t, tt.wantPolicy, target.Policy)
+			assert.Equal(t, tt.wantSuccessBefore, target.SuccessBefore)
+			assert.Equal(t, tt.wantFailureBefore, target.FailureBefore)
+		})
+	}
+}
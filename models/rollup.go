@@ -0,0 +1,195 @@
+package models
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rsstats"
+)
+
+// RollupGranularity is the period the results are rolled up by.
+type RollupGranularity string
+
+const (
+	RollupHourly RollupGranularity = "1h"
+	RollupDaily  RollupGranularity = "1d"
+)
+
+// RollupGranularities are rolled up in this order.
+var RollupGranularities = []RollupGranularity{RollupHourly, RollupDaily}
+
+func (granularity RollupGranularity) Duration() time.Duration {
+	switch granularity {
+	case RollupHourly:
+		return time.Hour
+	case RollupDaily:
+		return 24 * time.Hour
+	}
+	return 0
+}
+
+// RollupTableName is the table the rollups of the granularity are kept in.
+func (granularity RollupGranularity) RollupTableName() string {
+	switch granularity {
+	case RollupDaily:
+		return "test_result_rollups_daily"
+	}
+	return "test_result_rollups_hourly"
+}
+
+// RollupGranularityOf returns the granularity whose rollups make up a latency bucket, if any.
+func RollupGranularityOf(bucket string) (RollupGranularity, bool) {
+	for _, granularity := range RollupGranularities {
+		if string(granularity) == bucket {
+			return granularity, true
+		}
+	}
+	return "", false
+}
+
+// StatusCodeCounts is the number of results by status code. Results without a response have the status code 0.
+type StatusCodeCounts map[int]int
+
+func (counts *StatusCodeCounts) Scan(src interface{}) error {
+	return rsdb.ScanJson(counts, src)
+}
+
+func (counts StatusCodeCounts) Value() (driver.Value, error) {
+	return rsdb.JsonValue(counts)
+}
+
+// HistogramCounts are the counts of a histogram with the LatencyHistogramBounds.
+type HistogramCounts []int
+
+func (counts *HistogramCounts) Scan(src interface{}) error {
+	return rsdb.ScanJson(counts, src)
+}
+
+func (counts HistogramCounts) Value() (driver.Value, error) {
+	return rsdb.JsonValue(counts)
+}
+
+// TestResultRollup summarizes the results of a test in [Start, Start+granularity).
+// Like the uptime and the latency statistics, the counts and the response times leave out the results
+// in maintenance, which are only counted in MaintenanceCount. The response times are in milliseconds.
+type TestResultRollup struct {
+	TestId           string           `json:"testId" gorm:"primary_key;Size:36"`
+	Start            time.Time        `json:"start" gorm:"primary_key"`
+	TotalCount       int              `json:"totalCount"`
+	SuccessCount     int              `json:"successCount"`
+	FailureCount     int              `json:"failureCount"`
+	MaintenanceCount int              `json:"maintenanceCount"`
+	MinResponseTime  int64            `json:"minResponseTime"`
+	MaxResponseTime  int64            `json:"maxResponseTime"`
+	MeanResponseTime float64          `json:"meanResponseTime"`
+	P50ResponseTime  int64            `json:"p50ResponseTime"`
+	P90ResponseTime  int64            `json:"p90ResponseTime"`
+	P95ResponseTime  int64            `json:"p95ResponseTime"`
+	P99ResponseTime  int64            `json:"p99ResponseTime"`
+	StatusCodes      StatusCodeCounts `json:"statusCodes" gorm:"Type:JSON"`
+	Histogram        HistogramCounts  `json:"histogram" gorm:"Type:JSON"`
+}
+
+func (rollup TestResultRollup) Summary() rsstats.Summary {
+	return rsstats.Summary{
+		Count: rollup.TotalCount,
+		Min:   rollup.MinResponseTime,
+		Max:   rollup.MaxResponseTime,
+		Mean:  rollup.MeanResponseTime,
+		P50:   rollup.P50ResponseTime,
+		P90:   rollup.P90ResponseTime,
+		P95:   rollup.P95ResponseTime,
+		P99:   rollup.P99ResponseTime,
+	}
+}
+
+// TestResultRollupBuilder rolls up the results of a test in a period.
+type TestResultRollupBuilder struct {
+	rollup    *TestResultRollup
+	samples   []int64
+	histogram *rsstats.Histogram
+}
+
+func (builder *TestResultRollupBuilder) TestId() string {
+	return builder.rollup.TestId
+}
+
+func (builder *TestResultRollupBuilder) Add(result *TestResult) {
+	if result.InMaintenance {
+		builder.rollup.MaintenanceCount++
+		return
+	}
+	builder.rollup.TotalCount++
+	if result.IsSuccess {
+		builder.rollup.SuccessCount++
+	} else {
+		builder.rollup.FailureCount++
+	}
+	builder.rollup.StatusCodes[result.StatusCode]++
+	builder.samples = append(builder.samples, result.ResponseTime)
+	builder.histogram.Add(result.ResponseTime)
+}
+
+func (builder *TestResultRollupBuilder) Build() *TestResultRollup {
+	summary := rsstats.Summarize(builder.samples)
+	rollup := builder.rollup
+	rollup.MinResponseTime = summary.Min
+	rollup.MaxResponseTime = summary.Max
+	rollup.MeanResponseTime = summary.Mean
+	rollup.P50ResponseTime = summary.P50
+	rollup.P90ResponseTime = summary.P90
+	rollup.P95ResponseTime = summary.P95
+	rollup.P99ResponseTime = summary.P99
+	rollup.Histogram = builder.histogram.Counts()
+	return rollup
+}
+
+func NewTestResultRollupBuilder(testId string, start time.Time) *TestResultRollupBuilder {
+	return &TestResultRollupBuilder{
+		rollup: &TestResultRollup{
+			TestId:      testId,
+			Start:       start,
+			StatusCodes: make(StatusCodeCounts),
+		},
+		samples:   make([]int64, 0),
+		histogram: rsstats.NewHistogram(LatencyHistogramBounds...),
+	}
+}
+
+// RollupWatermark is the end of the last period rolled up by a granularity.
+// The results before it are in the rollups, and may be pruned once every granularity passed them.
+type RollupWatermark struct {
+	Granularity RollupGranularity `json:"granularity" gorm:"primary_key;Size:2"`
+	RolledUpTo  time.Time         `json:"rolledUpTo"`
+	ModifiedAt  time.Time         `json:"modifiedAt"`
+}
+
+func (watermark RollupWatermark) TableName() string {
+	return "rollup_watermarks"
+}
+
+// SplitByRollup splits the window into the whole periods of the granularity before the watermark,
+// which are read from the rollups, and the rest at the edges, which is read from the results.
+func SplitByRollup(window TimeWindow, granularity RollupGranularity, watermark time.Time) (rolledUp TimeWindow, ok bool, rest []TimeWindow) {
+	duration := granularity.Duration()
+	rolledUp = TimeWindow{Start: window.Start.Truncate(duration), End: window.End.Truncate(duration)}
+	if rolledUp.Start.Before(window.Start) {
+		rolledUp.Start = rolledUp.Start.Add(duration)
+	}
+	if watermark.Before(rolledUp.End) {
+		rolledUp.End = watermark
+	}
+	if !rolledUp.End.After(rolledUp.Start) {
+		return TimeWindow{}, false, []TimeWindow{window}
+	}
+
+	rest = make([]TimeWindow, 0, 2)
+	if rolledUp.Start.After(window.Start) {
+		rest = append(rest, TimeWindow{Start: window.Start, End: rolledUp.Start})
+	}
+	if window.End.After(rolledUp.End) {
+		rest = append(rest, TimeWindow{Start: rolledUp.End, End: window.End})
+	}
+	return rolledUp, true, rest
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTestResultRollupBuilder(t *testing.T) {
+	start := time.Date(2020, 2, 1, 10, 0, 0, 0, time.UTC)
+	builder := NewTestResultRollupBuilder("test", start)
+	for _, result := range []*TestResult{
+		{TestId: "test", IsSuccess: true, StatusCode: 200, ResponseTime: 100},
+		{TestId: "test", IsSuccess: true, StatusCode: 200, ResponseTime: 300},
+		{TestId: "test", IsSuccess: false, StatusCode: 500, ResponseTime: 2000},
+		{TestId: "test", IsSuccess: false, StatusCode: 0, ResponseTime: 0},
+		{TestId: "test", IsSuccess: false, StatusCode: 503, ResponseTime: 50000, InMaintenance: true},
+	} {
+		builder.Add(result)
+	}
+
+	assert.Equal(t, &TestResultRollup{
+		TestId:           "test",
+		Start:            start,
+		TotalCount:       4,
+		SuccessCount:     2,
+		FailureCount:     2,
+		MaintenanceCount: 1,
+		MinResponseTime:  0,
+		MaxResponseTime:  2000,
+		MeanResponseTime: 600,
+		P50ResponseTime:  100,
+		P90ResponseTime:  2000,
+		P95ResponseTime:  2000,
+		P99ResponseTime:  2000,
+		StatusCodes:      StatusCodeCounts{0: 1, 200: 2, 500: 1},
+		Histogram:        HistogramCounts{1, 1, 0, 1, 0, 1, 0, 0, 0, 0},
+	}, builder.Build())
+}
+
+func TestSplitByRollup(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2020, 2, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name         string
+		window       TimeWindow
+		granularity  RollupGranularity
+		watermark    time.Time
+		wantRolledUp TimeWindow
+		wantOk       bool
+		wantRest     []TimeWindow
+	}{
+		{
+			name:         "edges",
+			window:       TimeWindow{Start: at(1, 10, 20), End: at(1, 15, 30)},
+			granularity:  RollupHourly,
+			watermark:    at(1, 20, 0),
+			wantRolledUp: TimeWindow{Start: at(1, 11, 0), End: at(1, 15, 0)},
+			wantOk:       true,
+			wantRest: []TimeWindow{
+				{Start: at(1, 10, 20), End: at(1, 11, 0)},
+				{Start: at(1, 15, 0), End: at(1, 15, 30)},
+			},
+		},
+		{
+			name:         "until the watermark",
+			window:       TimeWindow{Start: at(1, 0, 0), End: at(5, 12, 0)},
+			granularity:  RollupDaily,
+			watermark:    at(4, 0, 0),
+			wantRolledUp: TimeWindow{Start: at(1, 0, 0), End: at(4, 0, 0)},
+			wantOk:       true,
+			wantRest:     []TimeWindow{{Start: at(4, 0, 0), End: at(5, 12, 0)}},
+		},
+		{
+			name:        "not rolled up yet",
+			window:      TimeWindow{Start: at(1, 10, 0), End: at(1, 15, 0)},
+			granularity: RollupHourly,
+			watermark:   at(1, 9, 0),
+			wantRest:    []TimeWindow{{Start: at(1, 10, 0), End: at(1, 15, 0)}},
+		},
+		{
+			name:        "never rolled up",
+			window:      TimeWindow{Start: at(1, 10, 0), End: at(1, 15, 0)},
+			granularity: RollupHourly,
+			wantRest:    []TimeWindow{{Start: at(1, 10, 0), End: at(1, 15, 0)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rolledUp, ok, rest := SplitByRollup(tt.window, tt.granularity, tt.watermark)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.wantRolledUp, rolledUp)
+			assert.Equal(t, tt.wantRest, rest)
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+const StatusPageDateFormat = "2006-01-02"
+
+// ComponentStatus is the current status of a component of the status page, from the best to the worst.
+type ComponentStatus string
+
+const (
+	ComponentOperational ComponentStatus = "operational"
+	ComponentMaintenance ComponentStatus = "maintenance"
+	ComponentDegraded    ComponentStatus = "degraded"
+	ComponentOutage      ComponentStatus = "outage"
+)
+
+func (status ComponentStatus) severity() int {
+	switch status {
+	case ComponentMaintenance:
+		return 1
+	case ComponentDegraded:
+		return 2
+	case ComponentOutage:
+		return 3
+	}
+	return 0
+}
+
+// Worst returns the worse of both statuses.
+func (status ComponentStatus) Worst(other ComponentStatus) ComponentStatus {
+	if other.severity() > status.severity() {
+		return other
+	}
+	return status
+}
+
+// NewWebServiceStatus is an outage if every test of the web service is alerting, degraded if some are,
+// or else in maintenance if one is ongoing. The failing tests which are not alerted yet do not count.
+func NewWebServiceStatus(alertStatuses map[string]AlertStatus, inMaintenance bool) ComponentStatus {
+	alerting := 0
+	for _, status := range alertStatuses {
+		if status == AlertStatusAlerting {
+			alerting++
+		}
+	}
+	switch {
+	case alerting > 0 && alerting == len(alertStatuses):
+		return ComponentOutage
+	case alerting > 0:
+		return ComponentDegraded
+	case inMaintenance:
+		return ComponentMaintenance
+	}
+	return ComponentOperational
+}
+
+// StatusComponentDefinition groups web services under a name on the status page.
+type StatusComponentDefinition struct {
+	Name          string
+	WebServiceIds []string
+}
+
+// StatusPage is the public status of the components. It tells nothing of the web services
+// and the tests themselves, like their hosts.
+type StatusPage struct {
+	Title            string             `json:"title"`
+	Status           ComponentStatus    `json:"status"`
+	Components       []*StatusComponent `json:"components"`
+	OngoingIncidents []StatusIncident   `json:"ongoingIncidents"`
+	RecentIncidents  []StatusIncident   `json:"recentIncidents"`
+	GeneratedAt      time.Time          `json:"generatedAt"`
+}
+
+// AddComponent adds the component and folds its status into the overall one.
+func (page *StatusPage) AddComponent(component *StatusComponent) {
+	page.Components = append(page.Components, component)
+	page.Status = page.Status.Worst(component.Status)
+}
+
+// AddIncident lists the incident as ongoing or recent.
+func (page *StatusPage) AddIncident(component string, incident *Incident) {
+	statusIncident := StatusIncident{
+		Id:           incident.Id,
+		Component:    component,
+		StartedAt:    incident.StartedAt,
+		EndedAt:      incident.EndedAt,
+		Duration:     incident.Duration,
+		Acknowledged: incident.AcknowledgedAt != nil,
+	}
+	if incident.EndedAt == nil {
+		page.OngoingIncidents = append(page.OngoingIncidents, statusIncident)
+		return
+	}
+	page.RecentIncidents = append(page.RecentIncidents, statusIncident)
+}
+
+// SortIncidents lists the ongoing incidents oldest first, and the recent ones newest first.
+func (page *StatusPage) SortIncidents() {
+	sort.SliceStable(page.OngoingIncidents, func(i, j int) bool {
+		return page.OngoingIncidents[i].StartedAt.Before(page.OngoingIncidents[j].StartedAt)
+	})
+	sort.SliceStable(page.RecentIncidents, func(i, j int) bool {
+		return page.RecentIncidents[i].StartedAt.After(page.RecentIncidents[j].StartedAt)
+	})
+}
+
+func NewStatusPage(title string, generatedAt time.Time) *StatusPage {
+	return &StatusPage{
+		Title:            title,
+		Status:           ComponentOperational,
+		Components:       make([]*StatusComponent, 0),
+		OngoingIncidents: make([]StatusIncident, 0),
+		RecentIncidents:  make([]StatusIncident, 0),
+		GeneratedAt:      generatedAt,
+	}
+}
+
+// StatusComponent has a bar per day, the last being today so far. Uptime is the success ratio
+// of all the days, nil without any result like that of a day.
+type StatusComponent struct {
+	Name   string          `json:"name"`
+	Status ComponentStatus `json:"status"`
+	Uptime *float64        `json:"uptime"`
+	Days   []*UptimeDay    `json:"days"`
+
+	counts TestResultCount
+}
+
+// SetWebServiceStatus folds the status of one of its web services into that of the component.
+func (component *StatusComponent) SetWebServiceStatus(status ComponentStatus) {
+	component.Status = component.Status.Worst(status)
+}
+
+// AddCount adds the count of a web service on the day starting at start, if it is shown.
+func (component *StatusComponent) AddCount(start time.Time, count TestResultCount) {
+	date := start.UTC().Format(StatusPageDateFormat)
+	for _, day := range component.Days {
+		if day.Date != date {
+			continue
+		}
+		day.TotalCount += count.TotalCount
+		day.SuccessCount += count.SuccessCount
+		day.Uptime = successRatio(day.TotalCount, day.SuccessCount)
+		component.counts.TotalCount += count.TotalCount
+		component.counts.SuccessCount += count.SuccessCount
+		component.Uptime = successRatio(component.counts.TotalCount, component.counts.SuccessCount)
+		return
+	}
+}
+
+// NewStatusComponent has a bar for each of the days up to today, in UTC like the daily rollups.
+// It is operational until the statuses of its web services are folded in.
+func NewStatusComponent(name string, today time.Time, days int) *StatusComponent {
+	component := &StatusComponent{
+		Name:   name,
+		Status: ComponentOperational,
+		Days:   make([]*UptimeDay, 0, days),
+	}
+	today = today.UTC().Truncate(24 * time.Hour)
+	for i := days - 1; i >= 0; i-- {
+		component.Days = append(component.Days, &UptimeDay{Date: today.AddDate(0, 0, -i).Format(StatusPageDateFormat)})
+	}
+	return component
+}
+
+type UptimeDay struct {
+	Date         string   `json:"date"`
+	TotalCount   int      `json:"totalCount"`
+	SuccessCount int      `json:"successCount"`
+	Uptime       *float64 `json:"uptime"`
+}
+
+// StatusIncident is an incident of a component, without its notes which are kept internal.
+type StatusIncident struct {
+	Id           string     `json:"id"`
+	Component    string     `json:"component"`
+	StartedAt    time.Time  `json:"startedAt"`
+	EndedAt      *time.Time `json:"endedAt"`
+	Duration     int64      `json:"duration"`
+	Acknowledged bool       `json:"acknowledged"`
+}
+
+func successRatio(totalCount, successCount int) *float64 {
+	if totalCount == 0 {
+		return nil
+	}
+	ratio := float64(successCount) / float64(totalCount)
+	return &ratio
+}
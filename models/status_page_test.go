@@ -0,0 +1,82 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewWebServiceStatus(t *testing.T) {
+	tests := []struct {
+		name          string
+		alertStatuses map[string]AlertStatus
+		inMaintenance bool
+		want          ComponentStatus
+	}{
+		{name: "no tests", want: ComponentOperational},
+		{name: "failing but not alerted", alertStatuses: map[string]AlertStatus{"a": AlertStatusFailing, "b": AlertStatusOk}, want: ComponentOperational},
+		{name: "some alerting", alertStatuses: map[string]AlertStatus{"a": AlertStatusAlerting, "b": AlertStatusOk}, want: ComponentDegraded},
+		{name: "all alerting", alertStatuses: map[string]AlertStatus{"a": AlertStatusAlerting, "b": AlertStatusAlerting}, want: ComponentOutage},
+		{name: "maintenance", alertStatuses: map[string]AlertStatus{"a": AlertStatusOk}, inMaintenance: true, want: ComponentMaintenance},
+		{name: "alerting in maintenance", alertStatuses: map[string]AlertStatus{"a": AlertStatusAlerting}, inMaintenance: true, want: ComponentOutage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NewWebServiceStatus(tt.alertStatuses, tt.inMaintenance))
+		})
+	}
+}
+
+func TestStatusComponent_AddCount(t *testing.T) {
+	today := time.Date(2020, 3, 10, 15, 0, 0, 0, time.UTC)
+	component := NewStatusComponent("API", today, 3)
+	if !assert.Len(t, component.Days, 3) {
+		return
+	}
+	assert.Equal(t, "2020-03-08", component.Days[0].Date)
+	assert.Equal(t, "2020-03-10", component.Days[2].Date)
+	assert.Nil(t, component.Uptime)
+
+	component.AddCount(time.Date(2020, 3, 8, 0, 0, 0, 0, time.UTC), TestResultCount{TotalCount: 4, SuccessCount: 3})
+	component.AddCount(time.Date(2020, 3, 10, 0, 0, 0, 0, time.UTC), TestResultCount{TotalCount: 4, SuccessCount: 4})
+	// A day which is not shown is ignored.
+	component.AddCount(time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC), TestResultCount{TotalCount: 4})
+
+	assert.Equal(t, 0.75, *component.Days[0].Uptime)
+	assert.Nil(t, component.Days[1].Uptime)
+	assert.Equal(t, 1.0, *component.Days[2].Uptime)
+	assert.Equal(t, 0.875, *component.Uptime)
+
+	component.SetWebServiceStatus(ComponentDegraded)
+	component.SetWebServiceStatus(ComponentMaintenance)
+	assert.Equal(t, ComponentDegraded, component.Status)
+}
+
+func TestStatusPage_AddIncident(t *testing.T) {
+	base := time.Date(2020, 3, 10, 0, 0, 0, 0, time.UTC)
+	endedAt := base.Add(2 * time.Hour)
+	page := NewStatusPage("Status", base)
+	page.AddIncident("API", &Incident{Id: "old", StartedAt: base, EndedAt: &endedAt, Duration: 7200})
+	page.AddIncident("API", &Incident{Id: "new", StartedAt: base.Add(time.Hour), EndedAt: &endedAt, Duration: 3600})
+	page.AddIncident("Web", &Incident{Id: "late", StartedAt: base.Add(3 * time.Hour), AcknowledgedAt: &endedAt})
+	page.AddIncident("API", &Incident{Id: "early", StartedAt: base.Add(2 * time.Hour)})
+	page.SortIncidents()
+
+	if assert.Len(t, page.OngoingIncidents, 2) {
+		assert.Equal(t, "early", page.OngoingIncidents[0].Id)
+		assert.Equal(t, "late", page.OngoingIncidents[1].Id)
+		assert.True(t, page.OngoingIncidents[1].Acknowledged)
+	}
+	if assert.Len(t, page.RecentIncidents, 2) {
+		assert.Equal(t, "new", page.RecentIncidents[0].Id)
+		assert.Equal(t, "old", page.RecentIncidents[1].Id)
+	}
+
+	page.AddComponent(NewStatusComponent("API", base, 1))
+	assert.Equal(t, ComponentOperational, page.Status)
+	outage := NewStatusComponent("Web", base, 1)
+	outage.SetWebServiceStatus(ComponentOutage)
+	page.AddComponent(outage)
+	assert.Equal(t, ComponentOutage, page.Status)
+}
@@ -0,0 +1,55 @@
+package models
+
+type StreamEventType string
+
+const (
+	StreamEventResult     StreamEventType = "result"
+	StreamEventAlertState StreamEventType = "alertState"
+)
+
+// StreamEvent is pushed to the clients of the live stream: a new result of a test,
+// or a change of its alert state, with the status it changed from.
+type StreamEvent struct {
+	Type           StreamEventType `json:"type"`
+	WebServiceId   string          `json:"webServiceId"`
+	TestId         string          `json:"testId"`
+	Result         *TestResult     `json:"result,omitempty"`
+	AlertState     *AlertState     `json:"alertState,omitempty"`
+	PreviousStatus AlertStatus     `json:"previousStatus,omitempty"`
+}
+
+func NewResultStreamEvent(test *Test, result *TestResult) StreamEvent {
+	return StreamEvent{
+		Type:         StreamEventResult,
+		WebServiceId: test.WebServiceId,
+		TestId:       test.Id,
+		Result:       result,
+	}
+}
+
+// NewAlertStateStreamEvent copies the state, which keeps changing with the next results.
+func NewAlertStateStreamEvent(test *Test, previousStatus AlertStatus, state AlertState) StreamEvent {
+	return StreamEvent{
+		Type:           StreamEventAlertState,
+		WebServiceId:   test.WebServiceId,
+		TestId:         test.Id,
+		AlertState:     &state,
+		PreviousStatus: previousStatus,
+	}
+}
+
+// StreamFilter selects the events of a web service and of a test. An empty field matches every event.
+type StreamFilter struct {
+	WebServiceId string `json:"webServiceId"`
+	TestId       string `json:"testId"`
+}
+
+func (filter StreamFilter) Matches(event StreamEvent) bool {
+	if filter.WebServiceId != "" && filter.WebServiceId != event.WebServiceId {
+		return false
+	}
+	if filter.TestId != "" && filter.TestId != event.TestId {
+		return false
+	}
+	return true
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"context"
 	"database/sql/driver"
 	"encoding/json"
 	"net/url"
@@ -16,6 +17,7 @@ import (
 	"github.com/realsangil/apimonitor/pkg/rslog"
 	"github.com/realsangil/apimonitor/pkg/rsmodels"
 	"github.com/realsangil/apimonitor/pkg/rsstr"
+	"github.com/realsangil/apimonitor/pkg/rstrace"
 	"github.com/realsangil/apimonitor/pkg/rsvalid"
 )
 
@@ -33,12 +35,19 @@ type Test struct {
 	Schedule     TestSchedule        `json:"schedule" gorm:"Column:schedule;Type:VARCHAR(5)"`
 	Timeout      rshttp.Timeout      `json:"timeout"`
 	Assertion    AssertionV1         `json:"assertion" gorm:"Type:JSON"`
-	Alerts       WebHookAlerts       `json:"alerts" gorm:"Column:alerts;Type:JSON"`
+	Alerts       Alerts              `json:"alerts" gorm:"Column:alerts;Type:JSON"`
+	AlertPolicy  AlertPolicy         `json:"alertPolicy" gorm:"Type:JSON"`
+	LastRunAt    *time.Time          `json:"lastRunAt"`
+	NextRunAt    *time.Time          `json:"nextRunAt"`
 	CreatedAt    time.Time           `json:"createdAt"`
 	ModifiedAt   time.Time           `json:"modifiedAt"`
 }
 
 func (test *Test) UpdateFromRequest(request TestRequest) error {
+	if test.Schedule != request.Schedule || test.NextRunAt == nil {
+		nextRunAt := time.Now().Add(request.Schedule.GetDuration())
+		test.NextRunAt = &nextRunAt
+	}
 	test.Name = request.Name
 	test.Path = request.Path
 	test.Method = request.Method
@@ -47,12 +56,44 @@ func (test *Test) UpdateFromRequest(request TestRequest) error {
 	test.Parameters = request.Parameters
 	test.Schedule = request.Schedule
 	test.Assertion = request.Assertion
-	test.Alerts = request.Alerts
+	alerts, err := request.Alerts.keepCredentials(test.Alerts)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	test.Alerts = alerts
+	test.AlertPolicy = request.AlertPolicy
 	test.Timeout = rshttp.Timeout(request.Timeout)
 	test.ModifiedAt = time.Now()
 	return test.Validate()
 }
 
+// GetAlerts returns the alerts of the test and an email alert to the recipients of its WebService
+// who are not already mailed by the test.
+func (test Test) GetAlerts() Alerts {
+	if test.WebService == nil || len(test.WebService.AlertRecipients) == 0 {
+		return test.Alerts
+	}
+	mailed := make(EmailAddresses, 0)
+	for _, alert := range test.Alerts {
+		mailed = append(mailed, alert.recipients()...)
+	}
+	to := make([]string, 0)
+	for _, recipient := range test.WebService.AlertRecipients {
+		if !mailed.contains(recipient) {
+			to = append(to, recipient)
+		}
+	}
+	if len(to) == 0 {
+		return test.Alerts
+	}
+	alert, err := NewEmailAlert(to)
+	if err != nil {
+		rslog.Error(err)
+		return test.Alerts
+	}
+	return append(append(Alerts{}, test.Alerts...), alert)
+}
+
 func (test *Test) Validate() error {
 	if rsvalid.IsZero(
 		test.Id,
@@ -79,15 +120,23 @@ func (test *Test) Validate() error {
 	if err := test.Schedule.Validate(); err != nil {
 		return errors.WithStack(err)
 	}
+	if err := test.Alerts.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := test.AlertPolicy.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
 	test.SetValidated()
 	return nil
 }
 
-func (test Test) Execute() (*rshttp.Response, error) {
-	request, err := test.ToHttpRequest(test.WebService)
+// Execute sends the request of the test. The URL is recorded on the span of the context, if any.
+func (test Test) Execute(ctx context.Context) (*rshttp.Response, error) {
+	request, err := test.ToHttpRequest(ctx, test.WebService)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
+	rstrace.SpanFromContext(ctx).SetAttributes(rstrace.String("http.url", request.RawUrl))
 
 	res, err := rshttp.Do(request)
 	if err != nil {
@@ -98,7 +147,10 @@ func (test Test) Execute() (*rshttp.Response, error) {
 	return res, nil
 }
 
-func (test Test) ToHttpRequest(webService *WebService) (*rshttp.Request, error) {
+// ToHttpRequest builds the request of the test against the web service.
+// The span of the context is propagated with a traceparent header, so that the traces
+// of the web service link back to the check.
+func (test Test) ToHttpRequest(ctx context.Context, webService *WebService) (*rshttp.Request, error) {
 	if rsvalid.IsZero(webService) {
 		return nil, errors.WithStack(rserrors.ErrInvalidParameter)
 	}
@@ -110,9 +162,15 @@ func (test Test) ToHttpRequest(webService *WebService) (*rshttp.Request, error)
 	}
 	rslog.Debugf("rawUrl='%s'", rawUrl.String())
 
+	header := make(map[string]string, len(test.Parameters.Header)+1)
+	for key, value := range test.Parameters.Header {
+		header[key] = value
+	}
+	rstrace.Inject(ctx, header)
+
 	request := rshttp.Request{
 		Method:  test.Method.String(),
-		Header:  test.Parameters.Header,
+		Header:  header,
 		Query:   test.Parameters.Query,
 		Body:    test.Parameters.Body,
 		RawUrl:  rawUrl.String(),
@@ -137,6 +195,13 @@ func NewTest(webService *WebService, request TestRequest) (*Test, error) {
 	return test, nil
 }
 
+// TestDryRunRequest is an unsaved TestRequest which is executed against
+// the WebService of WebServiceId.
+type TestDryRunRequest struct {
+	WebServiceId string `json:"webServiceId"`
+	TestRequest
+}
+
 type TestRequest struct {
 	Id          string              `json:"-"`
 	Name        string              `json:"name"`
@@ -147,7 +212,8 @@ type TestRequest struct {
 	Parameters  Parameters          `json:"parameters"`
 	Schedule    TestSchedule        `json:"schedule"`
 	Assertion   AssertionV1         `json:"assertion"`
-	Alerts      WebHookAlerts       `json:"alerts"`
+	Alerts      Alerts              `json:"alerts"`
+	AlertPolicy AlertPolicy         `json:"alertPolicy"`
 	Timeout     int                 `json:"timeout"`
 }
 
@@ -168,6 +234,12 @@ func (request TestRequest) Validate() error {
 	if err := request.ContentType.Validate(); err != nil {
 		return err
 	}
+	if err := request.Alerts.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := request.AlertPolicy.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
 	return nil
 }
 
@@ -233,6 +305,58 @@ func (assertion AssertionV1) Assert(res *rshttp.Response) bool {
 	return !rsvalid.IsZero(res) && assertion.StatusCode == res.StatusCode
 }
 
+// AssertWithDetails evaluates every assertion field and reports each outcome.
+func (assertion AssertionV1) AssertWithDetails(res *rshttp.Response) AssertionResults {
+	statusCode := 0
+	if !rsvalid.IsZero(res) {
+		statusCode = res.StatusCode
+	}
+	return AssertionResults{
+		{
+			Field:    "statusCode",
+			Expected: assertion.StatusCode,
+			Actual:   statusCode,
+			Passed:   assertion.Assert(res),
+		},
+	}
+}
+
+type AssertionResult struct {
+	Field    string      `json:"field"`
+	Expected interface{} `json:"expected"`
+	Actual   interface{} `json:"actual"`
+	Passed   bool        `json:"passed"`
+}
+
+type AssertionResults []AssertionResult
+
+func (results *AssertionResults) Scan(src interface{}) error {
+	return rsdb.ScanJson(results, src)
+}
+
+func (results AssertionResults) Value() (driver.Value, error) {
+	return rsdb.JsonValue(results)
+}
+
+func (results AssertionResults) IsPassed() bool {
+	for _, result := range results {
+		if !result.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+func (results AssertionResults) Failures() AssertionResults {
+	failures := make(AssertionResults, 0)
+	for _, result := range results {
+		if !result.Passed {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
 const (
 	ScheduleOneMinute     TestSchedule = "1m"
 	ScheduleFiveMinute    TestSchedule = "5m"
@@ -289,8 +413,15 @@ func (schedule TestSchedule) GetDuration() time.Duration {
 	}
 }
 
-func (schedule TestSchedule) GetTicker() *time.Ticker {
-	return time.NewTicker(schedule.GetDuration())
+// NextAfter returns the first run of the schedule, starting at base, which is later than now.
+// It returns base itself if base is in the future.
+func (schedule TestSchedule) NextAfter(base, now time.Time) time.Time {
+	duration := schedule.GetDuration()
+	if base.After(now) || duration <= 0 {
+		return base
+	}
+	missed := now.Sub(base)/duration + 1
+	return base.Add(missed * duration)
 }
 
 type Parameters struct {
@@ -1,26 +1,56 @@
 package models
 
 import (
-	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rshttp"
 	"github.com/realsangil/apimonitor/pkg/rsmodels"
+	"github.com/realsangil/apimonitor/pkg/rsstr"
 	"github.com/realsangil/apimonitor/pkg/rsvalid"
 )
 
 type TestResult struct {
 	rsmodels.DefaultValidateChecker
-	Id           string    `json:"id" gorm:"Size:36"`
-	TestId       string    `json:"testId" gorm:"NOT NULL"`
-	IsSuccess    bool      `json:"isSuccess"`
-	StatusCode   int       `json:"statusCode"`
-	Response     string    `json:"response" gorm:"Type:TEXT"`
-	ResponseTime int64     `json:"responseTime"`
-	TestedAt     time.Time `json:"testedAt"`
+	Id            string           `json:"id" gorm:"Size:36"`
+	TestId        string           `json:"testId" gorm:"NOT NULL"`
+	IsSuccess     bool             `json:"isSuccess"`
+	StatusCode    int              `json:"statusCode"`
+	Response      string           `json:"response" gorm:"Type:TEXT"`
+	ResponseTime  int64            `json:"responseTime"`
+	Assertions    AssertionResults `json:"assertions" gorm:"Type:JSON"`
+	InMaintenance bool             `json:"inMaintenance"`
+	TestedAt      time.Time        `json:"testedAt"`
+}
+
+func NewTestResult(test *Test, res *rshttp.Response, testedAt time.Time) *TestResult {
+	assertions := test.Assertion.AssertWithDetails(res)
+	return &TestResult{
+		DefaultValidateChecker: rsmodels.ValidatedDefaultValidateChecker,
+		Id:                     rsstr.NewUUID(),
+		TestId:                 test.Id,
+		IsSuccess:              assertions.IsPassed(),
+		StatusCode:             res.StatusCode,
+		Response:               res.Body,
+		ResponseTime:           res.ResponseTime,
+		Assertions:             assertions,
+		TestedAt:               testedAt,
+	}
+}
+
+// NewErrorTestResult is the failed result of a test whose request could not be sent or answered.
+func NewErrorTestResult(test *Test, err error, testedAt time.Time) *TestResult {
+	return &TestResult{
+		DefaultValidateChecker: rsmodels.ValidatedDefaultValidateChecker,
+		Id:                     rsstr.NewUUID(),
+		TestId:                 test.Id,
+		IsSuccess:              false,
+		Response:               err.Error(),
+		TestedAt:               testedAt,
+	}
 }
 
 func (result TestResult) Validate() error {
@@ -31,15 +61,6 @@ func (result TestResult) Validate() error {
 	return nil
 }
 
-func (result TestResult) ErrorMessage() string {
-	return fmt.Sprintf(
-		"resultId: '%s'\nstatusCode: '%d'\nTestDate: '%v'",
-		result.Id,
-		result.StatusCode,
-		result.TestedAt,
-	)
-}
-
 func (result TestResult) TableName() string {
 	return "test_results"
 }
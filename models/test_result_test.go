@@ -0,0 +1,55 @@
+package models
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/realsangil/apimonitor/pkg/rshttp"
+)
+
+func TestNewTestResult(t *testing.T) {
+	testedAt := time.Now()
+	test := &Test{
+		Id:        "test",
+		Assertion: AssertionV1{StatusCode: http.StatusOK},
+	}
+
+	tests := []struct {
+		name           string
+		res            *rshttp.Response
+		wantIsSuccess  bool
+		wantAssertions AssertionResults
+	}{
+		{
+			name:          "pass",
+			res:           &rshttp.Response{StatusCode: http.StatusOK, ResponseTime: 10, Body: "ok"},
+			wantIsSuccess: true,
+			wantAssertions: AssertionResults{
+				{Field: "statusCode", Expected: http.StatusOK, Actual: http.StatusOK, Passed: true},
+			},
+		},
+		{
+			name:          "unexpected status code",
+			res:           &rshttp.Response{StatusCode: http.StatusBadGateway, ResponseTime: 10},
+			wantIsSuccess: false,
+			wantAssertions: AssertionResults{
+				{Field: "statusCode", Expected: http.StatusOK, Actual: http.StatusBadGateway, Passed: false},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewTestResult(test, tt.res, testedAt)
+			assert.Equal(t, test.Id, got.TestId)
+			assert.Equal(t, tt.res.StatusCode, got.StatusCode)
+			assert.Equal(t, tt.res.ResponseTime, got.ResponseTime)
+			assert.Equal(t, testedAt, got.TestedAt)
+			assert.Equal(t, tt.wantIsSuccess, got.IsSuccess)
+			assert.Equal(t, tt.wantAssertions, got.Assertions)
+			assert.Equal(t, !tt.wantIsSuccess, len(got.Assertions.Failures()) > 0)
+		})
+	}
+}
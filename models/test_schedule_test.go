@@ -0,0 +1,49 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTestSchedule_NextAfter(t *testing.T) {
+	base := time.Date(2020, 2, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		schedule TestSchedule
+		now      time.Time
+		want     time.Time
+	}{
+		{
+			name:     "base in the future",
+			schedule: ScheduleHourly,
+			now:      base.Add(-time.Minute),
+			want:     base,
+		},
+		{
+			name:     "base is now",
+			schedule: ScheduleHourly,
+			now:      base,
+			want:     base.Add(time.Hour),
+		},
+		{
+			name:     "missed several runs",
+			schedule: ScheduleHourly,
+			now:      base.Add(150 * time.Minute),
+			want:     base.Add(3 * time.Hour),
+		},
+		{
+			name:     "missed exactly one run",
+			schedule: ScheduleDaily,
+			now:      base.Add(24 * time.Hour),
+			want:     base.Add(48 * time.Hour),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.schedule.NextAfter(base, tt.now))
+		})
+	}
+}
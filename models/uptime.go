@@ -0,0 +1,171 @@
+package models
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+)
+
+const DefaultReportWindow = "24h"
+
+var reportWindows = map[string]time.Duration{
+	"1h":  time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+	"90d": 90 * 24 * time.Hour,
+}
+
+// UptimeRequest is the range [From, To) to report the availability of.
+type UptimeRequest struct {
+	From time.Time
+	To   time.Time
+}
+
+func (request UptimeRequest) Window() TimeWindow {
+	return TimeWindow{Start: request.From, End: request.To}
+}
+
+// NewUptimeRequest reads either a custom range in RFC 3339, whose end defaults to now,
+// or one of the windows like 24h, 7d and 30d ending now.
+func NewUptimeRequest(window, from, to string, now time.Time) (UptimeRequest, error) {
+	timeRange, err := parseTimeRange(window, from, to, now)
+	if err != nil {
+		return UptimeRequest{}, errors.WithStack(err)
+	}
+	return UptimeRequest{From: timeRange.Start, To: timeRange.End}, nil
+}
+
+// parseTimeRange reads the range of a report, see NewUptimeRequest.
+func parseTimeRange(window, from, to string, now time.Time) (TimeWindow, error) {
+	timeRange := TimeWindow{End: now}
+	if to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return timeRange, errors.Wrap(rserrors.ErrInvalidParameter, "to")
+		}
+		timeRange.End = t
+	}
+
+	if from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return timeRange, errors.Wrap(rserrors.ErrInvalidParameter, "from")
+		}
+		timeRange.Start = t
+	} else {
+		if window == "" {
+			window = DefaultReportWindow
+		}
+		duration, ok := reportWindows[window]
+		if !ok {
+			return timeRange, errors.Wrap(rserrors.ErrInvalidParameter, "window")
+		}
+		timeRange.Start = timeRange.End.Add(-duration)
+	}
+
+	if !timeRange.End.After(timeRange.Start) {
+		return timeRange, errors.Wrap(rserrors.ErrInvalidParameter, "from/to")
+	}
+	return timeRange, nil
+}
+
+// TestResultCount is the number of results of a test or a web service in a range, except those in maintenance.
+type TestResultCount struct {
+	TotalCount   int `json:"totalCount"`
+	SuccessCount int `json:"successCount"`
+}
+
+// TestResultPeriodCount is the TestResultCount of the rollup period starting at Start.
+type TestResultPeriodCount struct {
+	Start        time.Time `json:"start"`
+	TotalCount   int       `json:"totalCount"`
+	SuccessCount int       `json:"successCount"`
+}
+
+// Uptime is the availability of a test or a web service in a range. The durations are in seconds.
+//
+// SuccessRatio is over the results, so the periods without results, like when a test was paused,
+// don't count. Downtime is the time the test or the web service was alerted down,
+// and the maintenance windows are excluded from both downtime and the monitored time.
+// MTTR and MTBF are the mean downtime and the mean time up per incident, and zero without incidents.
+type Uptime struct {
+	From            time.Time `json:"from"`
+	To              time.Time `json:"to"`
+	TotalCount      int       `json:"totalCount"`
+	SuccessCount    int       `json:"successCount"`
+	SuccessRatio    float64   `json:"successRatio"`
+	MonitoredTime   int64     `json:"monitoredTime"`
+	MaintenanceTime int64     `json:"maintenanceTime"`
+	Downtime        int64     `json:"downtime"`
+	Availability    float64   `json:"availability"`
+	IncidentCount   int       `json:"incidentCount"`
+	MTTR            int64     `json:"mttr"`
+	MTBF            int64     `json:"mtbf"`
+}
+
+// NewUptime computes the uptime in the range of the request. An outage still open ends at now.
+func NewUptime(request UptimeRequest, count TestResultCount, outages, maintenances []TimeWindow, now time.Time) Uptime {
+	window := request.Window()
+	if now.Before(window.End) {
+		window.End = now
+	}
+
+	uptime := Uptime{
+		From:          request.From,
+		To:            request.To,
+		TotalCount:    count.TotalCount,
+		SuccessCount:  count.SuccessCount,
+		SuccessRatio:  1,
+		Availability:  1,
+		IncidentCount: len(outages),
+	}
+	if count.TotalCount > 0 {
+		uptime.SuccessRatio = float64(count.SuccessCount) / float64(count.TotalCount)
+	}
+	if !window.End.After(window.Start) {
+		return uptime
+	}
+
+	maintenanceTime := overlap(window, maintenances)
+	clipped := make([]TimeWindow, 0, len(outages))
+	for _, outage := range outages {
+		if outage.End.IsZero() || outage.End.After(window.End) {
+			outage.End = window.End
+		}
+		if intersection, ok := outage.Intersect(window); ok {
+			clipped = append(clipped, intersection)
+		}
+	}
+	merged := MergeTimeWindows(clipped)
+	downtime := time.Duration(0)
+	for _, outage := range merged {
+		downtime += outage.Duration() - overlap(outage, maintenances)
+	}
+	monitoredTime := window.Duration() - maintenanceTime
+
+	uptime.MaintenanceTime = int64(maintenanceTime / time.Second)
+	uptime.MonitoredTime = int64(monitoredTime / time.Second)
+	uptime.Downtime = int64(downtime / time.Second)
+	if monitoredTime > 0 {
+		uptime.Availability = 1 - float64(downtime)/float64(monitoredTime)
+	}
+	if uptime.IncidentCount > 0 {
+		uptime.MTTR = uptime.Downtime / int64(uptime.IncidentCount)
+		uptime.MTBF = (uptime.MonitoredTime - uptime.Downtime) / int64(uptime.IncidentCount)
+	}
+	return uptime
+}
+
+// overlap is the time of the window covered by the merged windows.
+func overlap(window TimeWindow, windows []TimeWindow) time.Duration {
+	duration := time.Duration(0)
+	for _, other := range windows {
+		if intersection, ok := window.Intersect(other); ok {
+			duration += intersection.Duration()
+		}
+	}
+	return duration
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewUptimeRequest(t *testing.T) {
+	now := time.Date(2020, 2, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		window   string
+		from     string
+		to       string
+		wantFrom time.Time
+		wantTo   time.Time
+		wantErr  bool
+	}{
+		{name: "default", wantFrom: now.Add(-24 * time.Hour), wantTo: now},
+		{name: "7 days", window: "7d", wantFrom: now.AddDate(0, 0, -7), wantTo: now},
+		{name: "custom range", from: "2020-01-01T00:00:00Z", to: "2020-02-01T00:00:00Z", wantFrom: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), wantTo: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "from until now", from: "2020-02-10T00:00:00Z", wantFrom: time.Date(2020, 2, 10, 0, 0, 0, 0, time.UTC), wantTo: now},
+		{name: "unknown window", window: "2w", wantErr: true},
+		{name: "invalid time", from: "yesterday", wantErr: true},
+		{name: "reversed range", from: "2020-02-01T00:00:00Z", to: "2020-01-01T00:00:00Z", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := NewUptimeRequest(tt.window, tt.from, tt.to, now)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, tt.wantFrom.Equal(request.From), "from: %v", request.From)
+			assert.True(t, tt.wantTo.Equal(request.To), "to: %v", request.To)
+		})
+	}
+}
+
+func TestNewUptime(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2020, 2, 1, hour, minute, 0, 0, time.UTC)
+	}
+	request := UptimeRequest{From: at(10, 0), To: at(20, 0)}
+	maintenances := []TimeWindow{{Start: at(12, 0), End: at(13, 0)}}
+
+	tests := []struct {
+		name    string
+		count   TestResultCount
+		outages []TimeWindow
+		now     time.Time
+		want    Uptime
+	}{
+		{
+			name:  "no incidents",
+			count: TestResultCount{TotalCount: 100, SuccessCount: 100},
+			now:   at(21, 0),
+			want: Uptime{
+				TotalCount:      100,
+				SuccessCount:    100,
+				SuccessRatio:    1,
+				MonitoredTime:   9 * 3600,
+				MaintenanceTime: 3600,
+				Availability:    1,
+			},
+		},
+		{
+			name:  "incidents clipped to the range and out of maintenance",
+			count: TestResultCount{TotalCount: 100, SuccessCount: 90},
+			outages: []TimeWindow{
+				{Start: at(9, 0), End: at(10, 30)},
+				{Start: at(12, 30), End: at(14, 0)},
+				{Start: at(19, 0)},
+			},
+			now: at(19, 30),
+			want: Uptime{
+				TotalCount:      100,
+				SuccessCount:    90,
+				SuccessRatio:    0.9,
+				MonitoredTime:   8*3600 + 1800,
+				MaintenanceTime: 3600,
+				Downtime:        2 * 3600,
+				Availability:    1 - 7200.0/30600,
+				IncidentCount:   3,
+				MTTR:            2400,
+				MTBF:            7800,
+			},
+		},
+		{
+			name: "no results",
+			now:  at(21, 0),
+			want: Uptime{
+				SuccessRatio:    1,
+				MonitoredTime:   9 * 3600,
+				MaintenanceTime: 3600,
+				Availability:    1,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.From, tt.want.To = request.From, request.To
+			uptime := NewUptime(request, tt.count, tt.outages, maintenances, tt.now)
+			assert.InDelta(t, tt.want.Availability, uptime.Availability, 1e-9)
+			uptime.Availability = tt.want.Availability
+			assert.Equal(t, tt.want, uptime)
+		})
+	}
+}
@@ -16,12 +16,14 @@ var regexExtractHost = regexp.MustCompile(`^(?:(?:(https?)?(?:\:?\/\/))|(?:\/\/)
 
 type WebService struct {
 	rsmodels.DefaultValidateChecker
-	Id          string    `json:"id" gorm:"private_key"`
-	Host        string    `json:"host" gorm:"unique"`
-	Schema      string    `json:"schema" gorm:"Size:20;Default:'http'"`
-	Description string    `json:"description" gorm:"Type:TEXT"`
-	CreatedAt   time.Time `json:"createdAt"`
-	ModifiedAt  time.Time `json:"modifiedAt"`
+	Id              string          `json:"id" gorm:"private_key"`
+	Host            string          `json:"host" gorm:"unique"`
+	Schema          string          `json:"schema" gorm:"Size:20;Default:'http'"`
+	Description     string          `json:"description" gorm:"Type:TEXT"`
+	AlertRecipients EmailAddresses  `json:"alertRecipients" gorm:"Type:JSON"`
+	Retention       RetentionPolicy `json:"retention" gorm:"Type:JSON"`
+	CreatedAt       time.Time       `json:"createdAt"`
+	ModifiedAt      time.Time       `json:"modifiedAt"`
 }
 
 func (webService *WebService) Validate() error {
@@ -39,10 +41,18 @@ func (webService *WebService) UpdateFromRequest(request WebServiceRequest) error
 	if err != nil {
 		return errors.WithStack(err)
 	}
+	if err := request.AlertRecipients.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := request.Retention.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
 
 	webService.Host = host[2]
 	webService.Schema = host[1]
 	webService.Description = request.Description
+	webService.AlertRecipients = request.AlertRecipients
+	webService.Retention = request.Retention
 	webService.ModifiedAt = time.Now()
 
 	return nil
@@ -73,8 +83,10 @@ func hostRegexpFindStringSubmatch(host string) ([]string, error) {
 }
 
 type WebServiceRequest struct {
-	Host        string `json:"host"`
-	Description string `json:"description"`
+	Host            string          `json:"host"`
+	Description     string          `json:"description"`
+	AlertRecipients EmailAddresses  `json:"alertRecipients"`
+	Retention       RetentionPolicy `json:"retention"`
 }
 
 type WebServiceListRequest struct {
@@ -9,15 +9,33 @@ const (
 	ErrBadRequest        = 400
 	ErrUnsupportedMethod = 40001
 
-	ErrNotFound           = 404
-	ErrWebServiceNotFound = 4041
-	ErrTestNotFound       = 4042
-
-	ErrConflict             = 409
-	ErrDuplicatedWebService = 4091
-	ErrDuplicatedTest       = 4092
+	ErrNotFound                           = 404
+	ErrWebServiceNotFound                 = 4041
+	ErrTestNotFound                       = 4042
+	ErrMaintenanceNotFound                = 4043
+	ErrAlertDeliveryNotFound              = 4044
+	ErrNotificationChannelNotFound        = 4045
+	ErrNotificationChannelBindingNotFound = 4046
+	ErrEscalationPolicyNotFound           = 4047
+	ErrEscalationNotFound                 = 4048
+	ErrIncidentNotFound                   = 4049
+	// The 404x codes are used up, so the next ones are the status followed by two digits, like ErrUnsupportedMethod.
+	ErrReportNotFound = 40401
+
+	ErrConflict                             = 409
+	ErrDuplicatedWebService                 = 4091
+	ErrDuplicatedTest                       = 4092
+	ErrDuplicatedNotificationChannel        = 4093
+	ErrDuplicatedNotificationChannelBinding = 4094
+	ErrDuplicatedEscalationPolicy           = 4095
+	ErrEscalationClosed                     = 4096
+	ErrIncidentAcknowledged                 = 4097
 
 	ErrInternalServer = 500
+
+	ErrBadGateway          = 502
+	ErrTestExecutionFailed = 5021
+	ErrAlertDeliveryFailed = 5022
 )
 
 var (
@@ -28,6 +46,15 @@ var (
 		ErrInternalServer: newErrorWithLanguage(
 			newError(http.StatusInternalServerError, ErrInternalServer, "서버 내부에러로 인해 작업을 완료할 수 없습니다."),
 		),
+		ErrBadGateway: newErrorWithLanguage(
+			newError(http.StatusBadGateway, ErrBadGateway, "외부 서버의 응답을 받을 수 없습니다."),
+		),
+		ErrTestExecutionFailed: newErrorWithLanguage(
+			newError(http.StatusBadGateway, ErrTestExecutionFailed, "테스트 요청을 실행할 수 없습니다."),
+		),
+		ErrAlertDeliveryFailed: newErrorWithLanguage(
+			newError(http.StatusBadGateway, ErrAlertDeliveryFailed, "알림을 전송할 수 없습니다."),
+		),
 		ErrConflict: newErrorWithLanguage(
 			newError(http.StatusConflict, ErrConflict, "중복된 요청입니다."),
 		),
@@ -45,6 +72,30 @@ var (
 		ErrTestNotFound: newErrorWithLanguage(
 			newError(http.StatusNotFound, ErrTestNotFound, "해당 테스트를 찾을 수 없습니다."),
 		),
+		ErrMaintenanceNotFound: newErrorWithLanguage(
+			newError(http.StatusNotFound, ErrMaintenanceNotFound, "해당 점검 일정을 찾을 수 없습니다."),
+		),
+		ErrAlertDeliveryNotFound: newErrorWithLanguage(
+			newError(http.StatusNotFound, ErrAlertDeliveryNotFound, "해당 알림 전송 기록을 찾을 수 없습니다."),
+		),
+		ErrNotificationChannelNotFound: newErrorWithLanguage(
+			newError(http.StatusNotFound, ErrNotificationChannelNotFound, "해당 알림 채널을 찾을 수 없습니다."),
+		),
+		ErrNotificationChannelBindingNotFound: newErrorWithLanguage(
+			newError(http.StatusNotFound, ErrNotificationChannelBindingNotFound, "해당 알림 채널 연결을 찾을 수 없습니다."),
+		),
+		ErrEscalationPolicyNotFound: newErrorWithLanguage(
+			newError(http.StatusNotFound, ErrEscalationPolicyNotFound, "해당 에스컬레이션 정책을 찾을 수 없습니다."),
+		),
+		ErrEscalationNotFound: newErrorWithLanguage(
+			newError(http.StatusNotFound, ErrEscalationNotFound, "해당 에스컬레이션을 찾을 수 없습니다."),
+		),
+		ErrIncidentNotFound: newErrorWithLanguage(
+			newError(http.StatusNotFound, ErrIncidentNotFound, "해당 장애를 찾을 수 없습니다."),
+		),
+		ErrReportNotFound: newErrorWithLanguage(
+			newError(http.StatusNotFound, ErrReportNotFound, "해당 리포트를 찾을 수 없습니다."),
+		),
 
 		ErrDuplicatedWebService: newErrorWithLanguage(
 			newError(http.StatusConflict, ErrDuplicatedWebService, "이미 같은 호스트의 웹서비스가 존재합니다."),
@@ -52,6 +103,21 @@ var (
 		ErrDuplicatedTest: newErrorWithLanguage(
 			newError(http.StatusConflict, ErrDuplicatedTest, "이미 같은 테스트가 존재합니다."),
 		),
+		ErrDuplicatedNotificationChannel: newErrorWithLanguage(
+			newError(http.StatusConflict, ErrDuplicatedNotificationChannel, "이미 같은 이름의 알림 채널이 존재합니다."),
+		),
+		ErrDuplicatedNotificationChannelBinding: newErrorWithLanguage(
+			newError(http.StatusConflict, ErrDuplicatedNotificationChannelBinding, "이미 같은 대상에 연결된 알림 채널입니다."),
+		),
+		ErrDuplicatedEscalationPolicy: newErrorWithLanguage(
+			newError(http.StatusConflict, ErrDuplicatedEscalationPolicy, "이미 같은 이름의 에스컬레이션 정책이 존재합니다."),
+		),
+		ErrEscalationClosed: newErrorWithLanguage(
+			newError(http.StatusConflict, ErrEscalationClosed, "이미 확인되었거나 복구된 에스컬레이션입니다."),
+		),
+		ErrIncidentAcknowledged: newErrorWithLanguage(
+			newError(http.StatusConflict, ErrIncidentAcknowledged, "이미 확인된 장애입니다."),
+		),
 	}
 )
 
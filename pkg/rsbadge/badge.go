@@ -0,0 +1,82 @@
+package rsbadge
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"math"
+)
+
+// ContentType is the content type of a rendered badge.
+const ContentType = "image/svg+xml; charset=utf-8"
+
+// The colors of shields.io.
+const (
+	ColorBrightGreen = "#4c1"
+	ColorGreen       = "#97ca00"
+	ColorYellowGreen = "#a4a61d"
+	ColorYellow      = "#dfb317"
+	ColorOrange      = "#fe7d37"
+	ColorRed         = "#e05d44"
+	ColorBlue        = "#007ec6"
+	ColorLightGrey   = "#9f9f9f"
+	ColorLabel       = "#555"
+)
+
+const (
+	fontSize     = 11
+	padding      = 6
+	defaultWidth = 7.0
+)
+
+// charWidths are the widths in pixels of the printable ASCII characters in Verdana 11px,
+// the font of the badges, from the space to the tilde.
+var charWidths = [...]float64{
+	3.87, 4.33, 5.05, 9.0, 6.99, 11.84, 7.99, 2.95, 4.99, 4.99, 6.99, 9.0, 4.0, 4.99, 4.0, 4.99,
+	6.99, 6.99, 6.99, 6.99, 6.99, 6.99, 6.99, 6.99, 6.99, 6.99, 4.99, 4.99, 9.0, 9.0, 9.0, 6.0,
+	11.0, 7.52, 7.54, 7.68, 8.48, 6.96, 6.32, 8.53, 8.27, 4.63, 4.99, 7.62, 6.12, 9.27, 8.23, 8.66,
+	6.63, 8.66, 7.65, 7.52, 6.78, 8.05, 7.52, 10.88, 7.54, 6.77, 7.54, 4.99, 4.99, 4.99, 9.0, 6.99,
+	6.99, 6.61, 6.85, 5.73, 6.85, 6.55, 3.87, 6.85, 6.96, 3.02, 3.79, 6.51, 3.02, 10.7, 6.96, 6.68,
+	6.85, 6.85, 4.69, 5.73, 4.33, 6.96, 6.51, 9.0, 6.51, 6.51, 5.78, 6.98, 4.99, 6.98, 9.0,
+}
+
+// TextWidth estimates the width in pixels of the text in the font of the badges.
+func TextWidth(text string) float64 {
+	width := 0.0
+	for _, r := range text {
+		if r >= ' ' && int(r-' ') < len(charWidths) {
+			width += charWidths[r-' ']
+			continue
+		}
+		width += defaultWidth
+	}
+	return width
+}
+
+// Render renders a flat badge like those of shields.io, with the label on grey
+// and the message on the color.
+func Render(label, message, color string) []byte {
+	labelWidth := int(math.Ceil(TextWidth(label))) + 2*padding
+	messageWidth := int(math.Ceil(TextWidth(message))) + 2*padding
+	width := labelWidth + messageWidth
+	label, message = html.EscapeString(label), html.EscapeString(message)
+	color = html.EscapeString(color)
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`, width, label, message)
+	fmt.Fprintf(buf, `<title>%s: %s</title>`, label, message)
+	buf.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	fmt.Fprintf(buf, `<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`, width)
+	fmt.Fprintf(buf, `<g clip-path="url(#r)"><rect width="%d" height="20" fill="%s"/><rect x="%d" width="%d" height="20" fill="%s"/><rect width="%d" height="20" fill="url(#s)"/></g>`,
+		labelWidth, ColorLabel, labelWidth, messageWidth, color, width)
+	fmt.Fprintf(buf, `<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="%d">`, fontSize)
+	writeText(buf, label, float64(labelWidth)/2)
+	writeText(buf, message, float64(labelWidth)+float64(messageWidth)/2)
+	buf.WriteString(`</g></svg>`)
+	return buf.Bytes()
+}
+
+// writeText writes the text centered at x with its shadow.
+func writeText(buf *bytes.Buffer, text string, x float64) {
+	fmt.Fprintf(buf, `<text x="%.1f" y="15" fill="#010101" fill-opacity=".3">%s</text><text x="%.1f" y="14">%s</text>`, x, text, x, text)
+}
@@ -0,0 +1,36 @@
+package rsbadge
+
+import (
+	"encoding/xml"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextWidth(t *testing.T) {
+	assert.Equal(t, 0.0, TextWidth(""))
+	assert.InDelta(t, 6.99*3, TextWidth("100"), 0.001)
+	assert.Greater(t, TextWidth("WWW"), TextWidth("iii"))
+	assert.Equal(t, defaultWidth, TextWidth("가"))
+}
+
+func TestRender(t *testing.T) {
+	svg := Render("status", "<up>", ColorBrightGreen)
+	var parsed struct {
+		Width int `xml:"width,attr"`
+	}
+	if !assert.NoError(t, xml.Unmarshal(svg, &parsed)) {
+		return
+	}
+	assert.Equal(t, int(math.Ceil(TextWidth("status")))+2*padding+int(math.Ceil(TextWidth("<up>")))+2*padding, parsed.Width)
+
+	text := string(svg)
+	assert.True(t, strings.HasPrefix(text, `<svg xmlns="http://www.w3.org/2000/svg" width="`))
+	assert.Contains(t, text, `<title>status: &lt;up&gt;</title>`)
+	assert.Contains(t, text, `fill="#4c1"`)
+	assert.NotContains(t, text, "<up>")
+
+	assert.Equal(t, svg, Render("status", "<up>", ColorBrightGreen))
+}
@@ -58,6 +58,14 @@ func (conn *defaultConnection) Conn() *gorm.DB {
 	return conn.db
 }
 
+// Close closes the connection which was opened by Init.
+func Close() error {
+	if conn == nil {
+		return nil
+	}
+	return conn.Close()
+}
+
 func NewConnection(tx *gorm.DB) Connection {
 	return &defaultConnection{tx}
 }
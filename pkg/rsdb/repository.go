@@ -1,7 +1,9 @@
 package rsdb
 
 import (
+	"database/sql/driver"
 	"fmt"
+	"net"
 	"reflect"
 	"runtime"
 	"strings"
@@ -157,6 +159,30 @@ func HandleSQLError(err error) error {
 	return err
 }
 
+// IsTransientError reports whether err is likely to succeed on retry,
+// such as a lost connection, a deadlock or a lock wait timeout.
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	cause := errors.Cause(err)
+	switch cause {
+	case driver.ErrBadConn, mysql.ErrInvalidConn:
+		return true
+	}
+	if mysqlError, ok := cause.(*mysql.MySQLError); ok {
+		switch mysqlError.Number {
+		case mysqlerr.ER_LOCK_DEADLOCK, mysqlerr.ER_LOCK_WAIT_TIMEOUT, mysqlerr.ER_CON_COUNT_ERROR, mysqlerr.ER_SERVER_SHUTDOWN:
+			return true
+		}
+		return false
+	}
+	if _, ok := cause.(net.Error); ok {
+		return true
+	}
+	return false
+}
+
 type ListFilter struct {
 	Page       int
 	NumItem    int
@@ -124,6 +124,50 @@ func TestHandleSQLError(t *testing.T) {
 	}
 }
 
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "nil",
+			err:  nil,
+			want: false,
+		},
+		{
+			name: "deadlock",
+			err:  &mysql.MySQLError{Number: 1213},
+			want: true,
+		},
+		{
+			name: "lock wait timeout",
+			err:  errors.WithStack(&mysql.MySQLError{Number: 1205}),
+			want: true,
+		},
+		{
+			name: "invalid connection",
+			err:  mysql.ErrInvalidConn,
+			want: true,
+		},
+		{
+			name: "duplicated",
+			err:  &mysql.MySQLError{Number: 1062},
+			want: false,
+		},
+		{
+			name: "record not found",
+			err:  ErrRecordNotFound,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsTransientError(tt.err))
+		})
+	}
+}
+
 func TestDefaultRepository_Create(t *testing.T) {
 	type args struct {
 		expectQuery func(mock sqlmock.Sqlmock)
@@ -0,0 +1,360 @@
+package rsmetrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the content type of the Prometheus text exposition format written by a registry.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+const (
+	kindCounter   = "counter"
+	kindGauge     = "gauge"
+	kindHistogram = "histogram"
+)
+
+// DefaultRegistry holds the metrics recorded by the packages of the process.
+var DefaultRegistry = NewRegistry()
+
+// Registry holds metric families and writes them in the Prometheus text exposition format.
+type Registry struct {
+	mux      sync.Mutex
+	families map[string]*family
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// Write writes every family sorted by name, and the series of each family sorted by label values.
+func (registry *Registry) Write(w io.Writer) error {
+	registry.mux.Lock()
+	families := make([]*family, 0, len(registry.families))
+	for _, f := range registry.families {
+		families = append(families, f)
+	}
+	registry.mux.Unlock()
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].name < families[j].name
+	})
+
+	buf := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(buf)
+	}
+	return buf.Flush()
+}
+
+// NewCounterVec registers a counter family. It panics if the name is already registered.
+func (registry *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	return &CounterVec{registry.register(name, help, kindCounter, nil, nil, labelNames)}
+}
+
+// NewGaugeVec registers a gauge family. It panics if the name is already registered.
+func (registry *Registry) NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	return &GaugeVec{registry.register(name, help, kindGauge, nil, nil, labelNames)}
+}
+
+// NewHistogramVec registers a histogram family with the upper bounds of its buckets, which must be increasing.
+// The +Inf bucket is added. It panics if the name is already registered.
+func (registry *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	return &HistogramVec{registry.register(name, help, kindHistogram, buckets, nil, labelNames)}
+}
+
+// NewGaugeFunc registers a gauge whose value is read from fn on every write.
+func (registry *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	registry.register(name, help, kindGauge, nil, fn, nil)
+}
+
+// NewCounterFunc registers a counter whose value is read from fn on every write.
+func (registry *Registry) NewCounterFunc(name, help string, fn func() float64) {
+	registry.register(name, help, kindCounter, nil, fn, nil)
+}
+
+func (registry *Registry) register(name, help, kind string, buckets []float64, fn func() float64, labelNames []string) *family {
+	registry.mux.Lock()
+	defer registry.mux.Unlock()
+	if _, exist := registry.families[name]; exist {
+		panic(fmt.Sprintf("rsmetrics: metric already registered: '%s'", name))
+	}
+	f := &family{
+		name:       name,
+		help:       help,
+		kind:       kind,
+		labelNames: labelNames,
+		buckets:    buckets,
+		fn:         fn,
+		series:     make(map[string]*series),
+	}
+	registry.families[name] = f
+	return f
+}
+
+type family struct {
+	name       string
+	help       string
+	kind       string
+	labelNames []string
+	buckets    []float64
+	fn         func() float64
+
+	mux    sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	// counts, sum and count are those of a histogram; counts are not cumulative, the +Inf bucket last.
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func (f *family) with(labelValues []string) *series {
+	if len(labelValues) != len(f.labelNames) {
+		panic(fmt.Sprintf("rsmetrics: '%s' has %d labels, got %d values", f.name, len(f.labelNames), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	s, exist := f.series[key]
+	if !exist {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		if f.kind == kindHistogram {
+			s.counts = make([]uint64, len(f.buckets)+1)
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+func (f *family) deleteMatching(labelName, value string) {
+	i := indexOf(f.labelNames, labelName)
+	if i < 0 {
+		return
+	}
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	for key, s := range f.series {
+		if s.labelValues[i] == value {
+			delete(f.series, key)
+		}
+	}
+}
+
+func (f *family) write(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+	if f.fn != nil {
+		fmt.Fprintf(w, "%s %s\n", f.name, formatFloat(f.fn()))
+		return
+	}
+
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := f.series[key]
+		if f.kind != kindHistogram {
+			fmt.Fprintf(w, "%s%s %s\n", f.name, formatLabels(f.labelNames, s.labelValues, ""), formatFloat(s.value))
+			continue
+		}
+		cumulative := uint64(0)
+		for i, count := range s.counts {
+			cumulative += count
+			le := math.Inf(1)
+			if i < len(f.buckets) {
+				le = f.buckets[i]
+			}
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, formatLabels(f.labelNames, s.labelValues, formatFloat(le)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_sum%s %s\n", f.name, formatLabels(f.labelNames, s.labelValues, ""), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", f.name, formatLabels(f.labelNames, s.labelValues, ""), s.count)
+	}
+}
+
+// CounterVec is a family of counters partitioned by label values.
+type CounterVec struct {
+	family *family
+}
+
+// With returns the counter of the label values, in the order of the label names. It panics on a wrong count.
+func (vec *CounterVec) With(labelValues ...string) *Counter {
+	return &Counter{vec.family, vec.family.with(labelValues)}
+}
+
+// DeleteMatching deletes every counter whose label has the value.
+func (vec *CounterVec) DeleteMatching(labelName, value string) {
+	vec.family.deleteMatching(labelName, value)
+}
+
+type Counter struct {
+	family *family
+	series *series
+}
+
+func (counter *Counter) Inc() {
+	counter.Add(1)
+}
+
+// Add adds a delta, which is ignored if negative as a counter only goes up.
+func (counter *Counter) Add(delta float64) {
+	if delta < 0 {
+		return
+	}
+	counter.family.mux.Lock()
+	counter.series.value += delta
+	counter.family.mux.Unlock()
+}
+
+func (counter *Counter) Value() float64 {
+	counter.family.mux.Lock()
+	defer counter.family.mux.Unlock()
+	return counter.series.value
+}
+
+// GaugeVec is a family of gauges partitioned by label values.
+type GaugeVec struct {
+	family *family
+}
+
+// With returns the gauge of the label values, in the order of the label names. It panics on a wrong count.
+func (vec *GaugeVec) With(labelValues ...string) *Gauge {
+	return &Gauge{vec.family, vec.family.with(labelValues)}
+}
+
+// DeleteMatching deletes every gauge whose label has the value.
+func (vec *GaugeVec) DeleteMatching(labelName, value string) {
+	vec.family.deleteMatching(labelName, value)
+}
+
+type Gauge struct {
+	family *family
+	series *series
+}
+
+func (gauge *Gauge) Set(value float64) {
+	gauge.family.mux.Lock()
+	gauge.series.value = value
+	gauge.family.mux.Unlock()
+}
+
+func (gauge *Gauge) Add(delta float64) {
+	gauge.family.mux.Lock()
+	gauge.series.value += delta
+	gauge.family.mux.Unlock()
+}
+
+func (gauge *Gauge) Inc() {
+	gauge.Add(1)
+}
+
+func (gauge *Gauge) Dec() {
+	gauge.Add(-1)
+}
+
+func (gauge *Gauge) Value() float64 {
+	gauge.family.mux.Lock()
+	defer gauge.family.mux.Unlock()
+	return gauge.series.value
+}
+
+// HistogramVec is a family of histograms with the same buckets partitioned by label values.
+type HistogramVec struct {
+	family *family
+}
+
+// With returns the histogram of the label values, in the order of the label names. It panics on a wrong count.
+func (vec *HistogramVec) With(labelValues ...string) *Histogram {
+	return &Histogram{vec.family, vec.family.with(labelValues)}
+}
+
+// DeleteMatching deletes every histogram whose label has the value.
+func (vec *HistogramVec) DeleteMatching(labelName, value string) {
+	vec.family.deleteMatching(labelName, value)
+}
+
+type Histogram struct {
+	family *family
+	series *series
+}
+
+// Observe counts the value in the first bucket whose upper bound is not below it.
+func (histogram *Histogram) Observe(value float64) {
+	buckets := histogram.family.buckets
+	i := sort.SearchFloat64s(buckets, value)
+	histogram.family.mux.Lock()
+	histogram.series.counts[i]++
+	histogram.series.sum += value
+	histogram.series.count++
+	histogram.family.mux.Unlock()
+}
+
+func formatLabels(names, values []string, le string) string {
+	if len(names) == 0 && le == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=\"%s\"", name, escapeLabelValue(values[i]))
+	}
+	if le != "" {
+		if len(names) > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "le=\"%s\"", le)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var (
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(help string) string {
+	return helpEscaper.Replace(help)
+}
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+func indexOf(names []string, name string) int {
+	for i := range names {
+		if names[i] == name {
+			return i
+		}
+	}
+	return -1
+}
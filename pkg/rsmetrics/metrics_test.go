@@ -0,0 +1,77 @@
+package rsmetrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_Write(t *testing.T) {
+	registry := NewRegistry()
+
+	success := registry.NewGaugeVec("check_success", "Whether the last check succeeded.", "test_id", "name")
+	success.With("b", "second").Set(0)
+	success.With("a", `say "hi"`).Set(1)
+
+	deliveries := registry.NewCounterVec("deliveries_total", "Alert deliveries.\nBy status.", "status")
+	deliveries.With("success").Inc()
+	deliveries.With("success").Add(2)
+	deliveries.With("success").Add(-1)
+
+	duration := registry.NewHistogramVec("duration_seconds", "Check duration.", []float64{0.1, 0.5}, "test_id")
+	for _, value := range []float64{0.05, 0.1, 0.3, 2} {
+		duration.With("a").Observe(value)
+	}
+
+	registry.NewGaugeFunc("queue_length", "Queue length.", func() float64 { return 3 })
+
+	buf := &bytes.Buffer{}
+	if err := registry.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `# HELP check_success Whether the last check succeeded.
+# TYPE check_success gauge
+check_success{test_id="a",name="say \"hi\""} 1
+check_success{test_id="b",name="second"} 0
+# HELP deliveries_total Alert deliveries.\nBy status.
+# TYPE deliveries_total counter
+deliveries_total{status="success"} 3
+# HELP duration_seconds Check duration.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{test_id="a",le="0.1"} 2
+duration_seconds_bucket{test_id="a",le="0.5"} 3
+duration_seconds_bucket{test_id="a",le="+Inf"} 4
+duration_seconds_sum{test_id="a"} 2.45
+duration_seconds_count{test_id="a"} 4
+# HELP queue_length Queue length.
+# TYPE queue_length gauge
+queue_length 3
+`, buf.String())
+}
+
+func TestGaugeVec_DeleteMatching(t *testing.T) {
+	registry := NewRegistry()
+	gauge := registry.NewGaugeVec("last_run", "Last run.", "web_service_id", "test_id")
+	gauge.With("ws", "a").Set(1)
+	gauge.With("ws", "b").Set(2)
+	gauge.DeleteMatching("test_id", "a")
+	gauge.DeleteMatching("unknown", "b")
+
+	buf := &bytes.Buffer{}
+	if err := registry.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `# HELP last_run Last run.
+# TYPE last_run gauge
+last_run{web_service_id="ws",test_id="b"} 2
+`, buf.String())
+}
+
+func TestRegistry_RegisterTwice(t *testing.T) {
+	registry := NewRegistry()
+	registry.NewGaugeVec("gauge", "Gauge.")
+	assert.Panics(t, func() {
+		registry.NewCounterVec("gauge", "Counter.")
+	})
+}
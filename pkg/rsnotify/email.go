@@ -0,0 +1,253 @@
+package rsnotify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// StartTLSAuto upgrades the connection whenever the server offers STARTTLS.
+	StartTLSAuto   = "auto"
+	StartTLSAlways = "always"
+	StartTLSNever  = "never"
+
+	defaultSMTPTimeout = 10 * time.Second
+	smtpReplyOK        = 250
+)
+
+// SMTPConfig is the SMTP server which every email alert is sent through.
+type SMTPConfig interface {
+	GetHost() string
+	GetPort() int
+	GetUsername() string
+	GetPassword() string
+	GetFrom() string
+	GetStartTLS() string
+	GetInsecureSkipVerify() bool
+	GetTimeout() time.Duration
+}
+
+type smtpServer struct {
+	host               string
+	port               int
+	username           string
+	password           string
+	from               *mail.Address
+	startTLS           string
+	insecureSkipVerify bool
+	timeout            time.Duration
+}
+
+// InitSMTP registers the email notifier for the server of config.
+// Email alerts are rejected until it is called with a host.
+func InitSMTP(config SMTPConfig) error {
+	if config.GetHost() == "" {
+		Register(TypeEmail, newEmailFactory(smtpServer{}))
+		return nil
+	}
+	if config.GetPort() <= 0 || config.GetPort() > 65535 {
+		return errors.Wrap(ErrInvalidConfig, "smtp.port")
+	}
+	from, err := mail.ParseAddress(config.GetFrom())
+	if err != nil {
+		return errors.Wrap(ErrInvalidConfig, "smtp.from")
+	}
+	startTLS := config.GetStartTLS()
+	switch startTLS {
+	case "":
+		startTLS = StartTLSAuto
+	case StartTLSAuto, StartTLSAlways, StartTLSNever:
+	default:
+		return errors.Wrap(ErrInvalidConfig, "smtp.startTLS")
+	}
+	timeout := config.GetTimeout()
+	if timeout < 0 {
+		return errors.Wrap(ErrInvalidConfig, "smtp.timeout")
+	}
+	if timeout == 0 {
+		timeout = defaultSMTPTimeout
+	}
+
+	Register(TypeEmail, newEmailFactory(smtpServer{
+		host:               config.GetHost(),
+		port:               config.GetPort(),
+		username:           config.GetUsername(),
+		password:           config.GetPassword(),
+		from:               from,
+		startTLS:           startTLS,
+		insecureSkipVerify: config.GetInsecureSkipVerify(),
+		timeout:            timeout,
+	}))
+	return nil
+}
+
+// EmailConfig is the recipients of an email alert. The server is configured once by InitSMTP.
+type EmailConfig struct {
+	To []string `json:"to"`
+}
+
+func (config EmailConfig) Validate() error {
+	if len(config.To) == 0 {
+		return errors.Wrap(ErrInvalidConfig, "to")
+	}
+	for _, to := range config.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return errors.Wrapf(ErrInvalidConfig, "to: '%s'", to)
+		}
+	}
+	return nil
+}
+
+type emailNotifier struct {
+	server smtpServer
+	to     []string
+}
+
+// Notify returns 250 when the server accepted the mail, or the reply code it rejected it with.
+func (notifier *emailNotifier) Notify(ctx context.Context, message Message) (int, error) {
+	if err := notifier.send(ctx, message); err != nil {
+		if replyErr, ok := errors.Cause(err).(*textproto.Error); ok {
+			return replyErr.Code, err
+		}
+		return 0, err
+	}
+	return smtpReplyOK, nil
+}
+
+func (notifier *emailNotifier) send(ctx context.Context, message Message) error {
+	server := notifier.server
+	addr := net.JoinHostPort(server.host, strconv.Itoa(server.port))
+
+	dialer := &net.Dialer{Timeout: server.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	deadline := time.Now().Add(server.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, server.host)
+	if err != nil {
+		_ = conn.Close()
+		return errors.WithStack(err)
+	}
+	defer client.Close()
+
+	if server.startTLS != StartTLSNever {
+		ok, _ := client.Extension("STARTTLS")
+		if !ok && server.startTLS == StartTLSAlways {
+			// Permanent, so it is not retried.
+			return errors.WithStack(&textproto.Error{Code: 554, Msg: "server does not offer STARTTLS"})
+		}
+		if ok {
+			if err := client.StartTLS(&tls.Config{ServerName: server.host, InsecureSkipVerify: server.insecureSkipVerify}); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+	if server.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", server.username, server.password, server.host)); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	if err := client.Mail(server.from.Address); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, to := range notifier.to {
+		address, _ := mail.ParseAddress(to)
+		if err := client.Rcpt(address.Address); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if _, err := writer.Write(notifier.compose(message)); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := writer.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(client.Quit())
+}
+
+// compose builds a text/plain mail, or a multipart/alternative one if the message has HTML.
+func (notifier *emailNotifier) compose(message Message) []byte {
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		buf.WriteString(fmt.Sprintf("%s: %s\r\n", key, value))
+	}
+	header("From", notifier.server.from.String())
+	header("To", strings.Join(notifier.to, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", message.Subject))
+	header("Date", message.SentAt.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+
+	if message.HTML == "" {
+		header("Content-Type", "text/plain; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		writeQuotedPrintable(&buf, message.Text)
+		return buf.Bytes()
+	}
+
+	writer := multipart.NewWriter(&buf)
+	header("Content-Type", fmt.Sprintf("multipart/alternative; boundary=%s", writer.Boundary()))
+	buf.WriteString("\r\n")
+	// Clients show the last part they can render, so the plain text goes first.
+	for _, part := range []struct {
+		contentType string
+		body        string
+	}{
+		{contentType: "text/plain; charset=utf-8", body: message.Text},
+		{contentType: "text/html; charset=utf-8", body: message.HTML},
+	} {
+		partWriter, _ := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		writeQuotedPrintable(partWriter, part.body)
+	}
+	_ = writer.Close()
+	return buf.Bytes()
+}
+
+func writeQuotedPrintable(w io.Writer, text string) {
+	writer := quotedprintable.NewWriter(w)
+	_, _ = writer.Write([]byte(strings.ReplaceAll(text, "\n", "\r\n")))
+	_ = writer.Close()
+}
+
+func newEmailFactory(server smtpServer) Factory {
+	return func(data []byte) (Notifier, error) {
+		if server.host == "" {
+			return nil, errors.WithStack(ErrNoSMTPServer)
+		}
+		config := &EmailConfig{}
+		if err := unmarshalConfig(data, config); err != nil {
+			return nil, err
+		}
+		return &emailNotifier{server: server, to: config.To}, nil
+	}
+}
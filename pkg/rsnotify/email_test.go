@@ -0,0 +1,142 @@
+package rsnotify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/realsangil/apimonitor/pkg/testutils"
+)
+
+type testSMTPConfig struct {
+	host     string
+	port     int
+	from     string
+	startTLS string
+}
+
+func (config *testSMTPConfig) GetHost() string             { return config.host }
+func (config *testSMTPConfig) GetPort() int                { return config.port }
+func (config *testSMTPConfig) GetUsername() string         { return "" }
+func (config *testSMTPConfig) GetPassword() string         { return "" }
+func (config *testSMTPConfig) GetFrom() string             { return config.from }
+func (config *testSMTPConfig) GetStartTLS() string         { return config.startTLS }
+func (config *testSMTPConfig) GetInsecureSkipVerify() bool { return false }
+func (config *testSMTPConfig) GetTimeout() time.Duration   { return time.Second }
+
+func TestEmailNotifier(t *testing.T) {
+	server, err := testutils.NewSMTPServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	if err := InitSMTP(&testSMTPConfig{host: server.Host(), port: server.Port(), from: "API Monitor <monitor@example.com>"}); err != nil {
+		t.Fatal(err)
+	}
+	defer InitSMTP(&testSMTPConfig{})
+
+	notifier, err := New(TypeEmail, []byte(`{"to": ["ops@example.com", "dev@example.com"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		message  Message
+		contains []string
+	}{
+		{
+			name:    "plain text",
+			message: NewMessage("[DOWN] 상태 확인\nGET /health failed"),
+			contains: []string{
+				"Subject: =?utf-8?q?[DOWN]_",
+				"Content-Type: text/plain; charset=utf-8",
+				"GET /health failed",
+			},
+		},
+		{
+			name: "html",
+			message: Message{
+				Subject: "[DOWN] health",
+				Text:    "GET /health failed",
+				HTML:    "<p>GET /health failed</p>",
+			},
+			contains: []string{
+				"Content-Type: multipart/alternative; boundary=",
+				"Content-Type: text/plain; charset=utf-8",
+				"Content-Type: text/html; charset=utf-8",
+				"<p>GET /health failed</p>",
+			},
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts, err := Send(context.Background(), notifier, tt.message, DefaultRetry)
+			assert.NoError(t, err)
+			if assert.Len(t, attempts, 1) {
+				assert.Equal(t, 250, attempts[0].StatusCode)
+			}
+
+			messages := server.Messages()
+			if assert.Len(t, messages, i+1) {
+				message := messages[i]
+				assert.Equal(t, "monitor@example.com", message.From)
+				assert.Equal(t, []string{"ops@example.com", "dev@example.com"}, message.To)
+				for _, s := range tt.contains {
+					assert.Contains(t, message.Data, s)
+				}
+			}
+		})
+	}
+}
+
+func TestEmailNotifier_StartTLSAlways(t *testing.T) {
+	server, err := testutils.NewSMTPServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	if err := InitSMTP(&testSMTPConfig{host: server.Host(), port: server.Port(), from: "monitor@example.com", startTLS: StartTLSAlways}); err != nil {
+		t.Fatal(err)
+	}
+	defer InitSMTP(&testSMTPConfig{})
+
+	notifier, err := New(TypeEmail, []byte(`{"to": ["ops@example.com"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Send(context.Background(), notifier, NewMessage("[DOWN] health"), Retry{Attempts: 3, Interval: time.Second})
+	assert.Error(t, err)
+	assert.False(t, IsRetryable(err))
+	assert.Empty(t, server.Messages())
+}
+
+func TestInitSMTP(t *testing.T) {
+	defer InitSMTP(&testSMTPConfig{})
+
+	tests := []struct {
+		name    string
+		config  *testSMTPConfig
+		wantErr bool
+	}{
+		{name: "not configured", config: &testSMTPConfig{}},
+		{name: "configured", config: &testSMTPConfig{host: "127.0.0.1", port: 25, from: "monitor@example.com"}},
+		{name: "invalid port", config: &testSMTPConfig{host: "127.0.0.1", from: "monitor@example.com"}, wantErr: true},
+		{name: "invalid from", config: &testSMTPConfig{host: "127.0.0.1", port: 25, from: "monitor"}, wantErr: true},
+		{name: "invalid startTLS", config: &testSMTPConfig{host: "127.0.0.1", port: 25, from: "monitor@example.com", startTLS: "sometimes"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := InitSMTP(tt.config)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+
+	assert.NoError(t, InitSMTP(&testSMTPConfig{}))
+	_, err := New(TypeEmail, []byte(`{"to": ["ops@example.com"]}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,435 @@
+package rsnotify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rshttp"
+)
+
+const (
+	// HeaderTimestamp and HeaderSignature are sent when the config has a secret.
+	// The receiver should recompute the signature with Sign and reject old timestamps to prevent replays.
+	HeaderTimestamp = "X-Apimonitor-Timestamp"
+	HeaderSignature = "X-Apimonitor-Signature"
+
+	ErrInvalidSignature = rserrors.Error("invalid signature")
+	ErrExpiredSignature = rserrors.Error("expired signature")
+
+	discordMaxContentLength = 2000
+	signaturePrefix         = "sha256="
+	maskedSecret            = "********"
+)
+
+// StatusError is returned when the receiver answered with a non-2xx status.
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (err *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status: statusCode='%d', body='%s'", err.StatusCode, err.Body)
+}
+
+// IsRetryable reports whether the delivery may succeed if it is sent again.
+func IsRetryable(err error) bool {
+	switch cause := errors.Cause(err).(type) {
+	case *StatusError:
+		return cause.StatusCode >= http.StatusInternalServerError || cause.StatusCode == http.StatusTooManyRequests
+	case *url.Error:
+		return true
+	case net.Error:
+		return true
+	case *textproto.Error:
+		// 4xx replies of SMTP are transient, 5xx are permanent.
+		return cause.Code >= 400 && cause.Code < 500
+	}
+	return errors.Cause(err) == context.DeadlineExceeded
+}
+
+// Sign returns the signature of a body sent at timestamp, in unix seconds:
+// "sha256=" and the hex encoded HMAC-SHA256 of "<timestamp>.<body>" keyed by the secret.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and timestamp headers of a received body.
+// Timestamps more than tolerance away from now are rejected.
+func Verify(secret, signature, timestamp string, body []byte, tolerance time.Duration, now time.Time) error {
+	sentAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.WithStack(ErrInvalidSignature)
+	}
+	if diff := now.Sub(time.Unix(sentAt, 0)); diff > tolerance || diff < -tolerance {
+		return errors.WithStack(ErrExpiredSignature)
+	}
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, sentAt, body))) {
+		return errors.WithStack(ErrInvalidSignature)
+	}
+	return nil
+}
+
+// Secret is write-only: it is marshalled masked, so that it is never shown once configured.
+type Secret string
+
+func (secret Secret) MarshalJSON() ([]byte, error) {
+	if secret == "" {
+		return []byte(`""`), nil
+	}
+	return json.Marshal(maskedSecret)
+}
+
+// Headers are write-only like Secret: they often carry credentials, such as an Authorization,
+// so their values are marshalled masked.
+type Headers map[string]string
+
+func (headers Headers) MarshalJSON() ([]byte, error) {
+	masked := make(map[string]string, len(headers))
+	for key := range headers {
+		masked[key] = maskedSecret
+	}
+	return json.Marshal(masked)
+}
+
+// RedactConfig masks the credentials of a JSON config: its secret and the values of its headers.
+// The config is returned as is if it is not a JSON object.
+func RedactConfig(config []byte) []byte {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(config, &fields); err != nil {
+		return config
+	}
+	redacted := false
+	if secret, ok := fields["secret"]; ok && string(secret) != `""` {
+		fields["secret"], _ = json.Marshal(maskedSecret)
+		redacted = true
+	}
+	headers := map[string]string{}
+	if raw, ok := fields["headers"]; ok && json.Unmarshal(raw, &headers) == nil && len(headers) > 0 {
+		fields["headers"], _ = json.Marshal(Headers(headers))
+		redacted = true
+	}
+	if !redacted {
+		return config
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return config
+	}
+	return data
+}
+
+// SameReceiver reports whether both JSON configs send to the same place, whatever their credentials are.
+func SameReceiver(config, other []byte) bool {
+	var fields, otherFields map[string]interface{}
+	if err := json.Unmarshal(config, &fields); err != nil {
+		return false
+	}
+	if err := json.Unmarshal(other, &otherFields); err != nil {
+		return false
+	}
+	for _, key := range []string{"secret", "headers"} {
+		delete(fields, key)
+		delete(otherFields, key)
+	}
+	return reflect.DeepEqual(fields, otherFields)
+}
+
+// KeepCredentials returns the config with the credentials of the previous config, unless it sets new ones.
+// A config which was read back from the API, without the secret or with it and its headers masked,
+// keeps them; an empty secret removes it.
+//
+// Credentials are only kept for the same URL, so that they are never sent elsewhere:
+// a config which moves to another URL with masked credentials is invalid.
+func KeepCredentials(previous, config []byte) ([]byte, error) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(config, &fields); err != nil {
+		return config, nil
+	}
+	previousFields := map[string]json.RawMessage{}
+	_ = json.Unmarshal(previous, &previousFields)
+
+	var receiverURL, previousURL string
+	_ = json.Unmarshal(fields["url"], &receiverURL)
+	_ = json.Unmarshal(previousFields["url"], &previousURL)
+	sameURL := receiverURL != "" && receiverURL == previousURL
+
+	var secret string
+	raw, hasSecret := fields["secret"]
+	if hasSecret && json.Unmarshal(raw, &secret) == nil && secret == maskedSecret {
+		previousSecret, ok := previousFields["secret"]
+		if !sameURL || !ok {
+			return nil, errors.Wrap(ErrInvalidConfig, "secret")
+		}
+		fields["secret"] = previousSecret
+	} else if previousSecret, ok := previousFields["secret"]; !hasSecret && ok && sameURL {
+		fields["secret"] = previousSecret
+	}
+
+	headers := map[string]string{}
+	if raw, ok := fields["headers"]; ok && json.Unmarshal(raw, &headers) == nil {
+		previousHeaders := map[string]string{}
+		_ = json.Unmarshal(previousFields["headers"], &previousHeaders)
+		for key, value := range headers {
+			if value != maskedSecret {
+				continue
+			}
+			previousValue, ok := previousHeaders[key]
+			if !sameURL || !ok {
+				return nil, errors.Wrap(ErrInvalidConfig, "headers")
+			}
+			headers[key] = previousValue
+		}
+		fields["headers"], _ = json.Marshal(headers)
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return data, nil
+}
+
+// HTTPConfig is what every HTTP channel is configured with.
+// With a secret, each request is signed, see Sign.
+type HTTPConfig struct {
+	URL     string         `json:"url"`
+	Headers Headers        `json:"headers,omitempty"`
+	Timeout rshttp.Timeout `json:"timeout,omitempty"`
+	Secret  Secret         `json:"secret,omitempty"`
+}
+
+func (config HTTPConfig) Validate() error {
+	u, err := url.Parse(config.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Wrap(ErrInvalidConfig, "url")
+	}
+	if config.Timeout < 0 {
+		return errors.Wrap(ErrInvalidConfig, "timeout")
+	}
+	return nil
+}
+
+type httpNotifier struct {
+	config HTTPConfig
+	method string
+	body   func(message Message) interface{}
+}
+
+func (notifier *httpNotifier) Notify(ctx context.Context, message Message) (int, error) {
+	body, err := json.Marshal(notifier.body(message))
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, notifier.config.Timeout.GetDuration())
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, notifier.method, notifier.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range notifier.config.Headers {
+		request.Header.Set(key, value)
+	}
+	if notifier.config.Secret != "" {
+		// Signed on every attempt, so that a retry is not taken for a replay.
+		timestamp := time.Now().Unix()
+		request.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+		request.Header.Set(HeaderSignature, Sign(string(notifier.config.Secret), timestamp, body))
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		responseBody, _ := ioutil.ReadAll(io.LimitReader(response.Body, 1024))
+		return response.StatusCode, errors.WithStack(&StatusError{StatusCode: response.StatusCode, Body: string(responseBody)})
+	}
+	_, _ = io.Copy(ioutil.Discard, response.Body)
+	return response.StatusCode, nil
+}
+
+func unmarshalConfig(data []byte, config interface{ Validate() error }) error {
+	if len(data) == 0 {
+		return errors.Wrap(ErrInvalidConfig, "empty")
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return errors.Wrap(ErrInvalidConfig, err.Error())
+	}
+	return config.Validate()
+}
+
+// WebhookConfig posts {"text": "..."}, which is what the alerts always sent.
+type WebhookConfig struct {
+	HTTPConfig
+}
+
+func newWebhookNotifier(data []byte) (Notifier, error) {
+	config := &WebhookConfig{}
+	if err := unmarshalConfig(data, config); err != nil {
+		return nil, err
+	}
+	return &httpNotifier{
+		config: config.HTTPConfig,
+		method: http.MethodPost,
+		body: func(message Message) interface{} {
+			return map[string]string{"text": message.Text}
+		},
+	}, nil
+}
+
+// SlackConfig posts to an incoming webhook of Slack.
+type SlackConfig struct {
+	HTTPConfig
+	Channel   string `json:"channel"`
+	Username  string `json:"username"`
+	IconEmoji string `json:"iconEmoji"`
+}
+
+func newSlackNotifier(data []byte) (Notifier, error) {
+	config := &SlackConfig{}
+	if err := unmarshalConfig(data, config); err != nil {
+		return nil, err
+	}
+	return &httpNotifier{
+		config: config.HTTPConfig,
+		method: http.MethodPost,
+		body: func(message Message) interface{} {
+			return struct {
+				Text      string `json:"text"`
+				Channel   string `json:"channel,omitempty"`
+				Username  string `json:"username,omitempty"`
+				IconEmoji string `json:"icon_emoji,omitempty"`
+			}{
+				Text:      message.Text,
+				Channel:   config.Channel,
+				Username:  config.Username,
+				IconEmoji: config.IconEmoji,
+			}
+		},
+	}, nil
+}
+
+// DiscordConfig posts to a webhook of Discord, which rejects contents over 2000 characters.
+type DiscordConfig struct {
+	HTTPConfig
+	Username string `json:"username"`
+}
+
+func newDiscordNotifier(data []byte) (Notifier, error) {
+	config := &DiscordConfig{}
+	if err := unmarshalConfig(data, config); err != nil {
+		return nil, err
+	}
+	return &httpNotifier{
+		config: config.HTTPConfig,
+		method: http.MethodPost,
+		body: func(message Message) interface{} {
+			content := []rune(message.Text)
+			if len(content) > discordMaxContentLength {
+				content = append(content[:discordMaxContentLength-1], '…')
+			}
+			return struct {
+				Content  string `json:"content"`
+				Username string `json:"username,omitempty"`
+			}{
+				Content:  string(content),
+				Username: config.Username,
+			}
+		},
+	}, nil
+}
+
+// TeamsConfig posts a MessageCard to an incoming webhook of Microsoft Teams.
+type TeamsConfig struct {
+	HTTPConfig
+	ThemeColor string `json:"themeColor"`
+}
+
+func newTeamsNotifier(data []byte) (Notifier, error) {
+	config := &TeamsConfig{}
+	if err := unmarshalConfig(data, config); err != nil {
+		return nil, err
+	}
+	return &httpNotifier{
+		config: config.HTTPConfig,
+		method: http.MethodPost,
+		body: func(message Message) interface{} {
+			return struct {
+				Type       string `json:"@type"`
+				Context    string `json:"@context"`
+				Summary    string `json:"summary"`
+				Title      string `json:"title"`
+				Text       string `json:"text"`
+				ThemeColor string `json:"themeColor,omitempty"`
+			}{
+				Type:    "MessageCard",
+				Context: "https://schema.org/extensions",
+				Summary: message.Subject,
+				Title:   message.Subject,
+				// Teams renders markdown, where a single newline is not a line break.
+				Text:       strings.ReplaceAll(message.Text, "\n", "\n\n"),
+				ThemeColor: config.ThemeColor,
+			}
+		},
+	}, nil
+}
+
+// JSONConfig sends the Message as is to any HTTP endpoint.
+type JSONConfig struct {
+	HTTPConfig
+	Method string `json:"method"`
+}
+
+func (config JSONConfig) Validate() error {
+	switch config.Method {
+	case "", http.MethodPost, http.MethodPut:
+	default:
+		return errors.Wrap(ErrInvalidConfig, "method")
+	}
+	return config.HTTPConfig.Validate()
+}
+
+func newJSONNotifier(data []byte) (Notifier, error) {
+	config := &JSONConfig{}
+	if err := unmarshalConfig(data, config); err != nil {
+		return nil, err
+	}
+	method := config.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	return &httpNotifier{
+		config: config.HTTPConfig,
+		method: method,
+		body: func(message Message) interface{} {
+			return message
+		},
+	}, nil
+}
@@ -0,0 +1,157 @@
+// Package rsnotify delivers short messages to chat services, webhooks and email.
+// Each channel type registers a Factory which validates its JSON config.
+package rsnotify
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+)
+
+const (
+	ErrUnknownType   = rserrors.Error("unknown notifier type")
+	ErrInvalidConfig = rserrors.Error("invalid notifier config")
+	ErrNoSMTPServer  = rserrors.Error("smtp server is not configured")
+)
+
+type Type string
+
+const (
+	TypeWebhook Type = "webhook"
+	TypeSlack   Type = "slack"
+	TypeDiscord Type = "discord"
+	TypeTeams   Type = "teams"
+	TypeEmail   Type = "email"
+	TypeJSON    Type = "json"
+)
+
+// Message is what is sent. Channels without a subject prepend it to the text.
+// HTML is an optional rich version of Text for the channels which render it, like email.
+type Message struct {
+	Subject string    `json:"subject"`
+	Text    string    `json:"text"`
+	HTML    string    `json:"-"`
+	SentAt  time.Time `json:"sentAt"`
+}
+
+// NewMessage uses the first line of text as the subject.
+func NewMessage(text string) Message {
+	subject := text
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		subject = text[:i]
+	}
+	return Message{
+		Subject: subject,
+		Text:    text,
+		SentAt:  time.Now(),
+	}
+}
+
+// Notifier delivers a message. The status code is what the receiver answered,
+// an HTTP status or an SMTP reply code, and 0 if it did not answer.
+type Notifier interface {
+	Notify(ctx context.Context, message Message) (statusCode int, err error)
+}
+
+// Factory creates a Notifier from its JSON config and rejects invalid configs.
+type Factory func(config []byte) (Notifier, error)
+
+var (
+	mux      sync.RWMutex
+	registry = map[Type]Factory{}
+)
+
+func Register(t Type, factory Factory) {
+	mux.Lock()
+	defer mux.Unlock()
+	registry[t] = factory
+}
+
+func New(t Type, config []byte) (Notifier, error) {
+	mux.RLock()
+	factory, exist := registry[t]
+	mux.RUnlock()
+	if !exist {
+		return nil, errors.Wrapf(ErrUnknownType, "'%s'", t)
+	}
+	notifier, err := factory(config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "'%s'", t)
+	}
+	return notifier, nil
+}
+
+func Types() []Type {
+	mux.RLock()
+	defer mux.RUnlock()
+	types := make([]Type, 0, len(registry))
+	for t := range registry {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// Retry is how often and how fast a failed delivery is retried.
+// The interval doubles after each attempt.
+type Retry struct {
+	Attempts int
+	Interval time.Duration
+}
+
+var DefaultRetry = Retry{Attempts: 3, Interval: time.Second}
+
+// Attempt is the outcome of one try to deliver a message.
+type Attempt struct {
+	StatusCode int `json:"statusCode"`
+	// Latency is in milliseconds.
+	Latency     int64     `json:"latency"`
+	Error       string    `json:"error,omitempty"`
+	AttemptedAt time.Time `json:"attemptedAt"`
+}
+
+// Send delivers the message and retries the errors which may go away,
+// like timeouts and 5xx responses. It returns every attempt, the last of which is the outcome.
+func Send(ctx context.Context, notifier Notifier, message Message, retry Retry) ([]Attempt, error) {
+	var err error
+	attempts := make([]Attempt, 0, retry.Attempts)
+	interval := retry.Interval
+	for i := 0; i < retry.Attempts || i == 0; i++ {
+		if i > 0 {
+			select {
+			case <-time.After(interval):
+			case <-ctx.Done():
+				return attempts, errors.WithStack(ctx.Err())
+			}
+			interval *= 2
+		}
+
+		attempt := Attempt{AttemptedAt: time.Now()}
+		attempt.StatusCode, err = notifier.Notify(ctx, message)
+		attempt.Latency = time.Since(attempt.AttemptedAt).Milliseconds()
+		if err != nil {
+			attempt.Error = err.Error()
+		}
+		attempts = append(attempts, attempt)
+
+		if err == nil || !IsRetryable(err) {
+			return attempts, err
+		}
+	}
+	return attempts, err
+}
+
+func init() {
+	Register(TypeWebhook, newWebhookNotifier)
+	Register(TypeSlack, newSlackNotifier)
+	Register(TypeDiscord, newDiscordNotifier)
+	Register(TypeTeams, newTeamsNotifier)
+	Register(TypeJSON, newJSONNotifier)
+	Register(TypeEmail, newEmailFactory(smtpServer{}))
+}
@@ -0,0 +1,303 @@
+package rsnotify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type receivedRequest struct {
+	method string
+	header http.Header
+	raw    []byte
+	body   map[string]interface{}
+}
+
+func newStandInServer(statusCodes ...int) (*httptest.Server, chan receivedRequest) {
+	received := make(chan receivedRequest, 10)
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		body := map[string]interface{}{}
+		_ = json.Unmarshal(data, &body)
+		received <- receivedRequest{method: r.Method, header: r.Header, raw: data, body: body}
+
+		statusCode := http.StatusOK
+		if calls < len(statusCodes) {
+			statusCode = statusCodes[calls]
+		}
+		calls++
+		w.WriteHeader(statusCode)
+	}))
+	return server, received
+}
+
+func TestNotifiers(t *testing.T) {
+	message := NewMessage("[DOWN] health\nGET /health failed")
+
+	tests := []struct {
+		name      string
+		t         Type
+		config    string
+		wantField string
+		wantValue interface{}
+	}{
+		{name: "webhook", t: TypeWebhook, config: `{"url": "%s"}`, wantField: "text", wantValue: message.Text},
+		{name: "slack", t: TypeSlack, config: `{"url": "%s", "channel": "#ops"}`, wantField: "channel", wantValue: "#ops"},
+		{name: "discord", t: TypeDiscord, config: `{"url": "%s"}`, wantField: "content", wantValue: message.Text},
+		{name: "teams", t: TypeTeams, config: `{"url": "%s"}`, wantField: "title", wantValue: "[DOWN] health"},
+		{name: "json", t: TypeJSON, config: `{"url": "%s", "headers": {"X-Token": "secret"}}`, wantField: "subject", wantValue: "[DOWN] health"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, received := newStandInServer()
+			defer server.Close()
+
+			notifier, err := New(tt.t, []byte(fmt.Sprintf(tt.config, server.URL)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = Send(context.Background(), notifier, message, DefaultRetry)
+			assert.NoError(t, err)
+
+			request := <-received
+			assert.Equal(t, http.MethodPost, request.method)
+			assert.Equal(t, "application/json", request.header.Get("Content-Type"))
+			assert.Equal(t, tt.wantValue, request.body[tt.wantField])
+			if tt.t == TypeJSON {
+				assert.Equal(t, "secret", request.header.Get("X-Token"))
+			}
+		})
+	}
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	if err := InitSMTP(&testSMTPConfig{host: "localhost", port: 25, from: "monitor@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	defer InitSMTP(&testSMTPConfig{})
+
+	tests := []struct {
+		name   string
+		t      Type
+		config string
+	}{
+		{name: "unknown type", t: "pager", config: `{"url": "http://localhost"}`},
+		{name: "empty config", t: TypeWebhook, config: ``},
+		{name: "missing url", t: TypeSlack, config: `{"channel": "#ops"}`},
+		{name: "relative url", t: TypeDiscord, config: `{"url": "/hooks"}`},
+		{name: "invalid method", t: TypeJSON, config: `{"url": "http://localhost", "method": "GET"}`},
+		{name: "email without recipients", t: TypeEmail, config: `{"to": []}`},
+		{name: "email with invalid recipient", t: TypeEmail, config: `{"to": ["ops"]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.t, []byte(tt.config))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSend_Retry(t *testing.T) {
+	retry := Retry{Attempts: 3, Interval: time.Millisecond}
+
+	tests := []struct {
+		name        string
+		statusCodes []int
+		wantCalls   int
+		wantErr     bool
+		wantLast    int
+	}{
+		{name: "recovered after 503", statusCodes: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}, wantCalls: 3, wantErr: false, wantLast: http.StatusOK},
+		{name: "gave up", statusCodes: []int{500, 500, 500, 500}, wantCalls: 3, wantErr: true, wantLast: 500},
+		{name: "not retried on 400", statusCodes: []int{http.StatusBadRequest}, wantCalls: 1, wantErr: true, wantLast: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, received := newStandInServer(tt.statusCodes...)
+			defer server.Close()
+
+			notifier, err := New(TypeWebhook, []byte(fmt.Sprintf(`{"url": "%s"}`, server.URL)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			attempts, err := Send(context.Background(), notifier, NewMessage("text"), retry)
+			assert.Equal(t, tt.wantErr, err != nil, "error='%v'", err)
+			assert.Len(t, received, tt.wantCalls)
+			if assert.Len(t, attempts, tt.wantCalls) {
+				last := attempts[len(attempts)-1]
+				assert.Equal(t, tt.wantLast, last.StatusCode)
+				assert.Equal(t, tt.wantErr, last.Error != "")
+			}
+		})
+	}
+}
+
+func TestSend_Timeout(t *testing.T) {
+	release := make(chan bool)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	notifier, err := New(TypeWebhook, []byte(fmt.Sprintf(`{"url": "%s", "timeout": 1}`, server.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	startedAt := time.Now()
+	_, err = Send(context.Background(), notifier, NewMessage("text"), Retry{Attempts: 1})
+	assert.Error(t, err)
+	assert.True(t, IsRetryable(err))
+	assert.True(t, time.Since(startedAt) < 3*time.Second)
+}
+
+func TestDiscord_Truncate(t *testing.T) {
+	server, received := newStandInServer()
+	defer server.Close()
+
+	notifier, err := New(TypeDiscord, []byte(fmt.Sprintf(`{"url": "%s"}`, server.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = notifier.Notify(context.Background(), NewMessage(strings.Repeat("가", 3000)))
+	assert.NoError(t, err)
+	content := (<-received).body["content"].(string)
+	assert.Equal(t, discordMaxContentLength, len([]rune(content)))
+}
+
+func TestHTTPNotifier_Signature(t *testing.T) {
+	server, received := newStandInServer()
+	defer server.Close()
+
+	notifier, err := New(TypeWebhook, []byte(fmt.Sprintf(`{"url": "%s", "secret": "s3cr3t"}`, server.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := notifier.Notify(context.Background(), NewMessage("text")); err != nil {
+		t.Fatal(err)
+	}
+
+	request := <-received
+	signature, timestamp := request.header.Get(HeaderSignature), request.header.Get(HeaderTimestamp)
+	assert.True(t, strings.HasPrefix(signature, "sha256="))
+	assert.NoError(t, Verify("s3cr3t", signature, timestamp, request.raw, 5*time.Minute, time.Now()))
+	assert.Error(t, Verify("other", signature, timestamp, request.raw, 5*time.Minute, time.Now()))
+}
+
+func TestHTTPNotifier_Unsigned(t *testing.T) {
+	server, received := newStandInServer()
+	defer server.Close()
+
+	notifier, err := New(TypeWebhook, []byte(fmt.Sprintf(`{"url": "%s"}`, server.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := notifier.Notify(context.Background(), NewMessage("text")); err != nil {
+		t.Fatal(err)
+	}
+
+	request := <-received
+	assert.Empty(t, request.header.Get(HeaderSignature))
+	assert.Empty(t, request.header.Get(HeaderTimestamp))
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	body := []byte(`{"text":"hello"}`)
+	signature := Sign("s3cr3t", now.Unix(), body)
+
+	tests := []struct {
+		name      string
+		signature string
+		timestamp string
+		body      []byte
+		now       time.Time
+		wantErr   error
+	}{
+		{name: "valid", signature: signature, timestamp: "1600000000", body: body, now: now},
+		{name: "within tolerance", signature: signature, timestamp: "1600000000", body: body, now: now.Add(4 * time.Minute)},
+		{name: "tampered body", signature: signature, timestamp: "1600000000", body: []byte(`{"text":"bye"}`), now: now, wantErr: ErrInvalidSignature},
+		{name: "tampered timestamp", signature: signature, timestamp: "1600000001", body: body, now: now, wantErr: ErrInvalidSignature},
+		{name: "replayed", signature: signature, timestamp: "1600000000", body: body, now: now.Add(6 * time.Minute), wantErr: ErrExpiredSignature},
+		{name: "invalid timestamp", signature: signature, timestamp: "yesterday", body: body, now: now, wantErr: ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify("s3cr3t", tt.signature, tt.timestamp, tt.body, 5*time.Minute, tt.now)
+			assert.Equal(t, tt.wantErr, errors.Cause(err))
+		})
+	}
+}
+
+func TestHTTPConfig_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(WebhookConfig{HTTPConfig: HTTPConfig{URL: "https://hooks.example.com", Secret: "s3cr3t"}})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"url": "https://hooks.example.com", "secret": "********"}`, string(data))
+
+	data, err = json.Marshal(WebhookConfig{HTTPConfig: HTTPConfig{URL: "https://hooks.example.com"}})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"url": "https://hooks.example.com"}`, string(data))
+}
+
+func TestRedactConfig(t *testing.T) {
+	assert.JSONEq(t, `{"url": "https://hooks.example.com", "secret": "********"}`,
+		string(RedactConfig([]byte(`{"url": "https://hooks.example.com", "secret": "s3cr3t"}`))))
+	assert.JSONEq(t, `{"url": "https://hooks.example.com", "headers": {"Authorization": "********"}}`,
+		string(RedactConfig([]byte(`{"url": "https://hooks.example.com", "headers": {"Authorization": "Bearer t0k3n"}}`))))
+	assert.Equal(t, `{"url": "https://hooks.example.com"}`, string(RedactConfig([]byte(`{"url": "https://hooks.example.com"}`))))
+	assert.Equal(t, `not json`, string(RedactConfig([]byte(`not json`))))
+
+	data, err := json.Marshal(HTTPConfig{URL: "https://hooks.example.com", Headers: Headers{"Authorization": "Bearer t0k3n"}})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "t0k3n")
+}
+
+func TestSameReceiver(t *testing.T) {
+	config := []byte(`{"url": "https://hooks.example.com", "secret": "s3cr3t", "headers": {"Authorization": "Bearer t0k3n"}}`)
+	assert.True(t, SameReceiver(config, RedactConfig(config)))
+	assert.True(t, SameReceiver(config, []byte(`{"url": "https://hooks.example.com"}`)))
+	assert.False(t, SameReceiver(config, []byte(`{"url": "https://hooks.example.com/other"}`)))
+	assert.False(t, SameReceiver(config, []byte(`not json`)))
+}
+
+func TestKeepCredentials(t *testing.T) {
+	previous := []byte(`{"url": "https://hooks.example.com", "secret": "s3cr3t", "headers": {"Authorization": "Bearer t0k3n"}}`)
+	tests := []struct {
+		name     string
+		previous []byte
+		config   string
+		want     string
+		wantErr  bool
+	}{
+		{name: "omitted", previous: previous, config: `{"url": "https://hooks.example.com"}`, want: `{"url": "https://hooks.example.com", "secret": "s3cr3t"}`},
+		{name: "masked", previous: previous, config: `{"url": "https://hooks.example.com", "secret": "********", "headers": {"Authorization": "********", "X-Team": "ops"}}`, want: `{"url": "https://hooks.example.com", "secret": "s3cr3t", "headers": {"Authorization": "Bearer t0k3n", "X-Team": "ops"}}`},
+		{name: "changed", previous: previous, config: `{"url": "https://hooks.example.com", "secret": "other", "headers": {"Authorization": "Bearer other"}}`, want: `{"url": "https://hooks.example.com", "secret": "other", "headers": {"Authorization": "Bearer other"}}`},
+		{name: "removed", previous: previous, config: `{"url": "https://hooks.example.com", "secret": ""}`, want: `{"url": "https://hooks.example.com", "secret": ""}`},
+		{name: "other url", previous: previous, config: `{"url": "https://hooks.example.com/new"}`, want: `{"url": "https://hooks.example.com/new"}`},
+		{name: "other url with masked secret", previous: previous, config: `{"url": "https://hooks.example.com/new", "secret": "********"}`, wantErr: true},
+		{name: "other url with masked header", previous: previous, config: `{"url": "https://hooks.example.com/new", "headers": {"Authorization": "********"}}`, wantErr: true},
+		{name: "masked without previous", config: `{"url": "https://hooks.example.com", "secret": "********"}`, wantErr: true},
+		{name: "no previous", config: `{"url": "https://hooks.example.com"}`, want: `{"url": "https://hooks.example.com"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := KeepCredentials(tt.previous, []byte(tt.config))
+			if tt.wantErr {
+				assert.Equal(t, ErrInvalidConfig, errors.Cause(err))
+				return
+			}
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package rsstats
+
+import (
+	"math"
+	"sort"
+)
+
+// Summary describes a set of samples. The percentiles are nearest-rank, so they are always one of the samples.
+type Summary struct {
+	Count int     `json:"count"`
+	Min   int64   `json:"min"`
+	Max   int64   `json:"max"`
+	Mean  float64 `json:"mean"`
+	P50   int64   `json:"p50"`
+	P90   int64   `json:"p90"`
+	P95   int64   `json:"p95"`
+	P99   int64   `json:"p99"`
+}
+
+// Summarize returns the summary of the samples, which may be in any order. It sorts the slice in place.
+func Summarize(samples []int64) Summary {
+	if len(samples) == 0 {
+		return Summary{}
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i] < samples[j]
+	})
+
+	var sum float64
+	for _, sample := range samples {
+		sum += float64(sample)
+	}
+	return Summary{
+		Count: len(samples),
+		Min:   samples[0],
+		Max:   samples[len(samples)-1],
+		Mean:  sum / float64(len(samples)),
+		P50:   Percentile(samples, 50),
+		P90:   Percentile(samples, 90),
+		P95:   Percentile(samples, 95),
+		P99:   Percentile(samples, 99),
+	}
+}
+
+// Percentile returns the nearest-rank p-th percentile of the sorted samples.
+func Percentile(sorted []int64, p float64) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+// Histogram counts the samples in buckets bounded by the upper bounds, inclusive.
+// The samples above the last bound are counted in an overflow bucket.
+type Histogram struct {
+	bounds []int64
+	counts []int
+}
+
+type HistogramBucket struct {
+	// UpperBound is nil for the overflow bucket.
+	UpperBound *int64 `json:"le"`
+	Count      int    `json:"count"`
+}
+
+func (histogram *Histogram) Add(sample int64) {
+	i := sort.Search(len(histogram.bounds), func(i int) bool {
+		return sample <= histogram.bounds[i]
+	})
+	histogram.counts[i]++
+}
+
+func (histogram *Histogram) Buckets() []HistogramBucket {
+	buckets := make([]HistogramBucket, 0, len(histogram.counts))
+	for i, count := range histogram.counts {
+		bucket := HistogramBucket{Count: count}
+		if i < len(histogram.bounds) {
+			bound := histogram.bounds[i]
+			bucket.UpperBound = &bound
+		}
+		buckets = append(buckets, bucket)
+	}
+	return buckets
+}
+
+// NewHistogram returns a histogram with the bounds, which must be increasing.
+func NewHistogram(bounds ...int64) *Histogram {
+	return &Histogram{
+		bounds: bounds,
+		counts: make([]int, len(bounds)+1),
+	}
+}
+
+// Counts returns the count of each bucket, the overflow bucket last, to be merged later.
+func (histogram *Histogram) Counts() []int {
+	counts := make([]int, len(histogram.counts))
+	copy(counts, histogram.counts)
+	return counts
+}
+
+// Merge adds the counts of a histogram with the same bounds.
+func (histogram *Histogram) Merge(counts []int) {
+	for i := 0; i < len(counts) && i < len(histogram.counts); i++ {
+		histogram.counts[i] += counts[i]
+	}
+}
+
+// Percentile estimates the p-th percentile as the upper bound of the bucket holding its rank,
+// or max if it is in the overflow bucket. It is used when the samples themselves are gone.
+func (histogram *Histogram) Percentile(p float64, max int64) int64 {
+	total := 0
+	for _, count := range histogram.counts {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(total)))
+	if rank < 1 {
+		rank = 1
+	}
+	for i, count := range histogram.counts {
+		rank -= count
+		if rank <= 0 && i < len(histogram.bounds) {
+			return histogram.bounds[i]
+		}
+	}
+	return max
+}
@@ -0,0 +1,71 @@
+package rsstats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarize(t *testing.T) {
+	samples := make([]int64, 0, 100)
+	for i := int64(100); i >= 1; i-- {
+		samples = append(samples, i)
+	}
+
+	tests := []struct {
+		name    string
+		samples []int64
+		want    Summary
+	}{
+		{
+			name:    "1 to 100",
+			samples: samples,
+			want:    Summary{Count: 100, Min: 1, Max: 100, Mean: 50.5, P50: 50, P90: 90, P95: 95, P99: 99},
+		},
+		{
+			name:    "single sample",
+			samples: []int64{42},
+			want:    Summary{Count: 1, Min: 42, Max: 42, Mean: 42, P50: 42, P90: 42, P95: 42, P99: 42},
+		},
+		{
+			name:    "outlier",
+			samples: []int64{10, 12, 11, 10, 5000},
+			want:    Summary{Count: 5, Min: 10, Max: 5000, Mean: 1008.6, P50: 11, P90: 5000, P95: 5000, P99: 5000},
+		},
+		{
+			name: "no samples",
+			want: Summary{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Summarize(tt.samples))
+		})
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	histogram := NewHistogram(100, 500, 1000)
+	for _, sample := range []int64{0, 100, 101, 499, 500, 999, 1000, 1001, 30000} {
+		histogram.Add(sample)
+	}
+
+	counts := make([]int, 0)
+	for _, bucket := range histogram.Buckets() {
+		counts = append(counts, bucket.Count)
+	}
+	assert.Equal(t, []int{2, 3, 2, 2}, counts)
+	assert.Nil(t, histogram.Buckets()[3].UpperBound)
+	assert.Equal(t, int64(500), *histogram.Buckets()[1].UpperBound)
+}
+
+func TestHistogram_Percentile(t *testing.T) {
+	histogram := NewHistogram(100, 500, 1000)
+	histogram.Merge([]int{50, 40, 0, 10})
+
+	assert.Equal(t, []int{50, 40, 0, 10}, histogram.Counts())
+	assert.Equal(t, int64(100), histogram.Percentile(50, 4000))
+	assert.Equal(t, int64(500), histogram.Percentile(90, 4000))
+	assert.Equal(t, int64(4000), histogram.Percentile(95, 4000))
+	assert.Equal(t, int64(0), NewHistogram(100).Percentile(50, 0))
+}
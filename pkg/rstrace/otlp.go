@@ -0,0 +1,177 @@
+package rstrace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const DefaultOTLPTimeout = 10 * time.Second
+
+// OTLPExporter sends the spans to an OpenTelemetry collector with OTLP/HTTP in the JSON encoding.
+type OTLPExporter struct {
+	endpoint    string
+	serviceName string
+	headers     map[string]string
+	client      *http.Client
+}
+
+func (exporter *OTLPExporter) ExportSpans(ctx context.Context, spans []SpanData) error {
+	body, err := json.Marshal(exporter.request(spans))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, exporter.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range exporter.headers {
+		req.Header.Set(key, value)
+	}
+
+	res, err := exporter.client.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+	if res.StatusCode/100 != 2 {
+		return errors.Errorf("otlp export failed: endpoint='%s', statusCode=%d", exporter.endpoint, res.StatusCode)
+	}
+	return nil
+}
+
+func (exporter *OTLPExporter) Shutdown(ctx context.Context) error {
+	exporter.client.CloseIdleConnections()
+	return nil
+}
+
+func (exporter *OTLPExporter) request(spans []SpanData) otlpRequest {
+	otlpSpans := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		s := otlpSpan{
+			TraceId:           span.SpanContext.TraceId.String(),
+			SpanId:            span.SpanContext.SpanId.String(),
+			Name:              span.Name,
+			Kind:              int(span.Kind),
+			StartTimeUnixNano: strconv.FormatInt(span.StartTime.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.EndTime.UnixNano(), 10),
+			Attributes:        otlpAttributes(span.Attributes),
+			Status:            otlpStatus{Code: int(span.Status.Code), Message: span.Status.Message},
+		}
+		if span.ParentSpanId.IsValid() {
+			s.ParentSpanId = span.ParentSpanId.String()
+		}
+		otlpSpans = append(otlpSpans, s)
+	}
+	return otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: otlpAttributes([]Attribute{String("service.name", exporter.serviceName)}),
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: "github.com/realsangil/apimonitor"},
+				Spans: otlpSpans,
+			}},
+		}},
+	}
+}
+
+// NewOTLPExporter returns an exporter to the traces endpoint of a collector, e.g. http://localhost:4318/v1/traces.
+// The headers are sent with every export, e.g. for authentication.
+func NewOTLPExporter(endpoint, serviceName string, headers map[string]string, timeout time.Duration) *OTLPExporter {
+	if timeout <= 0 {
+		timeout = DefaultOTLPTimeout
+	}
+	return &OTLPExporter{
+		endpoint:    endpoint,
+		serviceName: serviceName,
+		headers:     headers,
+		client:      &http.Client{Timeout: timeout},
+	}
+}
+
+// The otlp types follow the JSON mapping of the OTLP protobuf messages,
+// where the ids are hex strings and the 64 bit integers are strings.
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceId           string          `json:"traceId"`
+	SpanId            string          `json:"spanId"`
+	ParentSpanId      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func otlpAttributes(attributes []Attribute) []otlpAttribute {
+	otlp := make([]otlpAttribute, 0, len(attributes))
+	for _, attribute := range attributes {
+		var value otlpValue
+		switch v := attribute.Value.(type) {
+		case string:
+			value.StringValue = &v
+		case bool:
+			value.BoolValue = &v
+		case int64:
+			s := strconv.FormatInt(v, 10)
+			value.IntValue = &s
+		case float64:
+			value.DoubleValue = &v
+		default:
+			continue
+		}
+		otlp = append(otlp, otlpAttribute{Key: attribute.Key, Value: value})
+	}
+	return otlp
+}
@@ -0,0 +1,74 @@
+package rstrace
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOTLPExporter_ExportSpans(t *testing.T) {
+	var (
+		received map[string]interface{}
+		header   http.Header
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		_ = json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	exporter := NewOTLPExporter(server.URL+"/v1/traces", "apimonitor", map[string]string{"Authorization": "Bearer token"}, time.Second)
+	span := SpanData{
+		Name:         "check GET",
+		SpanContext:  SpanContext{TraceId: TraceId{1}, SpanId: SpanId{2}, Sampled: true},
+		ParentSpanId: SpanId{3},
+		Kind:         SpanKindClient,
+		StartTime:    time.Unix(1, 0),
+		EndTime:      time.Unix(2, 0),
+		Attributes:   []Attribute{String("http.method", "GET"), Int("http.status_code", 200), Bool("apimonitor.check.success", true)},
+		Status:       Status{Code: StatusOk},
+	}
+	if err := exporter.ExportSpans(context.Background(), []SpanData{span}); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+	assert.Equal(t, "Bearer token", header.Get("Authorization"))
+
+	resourceSpans := received["resourceSpans"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"key": "service.name", "value": map[string]interface{}{"stringValue": "apimonitor"}},
+	}, resourceSpans["resource"].(map[string]interface{})["attributes"])
+
+	spans := resourceSpans["scopeSpans"].([]interface{})[0].(map[string]interface{})["spans"].([]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"traceId":           "01000000000000000000000000000000",
+		"spanId":            "0200000000000000",
+		"parentSpanId":      "0300000000000000",
+		"name":              "check GET",
+		"kind":              float64(3),
+		"startTimeUnixNano": "1000000000",
+		"endTimeUnixNano":   "2000000000",
+		"attributes": []interface{}{
+			map[string]interface{}{"key": "http.method", "value": map[string]interface{}{"stringValue": "GET"}},
+			map[string]interface{}{"key": "http.status_code", "value": map[string]interface{}{"intValue": "200"}},
+			map[string]interface{}{"key": "apimonitor.check.success", "value": map[string]interface{}{"boolValue": true}},
+		},
+		"status": map[string]interface{}{"code": float64(1)},
+	}, spans[0])
+}
+
+func TestOTLPExporter_ExportSpans_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	exporter := NewOTLPExporter(server.URL, "apimonitor", nil, time.Second)
+	assert.Error(t, exporter.ExportSpans(context.Background(), []SpanData{{Name: "check"}}))
+}
@@ -0,0 +1,260 @@
+package rstrace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+)
+
+// TraceparentHeader is the W3C Trace Context header which carries the span of the caller.
+const TraceparentHeader = "traceparent"
+
+type TraceId [16]byte
+
+func (id TraceId) IsValid() bool {
+	return id != TraceId{}
+}
+
+func (id TraceId) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+type SpanId [8]byte
+
+func (id SpanId) IsValid() bool {
+	return id != SpanId{}
+}
+
+func (id SpanId) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanContext identifies a span across processes.
+type SpanContext struct {
+	TraceId TraceId
+	SpanId  SpanId
+	Sampled bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceId.IsValid() && sc.SpanId.IsValid()
+}
+
+// Traceparent formats the span context as the value of the traceparent header, version 00.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceId.String() + "-" + sc.SpanId.String() + "-" + flags
+}
+
+// ParseTraceparent parses the value of a traceparent header.
+func ParseTraceparent(value string) (SpanContext, error) {
+	var sc SpanContext
+	if len(value) != 55 || value[2] != '-' || value[35] != '-' || value[52] != '-' || value[:2] == "ff" {
+		return sc, errors.Wrapf(rserrors.ErrInvalidParameter, "traceparent: '%s'", value)
+	}
+	var flags [1]byte
+	if _, err := hex.Decode(sc.TraceId[:], []byte(value[3:35])); err != nil {
+		return sc, errors.Wrapf(rserrors.ErrInvalidParameter, "traceparent: '%s'", value)
+	}
+	if _, err := hex.Decode(sc.SpanId[:], []byte(value[36:52])); err != nil {
+		return sc, errors.Wrapf(rserrors.ErrInvalidParameter, "traceparent: '%s'", value)
+	}
+	if _, err := hex.Decode(flags[:], []byte(value[53:])); err != nil {
+		return sc, errors.Wrapf(rserrors.ErrInvalidParameter, "traceparent: '%s'", value)
+	}
+	if !sc.IsValid() {
+		return sc, errors.Wrapf(rserrors.ErrInvalidParameter, "traceparent: '%s'", value)
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, nil
+}
+
+// Inject sets the traceparent header to the span of the context, if it is recorded.
+func Inject(ctx context.Context, header map[string]string) {
+	sc := SpanFromContext(ctx).SpanContext()
+	if !sc.IsValid() {
+		return
+	}
+	header[TraceparentHeader] = sc.Traceparent()
+}
+
+// SpanKind values are those of OTLP.
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// StatusCode values are those of OTLP.
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOk    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+type Status struct {
+	Code    StatusCode
+	Message string
+}
+
+// Attribute is a key with a string, bool, int64 or float64 value.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+func Bool(key string, value bool) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+func Int(key string, value int) Attribute {
+	return Attribute{Key: key, Value: int64(value)}
+}
+
+func Int64(key string, value int64) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+func Float64(key string, value float64) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// SpanData is a span which ended, as it is exported.
+type SpanData struct {
+	Name         string
+	SpanContext  SpanContext
+	ParentSpanId SpanId
+	Kind         SpanKind
+	StartTime    time.Time
+	EndTime      time.Time
+	Attributes   []Attribute
+	Status       Status
+}
+
+// Attribute returns the value of the attribute, or nil if it is not set.
+func (data SpanData) Attribute(key string) interface{} {
+	for _, attribute := range data.Attributes {
+		if attribute.Key == key {
+			return attribute.Value
+		}
+	}
+	return nil
+}
+
+// Span is an operation being traced. A span which is not recording ignores every call.
+type Span struct {
+	tracer *Tracer
+	mux    sync.Mutex
+	data   SpanData
+	ended  bool
+}
+
+func (span *Span) IsRecording() bool {
+	return span != nil && span.tracer != nil
+}
+
+func (span *Span) SpanContext() SpanContext {
+	if span == nil {
+		return SpanContext{}
+	}
+	return span.data.SpanContext
+}
+
+// SetAttributes sets the attributes, replacing those with the same keys.
+func (span *Span) SetAttributes(attributes ...Attribute) {
+	if !span.IsRecording() {
+		return
+	}
+	span.mux.Lock()
+	defer span.mux.Unlock()
+	for _, attribute := range attributes {
+		replaced := false
+		for i := range span.data.Attributes {
+			if span.data.Attributes[i].Key == attribute.Key {
+				span.data.Attributes[i] = attribute
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			span.data.Attributes = append(span.data.Attributes, attribute)
+		}
+	}
+}
+
+// SetStatus sets the status. The message is only kept for an error, like in OTLP.
+func (span *Span) SetStatus(code StatusCode, message string) {
+	if !span.IsRecording() {
+		return
+	}
+	if code != StatusError {
+		message = ""
+	}
+	span.mux.Lock()
+	span.data.Status = Status{Code: code, Message: message}
+	span.mux.Unlock()
+}
+
+// End ends the span and passes it to the exporter of its tracer. Only the first call counts.
+func (span *Span) End() {
+	if !span.IsRecording() {
+		return
+	}
+	span.mux.Lock()
+	if span.ended {
+		span.mux.Unlock()
+		return
+	}
+	span.ended = true
+	span.data.EndTime = time.Now()
+	data := span.data
+	span.mux.Unlock()
+	span.tracer.export(data)
+}
+
+type spanContextKey struct{}
+
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, span)
+}
+
+// SpanFromContext returns the span of the context, or a span which is not recording.
+func SpanFromContext(ctx context.Context) *Span {
+	if span, ok := ctx.Value(spanContextKey{}).(*Span); ok && span != nil {
+		return span
+	}
+	return &Span{}
+}
+
+func newTraceId() TraceId {
+	var id TraceId
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanId() SpanId {
+	var id SpanId
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
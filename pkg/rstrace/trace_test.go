@@ -0,0 +1,102 @@
+package rstrace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracer_Start(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	tracer := NewTracer(exporter)
+
+	ctx, parent := tracer.Start(context.Background(), "parent", SpanKindInternal)
+	_, child := tracer.Start(ctx, "child", SpanKindClient, String("http.method", "GET"))
+	child.SetAttributes(Int("http.status_code", 500), String("http.method", "POST"))
+	child.SetStatus(StatusError, "assertion failed")
+	child.End()
+	child.End()
+	parent.SetStatus(StatusOk, "ignored")
+	parent.End()
+
+	spans := exporter.GetSpans()
+	if assert.Len(t, spans, 2) {
+		assert.Equal(t, "child", spans[0].Name)
+		assert.Equal(t, SpanKindClient, spans[0].Kind)
+		assert.Equal(t, parent.SpanContext().TraceId, spans[0].SpanContext.TraceId)
+		assert.Equal(t, parent.SpanContext().SpanId, spans[0].ParentSpanId)
+		assert.Equal(t, []Attribute{String("http.method", "POST"), Int("http.status_code", 500)}, spans[0].Attributes)
+		assert.Equal(t, Status{Code: StatusError, Message: "assertion failed"}, spans[0].Status)
+		assert.False(t, spans[0].EndTime.Before(spans[0].StartTime))
+
+		assert.Equal(t, "parent", spans[1].Name)
+		assert.False(t, spans[1].ParentSpanId.IsValid())
+		assert.Equal(t, Status{Code: StatusOk}, spans[1].Status)
+	}
+}
+
+func TestTracer_NotRecording(t *testing.T) {
+	ctx, span := NewTracer(nil).Start(context.Background(), "check", SpanKindClient)
+	span.SetAttributes(String("key", "value"))
+	span.End()
+
+	assert.False(t, span.IsRecording())
+	header := map[string]string{}
+	Inject(ctx, header)
+	assert.Empty(t, header)
+}
+
+func TestBatchTracer_Shutdown(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	tracer := NewBatchTracer(exporter, 2, 10, time.Hour)
+	for i := 0; i < 3; i++ {
+		_, span := tracer.Start(context.Background(), "check", SpanKindClient)
+		span.End()
+	}
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, exporter.GetSpans(), 3)
+}
+
+func TestInject(t *testing.T) {
+	ctx, span := NewTracer(NewInMemoryExporter()).Start(context.Background(), "check", SpanKindClient)
+	header := map[string]string{"Accept": "application/json"}
+	Inject(ctx, header)
+
+	sc, err := ParseTraceparent(header[TraceparentHeader])
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, span.SpanContext(), sc)
+	assert.Equal(t, "application/json", header["Accept"])
+}
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "sampled", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", want: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{name: "not sampled", value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", want: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"},
+		{name: "zero trace id", value: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", wantErr: true},
+		{name: "invalid version", value: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", wantErr: true},
+		{name: "not hex", value: "00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01", wantErr: true},
+		{name: "too short", value: "00-4bf92f3577b34da6-00f067aa0ba902b7-01", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, err := ParseTraceparent(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, sc.Traceparent())
+		})
+	}
+}
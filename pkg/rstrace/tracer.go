@@ -0,0 +1,212 @@
+package rstrace
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/pkg/rslog"
+)
+
+// Exporter sends the spans which ended to a backend.
+type Exporter interface {
+	ExportSpans(ctx context.Context, spans []SpanData) error
+	Shutdown(ctx context.Context) error
+}
+
+// Tracer starts spans and exports them once they end. A tracer without an exporter starts spans
+// which are not recording, so that tracing costs nothing when it is disabled.
+type Tracer struct {
+	exporter Exporter
+	// queue is nil if the spans are exported one by one as they end.
+	queue     chan SpanData
+	batchSize int
+	interval  time.Duration
+	dropped   uint64
+
+	closeOnce sync.Once
+	closeChan chan bool
+	doneChan  chan bool
+}
+
+// Start starts a span, child of the span of the context if there is one,
+// and returns a copy of the context with the new span.
+func (tracer *Tracer) Start(ctx context.Context, name string, kind SpanKind, attributes ...Attribute) (context.Context, *Span) {
+	if tracer == nil || tracer.exporter == nil {
+		return ctx, &Span{}
+	}
+
+	parent := SpanFromContext(ctx).SpanContext()
+	sc := SpanContext{TraceId: parent.TraceId, SpanId: newSpanId(), Sampled: true}
+	if !parent.IsValid() {
+		sc.TraceId = newTraceId()
+	}
+	span := &Span{
+		tracer: tracer,
+		data: SpanData{
+			Name:         name,
+			SpanContext:  sc,
+			ParentSpanId: parent.SpanId,
+			Kind:         kind,
+			StartTime:    time.Now(),
+		},
+	}
+	span.SetAttributes(attributes...)
+	return ContextWithSpan(ctx, span), span
+}
+
+// Dropped counts the spans dropped because the queue of a batch tracer was full.
+func (tracer *Tracer) Dropped() uint64 {
+	return atomic.LoadUint64(&tracer.dropped)
+}
+
+// Shutdown exports the queued spans and shuts the exporter down.
+func (tracer *Tracer) Shutdown(ctx context.Context) error {
+	if tracer == nil || tracer.exporter == nil {
+		return nil
+	}
+	if tracer.queue != nil {
+		tracer.closeOnce.Do(func() {
+			close(tracer.closeChan)
+		})
+		select {
+		case <-tracer.doneChan:
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		}
+	}
+	return errors.WithStack(tracer.exporter.Shutdown(ctx))
+}
+
+func (tracer *Tracer) export(data SpanData) {
+	if tracer.queue == nil {
+		if err := tracer.exporter.ExportSpans(context.Background(), []SpanData{data}); err != nil {
+			rslog.Errorf("failed to export span: error='%v'", err)
+		}
+		return
+	}
+	select {
+	case tracer.queue <- data:
+	default:
+		atomic.AddUint64(&tracer.dropped, 1)
+	}
+}
+
+func (tracer *Tracer) run() {
+	defer close(tracer.doneChan)
+	ticker := time.NewTicker(tracer.interval)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, tracer.batchSize)
+	for {
+		select {
+		case data := <-tracer.queue:
+			batch = append(batch, data)
+			if len(batch) >= tracer.batchSize {
+				batch = tracer.flush(batch)
+			}
+		case <-ticker.C:
+			batch = tracer.flush(batch)
+		case <-tracer.closeChan:
+			for {
+				select {
+				case data := <-tracer.queue:
+					batch = append(batch, data)
+					if len(batch) >= tracer.batchSize {
+						batch = tracer.flush(batch)
+					}
+				default:
+					tracer.flush(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (tracer *Tracer) flush(batch []SpanData) []SpanData {
+	if len(batch) == 0 {
+		return batch
+	}
+	if err := tracer.exporter.ExportSpans(context.Background(), batch); err != nil {
+		rslog.Errorf("failed to export spans: count=%d, error='%v'", len(batch), err)
+	}
+	return batch[:0]
+}
+
+// NewTracer returns a tracer which exports every span as it ends, or which does not record if the exporter is nil.
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
+
+// NewBatchTracer returns a tracer which exports the spans in the background, in batches of batchSize
+// or every interval. The spans which end while queueSize spans are waiting are dropped.
+func NewBatchTracer(exporter Exporter, batchSize, queueSize int, interval time.Duration) *Tracer {
+	tracer := &Tracer{
+		exporter:  exporter,
+		queue:     make(chan SpanData, queueSize),
+		batchSize: batchSize,
+		interval:  interval,
+		closeChan: make(chan bool),
+		doneChan:  make(chan bool),
+	}
+	go tracer.run()
+	return tracer
+}
+
+var (
+	defaultTracerMux sync.RWMutex
+	defaultTracer    = NewTracer(nil)
+)
+
+// DefaultTracer returns the tracer of the process, which does not record until SetDefaultTracer is called.
+func DefaultTracer() *Tracer {
+	defaultTracerMux.RLock()
+	defer defaultTracerMux.RUnlock()
+	return defaultTracer
+}
+
+func SetDefaultTracer(tracer *Tracer) {
+	defaultTracerMux.Lock()
+	defer defaultTracerMux.Unlock()
+	defaultTracer = tracer
+}
+
+// InMemoryExporter keeps the spans in memory, to be inspected by tests.
+type InMemoryExporter struct {
+	mux   sync.Mutex
+	spans []SpanData
+}
+
+func (exporter *InMemoryExporter) ExportSpans(ctx context.Context, spans []SpanData) error {
+	exporter.mux.Lock()
+	defer exporter.mux.Unlock()
+	exporter.spans = append(exporter.spans, spans...)
+	return nil
+}
+
+func (exporter *InMemoryExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// GetSpans returns the spans exported so far.
+func (exporter *InMemoryExporter) GetSpans() []SpanData {
+	exporter.mux.Lock()
+	defer exporter.mux.Unlock()
+	spans := make([]SpanData, len(exporter.spans))
+	copy(spans, exporter.spans)
+	return spans
+}
+
+func (exporter *InMemoryExporter) Reset() {
+	exporter.mux.Lock()
+	defer exporter.mux.Unlock()
+	exporter.spans = nil
+}
+
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
@@ -0,0 +1,125 @@
+package testutils
+
+import (
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type SMTPMessage struct {
+	From string
+	To   []string
+	Data string
+}
+
+// SMTPServer is a local stand-in SMTP server which accepts every mail without TLS or auth.
+type SMTPServer struct {
+	listener net.Listener
+	mux      sync.Mutex
+	messages []SMTPMessage
+	wg       sync.WaitGroup
+}
+
+func NewSMTPServer() (*SMTPServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	server := &SMTPServer{listener: listener}
+	server.wg.Add(1)
+	go server.serve()
+	return server, nil
+}
+
+func (server *SMTPServer) Host() string {
+	return server.listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+func (server *SMTPServer) Port() int {
+	return server.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (server *SMTPServer) Addr() string {
+	return net.JoinHostPort(server.Host(), strconv.Itoa(server.Port()))
+}
+
+func (server *SMTPServer) Messages() []SMTPMessage {
+	server.mux.Lock()
+	defer server.mux.Unlock()
+	return append([]SMTPMessage(nil), server.messages...)
+}
+
+func (server *SMTPServer) Close() {
+	_ = server.listener.Close()
+	server.wg.Wait()
+}
+
+func (server *SMTPServer) serve() {
+	defer server.wg.Done()
+	for {
+		conn, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+		server.wg.Add(1)
+		go func() {
+			defer server.wg.Done()
+			server.handle(conn)
+		}()
+	}
+}
+
+func (server *SMTPServer) handle(conn net.Conn) {
+	text := textproto.NewConn(conn)
+	defer text.Close()
+
+	_ = text.PrintfLine("220 localhost ESMTP")
+	message := SMTPMessage{}
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch command {
+		case "EHLO", "HELO":
+			_ = text.PrintfLine("250-localhost")
+			_ = text.PrintfLine("250 8BITMIME")
+		case "MAIL":
+			message = SMTPMessage{From: smtpArgument(line)}
+			_ = text.PrintfLine("250 OK")
+		case "RCPT":
+			message.To = append(message.To, smtpArgument(line))
+			_ = text.PrintfLine("250 OK")
+		case "DATA":
+			_ = text.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			message.Data = string(data)
+			server.mux.Lock()
+			server.messages = append(server.messages, message)
+			server.mux.Unlock()
+			_ = text.PrintfLine("250 OK")
+		case "RSET", "NOOP":
+			_ = text.PrintfLine("250 OK")
+		case "QUIT":
+			_ = text.PrintfLine("221 Bye")
+			return
+		default:
+			_ = text.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+// smtpArgument returns the address of "MAIL FROM:<a@b>" or "RCPT TO:<a@b>".
+func smtpArgument(line string) string {
+	start, end := strings.IndexByte(line, '<'), strings.IndexByte(line, '>')
+	if start < 0 || end < start {
+		return ""
+	}
+	return line[start+1 : end]
+}
@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+)
+
+type AlertDeliveryRepository interface {
+	rsdb.Repository
+}
+
+type AlertDeliveryRepositoryImpl struct {
+	rsdb.Repository
+}
+
+func (repository AlertDeliveryRepositoryImpl) CreateTable(transaction rsdb.Connection) error {
+	m := &models.AlertDelivery{}
+	tx := transaction.Conn()
+	if tx.HasTable(m) {
+		return nil
+	}
+	if err := tx.AutoMigrate(m).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	if err := tx.Model(m).AddForeignKey("test_id", "tests(id)", "CASCADE", "CASCADE").Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func NewAlertDeliveryRepository() AlertDeliveryRepository {
+	return &AlertDeliveryRepositoryImpl{&rsdb.DefaultRepository{}}
+}
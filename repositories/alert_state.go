@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+)
+
+type AlertStateRepository interface {
+	rsdb.Repository
+	GetByTestId(conn rsdb.Connection, testId string) (*models.AlertState, error)
+	// GetStatusesByWebServiceId returns the alert status of every test of the web service by test id,
+	// ok for the tests never alerted.
+	GetStatusesByWebServiceId(conn rsdb.Connection, webServiceId string) (map[string]models.AlertStatus, error)
+}
+
+type AlertStateRepositoryImpl struct {
+	rsdb.Repository
+}
+
+// GetByTestId returns a new ok state if the test was never alerted.
+func (repository *AlertStateRepositoryImpl) GetByTestId(conn rsdb.Connection, testId string) (*models.AlertState, error) {
+	state := &models.AlertState{}
+	err := rsdb.HandleSQLError(conn.Conn().Where("test_id=?", testId).First(state).Error)
+	switch err {
+	case nil:
+		state.SetValidated()
+		return state, nil
+	case rsdb.ErrRecordNotFound:
+		return models.NewAlertState(testId), nil
+	default:
+		return nil, err
+	}
+}
+
+func (repository *AlertStateRepositoryImpl) GetStatusesByWebServiceId(conn rsdb.Connection, webServiceId string) (map[string]models.AlertStatus, error) {
+	var rows []struct {
+		TestId string
+		Status *models.AlertStatus
+	}
+	if err := conn.Conn().Table("tests AS t").
+		Select("t.id AS test_id, s.status AS status").
+		Joins("LEFT JOIN alert_states AS s ON s.test_id=t.id").
+		Where("t.web_service_id=?", webServiceId).
+		Scan(&rows).Error; err != nil {
+		return nil, rsdb.HandleSQLError(err)
+	}
+
+	statuses := make(map[string]models.AlertStatus, len(rows))
+	for _, row := range rows {
+		statuses[row.TestId] = models.AlertStatusOk
+		if row.Status != nil && *row.Status != "" {
+			statuses[row.TestId] = *row.Status
+		}
+	}
+	return statuses, nil
+}
+
+func (repository AlertStateRepositoryImpl) CreateTable(transaction rsdb.Connection) error {
+	m := &models.AlertState{}
+	tx := transaction.Conn()
+	if tx.HasTable(m) {
+		return nil
+	}
+	if err := tx.AutoMigrate(m).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	if err := tx.Model(m).AddForeignKey("test_id", "tests(id)", "CASCADE", "CASCADE").Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func NewAlertStateRepository() AlertStateRepository {
+	return &AlertStateRepositoryImpl{&rsdb.DefaultRepository{}}
+}
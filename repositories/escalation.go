@@ -0,0 +1,144 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+)
+
+type EscalationPolicyRepository interface {
+	rsdb.Repository
+}
+
+type EscalationPolicyRepositoryImpl struct {
+	rsdb.Repository
+}
+
+func (repository EscalationPolicyRepositoryImpl) CreateTable(transaction rsdb.Connection) error {
+	m := &models.EscalationPolicy{}
+	tx := transaction.Conn()
+	if tx.HasTable(m) {
+		return nil
+	}
+	if err := tx.AutoMigrate(m).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func NewEscalationPolicyRepository() EscalationPolicyRepository {
+	return &EscalationPolicyRepositoryImpl{&rsdb.DefaultRepository{}}
+}
+
+type EscalationRepository interface {
+	rsdb.Repository
+	// GetUnresolvedByTestId returns the escalation of the current outage of the test, acknowledged or not.
+	GetUnresolvedByTestId(conn rsdb.Connection, testId string) (*models.Escalation, error)
+	GetDueList(conn rsdb.Connection, now time.Time, limit int) ([]*models.Escalation, error)
+	// Advance saves the escalation only if it is still open at fromStep,
+	// so that a step is notified once even if two replicas poll it.
+	Advance(conn rsdb.Connection, escalation *models.Escalation, fromStep int) (bool, error)
+	// Acknowledge saves the acknowledgement only if the escalation was still open.
+	Acknowledge(conn rsdb.Connection, escalation *models.Escalation) (bool, error)
+	// Resolve saves the resolution only if the escalation was not resolved yet.
+	Resolve(conn rsdb.Connection, escalation *models.Escalation) (bool, error)
+}
+
+type EscalationRepositoryImpl struct {
+	rsdb.Repository
+}
+
+func (repository *EscalationRepositoryImpl) GetUnresolvedByTestId(conn rsdb.Connection, testId string) (*models.Escalation, error) {
+	escalation := &models.Escalation{}
+	if err := conn.Conn().
+		Where("test_id=? AND resolved_at IS NULL", testId).
+		Order("created_at DESC").
+		First(escalation).Error; err != nil {
+		return nil, rsdb.HandleSQLError(err)
+	}
+	escalation.SetValidated()
+	return escalation, nil
+}
+
+func (repository *EscalationRepositoryImpl) GetDueList(conn rsdb.Connection, now time.Time, limit int) ([]*models.Escalation, error) {
+	escalations := make([]*models.Escalation, 0)
+	if err := conn.Conn().
+		Where("next_at<=? AND acknowledged_at IS NULL AND resolved_at IS NULL", now).
+		Order("next_at ASC").
+		Limit(limit).
+		Find(&escalations).Error; err != nil {
+		return nil, rsdb.HandleSQLError(err)
+	}
+	for _, escalation := range escalations {
+		escalation.SetValidated()
+	}
+	return escalations, nil
+}
+
+func (repository *EscalationRepositoryImpl) Advance(conn rsdb.Connection, escalation *models.Escalation, fromStep int) (bool, error) {
+	query := conn.Conn().Model(&models.Escalation{}).
+		Where("id=? AND step=? AND acknowledged_at IS NULL AND resolved_at IS NULL", escalation.Id, fromStep).
+		UpdateColumns(map[string]interface{}{
+			"step":        escalation.Step,
+			"next_at":     escalation.NextAt,
+			"modified_at": escalation.ModifiedAt,
+		})
+	if err := query.Error; err != nil {
+		return false, rsdb.HandleSQLError(err)
+	}
+	return query.RowsAffected == 1, nil
+}
+
+func (repository *EscalationRepositoryImpl) Acknowledge(conn rsdb.Connection, escalation *models.Escalation) (bool, error) {
+	query := conn.Conn().Model(&models.Escalation{}).
+		Where("id=? AND acknowledged_at IS NULL AND resolved_at IS NULL", escalation.Id).
+		UpdateColumns(map[string]interface{}{
+			"next_at":         escalation.NextAt,
+			"acknowledged_at": escalation.AcknowledgedAt,
+			"acknowledged_by": escalation.AcknowledgedBy,
+			"modified_at":     escalation.ModifiedAt,
+		})
+	if err := query.Error; err != nil {
+		return false, rsdb.HandleSQLError(err)
+	}
+	return query.RowsAffected == 1, nil
+}
+
+func (repository *EscalationRepositoryImpl) Resolve(conn rsdb.Connection, escalation *models.Escalation) (bool, error) {
+	query := conn.Conn().Model(&models.Escalation{}).
+		Where("id=? AND resolved_at IS NULL", escalation.Id).
+		UpdateColumns(map[string]interface{}{
+			"next_at":     escalation.NextAt,
+			"resolved_at": escalation.ResolvedAt,
+			"modified_at": escalation.ModifiedAt,
+		})
+	if err := query.Error; err != nil {
+		return false, rsdb.HandleSQLError(err)
+	}
+	return query.RowsAffected == 1, nil
+}
+
+func (repository EscalationRepositoryImpl) CreateTable(transaction rsdb.Connection) error {
+	m := &models.Escalation{}
+	tx := transaction.Conn()
+	if tx.HasTable(m) {
+		return nil
+	}
+	if err := tx.AutoMigrate(m).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	if err := tx.Model(m).AddForeignKey("test_id", "tests(id)", "CASCADE", "CASCADE").Error; err != nil {
+		return errors.WithStack(err)
+	}
+	if err := tx.Model(m).AddForeignKey("policy_id", "escalation_policies(id)", "CASCADE", "CASCADE").Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func NewEscalationRepository() EscalationRepository {
+	return &EscalationRepositoryImpl{&rsdb.DefaultRepository{}}
+}
@@ -0,0 +1,227 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rsmodels"
+)
+
+type IncidentRepository interface {
+	rsdb.Repository
+	GetOpenByWebServiceId(conn rsdb.Connection, webServiceId string) (*models.Incident, error)
+	// GetListInRange returns the incidents of the web service which were open at some time in [from, to).
+	GetListInRange(conn rsdb.Connection, webServiceId string, from, to time.Time) ([]*models.Incident, error)
+	// Close saves the end of the incident only if it was still open and none of its tests is still down,
+	// so that two tests recovering at once close it once, and a test joining it meanwhile keeps it open.
+	Close(conn rsdb.Connection, incident *models.Incident) (bool, error)
+	// Acknowledge saves the acknowledgement only if the incident was not acknowledged yet.
+	Acknowledge(conn rsdb.Connection, incident *models.Incident) (bool, error)
+}
+
+type IncidentRepositoryImpl struct {
+	rsdb.Repository
+}
+
+// GetById returns the incident with its web service, its tests and its notes.
+func (repository *IncidentRepositoryImpl) GetById(conn rsdb.Connection, incident rsmodels.ValidatedObject) error {
+	err := conn.Conn().
+		Preload("WebService").
+		Preload("Tests", func(db *gorm.DB) *gorm.DB {
+			return db.Order("started_at ASC")
+		}).
+		Preload("Tests.Test").
+		Preload("Notes", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		First(incident).Error
+	return rsdb.HandleSQLError(err)
+}
+
+func (repository *IncidentRepositoryImpl) GetOpenByWebServiceId(conn rsdb.Connection, webServiceId string) (*models.Incident, error) {
+	incident := &models.Incident{}
+	if err := conn.Conn().
+		Where("open_key=?", webServiceId).
+		First(incident).Error; err != nil {
+		return nil, rsdb.HandleSQLError(err)
+	}
+	incident.SetValidated()
+	return incident, nil
+}
+
+func (repository *IncidentRepositoryImpl) GetListInRange(conn rsdb.Connection, webServiceId string, from, to time.Time) ([]*models.Incident, error) {
+	incidents := make([]*models.Incident, 0)
+	if err := conn.Conn().
+		Where("web_service_id=? AND started_at<? AND (ended_at IS NULL OR ended_at>?)", webServiceId, to, from).
+		Order("started_at ASC").
+		Find(&incidents).Error; err != nil {
+		return nil, rsdb.HandleSQLError(err)
+	}
+	for _, incident := range incidents {
+		incident.SetValidated()
+	}
+	return incidents, nil
+}
+
+func (repository *IncidentRepositoryImpl) Close(conn rsdb.Connection, incident *models.Incident) (bool, error) {
+	query := conn.Conn().Model(&models.Incident{}).
+		Where("id=? AND ended_at IS NULL AND NOT EXISTS "+
+			"(SELECT 1 FROM incident_tests WHERE incident_id=? AND recovered_at IS NULL)", incident.Id, incident.Id).
+		UpdateColumns(map[string]interface{}{
+			"open_key":    incident.OpenKey,
+			"ended_at":    incident.EndedAt,
+			"duration":    incident.Duration,
+			"modified_at": incident.ModifiedAt,
+		})
+	if err := query.Error; err != nil {
+		return false, rsdb.HandleSQLError(err)
+	}
+	return query.RowsAffected == 1, nil
+}
+
+func (repository *IncidentRepositoryImpl) Acknowledge(conn rsdb.Connection, incident *models.Incident) (bool, error) {
+	query := conn.Conn().Model(&models.Incident{}).
+		Where("id=? AND acknowledged_at IS NULL", incident.Id).
+		UpdateColumns(map[string]interface{}{
+			"acknowledged_at": incident.AcknowledgedAt,
+			"acknowledged_by": incident.AcknowledgedBy,
+			"modified_at":     incident.ModifiedAt,
+		})
+	if err := query.Error; err != nil {
+		return false, rsdb.HandleSQLError(err)
+	}
+	return query.RowsAffected == 1, nil
+}
+
+func (repository IncidentRepositoryImpl) CreateTable(transaction rsdb.Connection) error {
+	m := &models.Incident{}
+	tx := transaction.Conn()
+	if tx.HasTable(m) {
+		return nil
+	}
+	if err := tx.AutoMigrate(m).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	if err := tx.Model(m).AddForeignKey("web_service_id", "web_services(id)", "CASCADE", "CASCADE").Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func NewIncidentRepository() IncidentRepository {
+	return &IncidentRepositoryImpl{&rsdb.DefaultRepository{}}
+}
+
+type IncidentTestRepository interface {
+	rsdb.Repository
+	// GetUnrecoveredByTestId returns the test in the incident of its current outage.
+	GetUnrecoveredByTestId(conn rsdb.Connection, testId string) (*models.IncidentTest, error)
+	// GetListInRange returns the outages of the test which were not recovered at some time in [from, to).
+	GetListInRange(conn rsdb.Connection, testId string, from, to time.Time) ([]*models.IncidentTest, error)
+	// Recover saves the recovery only if the test was not recovered yet.
+	Recover(conn rsdb.Connection, incidentTest *models.IncidentTest) (bool, error)
+	CountUnrecovered(conn rsdb.Connection, incidentId string) (int, error)
+}
+
+type IncidentTestRepositoryImpl struct {
+	rsdb.Repository
+}
+
+func (repository *IncidentTestRepositoryImpl) GetUnrecoveredByTestId(conn rsdb.Connection, testId string) (*models.IncidentTest, error) {
+	incidentTest := &models.IncidentTest{}
+	if err := conn.Conn().
+		Where("test_id=? AND recovered_at IS NULL", testId).
+		Order("started_at DESC").
+		First(incidentTest).Error; err != nil {
+		return nil, rsdb.HandleSQLError(err)
+	}
+	incidentTest.SetValidated()
+	return incidentTest, nil
+}
+
+func (repository *IncidentTestRepositoryImpl) GetListInRange(conn rsdb.Connection, testId string, from, to time.Time) ([]*models.IncidentTest, error) {
+	incidentTests := make([]*models.IncidentTest, 0)
+	if err := conn.Conn().
+		Where("test_id=? AND started_at<? AND (recovered_at IS NULL OR recovered_at>?)", testId, to, from).
+		Order("started_at ASC").
+		Find(&incidentTests).Error; err != nil {
+		return nil, rsdb.HandleSQLError(err)
+	}
+	for _, incidentTest := range incidentTests {
+		incidentTest.SetValidated()
+	}
+	return incidentTests, nil
+}
+
+func (repository *IncidentTestRepositoryImpl) Recover(conn rsdb.Connection, incidentTest *models.IncidentTest) (bool, error) {
+	query := conn.Conn().Model(&models.IncidentTest{}).
+		Where("id=? AND recovered_at IS NULL", incidentTest.Id).
+		UpdateColumn("recovered_at", incidentTest.RecoveredAt)
+	if err := query.Error; err != nil {
+		return false, rsdb.HandleSQLError(err)
+	}
+	return query.RowsAffected == 1, nil
+}
+
+func (repository *IncidentTestRepositoryImpl) CountUnrecovered(conn rsdb.Connection, incidentId string) (int, error) {
+	var count int
+	if err := conn.Conn().Model(&models.IncidentTest{}).
+		Where("incident_id=? AND recovered_at IS NULL", incidentId).
+		Count(&count).Error; err != nil {
+		return 0, rsdb.HandleSQLError(err)
+	}
+	return count, nil
+}
+
+func (repository IncidentTestRepositoryImpl) CreateTable(transaction rsdb.Connection) error {
+	m := &models.IncidentTest{}
+	tx := transaction.Conn()
+	if tx.HasTable(m) {
+		return nil
+	}
+	if err := tx.AutoMigrate(m).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	if err := tx.Model(m).AddForeignKey("incident_id", "incidents(id)", "CASCADE", "CASCADE").Error; err != nil {
+		return errors.WithStack(err)
+	}
+	if err := tx.Model(m).AddForeignKey("test_id", "tests(id)", "CASCADE", "CASCADE").Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func NewIncidentTestRepository() IncidentTestRepository {
+	return &IncidentTestRepositoryImpl{&rsdb.DefaultRepository{}}
+}
+
+type IncidentNoteRepository interface {
+	rsdb.Repository
+}
+
+type IncidentNoteRepositoryImpl struct {
+	rsdb.Repository
+}
+
+func (repository IncidentNoteRepositoryImpl) CreateTable(transaction rsdb.Connection) error {
+	m := &models.IncidentNote{}
+	tx := transaction.Conn()
+	if tx.HasTable(m) {
+		return nil
+	}
+	if err := tx.AutoMigrate(m).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	if err := tx.Model(m).AddForeignKey("incident_id", "incidents(id)", "CASCADE", "CASCADE").Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func NewIncidentNoteRepository() IncidentNoteRepository {
+	return &IncidentNoteRepositoryImpl{&rsdb.DefaultRepository{}}
+}
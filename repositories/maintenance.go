@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+)
+
+type MaintenanceRepository interface {
+	rsdb.Repository
+	GetByIdAndWebServiceId(conn rsdb.Connection, maintenance *models.Maintenance) error
+	GetList(conn rsdb.Connection, items interface{}, filter rsdb.ListFilter, orders rsdb.Orders) (int, error)
+}
+
+type MaintenanceRepositoryImpl struct {
+	rsdb.Repository
+}
+
+func (repository *MaintenanceRepositoryImpl) GetByIdAndWebServiceId(conn rsdb.Connection, maintenance *models.Maintenance) error {
+	if err := conn.Conn().
+		Where("web_service_id=? AND id=?", maintenance.WebServiceId, maintenance.Id).
+		First(maintenance).Error; err != nil {
+		return rsdb.HandleSQLError(err)
+	}
+
+	return nil
+}
+
+func (repository *MaintenanceRepositoryImpl) GetList(conn rsdb.Connection, items interface{}, filter rsdb.ListFilter, orders rsdb.Orders) (int, error) {
+	where := rsdb.NewEmptyQuery()
+	if v, exist := filter.Conditions["web_service_id"]; exist {
+		w, _ := rsdb.NewQuery("web_service_id=?", v)
+		where.And(w)
+	}
+
+	query := conn.Conn().Model(items).Where(where.Where(), where.Values()...)
+	if !rsvalid.IsZero(orders) {
+		query = query.Order(orders.String())
+	}
+
+	var totalCount int
+	if err := query.Count(&totalCount).Error; err != nil {
+		return 0, rsdb.HandleSQLError(err)
+	}
+
+	if filter.Page > 0 {
+		query = query.Offset(filter.Offset())
+	}
+
+	if filter.NumItem > 0 {
+		query = query.Limit(filter.NumItem)
+	}
+
+	if err := query.Find(items).Error; err != nil {
+		return 0, rsdb.HandleSQLError(err)
+	}
+
+	return totalCount, nil
+}
+
+func (repository MaintenanceRepositoryImpl) CreateTable(transaction rsdb.Connection) error {
+	m := &models.Maintenance{}
+	tx := transaction.Conn()
+	if tx.HasTable(m) {
+		return nil
+	}
+	if err := tx.AutoMigrate(m).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	if err := tx.Model(m).AddForeignKey("web_service_id", "web_services(id)", "CASCADE", "CASCADE").Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func NewMaintenanceRepository() MaintenanceRepository {
+	return &MaintenanceRepositoryImpl{&rsdb.DefaultRepository{}}
+}
@@ -2,11 +2,11 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
 import models "github.com/realsangil/apimonitor/models"
-
 import rsdb "github.com/realsangil/apimonitor/pkg/rsdb"
 import rsmodels "github.com/realsangil/apimonitor/pkg/rsmodels"
+import mock "github.com/stretchr/testify/mock"
+import time "time"
 
 // TestRepository is an autogenerated mock type for the TestRepository type
 type TestRepository struct {
@@ -98,18 +98,18 @@ func (_m *TestRepository) GetByIdAndWebServiceId(conn rsdb.Connection, endpoint
 }
 
 // GetList provides a mock function with given fields: conn, items, filter, orders
-func (_m *TestRepository) GetList(conn rsdb.Connection, items *[]*models.TestListItem, filter rsdb.ListFilter, orders rsdb.Orders) (int, error) {
+func (_m *TestRepository) GetList(conn rsdb.Connection, items interface{}, filter rsdb.ListFilter, orders rsdb.Orders) (int, error) {
 	ret := _m.Called(conn, items, filter, orders)
 
 	var r0 int
-	if rf, ok := ret.Get(0).(func(rsdb.Connection, *[]*models.TestListItem, rsdb.ListFilter, rsdb.Orders) int); ok {
+	if rf, ok := ret.Get(0).(func(rsdb.Connection, interface{}, rsdb.ListFilter, rsdb.Orders) int); ok {
 		r0 = rf(conn, items, filter, orders)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(rsdb.Connection, *[]*models.TestListItem, rsdb.ListFilter, rsdb.Orders) error); ok {
+	if rf, ok := ret.Get(1).(func(rsdb.Connection, interface{}, rsdb.ListFilter, rsdb.Orders) error); ok {
 		r1 = rf(conn, items, filter, orders)
 	} else {
 		r1 = ret.Error(1)
@@ -166,3 +166,17 @@ func (_m *TestRepository) Save(tx rsdb.Connection, src rsmodels.ValidatedObject)
 
 	return r0
 }
+
+// UpdateRunAt provides a mock function with given fields: conn, testId, lastRunAt, nextRunAt
+func (_m *TestRepository) UpdateRunAt(conn rsdb.Connection, testId string, lastRunAt *time.Time, nextRunAt time.Time) error {
+	ret := _m.Called(conn, testId, lastRunAt, nextRunAt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(rsdb.Connection, string, *time.Time, time.Time) error); ok {
+		r0 = rf(conn, testId, lastRunAt, nextRunAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
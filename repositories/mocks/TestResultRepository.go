@@ -2,17 +2,80 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
 import models "github.com/realsangil/apimonitor/models"
-
 import rsdb "github.com/realsangil/apimonitor/pkg/rsdb"
 import rsmodels "github.com/realsangil/apimonitor/pkg/rsmodels"
+import mock "github.com/stretchr/testify/mock"
+import time "time"
 
 // TestResultRepository is an autogenerated mock type for the TestResultRepository type
 type TestResultRepository struct {
 	mock.Mock
 }
 
+// CountByTest provides a mock function with given fields: conn, test, from, to
+func (_m *TestResultRepository) CountByTest(conn rsdb.Connection, test *models.Test, from time.Time, to time.Time) (models.TestResultCount, error) {
+	ret := _m.Called(conn, test, from, to)
+
+	var r0 models.TestResultCount
+	if rf, ok := ret.Get(0).(func(rsdb.Connection, *models.Test, time.Time, time.Time) models.TestResultCount); ok {
+		r0 = rf(conn, test, from, to)
+	} else {
+		r0 = ret.Get(0).(models.TestResultCount)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(rsdb.Connection, *models.Test, time.Time, time.Time) error); ok {
+		r1 = rf(conn, test, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountByWebService provides a mock function with given fields: conn, webService, from, to
+func (_m *TestResultRepository) CountByWebService(conn rsdb.Connection, webService *models.WebService, from time.Time, to time.Time) (models.TestResultCount, error) {
+	ret := _m.Called(conn, webService, from, to)
+
+	var r0 models.TestResultCount
+	if rf, ok := ret.Get(0).(func(rsdb.Connection, *models.WebService, time.Time, time.Time) models.TestResultCount); ok {
+		r0 = rf(conn, webService, from, to)
+	} else {
+		r0 = ret.Get(0).(models.TestResultCount)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(rsdb.Connection, *models.WebService, time.Time, time.Time) error); ok {
+		r1 = rf(conn, webService, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountPurgeable provides a mock function with given fields: conn, webServiceId, isSuccess, before
+func (_m *TestResultRepository) CountPurgeable(conn rsdb.Connection, webServiceId string, isSuccess bool, before time.Time) (int64, error) {
+	ret := _m.Called(conn, webServiceId, isSuccess, before)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(rsdb.Connection, string, bool, time.Time) int64); ok {
+		r0 = rf(conn, webServiceId, isSuccess, before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(rsdb.Connection, string, bool, time.Time) error); ok {
+		r1 = rf(conn, webServiceId, isSuccess, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Create provides a mock function with given fields: tx, src
 func (_m *TestResultRepository) Create(tx rsdb.Connection, src rsmodels.ValidatedObject) error {
 	ret := _m.Called(tx, src)
@@ -27,6 +90,20 @@ func (_m *TestResultRepository) Create(tx rsdb.Connection, src rsmodels.Validate
 	return r0
 }
 
+// CreateBatch provides a mock function with given fields: conn, results
+func (_m *TestResultRepository) CreateBatch(conn rsdb.Connection, results []*models.TestResult) error {
+	ret := _m.Called(conn, results)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(rsdb.Connection, []*models.TestResult) error); ok {
+		r0 = rf(conn, results)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // CreateTable provides a mock function with given fields: tx
 func (_m *TestResultRepository) CreateTable(tx rsdb.Connection) error {
 	ret := _m.Called(tx)
@@ -55,6 +132,27 @@ func (_m *TestResultRepository) DeleteById(tx rsdb.Connection, id rsmodels.Valid
 	return r0
 }
 
+// DeletePurgeable provides a mock function with given fields: conn, webServiceId, isSuccess, before, limit
+func (_m *TestResultRepository) DeletePurgeable(conn rsdb.Connection, webServiceId string, isSuccess bool, before time.Time, limit int) (int64, error) {
+	ret := _m.Called(conn, webServiceId, isSuccess, before, limit)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(rsdb.Connection, string, bool, time.Time, int) int64); ok {
+		r0 = rf(conn, webServiceId, isSuccess, before, limit)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(rsdb.Connection, string, bool, time.Time, int) error); ok {
+		r1 = rf(conn, webServiceId, isSuccess, before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FirstOrCreate provides a mock function with given fields: tx, src
 func (_m *TestResultRepository) FirstOrCreate(tx rsdb.Connection, src rsmodels.ValidatedObject) error {
 	ret := _m.Called(tx, src)
@@ -83,8 +181,29 @@ func (_m *TestResultRepository) GetById(tx rsdb.Connection, id rsmodels.Validate
 	return r0
 }
 
-// GetResultList provides a mock function with given fields: conn, test, request
-func (_m *TestResultRepository) GetResultList(conn rsdb.Connection, test *models.Test, request models.TestResultListRequest) (*rsmodels.PaginatedList, error) {
+// GetOldestTestedAt provides a mock function with given fields: conn
+func (_m *TestResultRepository) GetOldestTestedAt(conn rsdb.Connection) (time.Time, error) {
+	ret := _m.Called(conn)
+
+	var r0 time.Time
+	if rf, ok := ret.Get(0).(func(rsdb.Connection) time.Time); ok {
+		r0 = rf(conn)
+	} else {
+		r0 = ret.Get(0).(time.Time)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(rsdb.Connection) error); ok {
+		r1 = rf(conn)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetResultListByTest provides a mock function with given fields: conn, test, request
+func (_m *TestResultRepository) GetResultListByTest(conn rsdb.Connection, test *models.Test, request models.TestResultListRequest) (*rsmodels.PaginatedList, error) {
 	ret := _m.Called(conn, test, request)
 
 	var r0 *rsmodels.PaginatedList
@@ -106,6 +225,29 @@ func (_m *TestResultRepository) GetResultList(conn rsdb.Connection, test *models
 	return r0, r1
 }
 
+// GetResultListByWebService provides a mock function with given fields: conn, webService, request
+func (_m *TestResultRepository) GetResultListByWebService(conn rsdb.Connection, webService *models.WebService, request models.TestResultListRequest) (*rsmodels.PaginatedList, error) {
+	ret := _m.Called(conn, webService, request)
+
+	var r0 *rsmodels.PaginatedList
+	if rf, ok := ret.Get(0).(func(rsdb.Connection, *models.WebService, models.TestResultListRequest) *rsmodels.PaginatedList); ok {
+		r0 = rf(conn, webService, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*rsmodels.PaginatedList)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(rsdb.Connection, *models.WebService, models.TestResultListRequest) error); ok {
+		r1 = rf(conn, webService, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // List provides a mock function with given fields: tx, items, filter, orders
 func (_m *TestResultRepository) List(tx rsdb.Connection, items interface{}, filter rsdb.ListFilter, orders rsdb.Orders) (int, error) {
 	ret := _m.Called(tx, items, filter, orders)
@@ -154,3 +296,45 @@ func (_m *TestResultRepository) Save(tx rsdb.Connection, src rsmodels.ValidatedO
 
 	return r0
 }
+
+// ScanExport provides a mock function with given fields: conn, request, fn
+func (_m *TestResultRepository) ScanExport(conn rsdb.Connection, request models.TestResultExportRequest, fn func(export *models.TestResultExport) error) error {
+	ret := _m.Called(conn, request, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(rsdb.Connection, models.TestResultExportRequest, func(export *models.TestResultExport) error) error); ok {
+		r0 = rf(conn, request, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ScanResponseTimes provides a mock function with given fields: conn, test, from, to, fn
+func (_m *TestResultRepository) ScanResponseTimes(conn rsdb.Connection, test *models.Test, from time.Time, to time.Time, fn func(testedAt time.Time, responseTime int64)) error {
+	ret := _m.Called(conn, test, from, to, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(rsdb.Connection, *models.Test, time.Time, time.Time, func(testedAt time.Time, responseTime int64)) error); ok {
+		r0 = rf(conn, test, from, to, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ScanResults provides a mock function with given fields: conn, from, to, fn
+func (_m *TestResultRepository) ScanResults(conn rsdb.Connection, from time.Time, to time.Time, fn func(result *models.TestResult)) error {
+	ret := _m.Called(conn, from, to, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(rsdb.Connection, time.Time, time.Time, func(result *models.TestResult)) error); ok {
+		r0 = rf(conn, from, to, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
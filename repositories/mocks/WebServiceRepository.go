@@ -2,11 +2,10 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
 import models "github.com/realsangil/apimonitor/models"
-
 import rsdb "github.com/realsangil/apimonitor/pkg/rsdb"
 import rsmodels "github.com/realsangil/apimonitor/pkg/rsmodels"
+import mock "github.com/stretchr/testify/mock"
 
 // WebServiceRepository is an autogenerated mock type for the WebServiceRepository type
 type WebServiceRepository struct {
@@ -69,6 +68,29 @@ func (_m *WebServiceRepository) FirstOrCreate(tx rsdb.Connection, src rsmodels.V
 	return r0
 }
 
+// GetAllWebServices provides a mock function with given fields: conn
+func (_m *WebServiceRepository) GetAllWebServices(conn rsdb.Connection) ([]*models.WebService, error) {
+	ret := _m.Called(conn)
+
+	var r0 []*models.WebService
+	if rf, ok := ret.Get(0).(func(rsdb.Connection) []*models.WebService); ok {
+		r0 = rf(conn)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.WebService)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(rsdb.Connection) error); ok {
+		r1 = rf(conn)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetAllWebServicesWithTests provides a mock function with given fields: conn
 func (_m *WebServiceRepository) GetAllWebServicesWithTests(conn rsdb.Connection) ([]models.WebService, error) {
 	ret := _m.Called(conn)
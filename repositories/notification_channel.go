@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+)
+
+type NotificationChannelRepository interface {
+	rsdb.Repository
+}
+
+type NotificationChannelRepositoryImpl struct {
+	rsdb.Repository
+}
+
+func (repository NotificationChannelRepositoryImpl) CreateTable(transaction rsdb.Connection) error {
+	m := &models.NotificationChannel{}
+	tx := transaction.Conn()
+	if tx.HasTable(m) {
+		return nil
+	}
+	if err := tx.AutoMigrate(m).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func NewNotificationChannelRepository() NotificationChannelRepository {
+	return &NotificationChannelRepositoryImpl{&rsdb.DefaultRepository{}}
+}
+
+type NotificationChannelBindingRepository interface {
+	rsdb.Repository
+	GetByIdAndChannelId(conn rsdb.Connection, binding *models.NotificationChannelBinding) error
+	GetListByTest(conn rsdb.Connection, test *models.Test) (models.NotificationChannelBindings, error)
+	GetListByWebService(conn rsdb.Connection, webServiceId string) (models.NotificationChannelBindings, error)
+	// DeleteByTest deletes the bindings of the test, which scope_id cannot cascade from.
+	DeleteByTest(conn rsdb.Connection, testId string) error
+	// DeleteByWebService deletes the bindings of the web service and of its tests,
+	// so it is called before the tests are deleted with the web service.
+	DeleteByWebService(conn rsdb.Connection, webServiceId string) error
+}
+
+type NotificationChannelBindingRepositoryImpl struct {
+	rsdb.Repository
+}
+
+func (repository *NotificationChannelBindingRepositoryImpl) GetByIdAndChannelId(conn rsdb.Connection, binding *models.NotificationChannelBinding) error {
+	if err := conn.Conn().
+		Where("channel_id=? AND id=?", binding.ChannelId, binding.Id).
+		First(binding).Error; err != nil {
+		return rsdb.HandleSQLError(err)
+	}
+
+	return nil
+}
+
+// GetListByTest returns the bindings at every level the test inherits from, with their channels.
+func (repository *NotificationChannelBindingRepositoryImpl) GetListByTest(conn rsdb.Connection, test *models.Test) (models.NotificationChannelBindings, error) {
+	bindings := make(models.NotificationChannelBindings, 0)
+	if err := conn.Conn().
+		Preload("Channel").
+		Where("scope=? OR (scope=? AND scope_id=?) OR (scope=? AND scope_id=?)",
+			models.ChannelScopeGlobal,
+			models.ChannelScopeWebService, test.WebServiceId,
+			models.ChannelScopeTest, test.Id,
+		).
+		Find(&bindings).Error; err != nil {
+		return nil, rsdb.HandleSQLError(err)
+	}
+	return bindings, nil
+}
+
+// GetListByWebService returns the global bindings and those of the web service, with their channels.
+func (repository *NotificationChannelBindingRepositoryImpl) GetListByWebService(conn rsdb.Connection, webServiceId string) (models.NotificationChannelBindings, error) {
+	bindings := make(models.NotificationChannelBindings, 0)
+	if err := conn.Conn().
+		Preload("Channel").
+		Where("scope=? OR (scope=? AND scope_id=?)",
+			models.ChannelScopeGlobal,
+			models.ChannelScopeWebService, webServiceId,
+		).
+		Find(&bindings).Error; err != nil {
+		return nil, rsdb.HandleSQLError(err)
+	}
+	return bindings, nil
+}
+
+func (repository *NotificationChannelBindingRepositoryImpl) DeleteByTest(conn rsdb.Connection, testId string) error {
+	err := conn.Conn().
+		Where("scope=? AND scope_id=?", models.ChannelScopeTest, testId).
+		Delete(&models.NotificationChannelBinding{}).Error
+	return rsdb.HandleSQLError(err)
+}
+
+func (repository *NotificationChannelBindingRepositoryImpl) DeleteByWebService(conn rsdb.Connection, webServiceId string) error {
+	err := conn.Conn().
+		Where("(scope=? AND scope_id=?) OR (scope=? AND scope_id IN (SELECT id FROM tests WHERE web_service_id=?))",
+			models.ChannelScopeWebService, webServiceId,
+			models.ChannelScopeTest, webServiceId,
+		).
+		Delete(&models.NotificationChannelBinding{}).Error
+	return rsdb.HandleSQLError(err)
+}
+
+func (repository NotificationChannelBindingRepositoryImpl) CreateTable(transaction rsdb.Connection) error {
+	m := &models.NotificationChannelBinding{}
+	tx := transaction.Conn()
+	if tx.HasTable(m) {
+		return nil
+	}
+	if err := tx.AutoMigrate(m).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	if err := tx.Model(m).AddForeignKey("channel_id", "notification_channels(id)", "CASCADE", "CASCADE").Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func NewNotificationChannelBindingRepository() NotificationChannelBindingRepository {
+	return &NotificationChannelBindingRepositoryImpl{&rsdb.DefaultRepository{}}
+}
@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+)
+
+func TestNotificationChannelBindingRepositoryImpl_DeleteByTest(t *testing.T) {
+	gormDB, mock, err := rsdb.CreateMockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM .* WHERE \(scope=\? AND scope_id=\?\)`).
+		WithArgs(models.ChannelScopeTest, "test").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	err = NewNotificationChannelBindingRepository().DeleteByTest(rsdb.NewConnection(gormDB), "test")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNotificationChannelBindingRepositoryImpl_DeleteByWebService(t *testing.T) {
+	gormDB, mock, err := rsdb.CreateMockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The bindings of the tests are deleted too, since the tests are deleted with the web service.
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM .* WHERE \(\(scope=\? AND scope_id=\?\) OR \(scope=\? AND scope_id IN \(SELECT id FROM tests WHERE web_service_id=\?\)\)\)`).
+		WithArgs(models.ChannelScopeWebService, "web-service", models.ChannelScopeTest, "web-service").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	err = NewNotificationChannelBindingRepository().DeleteByWebService(rsdb.NewConnection(gormDB), "web-service")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+)
+
+type ReplicaRepository interface {
+	rsdb.Repository
+	Heartbeat(conn rsdb.Connection, replica *models.Replica) error
+	GetLiveReplicas(conn rsdb.Connection, timeout time.Duration) ([]*models.Replica, error)
+	DeleteExpired(conn rsdb.Connection, timeout time.Duration) error
+}
+
+type ReplicaRepositoryImpl struct {
+	rsdb.Repository
+}
+
+// Heartbeat registers the replica or refreshes its heartbeat with the database clock.
+func (repository *ReplicaRepositoryImpl) Heartbeat(conn rsdb.Connection, replica *models.Replica) error {
+	if !replica.IsValidated() {
+		return rsdb.ErrInvalidModel
+	}
+	if err := conn.Conn().Exec(
+		"INSERT INTO replicas (id, hostname, started_at, heartbeat_at) VALUES (?, ?, ?, CURRENT_TIMESTAMP(3)) "+
+			"ON DUPLICATE KEY UPDATE hostname=VALUES(hostname), heartbeat_at=CURRENT_TIMESTAMP(3)",
+		replica.Id, replica.Hostname, replica.StartedAt,
+	).Error; err != nil {
+		return rsdb.HandleSQLError(err)
+	}
+	return nil
+}
+
+// GetLiveReplicas returns the replicas whose heartbeat is younger than timeout, oldest first.
+func (repository *ReplicaRepositoryImpl) GetLiveReplicas(conn rsdb.Connection, timeout time.Duration) ([]*models.Replica, error) {
+	replicas := make([]*models.Replica, 0)
+	if err := conn.Conn().
+		Where("heartbeat_at > CURRENT_TIMESTAMP(3) - INTERVAL ? MICROSECOND", timeout.Microseconds()).
+		Order("started_at, id").
+		Find(&replicas).Error; err != nil {
+		return nil, rsdb.HandleSQLError(err)
+	}
+	return replicas, nil
+}
+
+func (repository *ReplicaRepositoryImpl) DeleteExpired(conn rsdb.Connection, timeout time.Duration) error {
+	if err := conn.Conn().
+		Where("heartbeat_at <= CURRENT_TIMESTAMP(3) - INTERVAL ? MICROSECOND", timeout.Microseconds()).
+		Delete(&models.Replica{}).Error; err != nil {
+		return rsdb.HandleSQLError(err)
+	}
+	return nil
+}
+
+func (repository ReplicaRepositoryImpl) CreateTable(transaction rsdb.Connection) error {
+	m := &models.Replica{}
+	tx := transaction.Conn()
+	if tx.HasTable(m) {
+		return nil
+	}
+	if err := tx.AutoMigrate(m).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func NewReplicaRepository() ReplicaRepository {
+	return &ReplicaRepositoryImpl{&rsdb.DefaultRepository{}}
+}
@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rsmodels"
+)
+
+type ReportRepository interface {
+	rsdb.Repository
+	// Exists reports whether the report of the web service for the period starting at start was generated.
+	Exists(conn rsdb.Connection, webServiceId string, period models.ReportPeriod, start time.Time) (bool, error)
+	// UpdateDeliveries saves only the deliveries of the report.
+	UpdateDeliveries(conn rsdb.Connection, report *models.Report) error
+}
+
+type ReportRepositoryImpl struct {
+	rsdb.Repository
+}
+
+// GetById returns the report with its web service.
+func (repository *ReportRepositoryImpl) GetById(conn rsdb.Connection, report rsmodels.ValidatedObject) error {
+	err := conn.Conn().
+		Preload("WebService").
+		First(report).Error
+	return rsdb.HandleSQLError(err)
+}
+
+func (repository *ReportRepositoryImpl) Exists(conn rsdb.Connection, webServiceId string, period models.ReportPeriod, start time.Time) (bool, error) {
+	var count int
+	if err := conn.Conn().Model(&models.Report{}).
+		Where("web_service_id=? AND period=? AND start=?", webServiceId, period, start).
+		Count(&count).Error; err != nil {
+		return false, rsdb.HandleSQLError(err)
+	}
+	return count > 0, nil
+}
+
+func (repository *ReportRepositoryImpl) UpdateDeliveries(conn rsdb.Connection, report *models.Report) error {
+	err := conn.Conn().Model(&models.Report{}).
+		Where("id=?", report.Id).
+		UpdateColumn("deliveries", report.Deliveries).Error
+	return rsdb.HandleSQLError(err)
+}
+
+func (repository ReportRepositoryImpl) CreateTable(transaction rsdb.Connection) error {
+	m := &models.Report{}
+	tx := transaction.Conn()
+	if tx.HasTable(m) {
+		return nil
+	}
+	if err := tx.AutoMigrate(m).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	if err := tx.Model(m).AddForeignKey("web_service_id", "web_services(id)", "CASCADE", "CASCADE").Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func NewReportRepository() ReportRepository {
+	return &ReportRepositoryImpl{&rsdb.DefaultRepository{}}
+}
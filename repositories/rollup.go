@@ -0,0 +1,196 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+)
+
+type TestResultRollupRepository interface {
+	rsdb.Repository
+	// UpsertBatch saves the rollups of the granularity, replacing those of the same test and period,
+	// so a period can safely be rolled up again.
+	UpsertBatch(conn rsdb.Connection, granularity models.RollupGranularity, rollups []*models.TestResultRollup) error
+	// GetListByTest returns the rollups of the test starting in [from, to), oldest first.
+	GetListByTest(conn rsdb.Connection, granularity models.RollupGranularity, testId string, from, to time.Time) ([]*models.TestResultRollup, error)
+	// CountByTest and CountByWebService sum the counts of the hourly rollups starting in [from, to).
+	CountByTest(conn rsdb.Connection, test *models.Test, from, to time.Time) (models.TestResultCount, error)
+	CountByWebService(conn rsdb.Connection, webService *models.WebService, from, to time.Time) (models.TestResultCount, error)
+	// CountByWebServicePerPeriod sums the counts of the tests of the web service per rollup starting in [from, to),
+	// oldest first. The periods without any rollup are left out.
+	CountByWebServicePerPeriod(conn rsdb.Connection, granularity models.RollupGranularity, webServiceId string, from, to time.Time) ([]*models.TestResultPeriodCount, error)
+	// GetWatermark returns the end of the last period rolled up by the granularity, or the zero time.
+	GetWatermark(conn rsdb.Connection, granularity models.RollupGranularity) (time.Time, error)
+	SetWatermark(conn rsdb.Connection, granularity models.RollupGranularity, rolledUpTo time.Time) error
+}
+
+type TestResultRollupRepositoryImpl struct {
+	rsdb.Repository
+}
+
+var testResultRollupColumns = []string{
+	"test_id", "start", "total_count", "success_count", "failure_count", "maintenance_count",
+	"min_response_time", "max_response_time", "mean_response_time",
+	"p50_response_time", "p90_response_time", "p95_response_time", "p99_response_time",
+	"status_codes", "histogram",
+}
+
+func (repository *TestResultRollupRepositoryImpl) UpsertBatch(conn rsdb.Connection, granularity models.RollupGranularity, rollups []*models.TestResultRollup) error {
+	if len(rollups) == 0 {
+		return nil
+	}
+
+	placeholder := fmt.Sprintf("(%s)", strings.TrimSuffix(strings.Repeat("?,", len(testResultRollupColumns)), ","))
+	placeholders := make([]string, 0, len(rollups))
+	values := make([]interface{}, 0, len(rollups)*len(testResultRollupColumns))
+	for _, rollup := range rollups {
+		placeholders = append(placeholders, placeholder)
+		values = append(values,
+			rollup.TestId,
+			rollup.Start,
+			rollup.TotalCount,
+			rollup.SuccessCount,
+			rollup.FailureCount,
+			rollup.MaintenanceCount,
+			rollup.MinResponseTime,
+			rollup.MaxResponseTime,
+			rollup.MeanResponseTime,
+			rollup.P50ResponseTime,
+			rollup.P90ResponseTime,
+			rollup.P95ResponseTime,
+			rollup.P99ResponseTime,
+			rollup.StatusCodes,
+			rollup.Histogram,
+		)
+	}
+
+	updates := make([]string, 0, len(testResultRollupColumns)-2)
+	for _, column := range testResultRollupColumns[2:] {
+		updates = append(updates, fmt.Sprintf("%s=VALUES(%s)", column, column))
+	}
+
+	sql := fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES %s ON DUPLICATE KEY UPDATE %s",
+		granularity.RollupTableName(),
+		strings.Join(testResultRollupColumns, ", "),
+		strings.Join(placeholders, ", "),
+		strings.Join(updates, ", "),
+	)
+	if err := conn.Conn().Exec(sql, values...).Error; err != nil {
+		return rsdb.HandleSQLError(err)
+	}
+	return nil
+}
+
+func (repository *TestResultRollupRepositoryImpl) GetListByTest(conn rsdb.Connection, granularity models.RollupGranularity, testId string, from, to time.Time) ([]*models.TestResultRollup, error) {
+	rollups := make([]*models.TestResultRollup, 0)
+	if err := conn.Conn().Table(granularity.RollupTableName()).
+		Where("test_id=? AND start>=? AND start<?", testId, from, to).
+		Order("start ASC").
+		Find(&rollups).Error; err != nil {
+		return nil, rsdb.HandleSQLError(err)
+	}
+	return rollups, nil
+}
+
+func (repository *TestResultRollupRepositoryImpl) CountByTest(conn rsdb.Connection, test *models.Test, from, to time.Time) (models.TestResultCount, error) {
+	return repository.count(conn, test, from, to)
+}
+
+func (repository *TestResultRollupRepositoryImpl) CountByWebService(conn rsdb.Connection, webService *models.WebService, from, to time.Time) (models.TestResultCount, error) {
+	return repository.count(conn, webService, from, to)
+}
+
+func (repository *TestResultRollupRepositoryImpl) count(conn rsdb.Connection, joinObject interface{}, from, to time.Time) (models.TestResultCount, error) {
+	sql := conn.Conn().Table(fmt.Sprintf("%s AS r", models.RollupHourly.RollupTableName())).
+		Select("COALESCE(SUM(r.total_count), 0) AS total_count, COALESCE(SUM(r.success_count), 0) AS success_count")
+
+	switch obj := joinObject.(type) {
+	case *models.WebService:
+		sql = sql.Joins("INNER JOIN tests AS t ON r.test_id=t.id AND t.web_service_id=?", obj.Id)
+	case *models.Test:
+		sql = sql.Where("r.test_id=?", obj.Id)
+	}
+
+	var count models.TestResultCount
+	if err := sql.Where("r.start>=? AND r.start<?", from, to).Scan(&count).Error; err != nil {
+		return count, rsdb.HandleSQLError(err)
+	}
+	return count, nil
+}
+
+func (repository *TestResultRollupRepositoryImpl) CountByWebServicePerPeriod(conn rsdb.Connection, granularity models.RollupGranularity, webServiceId string, from, to time.Time) ([]*models.TestResultPeriodCount, error) {
+	counts := make([]*models.TestResultPeriodCount, 0)
+	if err := conn.Conn().Table(fmt.Sprintf("%s AS r", granularity.RollupTableName())).
+		Select("r.start AS start, SUM(r.total_count) AS total_count, SUM(r.success_count) AS success_count").
+		Joins("INNER JOIN tests AS t ON r.test_id=t.id AND t.web_service_id=?", webServiceId).
+		Where("r.start>=? AND r.start<?", from, to).
+		Group("r.start").
+		Order("r.start ASC").
+		Scan(&counts).Error; err != nil {
+		return nil, rsdb.HandleSQLError(err)
+	}
+	return counts, nil
+}
+
+func (repository *TestResultRollupRepositoryImpl) GetWatermark(conn rsdb.Connection, granularity models.RollupGranularity) (time.Time, error) {
+	watermark := &models.RollupWatermark{}
+	err := rsdb.HandleSQLError(conn.Conn().Where("granularity=?", granularity).First(watermark).Error)
+	switch err {
+	case nil:
+		return watermark.RolledUpTo, nil
+	case rsdb.ErrRecordNotFound:
+		return time.Time{}, nil
+	default:
+		return time.Time{}, err
+	}
+}
+
+func (repository *TestResultRollupRepositoryImpl) SetWatermark(conn rsdb.Connection, granularity models.RollupGranularity, rolledUpTo time.Time) error {
+	if err := conn.Conn().Exec(
+		"INSERT INTO rollup_watermarks (granularity, rolled_up_to, modified_at) VALUES (?, ?, ?) "+
+			"ON DUPLICATE KEY UPDATE rolled_up_to=VALUES(rolled_up_to), modified_at=VALUES(modified_at)",
+		granularity, rolledUpTo, time.Now(),
+	).Error; err != nil {
+		return rsdb.HandleSQLError(err)
+	}
+	return nil
+}
+
+func (repository TestResultRollupRepositoryImpl) CreateTable(transaction rsdb.Connection) error {
+	tx := transaction.Conn()
+	for _, granularity := range models.RollupGranularities {
+		table := granularity.RollupTableName()
+		if tx.HasTable(table) {
+			continue
+		}
+		if err := tx.Table(table).AutoMigrate(&models.TestResultRollup{}).Error; err != nil {
+			return errors.WithStack(err)
+		}
+		if err := tx.Table(table).AddForeignKey("test_id", "tests(id)", "CASCADE", "CASCADE").Error; err != nil {
+			return errors.WithStack(err)
+		}
+		// The rollups of the web service uptime are read by period.
+		if err := tx.Table(table).AddIndex(fmt.Sprintf("idx_%s_start", table), "start").Error; err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	m := &models.RollupWatermark{}
+	if tx.HasTable(m) {
+		return nil
+	}
+	if err := tx.AutoMigrate(m).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func NewTestResultRollupRepository() TestResultRollupRepository {
+	return &TestResultRollupRepositoryImpl{&rsdb.DefaultRepository{}}
+}
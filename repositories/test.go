@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/pkg/errors"
 
 	"github.com/realsangil/apimonitor/models"
@@ -13,6 +15,7 @@ type TestRepository interface {
 	rsdb.Repository
 	GetByIdAndWebServiceId(conn rsdb.Connection, endpoint *models.Test) error
 	GetList(conn rsdb.Connection, items interface{}, filter rsdb.ListFilter, orders rsdb.Orders) (int, error)
+	UpdateRunAt(conn rsdb.Connection, testId string, lastRunAt *time.Time, nextRunAt time.Time) error
 }
 
 type TestRepositoryImpl struct {
@@ -66,15 +69,30 @@ func (repository *TestRepositoryImpl) GetList(conn rsdb.Connection, items interf
 	return totalCount, nil
 }
 
+// UpdateRunAt stores the schedule state of the test without touching the other columns.
+// lastRunAt is left as is when it is nil.
+func (repository *TestRepositoryImpl) UpdateRunAt(conn rsdb.Connection, testId string, lastRunAt *time.Time, nextRunAt time.Time) error {
+	columns := map[string]interface{}{"next_run_at": nextRunAt}
+	if lastRunAt != nil {
+		columns["last_run_at"] = *lastRunAt
+	}
+	if err := conn.Conn().Model(&models.Test{}).Where("id=?", testId).UpdateColumns(columns).Error; err != nil {
+		return rsdb.HandleSQLError(err)
+	}
+	return nil
+}
+
 func (repository TestRepositoryImpl) CreateTable(transaction rsdb.Connection) error {
 	m := &models.Test{}
 	tx := transaction.Conn()
-	if tx.HasTable(m) {
-		return nil
-	}
+	hasTable := tx.HasTable(m)
+	// AutoMigrate adds the columns which are missing in an existing table.
 	if err := tx.AutoMigrate(m).Error; err != nil {
 		return errors.WithStack(err)
 	}
+	if hasTable {
+		return nil
+	}
 	if err := tx.Model(m).AddForeignKey("web_service_id", "web_services(id)", "CASCADE", "CASCADE").Error; err != nil {
 		return errors.WithStack(err)
 	}
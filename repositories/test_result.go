@@ -1,6 +1,10 @@
 package repositories
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/realsangil/apimonitor/models"
 	"github.com/realsangil/apimonitor/pkg/rsdb"
 	"github.com/realsangil/apimonitor/pkg/rsmodels"
@@ -10,6 +14,24 @@ type TestResultRepository interface {
 	rsdb.Repository
 	GetResultListByTest(conn rsdb.Connection, test *models.Test, request models.TestResultListRequest) (*rsmodels.PaginatedList, error)
 	GetResultListByWebService(conn rsdb.Connection, webService *models.WebService, request models.TestResultListRequest) (*rsmodels.PaginatedList, error)
+	CreateBatch(conn rsdb.Connection, results []*models.TestResult) error
+	// CountByTest and CountByWebService count the results in [from, to), except those in maintenance.
+	CountByTest(conn rsdb.Connection, test *models.Test, from, to time.Time) (models.TestResultCount, error)
+	CountByWebService(conn rsdb.Connection, webService *models.WebService, from, to time.Time) (models.TestResultCount, error)
+	// ScanResponseTimes calls fn with the response time of each result of the test in [from, to),
+	// except those in maintenance, in the order they were tested. The rows are streamed, not loaded at once.
+	ScanResponseTimes(conn rsdb.Connection, test *models.Test, from, to time.Time, fn func(testedAt time.Time, responseTime int64)) error
+	// ScanResults calls fn with each result in [from, to) ordered by test, without the response and the assertions.
+	ScanResults(conn rsdb.Connection, from, to time.Time, fn func(result *models.TestResult)) error
+	// ScanExport calls fn with each result of the request in the order they were tested, streaming the rows
+	// through a cursor instead of paginating. It stops at the first error of fn, and returns it.
+	ScanExport(conn rsdb.Connection, request models.TestResultExportRequest, fn func(export *models.TestResultExport) error) error
+	// GetOldestTestedAt returns when the oldest result was tested, or the zero time without results.
+	GetOldestTestedAt(conn rsdb.Connection) (time.Time, error)
+	// CountPurgeable counts the successes or the failures of the web service tested before the time.
+	CountPurgeable(conn rsdb.Connection, webServiceId string, isSuccess bool, before time.Time) (int64, error)
+	// DeletePurgeable deletes at most limit of the results CountPurgeable counts, and returns how many were deleted.
+	DeletePurgeable(conn rsdb.Connection, webServiceId string, isSuccess bool, before time.Time, limit int) (int64, error)
 }
 
 type TestResultRepositoryImp struct {
@@ -28,6 +50,10 @@ func (repository *TestResultRepositoryImp) CreateTable(conn rsdb.Connection) err
 	if err := conn.Conn().Model(m).AddIndex("idx_tested_at_status_code_is_success", "tested_at", "status_code", "is_success").Error; err != nil {
 		return rsdb.HandleSQLError(err)
 	}
+	// Covers the uptime counts, which are read from the index only.
+	if err := conn.Conn().Model(m).AddIndex("idx_test_id_tested_at_in_maintenance_is_success", "test_id", "tested_at", "in_maintenance", "is_success").Error; err != nil {
+		return rsdb.HandleSQLError(err)
+	}
 	return nil
 }
 
@@ -93,6 +119,214 @@ func (repository *TestResultRepositoryImp) getResultList(conn rsdb.Connection, j
 
 }
 
+func (repository *TestResultRepositoryImp) CountByTest(conn rsdb.Connection, test *models.Test, from, to time.Time) (models.TestResultCount, error) {
+	return repository.count(conn, test, from, to)
+}
+
+func (repository *TestResultRepositoryImp) CountByWebService(conn rsdb.Connection, webService *models.WebService, from, to time.Time) (models.TestResultCount, error) {
+	return repository.count(conn, webService, from, to)
+}
+
+func (repository *TestResultRepositoryImp) count(conn rsdb.Connection, joinObject interface{}, from, to time.Time) (models.TestResultCount, error) {
+	sql := conn.Conn().Table("test_results AS tr").
+		Select("COUNT(*) AS total_count, COALESCE(SUM(tr.is_success), 0) AS success_count")
+
+	switch obj := joinObject.(type) {
+	case *models.WebService:
+		sql = sql.Joins("INNER JOIN tests AS t ON tr.test_id=t.id AND t.web_service_id=?", obj.Id)
+	case *models.Test:
+		sql = sql.Where("tr.test_id=?", obj.Id)
+	}
+
+	var count models.TestResultCount
+	if err := sql.
+		Where("tr.tested_at>=? AND tr.tested_at<? AND tr.in_maintenance=?", from, to, false).
+		Scan(&count).Error; err != nil {
+		return count, rsdb.HandleSQLError(err)
+	}
+	return count, nil
+}
+
+func (repository *TestResultRepositoryImp) ScanResponseTimes(conn rsdb.Connection, test *models.Test, from, to time.Time, fn func(testedAt time.Time, responseTime int64)) error {
+	rows, err := conn.Conn().Table("test_results").
+		Select("tested_at, response_time").
+		Where("test_id=? AND tested_at>=? AND tested_at<? AND in_maintenance=?", test.Id, from, to, false).
+		Order("tested_at ASC").
+		Rows()
+	if err != nil {
+		return rsdb.HandleSQLError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			testedAt     time.Time
+			responseTime int64
+		)
+		if err := rows.Scan(&testedAt, &responseTime); err != nil {
+			return rsdb.HandleSQLError(err)
+		}
+		fn(testedAt, responseTime)
+	}
+	return rsdb.HandleSQLError(rows.Err())
+}
+
+func (repository *TestResultRepositoryImp) ScanResults(conn rsdb.Connection, from, to time.Time, fn func(result *models.TestResult)) error {
+	rows, err := conn.Conn().Table("test_results").
+		Select("id, test_id, is_success, status_code, response_time, in_maintenance, tested_at").
+		Where("tested_at>=? AND tested_at<?", from, to).
+		Order("test_id ASC, tested_at ASC").
+		Rows()
+	if err != nil {
+		return rsdb.HandleSQLError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		result := &models.TestResult{}
+		if err := rows.Scan(
+			&result.Id,
+			&result.TestId,
+			&result.IsSuccess,
+			&result.StatusCode,
+			&result.ResponseTime,
+			&result.InMaintenance,
+			&result.TestedAt,
+		); err != nil {
+			return rsdb.HandleSQLError(err)
+		}
+		fn(result)
+	}
+	return rsdb.HandleSQLError(rows.Err())
+}
+
+func (repository *TestResultRepositoryImp) ScanExport(conn rsdb.Connection, request models.TestResultExportRequest, fn func(export *models.TestResultExport) error) error {
+	query := rsdb.NewEmptyQuery()
+	if request.TestId != "" {
+		q, _ := rsdb.NewQuery("tr.test_id=?", request.TestId)
+		query = query.And(q)
+	}
+	if request.WebServiceId != "" {
+		q, _ := rsdb.NewQuery("t.web_service_id=?", request.WebServiceId)
+		query = query.And(q)
+	}
+	if request.IsSuccess != nil {
+		q, _ := rsdb.NewQuery("tr.is_success=?", *request.IsSuccess)
+		query = query.And(q)
+	}
+	q, _ := rsdb.NewQuery("tr.tested_at>=? AND tr.tested_at<?", request.From, request.To)
+	query = query.And(q)
+
+	rows, err := conn.Conn().Table("test_results AS tr").
+		Select("tr.id, t.web_service_id, tr.test_id, tr.tested_at, tr.is_success, tr.status_code, tr.response_time, tr.in_maintenance, tr.assertions").
+		Joins("INNER JOIN tests AS t ON tr.test_id=t.id").
+		Where(query.Where(), query.Values()...).
+		Order("tr.tested_at ASC, tr.id ASC").
+		Rows()
+	if err != nil {
+		return rsdb.HandleSQLError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		export := &models.TestResultExport{}
+		if err := rows.Scan(
+			&export.Id,
+			&export.WebServiceId,
+			&export.TestId,
+			&export.TestedAt,
+			&export.IsSuccess,
+			&export.StatusCode,
+			&export.ResponseTime,
+			&export.InMaintenance,
+			&export.Assertions,
+		); err != nil {
+			return rsdb.HandleSQLError(err)
+		}
+		if err := fn(export); err != nil {
+			return err
+		}
+	}
+	return rsdb.HandleSQLError(rows.Err())
+}
+
+func (repository *TestResultRepositoryImp) GetOldestTestedAt(conn rsdb.Connection) (time.Time, error) {
+	result := &models.TestResult{}
+	err := rsdb.HandleSQLError(conn.Conn().Select("tested_at").Order("tested_at ASC").First(result).Error)
+	switch err {
+	case nil:
+		return result.TestedAt, nil
+	case rsdb.ErrRecordNotFound:
+		return time.Time{}, nil
+	default:
+		return time.Time{}, err
+	}
+}
+
+const purgeableCondition = "test_id IN (SELECT id FROM tests WHERE web_service_id=?) AND is_success=? AND tested_at<?"
+
+func (repository *TestResultRepositoryImp) CountPurgeable(conn rsdb.Connection, webServiceId string, isSuccess bool, before time.Time) (int64, error) {
+	var count int64
+	if err := conn.Conn().Table("test_results").
+		Where(purgeableCondition, webServiceId, isSuccess, before).
+		Count(&count).Error; err != nil {
+		return 0, rsdb.HandleSQLError(err)
+	}
+	return count, nil
+}
+
+// DeletePurgeable deletes with a limit, so the table is not locked for long.
+func (repository *TestResultRepositoryImp) DeletePurgeable(conn rsdb.Connection, webServiceId string, isSuccess bool, before time.Time, limit int) (int64, error) {
+	sql := conn.Conn().Exec("DELETE FROM test_results WHERE "+purgeableCondition+" LIMIT ?", webServiceId, isSuccess, before, limit)
+	if err := sql.Error; err != nil {
+		return 0, rsdb.HandleSQLError(err)
+	}
+	return sql.RowsAffected, nil
+}
+
+var testResultBatchColumns = []string{
+	"id", "test_id", "is_success", "status_code", "response", "response_time", "assertions", "in_maintenance", "tested_at",
+}
+
+// CreateBatch inserts the results with a single multi-row statement.
+// Rows whose id already exists are skipped, so a batch can safely be retried.
+func (repository *TestResultRepositoryImp) CreateBatch(conn rsdb.Connection, results []*models.TestResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	placeholder := fmt.Sprintf("(%s)", strings.TrimSuffix(strings.Repeat("?,", len(testResultBatchColumns)), ","))
+	placeholders := make([]string, 0, len(results))
+	values := make([]interface{}, 0, len(results)*len(testResultBatchColumns))
+	for _, result := range results {
+		if !result.IsValidated() {
+			return rsdb.ErrInvalidModel
+		}
+		placeholders = append(placeholders, placeholder)
+		values = append(values,
+			result.Id,
+			result.TestId,
+			result.IsSuccess,
+			result.StatusCode,
+			result.Response,
+			result.ResponseTime,
+			result.Assertions,
+			result.InMaintenance,
+			result.TestedAt,
+		)
+	}
+
+	sql := fmt.Sprintf(
+		"INSERT IGNORE INTO test_results (%s) VALUES %s",
+		strings.Join(testResultBatchColumns, ", "),
+		strings.Join(placeholders, ", "),
+	)
+	if err := conn.Conn().Exec(sql, values...).Error; err != nil {
+		return rsdb.HandleSQLError(err)
+	}
+	return nil
+}
+
 func NewTestResultRepository() TestResultRepository {
 	return &TestResultRepositoryImp{
 		&rsdb.DefaultRepository{},
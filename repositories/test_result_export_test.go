@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+)
+
+func TestTestResultRepositoryImp_ScanExport_IsSuccess(t *testing.T) {
+	from := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+	testedAt := from.Add(time.Hour)
+
+	gormDB, mock, err := rsdb.CreateMockDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The filter is bound as a bool, since MySQL would compare the string 'true' as 0.
+	mock.ExpectQuery(`SELECT .* FROM test_results AS tr INNER JOIN tests AS t ON tr.test_id=t.id WHERE \(\(tr.is_success=\?\) AND \(tr.tested_at>=\? AND tr.tested_at<\?\)\)`).
+		WithArgs(true, from, to).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "web_service_id", "test_id", "tested_at", "is_success", "status_code", "response_time", "in_maintenance", "assertions",
+		}).AddRow("result", "web-service", "test", testedAt, true, 200, 10, false, []byte("[]")))
+
+	request, err := models.NewTestResultExportRequest("", "", "", "true", "", from.Format(time.RFC3339), to.Format(time.RFC3339), to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var exports []*models.TestResultExport
+	err = NewTestResultRepository().ScanExport(rsdb.NewConnection(gormDB), request, func(export *models.TestResultExport) error {
+		exports = append(exports, export)
+		return nil
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, exports, 1) {
+		assert.True(t, exports[0].IsSuccess)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -10,6 +10,7 @@ import (
 type WebServiceRepository interface {
 	rsdb.Repository
 	GetAllWebServicesWithTests(conn rsdb.Connection) ([]models.WebService, error)
+	GetAllWebServices(conn rsdb.Connection) ([]*models.WebService, error)
 }
 
 type WebServiceRepositoryImpl struct {
@@ -24,12 +25,21 @@ func (repository *WebServiceRepositoryImpl) GetAllWebServicesWithTests(conn rsdb
 	return items, nil
 }
 
+func (repository *WebServiceRepositoryImpl) GetAllWebServices(conn rsdb.Connection) ([]*models.WebService, error) {
+	items := make([]*models.WebService, 0)
+	if err := conn.Conn().Order("host ASC").Find(&items).Error; err != nil {
+		return nil, rsdb.HandleSQLError(err)
+	}
+	for _, item := range items {
+		item.SetValidated()
+	}
+	return items, nil
+}
+
 func (repository WebServiceRepositoryImpl) CreateTable(transaction rsdb.Connection) error {
 	m := &models.WebService{}
 	tx := transaction.Conn()
-	if tx.HasTable(m) {
-		return nil
-	}
+	// AutoMigrate adds the columns which are missing in an existing table.
 	if err := tx.AutoMigrate(m).Error; err != nil {
 		return errors.WithStack(err)
 	}
@@ -0,0 +1,399 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsmodels"
+	"github.com/realsangil/apimonitor/pkg/rsnotify"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/repositories"
+)
+
+const testAlertMessage = "[TEST] apimonitor\nThis is a test notification."
+
+var _ AlertService = &AlertServiceImpl{}
+
+// AlertManager notifies the alerts of a test when its alert state changes,
+// instead of on every failed run.
+type AlertManager interface {
+	HandleResult(test *models.Test, result *models.TestResult)
+	// Shutdown waits for the notifications being delivered until ctx is done.
+	Shutdown(ctx context.Context) error
+}
+
+// AlertManagerConfig is where the links in the alerts point to.
+type AlertManagerConfig interface {
+	GetPublicURL() string
+}
+
+type TestAlertManager struct {
+	alertStateRepository    repositories.AlertStateRepository
+	alertDeliveryRepository repositories.AlertDeliveryRepository
+	channelResolver         ChannelResolver
+	escalator               Escalator
+	incidentRecorder        IncidentRecorder
+	resultHub               ResultHub
+	config                  AlertManagerConfig
+
+	// mux guards locks and notifying.
+	mux sync.Mutex
+	// locks serializes the transitions of a test, because a manual run may race with a scheduled one.
+	locks map[string]*alertTestLock
+	// notifying is closed when the last notification of a test was delivered,
+	// so that the notifications of a test are still delivered in order.
+	notifying  map[string]chan bool
+	delivering sync.WaitGroup
+}
+
+type alertTestLock struct {
+	mux  sync.Mutex
+	refs int
+}
+
+func (manager *TestAlertManager) HandleResult(test *models.Test, result *models.TestResult) {
+	if result.InMaintenance {
+		return
+	}
+
+	unlock := manager.lock(test.Id)
+	state, err := manager.alertStateRepository.GetByTestId(rsdb.GetConnection(), test.Id)
+	if err != nil {
+		unlock()
+		rslog.Errorf("failed to get alert state: testId='%s', error='%v'", test.Id, err)
+		return
+	}
+	previousStatus := state.Status
+	event := state.Transition(result, test.AlertPolicy)
+	if err := manager.alertStateRepository.Save(rsdb.GetConnection(), state); err != nil {
+		rslog.Errorf("failed to save alert state: testId='%s', error='%v'", test.Id, err)
+	}
+	unlock()
+
+	if state.Status != previousStatus {
+		manager.resultHub.Publish(models.NewAlertStateStreamEvent(test, previousStatus, *state))
+	}
+
+	switch event {
+	case models.AlertEventDown:
+		rslog.Infof("test is down: testId='%s', failures='%d'", test.Id, state.ConsecutiveFailures)
+	case models.AlertEventRecovered:
+		rslog.Infof("test is recovered: testId='%s', outage='%v'", test.Id, state.OutageDuration())
+	default:
+		return
+	}
+
+	data := models.NewAlertMessageData(test, result, state, event, alertLink(manager.config, test))
+	deliveries := manager.deliveries(test, data)
+	switch event {
+	case models.AlertEventDown:
+		manager.incidentRecorder.Open(test, result)
+		deliveries = append(deliveries, manager.escalator.Start(test, data)...)
+	case models.AlertEventRecovered:
+		manager.incidentRecorder.Close(test, result)
+		deliveries = append(deliveries, manager.escalator.Resolve(test, data)...)
+	}
+	manager.deliver(test.Id, deliveries)
+}
+
+// lock locks the transitions of the test and returns the function to unlock them.
+func (manager *TestAlertManager) lock(testId string) (unlock func()) {
+	manager.mux.Lock()
+	testLock, ok := manager.locks[testId]
+	if !ok {
+		testLock = &alertTestLock{}
+		manager.locks[testId] = testLock
+	}
+	testLock.refs++
+	manager.mux.Unlock()
+
+	testLock.mux.Lock()
+	return func() {
+		testLock.mux.Unlock()
+		manager.mux.Lock()
+		testLock.refs--
+		if testLock.refs == 0 {
+			delete(manager.locks, testId)
+		}
+		manager.mux.Unlock()
+	}
+}
+
+// deliveries renders the message of each enabled alert of the test, without sending it.
+func (manager *TestAlertManager) deliveries(test *models.Test, data models.AlertMessageData) []*models.AlertDelivery {
+	deliveries := make([]*models.AlertDelivery, 0)
+	for _, alert := range manager.alerts(test) {
+		if alert.Disabled {
+			continue
+		}
+		message, err := renderAlert(alert, data)
+		if err != nil {
+			rslog.Error(err)
+			continue
+		}
+		deliveries = append(deliveries, models.NewAlertDelivery(test.Id, *alert, message))
+	}
+	return deliveries
+}
+
+// deliver sends the deliveries in the background, after the previous notification of the test was delivered.
+func (manager *TestAlertManager) deliver(testId string, deliveries []*models.AlertDelivery) {
+	if len(deliveries) == 0 {
+		return
+	}
+
+	manager.mux.Lock()
+	previous := manager.notifying[testId]
+	done := make(chan bool)
+	manager.notifying[testId] = done
+	manager.delivering.Add(1)
+	manager.mux.Unlock()
+
+	go func() {
+		defer manager.delivering.Done()
+		if previous != nil {
+			<-previous
+		}
+		for _, delivery := range deliveries {
+			deliverAlert(manager.alertDeliveryRepository, delivery)
+		}
+		close(done)
+
+		manager.mux.Lock()
+		if manager.notifying[testId] == done {
+			delete(manager.notifying, testId)
+		}
+		manager.mux.Unlock()
+	}()
+}
+
+func (manager *TestAlertManager) Shutdown(ctx context.Context) error {
+	rslog.Info("Shutting down TestAlertManager...")
+	delivered := make(chan bool)
+	go func() {
+		manager.delivering.Wait()
+		close(delivered)
+	}()
+	select {
+	case <-delivered:
+		return nil
+	case <-ctx.Done():
+		return errors.WithStack(ctx.Err())
+	}
+}
+
+// alerts returns the alerts of the test followed by those of the notification channels bound to it.
+// The test is still alerted through its own alerts if the channels cannot be resolved.
+func (manager *TestAlertManager) alerts(test *models.Test) models.Alerts {
+	alerts := test.GetAlerts()
+	channelAlerts, err := manager.channelResolver.ResolveAlerts(test)
+	if err != nil {
+		rslog.Errorf("failed to resolve notification channels: testId='%s', error='%v'", test.Id, err)
+		return alerts
+	}
+	return append(append(models.Alerts{}, alerts...), channelAlerts...)
+}
+
+// renderAlert renders the message of the alert, or the default message if its template fails.
+func renderAlert(alert *models.Alert, data models.AlertMessageData) (rsnotify.Message, error) {
+	message, err := alert.Message(data)
+	if err == nil {
+		return message, nil
+	}
+	// A template which passed the validation may still fail on real data, like a nil field.
+	rslog.Errorf("failed to render alert template, the default message is sent: testId='%s', error='%v'", data.Test.Id, err)
+	message, err = data.Message()
+	return message, errors.WithStack(err)
+}
+
+// deliverAlert sends the delivery with retries and records every attempt.
+func deliverAlert(repository repositories.AlertDeliveryRepository, delivery *models.AlertDelivery) {
+	attempts, err := delivery.Send(context.Background())
+	if err != nil {
+		rslog.Errorf("failed to deliver alert: deliveryId='%s', testId='%s', error='%v'", delivery.Id, delivery.TestId, err)
+	}
+	delivery.SetAttempts(attempts, err)
+	observeAlertDelivery(delivery)
+	if err := repository.Create(rsdb.GetConnection(), delivery); err != nil {
+		rslog.Errorf("failed to save alert delivery: deliveryId='%s', error='%v'", delivery.Id, err)
+	}
+}
+
+// alertLink is the page of the test, or empty if the public URL is not configured.
+func alertLink(config AlertManagerConfig, test *models.Test) string {
+	publicURL := strings.TrimSuffix(config.GetPublicURL(), "/")
+	if publicURL == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/tests/%s", publicURL, test.Id)
+}
+
+func NewTestAlertManager(
+	alertStateRepository repositories.AlertStateRepository,
+	alertDeliveryRepository repositories.AlertDeliveryRepository,
+	channelResolver ChannelResolver,
+	escalator Escalator,
+	incidentRecorder IncidentRecorder,
+	resultHub ResultHub,
+	config AlertManagerConfig,
+) (AlertManager, error) {
+	if rsvalid.IsZero(alertStateRepository, alertDeliveryRepository, channelResolver, escalator, incidentRecorder, resultHub, config) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "AlertManager")
+	}
+	return &TestAlertManager{
+		alertStateRepository:    alertStateRepository,
+		alertDeliveryRepository: alertDeliveryRepository,
+		channelResolver:         channelResolver,
+		escalator:               escalator,
+		incidentRecorder:        incidentRecorder,
+		resultHub:               resultHub,
+		config:                  config,
+		locks:                   make(map[string]*alertTestLock),
+		notifying:               make(map[string]chan bool),
+	}, nil
+}
+
+type AlertService interface {
+	SendTestAlert(alert *models.Alert) *amerr.ErrorWithLanguage
+	GetDelivery(delivery *models.AlertDelivery) *amerr.ErrorWithLanguage
+	GetDeliveryList(request models.AlertDeliveryListRequest) (*rsmodels.PaginatedList, *amerr.ErrorWithLanguage)
+	ResendDelivery(delivery *models.AlertDelivery) (*models.AlertDelivery, *amerr.ErrorWithLanguage)
+}
+
+type AlertServiceImpl struct {
+	alertDeliveryRepository repositories.AlertDeliveryRepository
+	testRepository          repositories.TestRepository
+	channelResolver         ChannelResolver
+}
+
+// SendTestAlert sends a test message, even if the alert is disabled, and reports whether it was delivered.
+func (service *AlertServiceImpl) SendTestAlert(alert *models.Alert) *amerr.ErrorWithLanguage {
+	if err := alert.Validate(); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest)
+	}
+	if _, err := alert.Send(context.Background(), rsnotify.NewMessage(testAlertMessage)); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrAlertDeliveryFailed)
+	}
+	return nil
+}
+
+func (service *AlertServiceImpl) GetDelivery(delivery *models.AlertDelivery) *amerr.ErrorWithLanguage {
+	if rsvalid.IsZero(delivery) {
+		rslog.Error(errors.Wrap(rserrors.ErrInvalidParameter, "AlertDelivery"))
+		return amerr.GetErrInternalServer()
+	}
+
+	if rsvalid.IsZero(delivery.Id) {
+		return amerr.GetErrorsFromCode(amerr.ErrAlertDeliveryNotFound)
+	}
+
+	if err := service.alertDeliveryRepository.GetById(rsdb.GetConnection(), delivery); err != nil {
+		switch err {
+		case rsdb.ErrRecordNotFound:
+			return amerr.GetErrorsFromCode(amerr.ErrAlertDeliveryNotFound)
+		default:
+			rslog.Error(err)
+			return amerr.GetErrInternalServer()
+		}
+	}
+	return nil
+}
+
+func (service *AlertServiceImpl) GetDeliveryList(request models.AlertDeliveryListRequest) (*rsmodels.PaginatedList, *amerr.ErrorWithLanguage) {
+	if err := request.IsSuccess.Validate(); err != nil {
+		rslog.Error(err)
+		return nil, amerr.GetErrorsFromCode(amerr.ErrBadRequest)
+	}
+
+	conditions := map[string]interface{}{}
+	if request.TestId != "" {
+		conditions["test_id"] = request.TestId
+	}
+	if !request.IsSuccess.IsBoth() {
+		conditions["success"], _ = strconv.ParseBool(request.IsSuccess.String())
+	}
+
+	items := make([]*models.AlertDelivery, 0)
+	totalCount, err := service.alertDeliveryRepository.List(rsdb.GetConnection(), &items, rsdb.ListFilter{
+		Page:       request.Page,
+		NumItem:    request.NumItem,
+		Conditions: conditions,
+	}, rsdb.Orders{
+		{
+			Field: "created_at",
+			IsASC: false,
+		},
+	})
+	if err != nil {
+		rslog.Error(err)
+		return nil, amerr.GetErrInternalServer()
+	}
+
+	return &rsmodels.PaginatedList{
+		CurrentPage: request.Page,
+		NumItem:     request.NumItem,
+		TotalCount:  totalCount,
+		Items:       items,
+	}, nil
+}
+
+// ResendDelivery sends the message of the delivery again with the same alert, even if it is disabled now.
+// The new delivery is returned whether or not it succeeded.
+func (service *AlertServiceImpl) ResendDelivery(delivery *models.AlertDelivery) (*models.AlertDelivery, *amerr.ErrorWithLanguage) {
+	if err := service.GetDelivery(delivery); err != nil {
+		return nil, err
+	}
+	resend := delivery.Resend(service.alert(delivery))
+	deliverAlert(service.alertDeliveryRepository, resend)
+	return resend, nil
+}
+
+// alert returns the alert of the delivery with its secret, which is not stored with the delivery,
+// from the alerts the test has now. Without it, e.g. after the alert was removed, the delivery is sent unsigned.
+func (service *AlertServiceImpl) alert(delivery *models.AlertDelivery) models.Alert {
+	test := &models.Test{Id: delivery.TestId}
+	if err := service.testRepository.GetById(rsdb.GetConnection(), test); err != nil {
+		rslog.Errorf("failed to get test of alert delivery: deliveryId='%s', testId='%s', error='%v'", delivery.Id, delivery.TestId, err)
+		return delivery.Alert
+	}
+	alerts := test.GetAlerts()
+	if channelAlerts, err := service.channelResolver.ResolveAlerts(test); err != nil {
+		rslog.Errorf("failed to resolve notification channels: testId='%s', error='%v'", test.Id, err)
+	} else {
+		alerts = append(append(models.Alerts{}, alerts...), channelAlerts...)
+	}
+	for _, alert := range alerts {
+		if alert.IsSameNotifier(delivery.Alert) {
+			return *alert
+		}
+	}
+	rslog.Warnf("alert of delivery is no longer configured, it is resent without its secret: deliveryId='%s', testId='%s'", delivery.Id, delivery.TestId)
+	return delivery.Alert
+}
+
+func NewAlertService(
+	alertDeliveryRepository repositories.AlertDeliveryRepository,
+	testRepository repositories.TestRepository,
+	channelResolver ChannelResolver,
+) (AlertService, error) {
+	if rsvalid.IsZero(alertDeliveryRepository, testRepository, channelResolver) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "AlertService")
+	}
+	return &AlertServiceImpl{
+		alertDeliveryRepository: alertDeliveryRepository,
+		testRepository:          testRepository,
+		channelResolver:         channelResolver,
+	}, nil
+}
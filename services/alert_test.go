@@ -0,0 +1,353 @@
+package services
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rsnotify"
+	"github.com/realsangil/apimonitor/repositories/mocks"
+)
+
+type fakeAlertManagerConfig struct {
+	publicURL string
+}
+
+func (config *fakeAlertManagerConfig) GetPublicURL() string {
+	return config.publicURL
+}
+
+type fakeChannelResolver struct {
+	alerts models.Alerts
+}
+
+func (resolver *fakeChannelResolver) ResolveAlerts(*models.Test) (models.Alerts, error) {
+	return resolver.alerts, nil
+}
+
+type fakeEscalator struct {
+	started  []string
+	resolved []string
+	// deliveries are returned by Start as the due steps.
+	deliveries []*models.AlertDelivery
+}
+
+func (escalator *fakeEscalator) Run() error {
+	return nil
+}
+
+func (escalator *fakeEscalator) Shutdown(context.Context) error {
+	return nil
+}
+
+func (escalator *fakeEscalator) Start(test *models.Test, data models.AlertMessageData) []*models.AlertDelivery {
+	escalator.started = append(escalator.started, test.Id)
+	return escalator.deliveries
+}
+
+func (escalator *fakeEscalator) Resolve(test *models.Test, data models.AlertMessageData) []*models.AlertDelivery {
+	escalator.resolved = append(escalator.resolved, test.Id)
+	return nil
+}
+
+type fakeIncidentRecorder struct {
+	opened []string
+	closed []string
+}
+
+func (recorder *fakeIncidentRecorder) Open(test *models.Test, result *models.TestResult) {
+	recorder.opened = append(recorder.opened, result.Id)
+}
+
+func (recorder *fakeIncidentRecorder) Close(test *models.Test, result *models.TestResult) {
+	recorder.closed = append(recorder.closed, result.Id)
+}
+
+func (recorder *fakeIncidentRecorder) Remove(test *models.Test) {}
+
+func TestTestAlertManager_HandleResult(t *testing.T) {
+	var (
+		mux      sync.Mutex
+		messages []string
+	)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]string{}
+		_ = jsoniter.NewDecoder(r.Body).Decode(&body)
+		mux.Lock()
+		messages = append(messages, body["text"])
+		mux.Unlock()
+	}))
+	defer webhook.Close()
+
+	// The repository keeps the state like the database would, across calls.
+	state := models.NewAlertState("test")
+	repository := &mocks.AlertStateRepository{}
+	repository.On("GetByTestId", mock.Anything, "test").Return(func(rsdb.Connection, string) *models.AlertState {
+		copied := *state
+		return &copied
+	}, nil)
+	repository.On("Save", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		state = args.Get(1).(*models.AlertState)
+	})
+
+	var deliveries []*models.AlertDelivery
+	deliveryRepository := &mocks.AlertDeliveryRepository{}
+	deliveryRepository.On("Create", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		deliveries = append(deliveries, args.Get(1).(*models.AlertDelivery))
+	})
+
+	escalator := &fakeEscalator{}
+	incidentRecorder := &fakeIncidentRecorder{}
+	resultHub := newTestResultHub(t)
+	subscription := resultHub.Subscribe(models.StreamFilter{TestId: "test"})
+	manager, err := NewTestAlertManager(repository, deliveryRepository, &fakeChannelResolver{}, escalator, incidentRecorder, resultHub, &fakeAlertManagerConfig{publicURL: "https://apimonitor.example.com/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test := &models.Test{
+		Id:   "test",
+		Name: "health",
+		Alerts: models.Alerts{
+			{Type: rsnotify.TypeWebhook, Config: []byte(`{"url": "` + webhook.URL + `"}`)},
+			{Type: rsnotify.TypeWebhook, Config: []byte(`{"url": "` + webhook.URL + `"}`), Template: "{{.Status}}: {{.Test.Name}} {{.Link}}"},
+		},
+		AlertPolicy: models.AlertPolicy{FailureThreshold: 2},
+	}
+	startedAt := time.Now().Add(-time.Hour)
+	for i, isSuccess := range []bool{false, false, false, false, true, true} {
+		manager.HandleResult(test, &models.TestResult{
+			Id:        "result-" + strconv.Itoa(i),
+			TestId:    test.Id,
+			IsSuccess: isSuccess,
+			TestedAt:  startedAt.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	assert.NoError(t, manager.Shutdown(context.Background()))
+
+	mux.Lock()
+	defer mux.Unlock()
+	if assert.Len(t, messages, 4) {
+		assert.Contains(t, messages[0], "[DOWN] health")
+		assert.Contains(t, messages[0], "https://apimonitor.example.com/tests/test")
+		assert.Equal(t, "DOWN: health https://apimonitor.example.com/tests/test", messages[1])
+		assert.Contains(t, messages[2], "[RECOVERED] health")
+		assert.Contains(t, messages[2], "4m0s")
+		assert.Equal(t, "RECOVERED: health https://apimonitor.example.com/tests/test", messages[3])
+	}
+	assert.Equal(t, models.AlertStatusOk, state.Status)
+	assert.Equal(t, []string{"test"}, escalator.started)
+	assert.Equal(t, []string{"test"}, escalator.resolved)
+	assert.Equal(t, []string{"result-1"}, incidentRecorder.opened)
+	assert.Equal(t, []string{"result-4"}, incidentRecorder.closed)
+	if assert.Len(t, deliveries, 4) {
+		for _, delivery := range deliveries {
+			assert.Equal(t, "test", delivery.TestId)
+			assert.True(t, delivery.Success)
+			assert.Equal(t, http.StatusOK, delivery.StatusCode)
+			assert.Len(t, delivery.Attempts, 1)
+		}
+	}
+
+	subscription.Close()
+	var transitions []string
+	for event := range subscription.Events() {
+		assert.Equal(t, models.StreamEventAlertState, event.Type)
+		transitions = append(transitions, string(event.PreviousStatus)+"->"+string(event.AlertState.Status))
+	}
+	assert.Equal(t, []string{"ok->failing", "failing->alerting", "alerting->recovered", "recovered->ok"}, transitions)
+}
+
+func TestAlertServiceImpl_ResendDelivery(t *testing.T) {
+	tests := []struct {
+		name           string
+		statusCode     int
+		removed        bool
+		wantSuccess    bool
+		wantStatusCode int
+		wantSigned     bool
+	}{
+		{name: "delivered", statusCode: http.StatusOK, wantSuccess: true, wantStatusCode: http.StatusOK, wantSigned: true},
+		{name: "rejected", statusCode: http.StatusBadRequest, wantSuccess: false, wantStatusCode: http.StatusBadRequest, wantSigned: true},
+		{name: "alert removed", statusCode: http.StatusOK, removed: true, wantSuccess: true, wantStatusCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var verifyErr error
+			webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				verifyErr = rsnotify.Verify(
+					"s3cr3t",
+					r.Header.Get(rsnotify.HeaderSignature),
+					r.Header.Get(rsnotify.HeaderTimestamp),
+					body,
+					time.Minute,
+					time.Now(),
+				)
+				w.WriteHeader(tt.statusCode)
+			}))
+			defer webhook.Close()
+
+			alert := &models.Alert{
+				Type:     rsnotify.TypeWebhook,
+				Disabled: true,
+				Config:   []byte(`{"url": "` + webhook.URL + `", "secret": "s3cr3t"}`),
+			}
+			sent := models.NewAlertDelivery("test", *alert, rsnotify.NewMessage("[DOWN] health"))
+			// The delivery as it is read from the database, without the secret.
+			original := models.AlertDelivery{Id: sent.Id, TestId: sent.TestId, Alert: sent.Alert, Text: sent.Text, CreatedAt: sent.CreatedAt}
+
+			var created *models.AlertDelivery
+			repository := &mocks.AlertDeliveryRepository{}
+			repository.On("GetById", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+				*args.Get(1).(*models.AlertDelivery) = original
+			})
+			repository.On("Create", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+				created = args.Get(1).(*models.AlertDelivery)
+			})
+			testRepository := &mocks.TestRepository{}
+			testRepository.On("GetById", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+				test := args.Get(1).(*models.Test)
+				if !tt.removed {
+					test.Alerts = models.Alerts{alert}
+				}
+			})
+
+			service, err := NewAlertService(repository, testRepository, &fakeChannelResolver{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			resend, aerr := service.ResendDelivery(&models.AlertDelivery{Id: original.Id})
+			if aerr != nil {
+				t.Fatal(aerr)
+			}
+
+			if tt.wantSigned {
+				assert.NoError(t, verifyErr)
+			} else {
+				assert.Error(t, verifyErr)
+			}
+			assert.Equal(t, resend, created)
+			assert.NotContains(t, string(resend.Alert.Config), "s3cr3t")
+			assert.Equal(t, original.Id, resend.ResendOf)
+			assert.NotEqual(t, original.Id, resend.Id)
+			assert.Equal(t, "[DOWN] health", resend.Text)
+			assert.Equal(t, tt.wantSuccess, resend.Success)
+			assert.Equal(t, tt.wantStatusCode, resend.StatusCode)
+			assert.Len(t, resend.Attempts, 1)
+		})
+	}
+}
+
+func TestAlertServiceImpl_GetDelivery_NotFound(t *testing.T) {
+	repository := &mocks.AlertDeliveryRepository{}
+	repository.On("GetById", mock.Anything, mock.Anything).Return(rsdb.ErrRecordNotFound)
+
+	service, err := NewAlertService(repository, &mocks.TestRepository{}, &fakeChannelResolver{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	aerr := service.GetDelivery(&models.AlertDelivery{Id: "unknown"})
+	if assert.NotNil(t, aerr) {
+		assert.Equal(t, amerr.GetErrorsFromCode(amerr.ErrAlertDeliveryNotFound), aerr)
+	}
+}
+
+func TestTestAlertManager_HandleResult_SlowDelivery(t *testing.T) {
+	release := make(chan bool)
+	delivered := make(chan string, 2)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-release
+		}
+		delivered <- r.URL.Path
+	}))
+	defer webhook.Close()
+
+	repository := &mocks.AlertStateRepository{}
+	repository.On("GetByTestId", mock.Anything, mock.Anything).Return(func(_ rsdb.Connection, testId string) *models.AlertState {
+		return models.NewAlertState(testId)
+	}, nil)
+	repository.On("Save", mock.Anything, mock.Anything).Return(nil)
+	deliveryRepository := &mocks.AlertDeliveryRepository{}
+	deliveryRepository.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	manager, err := NewTestAlertManager(repository, deliveryRepository, &fakeChannelResolver{}, &fakeEscalator{}, &fakeIncidentRecorder{}, newTestResultHub(t), &fakeAlertManagerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"slow", "fast"} {
+		test := &models.Test{
+			Id:          name,
+			Name:        name,
+			Alerts:      models.Alerts{{Type: rsnotify.TypeWebhook, Config: []byte(`{"url": "` + webhook.URL + `/` + name + `"}`)}},
+			AlertPolicy: models.AlertPolicy{FailureThreshold: 1},
+		}
+		manager.HandleResult(test, &models.TestResult{Id: name, TestId: test.Id, IsSuccess: false, TestedAt: time.Now()})
+	}
+
+	// The alert of the other test is not held up by the slow webhook.
+	select {
+	case path := <-delivered:
+		assert.Equal(t, "/fast", path)
+	case <-time.After(time.Second):
+		t.Fatal("alert was not delivered")
+	}
+
+	close(release)
+	assert.NoError(t, manager.Shutdown(context.Background()))
+	assert.Equal(t, "/slow", <-delivered)
+}
+
+func TestTestAlertManager_HandleResult_SlowEscalation(t *testing.T) {
+	release := make(chan bool)
+	delivered := make(chan string, 1)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		delivered <- r.URL.Path
+	}))
+	defer webhook.Close()
+
+	repository := &mocks.AlertStateRepository{}
+	repository.On("GetByTestId", mock.Anything, mock.Anything).Return(models.NewAlertState("test"), nil)
+	repository.On("Save", mock.Anything, mock.Anything).Return(nil)
+	deliveryRepository := &mocks.AlertDeliveryRepository{}
+	deliveryRepository.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	oncall := models.Alert{Type: rsnotify.TypeWebhook, Config: []byte(`{"url": "` + webhook.URL + `/oncall"}`)}
+	escalator := &fakeEscalator{deliveries: []*models.AlertDelivery{models.NewAlertDelivery("test", oncall, rsnotify.Message{Text: "DOWN"})}}
+	manager, err := NewTestAlertManager(repository, deliveryRepository, &fakeChannelResolver{}, escalator, &fakeIncidentRecorder{}, newTestResultHub(t), &fakeAlertManagerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The run which brought the test down is not held up by the slow escalation channel.
+	handled := make(chan bool)
+	go func() {
+		test := &models.Test{Id: "test", Name: "health", AlertPolicy: models.AlertPolicy{FailureThreshold: 1}}
+		manager.HandleResult(test, &models.TestResult{Id: "result", TestId: test.Id, IsSuccess: false, TestedAt: time.Now()})
+		close(handled)
+	}()
+	select {
+	case <-handled:
+	case <-time.After(time.Second):
+		t.Fatal("result was held up by the escalation")
+	}
+
+	close(release)
+	assert.NoError(t, manager.Shutdown(context.Background()))
+	assert.Equal(t, "/oncall", <-delivered)
+}
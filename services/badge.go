@@ -0,0 +1,245 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsstats"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/repositories"
+)
+
+const DefaultBadgeCacheMaxAge = 5 * time.Minute
+
+type BadgeConfig interface {
+	GetCacheMaxAge() time.Duration
+}
+
+var _ BadgeService = &BadgeServiceImpl{}
+
+// BadgeService makes the badges of the tests and the web services, to be embedded in READMEs and wikis.
+type BadgeService interface {
+	GetTestBadge(test *models.Test, request models.BadgeRequest) (*models.Badge, *amerr.ErrorWithLanguage)
+	GetWebServiceBadge(webService *models.WebService, request models.BadgeRequest) (*models.Badge, *amerr.ErrorWithLanguage)
+	// CacheMaxAge is how long a badge may be cached.
+	CacheMaxAge() time.Duration
+}
+
+// BadgeServiceImpl reads the status from the alert states, the uptime from UptimeService
+// and the latency from StatisticsService, so a badge tells the same as the API.
+// The badges are public, so each is reused for the cache max age, like the status page.
+type BadgeServiceImpl struct {
+	testRepository       repositories.TestRepository
+	alertStateRepository repositories.AlertStateRepository
+	maintenanceChecker   MaintenanceChecker
+	uptimeService        UptimeService
+	statisticsService    StatisticsService
+	cacheMaxAge          time.Duration
+
+	mux    sync.Mutex
+	badges map[badgeCacheKey]cachedBadge
+}
+
+// badgeCacheKey is what a badge depends on apart from its label, which is set on the cached badge.
+type badgeCacheKey struct {
+	scope     string
+	id        string
+	badgeType models.BadgeType
+	window    string
+}
+
+type cachedBadge struct {
+	badge     models.Badge
+	expiresAt time.Time
+}
+
+func (service *BadgeServiceImpl) GetTestBadge(test *models.Test, request models.BadgeRequest) (*models.Badge, *amerr.ErrorWithLanguage) {
+	key := badgeCacheKey{scope: "test", id: test.Id, badgeType: request.Type, window: request.Window}
+	return service.cached(key, request, func(request models.BadgeRequest) (models.Badge, *amerr.ErrorWithLanguage) {
+		return service.testBadge(test, request)
+	})
+}
+
+func (service *BadgeServiceImpl) GetWebServiceBadge(webService *models.WebService, request models.BadgeRequest) (*models.Badge, *amerr.ErrorWithLanguage) {
+	key := badgeCacheKey{scope: "webService", id: webService.Id, badgeType: request.Type, window: request.Window}
+	return service.cached(key, request, func(request models.BadgeRequest) (models.Badge, *amerr.ErrorWithLanguage) {
+		return service.webServiceBadge(webService, request)
+	})
+}
+
+func (service *BadgeServiceImpl) CacheMaxAge() time.Duration {
+	return service.cacheMaxAge
+}
+
+// cached returns the badge of key with the label of the request, and builds it without the label once it expired.
+// The expired badges are dropped whenever one is built, so that the cache holds no more than the badges in use.
+func (service *BadgeServiceImpl) cached(
+	key badgeCacheKey,
+	request models.BadgeRequest,
+	build func(request models.BadgeRequest) (models.Badge, *amerr.ErrorWithLanguage),
+) (*models.Badge, *amerr.ErrorWithLanguage) {
+	now := time.Now()
+	service.mux.Lock()
+	cached, ok := service.badges[key]
+	service.mux.Unlock()
+
+	if !ok || !now.Before(cached.expiresAt) {
+		unlabeled := request
+		unlabeled.Label = ""
+		badge, err := build(unlabeled)
+		if err != nil {
+			return nil, err
+		}
+		cached = cachedBadge{badge: badge, expiresAt: now.Add(service.cacheMaxAge)}
+
+		service.mux.Lock()
+		for cachedKey, cachedBadge := range service.badges {
+			if !now.Before(cachedBadge.expiresAt) {
+				delete(service.badges, cachedKey)
+			}
+		}
+		service.badges[key] = cached
+		service.mux.Unlock()
+	}
+
+	badge := cached.badge.WithLabel(request.Label)
+	return &badge, nil
+}
+
+func (service *BadgeServiceImpl) testBadge(test *models.Test, request models.BadgeRequest) (models.Badge, *amerr.ErrorWithLanguage) {
+	var badge models.Badge
+	switch request.Type {
+	case models.BadgeUptime:
+		uptime, err := service.uptimeService.GetTestUptime(test, request.UptimeRequest())
+		if err != nil {
+			return badge, err
+		}
+		badge = models.NewUptimeBadge(request, *uptime)
+	case models.BadgeLatency:
+		statistics, err := service.statisticsService.GetLatencyStatistics(test, request.LatencyStatisticsRequest())
+		if err != nil {
+			return badge, err
+		}
+		badge = models.NewLatencyBadge(request, statistics.Summary)
+	default:
+		status, err := service.testStatus(test, request.To)
+		if err != nil {
+			rslog.Error(err)
+			return badge, amerr.GetErrInternalServer()
+		}
+		badge = models.NewStatusBadge(request, status)
+	}
+	return badge, nil
+}
+
+func (service *BadgeServiceImpl) webServiceBadge(webService *models.WebService, request models.BadgeRequest) (models.Badge, *amerr.ErrorWithLanguage) {
+	var badge models.Badge
+	switch request.Type {
+	case models.BadgeUptime:
+		uptime, err := service.uptimeService.GetWebServiceUptime(webService, request.UptimeRequest())
+		if err != nil {
+			return badge, err
+		}
+		badge = models.NewUptimeBadge(request, *uptime)
+	case models.BadgeLatency:
+		summary, err := service.webServiceLatency(webService, request)
+		if err != nil {
+			return badge, err
+		}
+		badge = models.NewLatencyBadge(request, summary)
+	default:
+		status, err := service.webServiceStatus(webService, request.To)
+		if err != nil {
+			rslog.Error(err)
+			return badge, amerr.GetErrInternalServer()
+		}
+		badge = models.NewStatusBadge(request, status)
+	}
+	return badge, nil
+}
+
+// testStatus is empty, shown as unknown, for a test never run.
+func (service *BadgeServiceImpl) testStatus(test *models.Test, now time.Time) (models.ComponentStatus, error) {
+	if test.LastRunAt == nil {
+		return "", nil
+	}
+	state, err := service.alertStateRepository.GetByTestId(rsdb.GetConnection(), test.Id)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	inMaintenance, err := service.maintenanceChecker.InMaintenance(test.WebServiceId, now)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return models.NewWebServiceStatus(map[string]models.AlertStatus{test.Id: state.Status}, inMaintenance), nil
+}
+
+func (service *BadgeServiceImpl) webServiceStatus(webService *models.WebService, now time.Time) (models.ComponentStatus, error) {
+	alertStatuses, err := service.alertStateRepository.GetStatusesByWebServiceId(rsdb.GetConnection(), webService.Id)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	inMaintenance, err := service.maintenanceChecker.InMaintenance(webService.Id, now)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return models.NewWebServiceStatus(alertStatuses, inMaintenance), nil
+}
+
+// webServiceLatency is the mean response time of all the results of the tests of the web service.
+func (service *BadgeServiceImpl) webServiceLatency(webService *models.WebService, request models.BadgeRequest) (rsstats.Summary, *amerr.ErrorWithLanguage) {
+	var summary rsstats.Summary
+	tests := make([]*models.Test, 0)
+	filter := rsdb.ListFilter{
+		Conditions: map[string]interface{}{
+			"web_service_id": webService.Id,
+		},
+	}
+	if _, err := service.testRepository.GetList(rsdb.GetConnection(), &tests, filter, nil); err != nil {
+		rslog.Error(err)
+		return summary, amerr.GetErrInternalServer()
+	}
+
+	total := 0.0
+	for _, test := range tests {
+		statistics, err := service.statisticsService.GetLatencyStatistics(test, request.LatencyStatisticsRequest())
+		if err != nil {
+			return summary, err
+		}
+		summary.Count += statistics.Summary.Count
+		total += statistics.Summary.Mean * float64(statistics.Summary.Count)
+	}
+	if summary.Count > 0 {
+		summary.Mean = total / float64(summary.Count)
+	}
+	return summary, nil
+}
+
+func NewBadgeService(
+	testRepository repositories.TestRepository,
+	alertStateRepository repositories.AlertStateRepository,
+	maintenanceChecker MaintenanceChecker,
+	uptimeService UptimeService,
+	statisticsService StatisticsService,
+	config BadgeConfig,
+) (BadgeService, error) {
+	if rsvalid.IsZero(testRepository, alertStateRepository, maintenanceChecker, uptimeService, statisticsService, config) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "BadgeService")
+	}
+	return &BadgeServiceImpl{
+		testRepository:       testRepository,
+		alertStateRepository: alertStateRepository,
+		maintenanceChecker:   maintenanceChecker,
+		uptimeService:        uptimeService,
+		statisticsService:    statisticsService,
+		cacheMaxAge:          orDefaultDuration(config.GetCacheMaxAge(), DefaultBadgeCacheMaxAge),
+		badges:               make(map[badgeCacheKey]cachedBadge),
+	}, nil
+}
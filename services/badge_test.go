@@ -0,0 +1,101 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rsbadge"
+	"github.com/realsangil/apimonitor/pkg/rsstats"
+	"github.com/realsangil/apimonitor/repositories/mocks"
+)
+
+type badgeConfig struct{}
+
+func (badgeConfig) GetCacheMaxAge() time.Duration { return 0 }
+
+type fakeStatisticsService struct {
+	summaries map[string]rsstats.Summary
+}
+
+func (service fakeStatisticsService) GetLatencyStatistics(test *models.Test, request models.LatencyStatisticsRequest) (*models.LatencyStatistics, *amerr.ErrorWithLanguage) {
+	summary := service.summaries[test.Id]
+	buckets := make([]models.LatencyBucket, 0)
+	if summary.Count > 0 {
+		buckets = append(buckets, models.LatencyBucket{Start: request.From, Summary: summary})
+	}
+	return &models.LatencyStatistics{From: request.From, To: request.To, Bucket: request.Bucket, Summary: summary, Buckets: buckets}, nil
+}
+
+func TestBadgeServiceImpl(t *testing.T) {
+	now := time.Date(2020, 3, 10, 12, 0, 0, 0, time.UTC)
+	lastRunAt := now.Add(-time.Minute)
+
+	testRepository := &mocks.TestRepository{}
+	testRepository.On("GetList", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		tests := args.Get(1).(*[]*models.Test)
+		*tests = []*models.Test{{Id: "a"}, {Id: "b"}, {Id: "c"}}
+	}).Return(3, nil)
+	alertStateRepository := &mocks.AlertStateRepository{}
+	alertStateRepository.On("GetByTestId", mock.Anything, "a").Return(&models.AlertState{TestId: "a", Status: models.AlertStatusAlerting}, nil)
+	alertStateRepository.On("GetStatusesByWebServiceId", mock.Anything, "web-service").Return(map[string]models.AlertStatus{
+		"a": models.AlertStatusAlerting,
+		"b": models.AlertStatusOk,
+	}, nil)
+	statisticsService := &fakeStatisticsService{summaries: map[string]rsstats.Summary{
+		"a": {Count: 1, Mean: 400},
+		"b": {Count: 3, Mean: 100},
+	}}
+
+	service, err := NewBadgeService(testRepository, alertStateRepository, &fakeMaintenanceChecker{}, &UptimeServiceImpl{}, statisticsService, &badgeConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, DefaultBadgeCacheMaxAge, service.CacheMaxAge())
+
+	status, err := models.NewBadgeRequest("status", "", "", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	badge, aerr := service.GetTestBadge(&models.Test{Id: "a", WebServiceId: "web-service", LastRunAt: &lastRunAt}, status)
+	if assert.Nil(t, aerr) {
+		assert.Equal(t, "down", badge.Message)
+	}
+	badge, aerr = service.GetTestBadge(&models.Test{Id: "new", WebServiceId: "web-service"}, status)
+	if assert.Nil(t, aerr) {
+		assert.Equal(t, "unknown", badge.Message)
+	}
+	badge, aerr = service.GetWebServiceBadge(&models.WebService{Id: "web-service"}, status)
+	if assert.Nil(t, aerr) {
+		assert.Equal(t, "degraded", badge.Message)
+		assert.Equal(t, rsbadge.ColorYellow, badge.Color)
+	}
+
+	// The mean of the web service weighs the tests by their results.
+	latency, err := models.NewBadgeRequest("latency", "7d", "", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	badge, aerr = service.GetWebServiceBadge(&models.WebService{Id: "web-service"}, latency)
+	if assert.Nil(t, aerr) {
+		assert.Equal(t, "latency 7d", badge.Label)
+		assert.Equal(t, "175ms", badge.Message)
+	}
+
+	// The badge is reused for the cache max age, whatever its label is.
+	statisticsService.summaries["a"] = rsstats.Summary{Count: 1, Mean: 5000}
+	labeled, err := models.NewBadgeRequest("latency", "7d", "api", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	badge, aerr = service.GetWebServiceBadge(&models.WebService{Id: "web-service"}, labeled)
+	if assert.Nil(t, aerr) {
+		assert.Equal(t, "api", badge.Label)
+		assert.Equal(t, "175ms", badge.Message)
+	}
+	testRepository.AssertNumberOfCalls(t, "GetList", 1)
+}
@@ -0,0 +1,302 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsstr"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/repositories"
+)
+
+const (
+	// CoordinatorModeStandalone runs every test on this process without touching the database.
+	CoordinatorModeStandalone = ""
+	// CoordinatorModeLeader runs every test on the oldest live replica only.
+	CoordinatorModeLeader = "leader"
+	// CoordinatorModeShard spreads the tests over the live replicas.
+	CoordinatorModeShard = "shard"
+
+	DefaultHeartbeatInterval = 5 * time.Second
+	DefaultReplicaTimeout    = 15 * time.Second
+)
+
+type CoordinatorConfig interface {
+	GetMode() string
+	GetReplicaId() string
+	GetHeartbeatInterval() time.Duration
+	GetReplicaTimeout() time.Duration
+}
+
+// Coordinator decides which replica runs a scheduled execution,
+// so that replicas sharing a database don't execute and alert twice.
+type Coordinator interface {
+	ScheduleRunner
+	ScheduleShutdowner
+	// Owns reports whether this replica is responsible for the key, e.g. a test id.
+	Owns(key string) bool
+	// IsLeader reports whether this replica should run the jobs which must run once.
+	IsLeader() bool
+	// Ready is closed once the first heartbeat was attempted.
+	Ready() <-chan bool
+}
+
+// ReplicaCoordinator keeps a heartbeat row for this process in the replicas table
+// and derives the ownership from the replicas whose heartbeat is still alive.
+// Keys are assigned by rendezvous hashing, so when a replica dies or joins
+// only the keys of that replica move.
+//
+// A replica which could not heartbeat within the replica timeout owns nothing,
+// because the others have already taken over its keys.
+type ReplicaCoordinator struct {
+	replicaRepository repositories.ReplicaRepository
+	replica           *models.Replica
+	mode              string
+	heartbeatInterval time.Duration
+	replicaTimeout    time.Duration
+
+	mux             sync.RWMutex
+	replicaIds      []string
+	lastHeartbeatAt time.Time
+
+	isRunning  bool
+	isShutdown bool
+	readyOnce  sync.Once
+	readyChan  chan bool
+	closeChan  chan bool
+	doneChan   chan bool
+}
+
+func (coordinator *ReplicaCoordinator) Run() error {
+	if coordinator.mode == CoordinatorModeStandalone {
+		return nil
+	}
+
+	coordinator.mux.Lock()
+	if coordinator.isShutdown {
+		coordinator.mux.Unlock()
+		return nil
+	}
+	coordinator.isRunning = true
+	coordinator.mux.Unlock()
+	defer close(coordinator.doneChan)
+
+	rslog.Infof("Running ReplicaCoordinator: mode='%s', replica='%s'", coordinator.mode, coordinator.replica.Id)
+	coordinator.heartbeat()
+	coordinator.markReady()
+
+	ticker := time.NewTicker(coordinator.heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			coordinator.heartbeat()
+		case <-coordinator.closeChan:
+			if err := coordinator.replicaRepository.DeleteById(rsdb.GetConnection(), coordinator.replica); err != nil {
+				rslog.Errorf("failed to unregister replica: replica='%s', error='%v'", coordinator.replica.Id, err)
+			}
+			rslog.Debug("Closed ReplicaCoordinator")
+			return nil
+		}
+	}
+}
+
+// Shutdown unregisters the replica, so the others take over its keys without waiting for the timeout.
+func (coordinator *ReplicaCoordinator) Shutdown(ctx context.Context) error {
+	coordinator.mux.Lock()
+	if coordinator.isShutdown {
+		coordinator.mux.Unlock()
+		return nil
+	}
+	coordinator.isShutdown = true
+	isRunning := coordinator.isRunning
+	coordinator.mux.Unlock()
+
+	if !isRunning {
+		coordinator.markReady()
+		return nil
+	}
+
+	close(coordinator.closeChan)
+	select {
+	case <-coordinator.doneChan:
+		return nil
+	case <-ctx.Done():
+		return errors.WithStack(ctx.Err())
+	}
+}
+
+func (coordinator *ReplicaCoordinator) Ready() <-chan bool {
+	return coordinator.readyChan
+}
+
+func (coordinator *ReplicaCoordinator) markReady() {
+	coordinator.readyOnce.Do(func() {
+		close(coordinator.readyChan)
+	})
+}
+
+func (coordinator *ReplicaCoordinator) Owns(key string) bool {
+	if coordinator.mode == CoordinatorModeStandalone {
+		return true
+	}
+
+	coordinator.mux.RLock()
+	defer coordinator.mux.RUnlock()
+	if !coordinator.isAlive() {
+		return false
+	}
+
+	switch coordinator.mode {
+	case CoordinatorModeLeader:
+		return coordinator.isLeader()
+	default:
+		return rendezvousOwner(coordinator.replicaIds, key) == coordinator.replica.Id
+	}
+}
+
+func (coordinator *ReplicaCoordinator) IsLeader() bool {
+	if coordinator.mode == CoordinatorModeStandalone {
+		return true
+	}
+
+	coordinator.mux.RLock()
+	defer coordinator.mux.RUnlock()
+	return coordinator.isAlive() && coordinator.isLeader()
+}
+
+func (coordinator *ReplicaCoordinator) isAlive() bool {
+	return !coordinator.lastHeartbeatAt.IsZero() && time.Since(coordinator.lastHeartbeatAt) < coordinator.replicaTimeout
+}
+
+func (coordinator *ReplicaCoordinator) isLeader() bool {
+	return len(coordinator.replicaIds) > 0 && coordinator.replicaIds[0] == coordinator.replica.Id
+}
+
+func (coordinator *ReplicaCoordinator) heartbeat() {
+	conn := rsdb.GetConnection()
+	if err := coordinator.replicaRepository.Heartbeat(conn, coordinator.replica); err != nil {
+		rslog.Errorf("failed to heartbeat: replica='%s', error='%v'", coordinator.replica.Id, err)
+		return
+	}
+	heartbeatAt := time.Now()
+
+	replicas, err := coordinator.replicaRepository.GetLiveReplicas(conn, coordinator.replicaTimeout)
+	if err != nil {
+		rslog.Errorf("failed to get live replicas: error='%v'", err)
+		return
+	}
+
+	replicaIds := make([]string, 0, len(replicas)+1)
+	hasSelf := false
+	for _, replica := range replicas {
+		replicaIds = append(replicaIds, replica.Id)
+		hasSelf = hasSelf || replica.Id == coordinator.replica.Id
+	}
+	if !hasSelf {
+		replicaIds = append(replicaIds, coordinator.replica.Id)
+	}
+
+	coordinator.mux.Lock()
+	changed := strings.Join(coordinator.replicaIds, ",") != strings.Join(replicaIds, ",")
+	coordinator.replicaIds = replicaIds
+	coordinator.lastHeartbeatAt = heartbeatAt
+	isLeader := coordinator.isLeader()
+	coordinator.mux.Unlock()
+
+	if changed {
+		rslog.Infof("live replicas changed: replicas='%v', leader='%v'", replicaIds, isLeader)
+	}
+
+	if isLeader {
+		if err := coordinator.replicaRepository.DeleteExpired(conn, coordinator.replicaTimeout); err != nil {
+			rslog.Errorf("failed to delete expired replicas: error='%v'", err)
+		}
+	}
+}
+
+// rendezvousOwner returns the replica with the highest hash for the key.
+func rendezvousOwner(replicaIds []string, key string) string {
+	var (
+		owner     string
+		maxWeight uint64
+	)
+	for _, replicaId := range replicaIds {
+		weight := rendezvousWeight(replicaId, key)
+		if owner == "" || weight > maxWeight || (weight == maxWeight && replicaId < owner) {
+			owner = replicaId
+			maxWeight = weight
+		}
+	}
+	return owner
+}
+
+func rendezvousWeight(replicaId, key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(replicaId))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(key))
+	// fnv alone spreads similar inputs poorly, so the sum is mixed once more.
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+func NewReplicaCoordinator(replicaRepository repositories.ReplicaRepository, config CoordinatorConfig) (Coordinator, error) {
+	if rsvalid.IsZero(replicaRepository, config) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "ReplicaCoordinator")
+	}
+
+	mode := config.GetMode()
+	switch mode {
+	case CoordinatorModeStandalone, CoordinatorModeLeader, CoordinatorModeShard:
+	default:
+		return nil, errors.Wrapf(rserrors.ErrInvalidParameter, "coordinator mode: '%s'", mode)
+	}
+
+	hostname, _ := os.Hostname()
+	replicaId := config.GetReplicaId()
+	if replicaId == "" {
+		replicaId = fmt.Sprintf("%s-%s", hostname, rsstr.NewUUIDWithoutHyphen()[:8])
+	}
+	replica, err := models.NewReplica(replicaId, hostname)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	heartbeatInterval := orDefaultDuration(config.GetHeartbeatInterval(), DefaultHeartbeatInterval)
+	replicaTimeout := orDefaultDuration(config.GetReplicaTimeout(), DefaultReplicaTimeout)
+	if replicaTimeout <= heartbeatInterval {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "replica timeout must be longer than heartbeat interval")
+	}
+
+	coordinator := &ReplicaCoordinator{
+		replicaRepository: replicaRepository,
+		replica:           replica,
+		mode:              mode,
+		heartbeatInterval: heartbeatInterval,
+		replicaTimeout:    replicaTimeout,
+		readyChan:         make(chan bool),
+		closeChan:         make(chan bool),
+		doneChan:          make(chan bool),
+	}
+	if mode == CoordinatorModeStandalone {
+		coordinator.markReady()
+	}
+	return coordinator, nil
+}
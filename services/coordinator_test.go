@@ -0,0 +1,134 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/repositories/mocks"
+)
+
+type coordinatorConfig struct {
+	mode      string
+	replicaId string
+}
+
+func (c coordinatorConfig) GetMode() string                     { return c.mode }
+func (c coordinatorConfig) GetReplicaId() string                { return c.replicaId }
+func (c coordinatorConfig) GetHeartbeatInterval() time.Duration { return time.Second }
+func (c coordinatorConfig) GetReplicaTimeout() time.Duration    { return time.Minute }
+
+// newMockCoordinators creates coordinators which share the live replicas, like processes sharing a database.
+func newMockCoordinators(t *testing.T, mode string, replicaIds ...string) ([]*ReplicaCoordinator, *[]*models.Replica) {
+	live := make([]*models.Replica, 0, len(replicaIds))
+	for _, replicaId := range replicaIds {
+		live = append(live, &models.Replica{Id: replicaId})
+	}
+
+	repository := &mocks.ReplicaRepository{}
+	repository.On("Heartbeat", mock.Anything, mock.Anything).Return(nil)
+	repository.On("DeleteExpired", mock.Anything, mock.Anything).Return(nil)
+	repository.On("GetLiveReplicas", mock.Anything, mock.Anything).Return(
+		func(rsdb.Connection, time.Duration) []*models.Replica { return live }, nil)
+
+	coordinators := make([]*ReplicaCoordinator, 0, len(replicaIds))
+	for _, replicaId := range replicaIds {
+		coordinator, err := NewReplicaCoordinator(repository, coordinatorConfig{mode: mode, replicaId: replicaId})
+		if err != nil {
+			t.Fatal(err)
+		}
+		coordinators = append(coordinators, coordinator.(*ReplicaCoordinator))
+	}
+	return coordinators, &live
+}
+
+func countOwners(coordinators []*ReplicaCoordinator, key string) (int, string) {
+	owners, owner := 0, ""
+	for _, coordinator := range coordinators {
+		if coordinator.Owns(key) {
+			owners++
+			owner = coordinator.replica.Id
+		}
+	}
+	return owners, owner
+}
+
+func TestReplicaCoordinator_Shard(t *testing.T) {
+	coordinators, live := newMockCoordinators(t, CoordinatorModeShard, "a", "b", "c")
+	for _, coordinator := range coordinators {
+		coordinator.heartbeat()
+	}
+
+	keys := make([]string, 300)
+	before := make(map[string]string)
+	counts := make(map[string]int)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("test-%d", i)
+		owners, owner := countOwners(coordinators, keys[i])
+		assert.Equal(t, 1, owners, keys[i])
+		before[keys[i]] = owner
+		counts[owner]++
+	}
+	for _, replicaId := range []string{"a", "b", "c"} {
+		assert.True(t, counts[replicaId] > 50, "replica '%s' owns %d keys", replicaId, counts[replicaId])
+	}
+
+	// "b" dies: its keys are taken over and the other keys don't move.
+	*live = []*models.Replica{{Id: "a"}, {Id: "c"}}
+	survivors := []*ReplicaCoordinator{coordinators[0], coordinators[2]}
+	for _, coordinator := range survivors {
+		coordinator.heartbeat()
+	}
+	for _, key := range keys {
+		owners, owner := countOwners(survivors, key)
+		assert.Equal(t, 1, owners, key)
+		if before[key] != "b" {
+			assert.Equal(t, before[key], owner, key)
+		}
+	}
+}
+
+func TestReplicaCoordinator_Leader(t *testing.T) {
+	coordinators, live := newMockCoordinators(t, CoordinatorModeLeader, "a", "b")
+	for _, coordinator := range coordinators {
+		coordinator.heartbeat()
+	}
+	assert.True(t, coordinators[0].IsLeader())
+	assert.False(t, coordinators[1].IsLeader())
+	owners, owner := countOwners(coordinators, "test")
+	assert.Equal(t, 1, owners)
+	assert.Equal(t, "a", owner)
+
+	*live = []*models.Replica{{Id: "b"}}
+	coordinators[1].heartbeat()
+	assert.True(t, coordinators[1].IsLeader())
+	assert.True(t, coordinators[1].Owns("test"))
+}
+
+func TestReplicaCoordinator_LostHeartbeat(t *testing.T) {
+	coordinators, _ := newMockCoordinators(t, CoordinatorModeShard, "a")
+	coordinator := coordinators[0]
+	assert.False(t, coordinator.Owns("test"), "owns before the first heartbeat")
+
+	coordinator.heartbeat()
+	assert.True(t, coordinator.Owns("test"))
+
+	coordinator.lastHeartbeatAt = time.Now().Add(-2 * time.Minute)
+	assert.False(t, coordinator.Owns("test"))
+	assert.False(t, coordinator.IsLeader())
+}
+
+func TestReplicaCoordinator_Standalone(t *testing.T) {
+	coordinator, err := NewReplicaCoordinator(&mocks.ReplicaRepository{}, coordinatorConfig{mode: CoordinatorModeStandalone, replicaId: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, coordinator.Run())
+	assert.True(t, coordinator.Owns("test"))
+	assert.True(t, coordinator.IsLeader())
+}
@@ -0,0 +1,573 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsmodels"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/repositories"
+)
+
+const (
+	DefaultEscalationPollInterval = 30 * time.Second
+
+	escalationBatchSize = 100
+)
+
+var _ EscalationService = &EscalationServiceImpl{}
+
+type EscalationConfig interface {
+	GetPollInterval() time.Duration
+}
+
+// Escalator escalates the alerts of the tests with an escalation policy until they are acknowledged.
+type Escalator interface {
+	ScheduleRunner
+	ScheduleShutdowner
+	// Start starts the escalation policy of a test which went down,
+	// and returns the deliveries of the steps due at once for the caller to send.
+	Start(test *models.Test, data models.AlertMessageData) []*models.AlertDelivery
+	// Resolve stops the escalation of a test which recovered,
+	// and returns the deliveries telling the channels already notified for the caller to send.
+	Resolve(test *models.Test, data models.AlertMessageData) []*models.AlertDelivery
+}
+
+// TestEscalator polls the escalations whose next step is due.
+// The escalations are in the database, so a step due while the server was down fires once it is back,
+// and only the leader replica polls them.
+type TestEscalator struct {
+	escalationRepository       repositories.EscalationRepository
+	escalationPolicyRepository repositories.EscalationPolicyRepository
+	channelRepository          repositories.NotificationChannelRepository
+	testRepository             repositories.TestRepository
+	testResultRepository       repositories.TestResultRepository
+	alertStateRepository       repositories.AlertStateRepository
+	alertDeliveryRepository    repositories.AlertDeliveryRepository
+	coordinator                Coordinator
+	alertManagerConfig         AlertManagerConfig
+	pollInterval               time.Duration
+
+	mux        sync.Mutex
+	isRunning  bool
+	isShutdown bool
+	closeChan  chan bool
+	doneChan   chan bool
+}
+
+func (escalator *TestEscalator) Run() error {
+	escalator.mux.Lock()
+	if escalator.isShutdown {
+		escalator.mux.Unlock()
+		return nil
+	}
+	escalator.isRunning = true
+	escalator.mux.Unlock()
+	defer close(escalator.doneChan)
+
+	ticker := time.NewTicker(escalator.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			escalator.poll()
+		case <-escalator.closeChan:
+			rslog.Debug("Closed TestEscalator")
+			return nil
+		}
+	}
+}
+
+func (escalator *TestEscalator) Shutdown(ctx context.Context) error {
+	escalator.mux.Lock()
+	if escalator.isShutdown {
+		escalator.mux.Unlock()
+		return nil
+	}
+	escalator.isShutdown = true
+	isRunning := escalator.isRunning
+	escalator.mux.Unlock()
+
+	if !isRunning {
+		return nil
+	}
+
+	close(escalator.closeChan)
+	select {
+	case <-escalator.doneChan:
+		return nil
+	case <-ctx.Done():
+		return errors.WithStack(ctx.Err())
+	}
+}
+
+func (escalator *TestEscalator) poll() {
+	if !escalator.coordinator.IsLeader() {
+		return
+	}
+	escalations, err := escalator.escalationRepository.GetDueList(rsdb.GetConnection(), time.Now(), escalationBatchSize)
+	if err != nil {
+		rslog.Errorf("failed to get due escalations: error='%v'", err)
+		return
+	}
+	for _, escalation := range escalations {
+		for _, delivery := range escalator.escalate(escalation, nil) {
+			deliverAlert(escalator.alertDeliveryRepository, delivery)
+		}
+	}
+}
+
+func (escalator *TestEscalator) Start(test *models.Test, data models.AlertMessageData) []*models.AlertDelivery {
+	policyId := test.AlertPolicy.EscalationPolicyId
+	if policyId == "" {
+		return nil
+	}
+	policy, err := escalator.getPolicy(policyId)
+	if err != nil {
+		rslog.Errorf("failed to get escalation policy: testId='%s', policyId='%s', error='%v'", test.Id, policyId, err)
+		return nil
+	}
+
+	escalation := models.NewEscalation(test, policy, data.Result)
+	if err := escalator.escalationRepository.Create(rsdb.GetConnection(), escalation); err != nil {
+		rslog.Errorf("failed to create escalation: testId='%s', error='%v'", test.Id, err)
+		return nil
+	}
+	return escalator.escalateWithPolicy(escalation, policy, &data)
+}
+
+func (escalator *TestEscalator) Resolve(test *models.Test, data models.AlertMessageData) []*models.AlertDelivery {
+	conn := rsdb.GetConnection()
+	escalation, err := escalator.escalationRepository.GetUnresolvedByTestId(conn, test.Id)
+	switch err {
+	case nil:
+	case rsdb.ErrRecordNotFound:
+		return nil
+	default:
+		rslog.Errorf("failed to get escalation: testId='%s', error='%v'", test.Id, err)
+		return nil
+	}
+
+	escalation.Resolve(time.Now())
+	resolved, err := escalator.escalationRepository.Resolve(conn, escalation)
+	if err != nil {
+		rslog.Errorf("failed to resolve escalation: escalationId='%s', error='%v'", escalation.Id, err)
+		return nil
+	}
+	if !resolved {
+		return nil
+	}
+
+	policy, err := escalator.getPolicy(escalation.PolicyId)
+	if err != nil {
+		rslog.Errorf("failed to get escalation policy: escalationId='%s', error='%v'", escalation.Id, err)
+		return nil
+	}
+	return escalator.deliveries(test.Id, escalation.NotifiedChannelIds(policy), data)
+}
+
+// escalate returns the deliveries of the due steps of the escalation. data is loaded for the test if it is nil.
+func (escalator *TestEscalator) escalate(escalation *models.Escalation, data *models.AlertMessageData) []*models.AlertDelivery {
+	policy, err := escalator.getPolicy(escalation.PolicyId)
+	if err != nil {
+		rslog.Errorf("failed to get escalation policy: escalationId='%s', error='%v'", escalation.Id, err)
+		return nil
+	}
+	return escalator.escalateWithPolicy(escalation, policy, data)
+}
+
+func (escalator *TestEscalator) escalateWithPolicy(escalation *models.Escalation, policy *models.EscalationPolicy, data *models.AlertMessageData) []*models.AlertDelivery {
+	fromStep := escalation.Step
+	due := escalation.Advance(policy, time.Now())
+
+	advanced, err := escalator.escalationRepository.Advance(rsdb.GetConnection(), escalation, fromStep)
+	if err != nil {
+		rslog.Errorf("failed to advance escalation: escalationId='%s', error='%v'", escalation.Id, err)
+		return nil
+	}
+	if !advanced || len(due) == 0 {
+		// Another replica advanced it, or the policy changed and nothing is due.
+		return nil
+	}
+
+	if data == nil {
+		if data, err = escalator.messageData(escalation); err != nil {
+			rslog.Errorf("failed to load escalation message: escalationId='%s', error='%v'", escalation.Id, err)
+			return nil
+		}
+	}
+	deliveries := make([]*models.AlertDelivery, 0)
+	for i, step := range due {
+		rslog.Infof("escalating: escalationId='%s', testId='%s', step='%d'", escalation.Id, escalation.TestId, fromStep+i+1)
+		deliveries = append(deliveries, escalator.deliveries(escalation.TestId, step.ChannelIds, *data)...)
+	}
+	return deliveries
+}
+
+// messageData loads what the test alerted with when it went down.
+func (escalator *TestEscalator) messageData(escalation *models.Escalation) (*models.AlertMessageData, error) {
+	conn := rsdb.GetConnection()
+	test := &models.Test{Id: escalation.TestId}
+	if err := escalator.testRepository.GetById(conn, test); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	state, err := escalator.alertStateRepository.GetByTestId(conn, test.Id)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	result := &models.TestResult{Id: escalation.ResultId}
+	if err := escalator.testResultRepository.GetById(conn, result); err != nil {
+		// The result may be purged, which should not stop the escalation.
+		rslog.Warnf("failed to get the result of escalation: escalationId='%s', error='%v'", escalation.Id, err)
+		result = &models.TestResult{Id: escalation.ResultId, TestId: test.Id, TestedAt: escalation.StartedAt}
+	}
+	data := models.NewAlertMessageData(test, result, state, models.AlertEventDown, alertLink(escalator.alertManagerConfig, test))
+	return &data, nil
+}
+
+// deliveries renders the message of each enabled channel, without sending it.
+func (escalator *TestEscalator) deliveries(testId string, channelIds []string, data models.AlertMessageData) []*models.AlertDelivery {
+	deliveries := make([]*models.AlertDelivery, 0, len(channelIds))
+	for _, channelId := range channelIds {
+		channel := &models.NotificationChannel{Id: channelId}
+		if err := escalator.channelRepository.GetById(rsdb.GetConnection(), channel); err != nil {
+			rslog.Errorf("failed to get notification channel: channelId='%s', error='%v'", channelId, err)
+			continue
+		}
+		if channel.Disabled {
+			continue
+		}
+		alert := channel.Alert()
+		message, err := renderAlert(alert, data)
+		if err != nil {
+			rslog.Error(err)
+			continue
+		}
+		deliveries = append(deliveries, models.NewAlertDelivery(testId, *alert, message))
+	}
+	return deliveries
+}
+
+func (escalator *TestEscalator) getPolicy(policyId string) (*models.EscalationPolicy, error) {
+	policy := &models.EscalationPolicy{Id: policyId}
+	if err := escalator.escalationPolicyRepository.GetById(rsdb.GetConnection(), policy); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return policy, nil
+}
+
+func NewTestEscalator(
+	escalationRepository repositories.EscalationRepository,
+	escalationPolicyRepository repositories.EscalationPolicyRepository,
+	channelRepository repositories.NotificationChannelRepository,
+	testRepository repositories.TestRepository,
+	testResultRepository repositories.TestResultRepository,
+	alertStateRepository repositories.AlertStateRepository,
+	alertDeliveryRepository repositories.AlertDeliveryRepository,
+	coordinator Coordinator,
+	alertManagerConfig AlertManagerConfig,
+	config EscalationConfig,
+) (Escalator, error) {
+	if rsvalid.IsZero(
+		escalationRepository,
+		escalationPolicyRepository,
+		channelRepository,
+		testRepository,
+		testResultRepository,
+		alertStateRepository,
+		alertDeliveryRepository,
+		coordinator,
+		alertManagerConfig,
+		config,
+	) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "TestEscalator")
+	}
+	return &TestEscalator{
+		escalationRepository:       escalationRepository,
+		escalationPolicyRepository: escalationPolicyRepository,
+		channelRepository:          channelRepository,
+		testRepository:             testRepository,
+		testResultRepository:       testResultRepository,
+		alertStateRepository:       alertStateRepository,
+		alertDeliveryRepository:    alertDeliveryRepository,
+		coordinator:                coordinator,
+		alertManagerConfig:         alertManagerConfig,
+		pollInterval:               orDefaultDuration(config.GetPollInterval(), DefaultEscalationPollInterval),
+		closeChan:                  make(chan bool),
+		doneChan:                   make(chan bool),
+	}, nil
+}
+
+type EscalationService interface {
+	CreatePolicy(request models.EscalationPolicyRequest) (*models.EscalationPolicy, *amerr.ErrorWithLanguage)
+	GetPolicy(policy *models.EscalationPolicy) *amerr.ErrorWithLanguage
+	GetPolicyList(request models.EscalationPolicyListRequest) (*rsmodels.PaginatedList, *amerr.ErrorWithLanguage)
+	UpdatePolicy(policy *models.EscalationPolicy, request models.EscalationPolicyRequest) *amerr.ErrorWithLanguage
+	DeletePolicy(policy *models.EscalationPolicy) *amerr.ErrorWithLanguage
+	GetEscalation(escalation *models.Escalation) *amerr.ErrorWithLanguage
+	GetEscalationList(request models.EscalationListRequest) (*rsmodels.PaginatedList, *amerr.ErrorWithLanguage)
+	Acknowledge(escalation *models.Escalation, request models.EscalationAcknowledgeRequest) *amerr.ErrorWithLanguage
+	AcknowledgeTest(test *models.Test, request models.EscalationAcknowledgeRequest) (*models.Escalation, *amerr.ErrorWithLanguage)
+}
+
+type EscalationServiceImpl struct {
+	escalationRepository       repositories.EscalationRepository
+	escalationPolicyRepository repositories.EscalationPolicyRepository
+	channelRepository          repositories.NotificationChannelRepository
+}
+
+func (service *EscalationServiceImpl) CreatePolicy(request models.EscalationPolicyRequest) (*models.EscalationPolicy, *amerr.ErrorWithLanguage) {
+	policy, err := models.NewEscalationPolicy(request)
+	if err != nil {
+		rslog.Error(err)
+		return nil, amerr.GetErrorsFromCode(amerr.ErrBadRequest)
+	}
+
+	if err := service.checkChannels(policy); err != nil {
+		return nil, err
+	}
+
+	if err := service.escalationPolicyRepository.Create(rsdb.GetConnection(), policy); err != nil {
+		switch err {
+		case rsdb.ErrDuplicateData:
+			return nil, amerr.GetErrorsFromCode(amerr.ErrDuplicatedEscalationPolicy)
+		case rsdb.ErrInvalidData:
+			return nil, amerr.GetErrorsFromCode(amerr.ErrBadRequest)
+		default:
+			rslog.Error(err)
+			return nil, amerr.GetErrInternalServer()
+		}
+	}
+
+	return policy, nil
+}
+
+func (service *EscalationServiceImpl) GetPolicy(policy *models.EscalationPolicy) *amerr.ErrorWithLanguage {
+	if rsvalid.IsZero(policy) {
+		rslog.Error(errors.Wrap(rserrors.ErrInvalidParameter, "EscalationPolicy"))
+		return amerr.GetErrInternalServer()
+	}
+
+	if rsvalid.IsZero(policy.Id) {
+		return amerr.GetErrorsFromCode(amerr.ErrEscalationPolicyNotFound)
+	}
+
+	if err := service.escalationPolicyRepository.GetById(rsdb.GetConnection(), policy); err != nil {
+		switch err {
+		case rsdb.ErrRecordNotFound:
+			return amerr.GetErrorsFromCode(amerr.ErrEscalationPolicyNotFound)
+		default:
+			rslog.Error(err)
+			return amerr.GetErrInternalServer()
+		}
+	}
+
+	return nil
+}
+
+func (service *EscalationServiceImpl) GetPolicyList(request models.EscalationPolicyListRequest) (*rsmodels.PaginatedList, *amerr.ErrorWithLanguage) {
+	items := make([]*models.EscalationPolicy, 0)
+	totalCount, err := service.escalationPolicyRepository.List(rsdb.GetConnection(), &items, rsdb.ListFilter{
+		Page:    request.Page,
+		NumItem: request.NumItem,
+	}, rsdb.Orders{
+		{
+			Field: "name",
+			IsASC: true,
+		},
+	})
+	if err != nil {
+		rslog.Error(err)
+		return nil, amerr.GetErrInternalServer()
+	}
+
+	return &rsmodels.PaginatedList{
+		CurrentPage: request.Page,
+		NumItem:     request.NumItem,
+		TotalCount:  totalCount,
+		Items:       items,
+	}, nil
+}
+
+// UpdatePolicy changes the policy. The open escalations follow the new steps from their next poll.
+func (service *EscalationServiceImpl) UpdatePolicy(policy *models.EscalationPolicy, request models.EscalationPolicyRequest) *amerr.ErrorWithLanguage {
+	if err := service.GetPolicy(policy); err != nil {
+		return err
+	}
+
+	if err := policy.UpdateFromRequest(request); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest)
+	}
+
+	if err := service.checkChannels(policy); err != nil {
+		return err
+	}
+
+	if err := service.escalationPolicyRepository.Save(rsdb.GetConnection(), policy); err != nil {
+		switch err {
+		case rsdb.ErrDuplicateData:
+			return amerr.GetErrorsFromCode(amerr.ErrDuplicatedEscalationPolicy)
+		case rsdb.ErrInvalidData:
+			return amerr.GetErrorsFromCode(amerr.ErrBadRequest)
+		default:
+			rslog.Error(err)
+			return amerr.GetErrInternalServer()
+		}
+	}
+
+	return nil
+}
+
+// DeletePolicy deletes the policy with its escalations. Tests which still use it are no longer escalated.
+func (service *EscalationServiceImpl) DeletePolicy(policy *models.EscalationPolicy) *amerr.ErrorWithLanguage {
+	if err := service.GetPolicy(policy); err != nil {
+		return err
+	}
+
+	if err := service.escalationPolicyRepository.DeleteById(rsdb.GetConnection(), policy); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrInternalServer()
+	}
+
+	return nil
+}
+
+func (service *EscalationServiceImpl) checkChannels(policy *models.EscalationPolicy) *amerr.ErrorWithLanguage {
+	for _, channelId := range policy.Steps.ChannelIds() {
+		if err := service.channelRepository.GetById(rsdb.GetConnection(), &models.NotificationChannel{Id: channelId}); err != nil {
+			switch err {
+			case rsdb.ErrRecordNotFound:
+				return amerr.GetErrorsFromCode(amerr.ErrNotificationChannelNotFound)
+			default:
+				rslog.Error(err)
+				return amerr.GetErrInternalServer()
+			}
+		}
+	}
+	return nil
+}
+
+func (service *EscalationServiceImpl) GetEscalation(escalation *models.Escalation) *amerr.ErrorWithLanguage {
+	if rsvalid.IsZero(escalation) {
+		rslog.Error(errors.Wrap(rserrors.ErrInvalidParameter, "Escalation"))
+		return amerr.GetErrInternalServer()
+	}
+
+	if rsvalid.IsZero(escalation.Id) {
+		return amerr.GetErrorsFromCode(amerr.ErrEscalationNotFound)
+	}
+
+	if err := service.escalationRepository.GetById(rsdb.GetConnection(), escalation); err != nil {
+		switch err {
+		case rsdb.ErrRecordNotFound:
+			return amerr.GetErrorsFromCode(amerr.ErrEscalationNotFound)
+		default:
+			rslog.Error(err)
+			return amerr.GetErrInternalServer()
+		}
+	}
+
+	return nil
+}
+
+func (service *EscalationServiceImpl) GetEscalationList(request models.EscalationListRequest) (*rsmodels.PaginatedList, *amerr.ErrorWithLanguage) {
+	conditions := map[string]interface{}{}
+	if request.TestId != "" {
+		conditions["test_id"] = request.TestId
+	}
+	if request.IsOpen {
+		conditions["acknowledged_at"] = nil
+		conditions["resolved_at"] = nil
+	}
+
+	items := make([]*models.Escalation, 0)
+	totalCount, err := service.escalationRepository.List(rsdb.GetConnection(), &items, rsdb.ListFilter{
+		Page:       request.Page,
+		NumItem:    request.NumItem,
+		Conditions: conditions,
+	}, rsdb.Orders{
+		{
+			Field: "created_at",
+			IsASC: false,
+		},
+	})
+	if err != nil {
+		rslog.Error(err)
+		return nil, amerr.GetErrInternalServer()
+	}
+
+	return &rsmodels.PaginatedList{
+		CurrentPage: request.Page,
+		NumItem:     request.NumItem,
+		TotalCount:  totalCount,
+		Items:       items,
+	}, nil
+}
+
+// Acknowledge stops the escalation, so that no more steps are notified.
+func (service *EscalationServiceImpl) Acknowledge(escalation *models.Escalation, request models.EscalationAcknowledgeRequest) *amerr.ErrorWithLanguage {
+	if err := service.GetEscalation(escalation); err != nil {
+		return err
+	}
+	return service.acknowledge(escalation, request)
+}
+
+// AcknowledgeTest acknowledges the escalation of the current outage of the test.
+func (service *EscalationServiceImpl) AcknowledgeTest(test *models.Test, request models.EscalationAcknowledgeRequest) (*models.Escalation, *amerr.ErrorWithLanguage) {
+	escalation, err := service.escalationRepository.GetUnresolvedByTestId(rsdb.GetConnection(), test.Id)
+	if err != nil {
+		switch err {
+		case rsdb.ErrRecordNotFound:
+			return nil, amerr.GetErrorsFromCode(amerr.ErrEscalationNotFound)
+		default:
+			rslog.Error(err)
+			return nil, amerr.GetErrInternalServer()
+		}
+	}
+	if err := service.acknowledge(escalation, request); err != nil {
+		return nil, err
+	}
+	return escalation, nil
+}
+
+func (service *EscalationServiceImpl) acknowledge(escalation *models.Escalation, request models.EscalationAcknowledgeRequest) *amerr.ErrorWithLanguage {
+	if err := escalation.Acknowledge(request.By, time.Now()); err != nil {
+		return amerr.GetErrorsFromCode(amerr.ErrEscalationClosed)
+	}
+
+	acknowledged, err := service.escalationRepository.Acknowledge(rsdb.GetConnection(), escalation)
+	if err != nil {
+		rslog.Error(err)
+		return amerr.GetErrInternalServer()
+	}
+	if !acknowledged {
+		return amerr.GetErrorsFromCode(amerr.ErrEscalationClosed)
+	}
+
+	rslog.Infof("escalation is acknowledged: escalationId='%s', testId='%s', by='%s'", escalation.Id, escalation.TestId, escalation.AcknowledgedBy)
+	return nil
+}
+
+func NewEscalationService(
+	escalationRepository repositories.EscalationRepository,
+	escalationPolicyRepository repositories.EscalationPolicyRepository,
+	channelRepository repositories.NotificationChannelRepository,
+) (EscalationService, error) {
+	if rsvalid.IsZero(escalationRepository, escalationPolicyRepository, channelRepository) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "EscalationService")
+	}
+	return &EscalationServiceImpl{
+		escalationRepository:       escalationRepository,
+		escalationPolicyRepository: escalationPolicyRepository,
+		channelRepository:          channelRepository,
+	}, nil
+}
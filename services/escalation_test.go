@@ -0,0 +1,211 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rsnotify"
+	"github.com/realsangil/apimonitor/repositories/mocks"
+)
+
+type escalationConfig struct{}
+
+func (escalationConfig) GetPollInterval() time.Duration { return time.Hour }
+
+func TestTestEscalator(t *testing.T) {
+	var (
+		mux      sync.Mutex
+		messages = map[string][]string{}
+	)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]string{}
+		_ = jsoniter.NewDecoder(r.Body).Decode(&body)
+		mux.Lock()
+		messages[r.URL.Path] = append(messages[r.URL.Path], body["text"])
+		mux.Unlock()
+	}))
+	defer webhook.Close()
+	received := func(path string) []string {
+		mux.Lock()
+		defer mux.Unlock()
+		return append([]string{}, messages[path]...)
+	}
+
+	policy := &models.EscalationPolicy{
+		Id: "policy",
+		Steps: models.EscalationSteps{
+			{AfterMinutes: 0, ChannelIds: []string{"oncall"}},
+			{AfterMinutes: 15, ChannelIds: []string{"team"}},
+			{AfterMinutes: 60, ChannelIds: []string{"manager"}},
+		},
+	}
+	policyRepository := &mocks.EscalationPolicyRepository{}
+	policyRepository.On("GetById", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*models.EscalationPolicy) = *policy
+	})
+
+	channelRepository := &mocks.NotificationChannelRepository{}
+	channelRepository.On("GetById", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		channel := args.Get(1).(*models.NotificationChannel)
+		channel.Name = channel.Id
+		channel.Type = rsnotify.TypeWebhook
+		channel.Config = models.NotifierConfig(`{"url": "` + webhook.URL + `/` + channel.Id + `"}`)
+		channel.Template = "{{.Status}} {{.Test.Name}}"
+	})
+
+	test := &models.Test{
+		Id:          "test",
+		Name:        "health",
+		AlertPolicy: models.AlertPolicy{EscalationPolicyId: policy.Id},
+	}
+	testRepository := &mocks.TestRepository{}
+	testRepository.On("GetById", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		*args.Get(1).(*models.Test) = *test
+	})
+	testResultRepository := &mocks.TestResultRepository{}
+	testResultRepository.On("GetById", mock.Anything, mock.Anything).Return(rsdb.ErrRecordNotFound)
+	stateRepository := &mocks.AlertStateRepository{}
+	stateRepository.On("GetByTestId", mock.Anything, test.Id).Return(models.NewAlertState(test.Id), nil)
+	deliveryRepository := &mocks.AlertDeliveryRepository{}
+	deliveryRepository.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	// The repository keeps the escalation like the database would, and claims each step once.
+	var stored *models.Escalation
+	escalationRepository := &mocks.EscalationRepository{}
+	escalationRepository.On("Create", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		copied := *args.Get(1).(*models.Escalation)
+		stored = &copied
+	})
+	escalationRepository.On("Advance", mock.Anything, mock.Anything, mock.Anything).Return(func(_ rsdb.Connection, escalation *models.Escalation, fromStep int) bool {
+		if stored.Step != fromStep {
+			return false
+		}
+		copied := *escalation
+		stored = &copied
+		return true
+	}, nil)
+	escalationRepository.On("GetDueList", mock.Anything, mock.Anything, mock.Anything).Return(func(_ rsdb.Connection, now time.Time, _ int) []*models.Escalation {
+		if stored.NextAt == nil || stored.NextAt.After(now) {
+			return []*models.Escalation{}
+		}
+		copied := *stored
+		return []*models.Escalation{&copied}
+	}, nil)
+	escalationRepository.On("GetUnresolvedByTestId", mock.Anything, test.Id).Return(func(rsdb.Connection, string) *models.Escalation {
+		copied := *stored
+		return &copied
+	}, nil)
+	escalationRepository.On("Resolve", mock.Anything, mock.Anything).Return(true, nil)
+
+	escalator, err := NewTestEscalator(
+		escalationRepository,
+		policyRepository,
+		channelRepository,
+		testRepository,
+		testResultRepository,
+		stateRepository,
+		deliveryRepository,
+		&fakeCoordinator{},
+		&fakeAlertManagerConfig{},
+		&escalationConfig{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testEscalator := escalator.(*TestEscalator)
+	deliver := func(deliveries []*models.AlertDelivery) {
+		for _, delivery := range deliveries {
+			deliverAlert(deliveryRepository, delivery)
+		}
+	}
+
+	down := models.NewAlertMessageData(test, &models.TestResult{Id: "result", TestId: test.Id, TestedAt: time.Now()}, models.NewAlertState(test.Id), models.AlertEventDown, "")
+	deliveries := escalator.Start(test, down)
+	assert.Empty(t, received("/oncall"), "the deliveries are sent by the caller")
+	deliver(deliveries)
+	assert.Equal(t, []string{"DOWN health"}, received("/oncall"))
+	assert.Empty(t, received("/team"))
+
+	testEscalator.poll()
+	assert.Empty(t, received("/team"), "the team is notified after 15 minutes")
+
+	// 20 minutes later, polled twice as if by two replicas.
+	startedAt := stored.StartedAt.Add(-20 * time.Minute)
+	nextAt := startedAt.Add(15 * time.Minute)
+	stored.StartedAt, stored.NextAt = startedAt, &nextAt
+	testEscalator.poll()
+	testEscalator.poll()
+	assert.Equal(t, []string{"DOWN health"}, received("/oncall"))
+	assert.Equal(t, []string{"DOWN health"}, received("/team"))
+	assert.Empty(t, received("/manager"))
+	assert.Equal(t, 2, stored.Step)
+
+	up := down
+	up.Status = models.AlertMessageStatusRecovered
+	deliver(escalator.Resolve(test, up))
+	assert.Equal(t, []string{"DOWN health", "RECOVERED health"}, received("/oncall"))
+	assert.Equal(t, []string{"DOWN health", "RECOVERED health"}, received("/team"))
+	assert.Empty(t, received("/manager"))
+}
+
+func TestEscalationServiceImpl_AcknowledgeTest(t *testing.T) {
+	acknowledgedAt := time.Now()
+	tests := []struct {
+		name         string
+		escalation   *models.Escalation
+		getErr       error
+		acknowledged bool
+		want         *amerr.ErrorWithLanguage
+	}{
+		{
+			name:         "acknowledged",
+			escalation:   &models.Escalation{Id: "escalation", TestId: "test"},
+			acknowledged: true,
+		},
+		{
+			name:       "already acknowledged",
+			escalation: &models.Escalation{Id: "escalation", TestId: "test", AcknowledgedAt: &acknowledgedAt},
+			want:       amerr.GetErrorsFromCode(amerr.ErrEscalationClosed),
+		},
+		{
+			name:         "resolved by another request",
+			escalation:   &models.Escalation{Id: "escalation", TestId: "test"},
+			acknowledged: false,
+			want:         amerr.GetErrorsFromCode(amerr.ErrEscalationClosed),
+		},
+		{
+			name:   "not escalated",
+			getErr: rsdb.ErrRecordNotFound,
+			want:   amerr.GetErrorsFromCode(amerr.ErrEscalationNotFound),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository := &mocks.EscalationRepository{}
+			repository.On("GetUnresolvedByTestId", mock.Anything, "test").Return(tt.escalation, tt.getErr)
+			repository.On("Acknowledge", mock.Anything, mock.Anything).Return(tt.acknowledged, nil)
+
+			service, err := NewEscalationService(repository, &mocks.EscalationPolicyRepository{}, &mocks.NotificationChannelRepository{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			escalation, aerr := service.AcknowledgeTest(&models.Test{Id: "test"}, models.EscalationAcknowledgeRequest{By: "alice"})
+			assert.Equal(t, tt.want, aerr)
+			if tt.want == nil && assert.NotNil(t, escalation) {
+				assert.Equal(t, "alice", escalation.AcknowledgedBy)
+				assert.NotNil(t, escalation.AcknowledgedAt)
+				assert.Nil(t, escalation.NextAt)
+			}
+		})
+	}
+}
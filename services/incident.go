@@ -0,0 +1,287 @@
+package services
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsmodels"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/repositories"
+)
+
+// incidentOpenAttempts bounds how many times a test joins an incident which is closed meanwhile.
+const incidentOpenAttempts = 3
+
+var _ IncidentService = &IncidentServiceImpl{}
+
+// IncidentRecorder groups the alerts of the tests into incidents of their web services.
+type IncidentRecorder interface {
+	// Open adds a test which went down to the open incident of its web service, or opens one.
+	Open(test *models.Test, result *models.TestResult)
+	// Close recovers a test from its incident, and closes the incident once all its tests recovered.
+	Close(test *models.Test, result *models.TestResult)
+	// Remove recovers a test which is being deleted from its incident, since it can no longer recover by itself.
+	Remove(test *models.Test)
+}
+
+type IncidentService interface {
+	IncidentRecorder
+	GetIncident(incident *models.Incident) *amerr.ErrorWithLanguage
+	GetIncidentList(request models.IncidentListRequest) (*rsmodels.PaginatedList, *amerr.ErrorWithLanguage)
+	Acknowledge(incident *models.Incident, request models.IncidentAcknowledgeRequest) *amerr.ErrorWithLanguage
+	CreateNote(incident *models.Incident, request models.IncidentNoteRequest) (*models.IncidentNote, *amerr.ErrorWithLanguage)
+}
+
+type IncidentServiceImpl struct {
+	incidentRepository     repositories.IncidentRepository
+	incidentTestRepository repositories.IncidentTestRepository
+	incidentNoteRepository repositories.IncidentNoteRepository
+}
+
+func (service *IncidentServiceImpl) Open(test *models.Test, result *models.TestResult) {
+	if _, err := service.incidentTestRepository.GetUnrecoveredByTestId(rsdb.GetConnection(), test.Id); err == nil {
+		return
+	} else if err != rsdb.ErrRecordNotFound {
+		rslog.Errorf("failed to get incident of test: testId='%s', error='%v'", test.Id, err)
+		return
+	}
+
+	for attempt := 0; attempt < incidentOpenAttempts; attempt++ {
+		if service.joinIncident(test, result) {
+			return
+		}
+	}
+	rslog.Errorf("failed to add test to an open incident: testId='%s', webServiceId='%s'", test.Id, test.WebServiceId)
+}
+
+// joinIncident adds the test to the open incident of its web service, and reports whether it is done.
+// The incident may be closed by its last test recovering right before the test is added,
+// in which case the test is taken out of it again, to join or open the next incident.
+func (service *IncidentServiceImpl) joinIncident(test *models.Test, result *models.TestResult) bool {
+	conn := rsdb.GetConnection()
+	incident, err := service.openIncident(test, result)
+	if err != nil {
+		rslog.Errorf("failed to open incident: testId='%s', webServiceId='%s', error='%v'", test.Id, test.WebServiceId, err)
+		return true
+	}
+
+	incidentTest := models.NewIncidentTest(incident, test, result)
+	if err := service.incidentTestRepository.Create(conn, incidentTest); err != nil {
+		rslog.Errorf("failed to add test to incident: incidentId='%s', testId='%s', error='%v'", incident.Id, test.Id, err)
+		return true
+	}
+
+	current, err := service.incidentRepository.GetOpenByWebServiceId(conn, test.WebServiceId)
+	switch {
+	case err == nil && current.Id == incident.Id:
+		return true
+	case err != nil && err != rsdb.ErrRecordNotFound:
+		rslog.Errorf("failed to get open incident: webServiceId='%s', error='%v'", test.WebServiceId, err)
+		return true
+	}
+	rslog.Infof("incident was closed while the test joined it: incidentId='%s', testId='%s'", incident.Id, test.Id)
+	if err := service.incidentTestRepository.DeleteById(conn, incidentTest); err != nil {
+		rslog.Errorf("failed to remove test from closed incident: incidentId='%s', testId='%s', error='%v'", incident.Id, test.Id, err)
+		return true
+	}
+	return false
+}
+
+// openIncident returns the open incident of the web service of the test, or opens one.
+// If another replica opened it in between, the unique open key rejects the new one and it is read again.
+func (service *IncidentServiceImpl) openIncident(test *models.Test, result *models.TestResult) (*models.Incident, error) {
+	incident, err := service.incidentRepository.GetOpenByWebServiceId(rsdb.GetConnection(), test.WebServiceId)
+	if err == nil {
+		return incident, nil
+	} else if err != rsdb.ErrRecordNotFound {
+		return nil, errors.WithStack(err)
+	}
+
+	incident = models.NewIncident(test, result)
+	if err := service.incidentRepository.Create(rsdb.GetConnection(), incident); err != nil {
+		if err != rsdb.ErrDuplicateData {
+			return nil, errors.WithStack(err)
+		}
+		incident, err := service.incidentRepository.GetOpenByWebServiceId(rsdb.GetConnection(), test.WebServiceId)
+		return incident, errors.WithStack(err)
+	}
+
+	rslog.Infof("incident is opened: incidentId='%s', webServiceId='%s', testId='%s'", incident.Id, incident.WebServiceId, test.Id)
+	return incident, nil
+}
+
+func (service *IncidentServiceImpl) Close(test *models.Test, result *models.TestResult) {
+	service.recoverTest(test, result.TestedAt)
+}
+
+func (service *IncidentServiceImpl) Remove(test *models.Test) {
+	service.recoverTest(test, time.Now())
+}
+
+// recoverTest recovers the test from its incident at recoveredAt, and closes the incident once all its tests recovered.
+func (service *IncidentServiceImpl) recoverTest(test *models.Test, recoveredAt time.Time) {
+	incidentTest, err := service.incidentTestRepository.GetUnrecoveredByTestId(rsdb.GetConnection(), test.Id)
+	if err != nil {
+		if err != rsdb.ErrRecordNotFound {
+			rslog.Errorf("failed to get incident of test: testId='%s', error='%v'", test.Id, err)
+		}
+		return
+	}
+
+	incidentTest.Recover(recoveredAt)
+	recovered, err := service.incidentTestRepository.Recover(rsdb.GetConnection(), incidentTest)
+	if err != nil {
+		rslog.Errorf("failed to recover test from incident: incidentId='%s', testId='%s', error='%v'", incidentTest.IncidentId, test.Id, err)
+		return
+	}
+	if !recovered {
+		return
+	}
+
+	count, err := service.incidentTestRepository.CountUnrecovered(rsdb.GetConnection(), incidentTest.IncidentId)
+	if err != nil {
+		rslog.Errorf("failed to count tests of incident: incidentId='%s', error='%v'", incidentTest.IncidentId, err)
+		return
+	}
+	if count > 0 {
+		return
+	}
+
+	incident := &models.Incident{Id: incidentTest.IncidentId}
+	if err := service.incidentRepository.GetById(rsdb.GetConnection(), incident); err != nil {
+		rslog.Errorf("failed to get incident: incidentId='%s', error='%v'", incident.Id, err)
+		return
+	}
+	incident.Close(recoveredAt)
+	closed, err := service.incidentRepository.Close(rsdb.GetConnection(), incident)
+	if err != nil {
+		rslog.Errorf("failed to close incident: incidentId='%s', error='%v'", incident.Id, err)
+		return
+	}
+	if closed {
+		rslog.Infof("incident is closed: incidentId='%s', webServiceId='%s', duration='%ds'", incident.Id, incident.WebServiceId, incident.Duration)
+	}
+}
+
+func (service *IncidentServiceImpl) GetIncident(incident *models.Incident) *amerr.ErrorWithLanguage {
+	if rsvalid.IsZero(incident) {
+		rslog.Error(errors.Wrap(rserrors.ErrInvalidParameter, "Incident"))
+		return amerr.GetErrInternalServer()
+	}
+
+	if rsvalid.IsZero(incident.Id) {
+		return amerr.GetErrorsFromCode(amerr.ErrIncidentNotFound)
+	}
+
+	if err := service.incidentRepository.GetById(rsdb.GetConnection(), incident); err != nil {
+		switch err {
+		case rsdb.ErrRecordNotFound:
+			return amerr.GetErrorsFromCode(amerr.ErrIncidentNotFound)
+		default:
+			rslog.Error(err)
+			return amerr.GetErrInternalServer()
+		}
+	}
+
+	return nil
+}
+
+func (service *IncidentServiceImpl) GetIncidentList(request models.IncidentListRequest) (*rsmodels.PaginatedList, *amerr.ErrorWithLanguage) {
+	conditions := map[string]interface{}{}
+	if request.WebServiceId != "" {
+		conditions["web_service_id"] = request.WebServiceId
+	}
+	if request.IsOpen {
+		conditions["ended_at"] = nil
+	}
+
+	items := make([]*models.Incident, 0)
+	totalCount, err := service.incidentRepository.List(rsdb.GetConnection(), &items, rsdb.ListFilter{
+		Page:       request.Page,
+		NumItem:    request.NumItem,
+		Conditions: conditions,
+	}, rsdb.Orders{
+		{
+			Field: "started_at",
+			IsASC: false,
+		},
+	})
+	if err != nil {
+		rslog.Error(err)
+		return nil, amerr.GetErrInternalServer()
+	}
+
+	return &rsmodels.PaginatedList{
+		CurrentPage: request.Page,
+		NumItem:     request.NumItem,
+		TotalCount:  totalCount,
+		Items:       items,
+	}, nil
+}
+
+func (service *IncidentServiceImpl) Acknowledge(incident *models.Incident, request models.IncidentAcknowledgeRequest) *amerr.ErrorWithLanguage {
+	if err := service.GetIncident(incident); err != nil {
+		return err
+	}
+
+	if err := incident.Acknowledge(request.By, time.Now()); err != nil {
+		return amerr.GetErrorsFromCode(amerr.ErrIncidentAcknowledged)
+	}
+
+	acknowledged, err := service.incidentRepository.Acknowledge(rsdb.GetConnection(), incident)
+	if err != nil {
+		rslog.Error(err)
+		return amerr.GetErrInternalServer()
+	}
+	if !acknowledged {
+		return amerr.GetErrorsFromCode(amerr.ErrIncidentAcknowledged)
+	}
+
+	rslog.Infof("incident is acknowledged: incidentId='%s', by='%s'", incident.Id, incident.AcknowledgedBy)
+	return nil
+}
+
+func (service *IncidentServiceImpl) CreateNote(incident *models.Incident, request models.IncidentNoteRequest) (*models.IncidentNote, *amerr.ErrorWithLanguage) {
+	if err := service.GetIncident(incident); err != nil {
+		return nil, err
+	}
+
+	note, err := models.NewIncidentNote(incident, request)
+	if err != nil {
+		rslog.Error(err)
+		return nil, amerr.GetErrorsFromCode(amerr.ErrBadRequest)
+	}
+
+	if err := service.incidentNoteRepository.Create(rsdb.GetConnection(), note); err != nil {
+		switch err {
+		case rsdb.ErrInvalidData:
+			return nil, amerr.GetErrorsFromCode(amerr.ErrBadRequest)
+		default:
+			rslog.Error(err)
+			return nil, amerr.GetErrInternalServer()
+		}
+	}
+
+	return note, nil
+}
+
+func NewIncidentService(
+	incidentRepository repositories.IncidentRepository,
+	incidentTestRepository repositories.IncidentTestRepository,
+	incidentNoteRepository repositories.IncidentNoteRepository,
+) (IncidentService, error) {
+	if rsvalid.IsZero(incidentRepository, incidentTestRepository, incidentNoteRepository) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "IncidentService")
+	}
+	return &IncidentServiceImpl{
+		incidentRepository:     incidentRepository,
+		incidentTestRepository: incidentTestRepository,
+		incidentNoteRepository: incidentNoteRepository,
+	}, nil
+}
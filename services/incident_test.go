@@ -0,0 +1,191 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/repositories/mocks"
+)
+
+func TestIncidentServiceImpl_Open(t *testing.T) {
+	test := &models.Test{Id: "test", WebServiceId: "ws"}
+	result := &models.TestResult{Id: "result", TestId: test.Id, TestedAt: time.Now()}
+	opened := &models.Incident{Id: "opened", WebServiceId: "ws"}
+
+	// getOpen are the open incidents read in turn: "" when there is none, "created" for the one the test opened.
+	tests := []struct {
+		name           string
+		getOpen        []string
+		createErr      error
+		wantCreate     bool
+		wantDeleted    bool
+		wantIncidentId string
+	}{
+		{
+			name:           "joins the open incident",
+			getOpen:        []string{"opened", "opened"},
+			wantIncidentId: "opened",
+		},
+		{
+			name:       "opens an incident",
+			getOpen:    []string{"", "created"},
+			wantCreate: true,
+		},
+		{
+			name:           "opened by another replica in between",
+			getOpen:        []string{"", "opened", "opened"},
+			createErr:      rsdb.ErrDuplicateData,
+			wantCreate:     true,
+			wantIncidentId: "opened",
+		},
+		{
+			name:        "closed by its last test in between",
+			getOpen:     []string{"opened", "", "", "created"},
+			wantCreate:  true,
+			wantDeleted: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created *models.Incident
+			incidentRepository := &mocks.IncidentRepository{}
+			for _, id := range tt.getOpen {
+				switch id {
+				case "":
+					incidentRepository.On("GetOpenByWebServiceId", mock.Anything, "ws").Return(nil, rsdb.ErrRecordNotFound).Once()
+				case "created":
+					incidentRepository.On("GetOpenByWebServiceId", mock.Anything, "ws").Return(func(rsdb.Connection, string) *models.Incident {
+						return created
+					}, nil).Once()
+				default:
+					incidentRepository.On("GetOpenByWebServiceId", mock.Anything, "ws").Return(opened, nil).Once()
+				}
+			}
+			incidentRepository.On("Create", mock.Anything, mock.Anything).Return(tt.createErr).Run(func(args mock.Arguments) {
+				created = args.Get(1).(*models.Incident)
+			})
+
+			var incidentTest, deleted *models.IncidentTest
+			incidentTestRepository := &mocks.IncidentTestRepository{}
+			incidentTestRepository.On("GetUnrecoveredByTestId", mock.Anything, test.Id).Return(nil, rsdb.ErrRecordNotFound)
+			incidentTestRepository.On("Create", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+				incidentTest = args.Get(1).(*models.IncidentTest)
+			})
+			incidentTestRepository.On("DeleteById", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+				deleted = args.Get(1).(*models.IncidentTest)
+			})
+
+			service, err := NewIncidentService(incidentRepository, incidentTestRepository, &mocks.IncidentNoteRepository{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			service.Open(test, result)
+
+			incidentRepository.AssertNumberOfCalls(t, "GetOpenByWebServiceId", len(tt.getOpen))
+			assert.Equal(t, tt.wantCreate, created != nil)
+			if assert.Equal(t, tt.wantDeleted, deleted != nil) && tt.wantDeleted {
+				assert.Equal(t, "opened", deleted.IncidentId)
+			}
+			if !assert.NotNil(t, incidentTest) {
+				return
+			}
+			if tt.wantIncidentId == "" {
+				tt.wantIncidentId = created.Id
+			}
+			assert.Equal(t, tt.wantIncidentId, incidentTest.IncidentId)
+			assert.Equal(t, "result", incidentTest.ResultId)
+		})
+	}
+}
+
+func TestIncidentServiceImpl_Close(t *testing.T) {
+	startedAt := time.Now().Add(-time.Hour)
+	test := &models.Test{Id: "test", WebServiceId: "ws"}
+	result := &models.TestResult{Id: "result", TestId: test.Id, IsSuccess: true, TestedAt: startedAt.Add(10 * time.Minute)}
+
+	tests := []struct {
+		name        string
+		recovered   bool
+		unrecovered int
+		wantClosed  bool
+	}{
+		{name: "last test recovered", recovered: true, wantClosed: true},
+		{name: "other tests still down", recovered: true, unrecovered: 1},
+		{name: "recovered by another replica", recovered: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			incidentTestRepository := &mocks.IncidentTestRepository{}
+			incidentTestRepository.On("GetUnrecoveredByTestId", mock.Anything, test.Id).Return(&models.IncidentTest{Id: "incident-test", IncidentId: "incident", TestId: test.Id}, nil)
+			incidentTestRepository.On("Recover", mock.Anything, mock.Anything).Return(tt.recovered, nil)
+			incidentTestRepository.On("CountUnrecovered", mock.Anything, "incident").Return(tt.unrecovered, nil)
+
+			var closed *models.Incident
+			incidentRepository := &mocks.IncidentRepository{}
+			incidentRepository.On("GetById", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+				incident := args.Get(1).(*models.Incident)
+				incident.WebServiceId = "ws"
+				incident.StartedAt = startedAt
+			})
+			incidentRepository.On("Close", mock.Anything, mock.Anything).Return(true, nil).Run(func(args mock.Arguments) {
+				closed = args.Get(1).(*models.Incident)
+			})
+
+			service, err := NewIncidentService(incidentRepository, incidentTestRepository, &mocks.IncidentNoteRepository{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			service.Close(test, result)
+
+			if !tt.wantClosed {
+				assert.Nil(t, closed)
+				return
+			}
+			if assert.NotNil(t, closed) {
+				assert.Equal(t, "incident", closed.Id)
+				assert.Equal(t, int64(600), closed.Duration)
+				assert.Nil(t, closed.OpenKey)
+			}
+		})
+	}
+}
+
+func TestIncidentServiceImpl_Remove(t *testing.T) {
+	test := &models.Test{Id: "test", WebServiceId: "ws"}
+
+	incidentTestRepository := &mocks.IncidentTestRepository{}
+	incidentTestRepository.On("GetUnrecoveredByTestId", mock.Anything, test.Id).Return(&models.IncidentTest{Id: "incident-test", IncidentId: "incident", TestId: test.Id}, nil)
+	var recovered *models.IncidentTest
+	incidentTestRepository.On("Recover", mock.Anything, mock.Anything).Return(true, nil).Run(func(args mock.Arguments) {
+		recovered = args.Get(1).(*models.IncidentTest)
+	})
+	incidentTestRepository.On("CountUnrecovered", mock.Anything, "incident").Return(0, nil)
+
+	var closed *models.Incident
+	incidentRepository := &mocks.IncidentRepository{}
+	incidentRepository.On("GetById", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.Incident).StartedAt = time.Now().Add(-time.Hour)
+	})
+	incidentRepository.On("Close", mock.Anything, mock.Anything).Return(true, nil).Run(func(args mock.Arguments) {
+		closed = args.Get(1).(*models.Incident)
+	})
+
+	service, err := NewIncidentService(incidentRepository, incidentTestRepository, &mocks.IncidentNoteRepository{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service.Remove(test)
+
+	if assert.NotNil(t, recovered) {
+		assert.NotNil(t, recovered.RecoveredAt)
+	}
+	if assert.NotNil(t, closed) {
+		assert.Equal(t, "incident", closed.Id)
+		assert.NotNil(t, closed.EndedAt)
+	}
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsmodels"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/repositories"
+)
+
+var _ MaintenanceService = &MaintenanceServiceImpl{}
+
+type MaintenanceChecker interface {
+	InMaintenance(webServiceId string, at time.Time) (bool, error)
+	GetMaintenanceWindows(webServiceId string, from, to time.Time) ([]models.TimeWindow, error)
+}
+
+type MaintenanceService interface {
+	MaintenanceChecker
+	CreateMaintenance(webService *models.WebService, request models.MaintenanceRequest) (*models.Maintenance, *amerr.ErrorWithLanguage)
+	GetMaintenance(maintenance *models.Maintenance) *amerr.ErrorWithLanguage
+	GetMaintenanceList(request models.MaintenanceListRequest) (*rsmodels.PaginatedList, *amerr.ErrorWithLanguage)
+	UpdateMaintenance(maintenance *models.Maintenance, request models.MaintenanceRequest) *amerr.ErrorWithLanguage
+	DeleteMaintenance(maintenance *models.Maintenance) *amerr.ErrorWithLanguage
+}
+
+type MaintenanceServiceImpl struct {
+	maintenanceRepository repositories.MaintenanceRepository
+}
+
+func (service *MaintenanceServiceImpl) CreateMaintenance(webService *models.WebService, request models.MaintenanceRequest) (*models.Maintenance, *amerr.ErrorWithLanguage) {
+	if rsvalid.IsZero(webService, request) {
+		rslog.Error(rserrors.ErrInvalidParameter)
+		return nil, amerr.GetErrInternalServer()
+	}
+
+	maintenance, err := models.NewMaintenance(webService, request)
+	if err != nil {
+		rslog.Error(err)
+		return nil, amerr.GetErrorsFromCode(amerr.ErrBadRequest)
+	}
+
+	if err := service.maintenanceRepository.Create(rsdb.GetConnection(), maintenance); err != nil {
+		switch err {
+		case rsdb.ErrForeignKeyConstraint:
+			return nil, amerr.GetErrorsFromCode(amerr.ErrWebServiceNotFound)
+		case rsdb.ErrInvalidData:
+			return nil, amerr.GetErrorsFromCode(amerr.ErrBadRequest)
+		default:
+			rslog.Error(err)
+			return nil, amerr.GetErrInternalServer()
+		}
+	}
+
+	return maintenance, nil
+}
+
+func (service *MaintenanceServiceImpl) GetMaintenance(maintenance *models.Maintenance) *amerr.ErrorWithLanguage {
+	if rsvalid.IsZero(maintenance) {
+		rslog.Error(errors.Wrap(rserrors.ErrInvalidParameter, "Maintenance"))
+		return amerr.GetErrInternalServer()
+	}
+
+	if rsvalid.IsZero(maintenance.Id, maintenance.WebServiceId) {
+		return amerr.GetErrorsFromCode(amerr.ErrMaintenanceNotFound)
+	}
+
+	if err := service.maintenanceRepository.GetByIdAndWebServiceId(rsdb.GetConnection(), maintenance); err != nil {
+		switch err {
+		case rsdb.ErrRecordNotFound:
+			return amerr.GetErrorsFromCode(amerr.ErrMaintenanceNotFound)
+		default:
+			rslog.Error(err)
+			return amerr.GetErrInternalServer()
+		}
+	}
+
+	return nil
+}
+
+func (service *MaintenanceServiceImpl) GetMaintenanceList(request models.MaintenanceListRequest) (*rsmodels.PaginatedList, *amerr.ErrorWithLanguage) {
+	filter := rsdb.ListFilter{
+		Page:    request.Page,
+		NumItem: request.NumItem,
+		Conditions: map[string]interface{}{
+			"web_service_id": request.WebServiceId,
+		},
+	}
+
+	items := make([]*models.Maintenance, 0)
+	totalCount, err := service.maintenanceRepository.GetList(rsdb.GetConnection(), &items, filter, rsdb.Orders{
+		rsdb.Order{
+			Field: "created_at",
+			IsASC: false,
+		},
+	})
+	if err != nil {
+		rslog.Error(err)
+		return nil, amerr.GetErrInternalServer()
+	}
+
+	return &rsmodels.PaginatedList{
+		CurrentPage: request.Page,
+		NumItem:     request.NumItem,
+		TotalCount:  totalCount,
+		Items:       items,
+	}, nil
+}
+
+func (service *MaintenanceServiceImpl) UpdateMaintenance(maintenance *models.Maintenance, request models.MaintenanceRequest) *amerr.ErrorWithLanguage {
+	if err := service.GetMaintenance(maintenance); err != nil {
+		return err
+	}
+
+	if err := maintenance.UpdateFromRequest(request); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest)
+	}
+
+	if err := service.maintenanceRepository.Save(rsdb.GetConnection(), maintenance); err != nil {
+		switch err {
+		case rsdb.ErrRecordNotFound:
+			return amerr.GetErrorsFromCode(amerr.ErrMaintenanceNotFound)
+		case rsdb.ErrInvalidData:
+			return amerr.GetErrorsFromCode(amerr.ErrBadRequest)
+		default:
+			rslog.Error(err)
+			return amerr.GetErrInternalServer()
+		}
+	}
+
+	return nil
+}
+
+func (service *MaintenanceServiceImpl) DeleteMaintenance(maintenance *models.Maintenance) *amerr.ErrorWithLanguage {
+	if err := service.GetMaintenance(maintenance); err != nil {
+		return err
+	}
+
+	if err := service.maintenanceRepository.DeleteById(rsdb.GetConnection(), maintenance); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrInternalServer()
+	}
+
+	return nil
+}
+
+func (service *MaintenanceServiceImpl) InMaintenance(webServiceId string, at time.Time) (bool, error) {
+	maintenances, err := service.getMaintenancesByWebServiceId(webServiceId)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	for _, maintenance := range maintenances {
+		if maintenance.Contains(at) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (service *MaintenanceServiceImpl) GetMaintenanceWindows(webServiceId string, from, to time.Time) ([]models.TimeWindow, error) {
+	maintenances, err := service.getMaintenancesByWebServiceId(webServiceId)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	windows := make([]models.TimeWindow, 0)
+	for _, maintenance := range maintenances {
+		windows = append(windows, maintenance.Windows(from, to)...)
+	}
+	return models.MergeTimeWindows(windows), nil
+}
+
+func (service *MaintenanceServiceImpl) getMaintenancesByWebServiceId(webServiceId string) ([]*models.Maintenance, error) {
+	maintenances := make([]*models.Maintenance, 0)
+	filter := rsdb.ListFilter{
+		Page:    -1,
+		NumItem: -1,
+		Conditions: map[string]interface{}{
+			"web_service_id": webServiceId,
+		},
+	}
+	if _, err := service.maintenanceRepository.GetList(rsdb.GetConnection(), &maintenances, filter, nil); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return maintenances, nil
+}
+
+func NewMaintenanceService(maintenanceRepository repositories.MaintenanceRepository) (MaintenanceService, error) {
+	if rsvalid.IsZero(maintenanceRepository) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "MaintenanceService")
+	}
+	return &MaintenanceServiceImpl{
+		maintenanceRepository: maintenanceRepository,
+	}, nil
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rsmetrics"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+)
+
+// The check metrics are labelled by web service and test, so that they can be aggregated per web service.
+var (
+	checkSuccessMetric = rsmetrics.DefaultRegistry.NewGaugeVec(
+		"apimonitor_check_success",
+		"Whether the last check of the test succeeded (1) or failed (0).",
+		"web_service_id", "test_id",
+	)
+	checkDurationMetric = rsmetrics.DefaultRegistry.NewHistogramVec(
+		"apimonitor_check_duration_seconds",
+		"Response time of the checks of the test.",
+		latencyBucketsSeconds(),
+		"web_service_id", "test_id",
+	)
+	checkLastRunMetric = rsmetrics.DefaultRegistry.NewGaugeVec(
+		"apimonitor_check_last_run_timestamp_seconds",
+		"Unix time of the last check of the test.",
+		"web_service_id", "test_id",
+	)
+	checkConsecutiveFailuresMetric = rsmetrics.DefaultRegistry.NewGaugeVec(
+		"apimonitor_check_consecutive_failures",
+		"Failed checks of the test in a row, not counting those in a maintenance.",
+		"web_service_id", "test_id",
+	)
+
+	scheduledTestsMetric = rsmetrics.DefaultRegistry.NewGaugeVec(
+		"apimonitor_scheduler_scheduled_tests",
+		"Tests scheduled and owned by this replica.",
+	).With()
+	runningChecksMetric = rsmetrics.DefaultRegistry.NewGaugeVec(
+		"apimonitor_scheduler_running_checks",
+		"Checks being executed.",
+	).With()
+	dbWriteDurationMetric = rsmetrics.DefaultRegistry.NewHistogramVec(
+		"apimonitor_db_write_duration_seconds",
+		"Duration of the batch inserts of test results, every attempt included.",
+		[]float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+	).With()
+	alertDeliveriesMetric = rsmetrics.DefaultRegistry.NewCounterVec(
+		"apimonitor_alert_deliveries_total",
+		"Alert deliveries by channel type and status.",
+		"type", "status",
+	)
+	streamSubscriptionsMetric = rsmetrics.DefaultRegistry.NewGaugeVec(
+		"apimonitor_stream_subscriptions",
+		"Clients of the live results stream.",
+	).With()
+	streamLaggedMetric = rsmetrics.DefaultRegistry.NewCounterVec(
+		"apimonitor_stream_lagged_total",
+		"Clients of the live results stream dropped for lagging behind.",
+	).With()
+)
+
+func latencyBucketsSeconds() []float64 {
+	buckets := make([]float64, 0, len(models.LatencyHistogramBounds))
+	for _, bound := range models.LatencyHistogramBounds {
+		buckets = append(buckets, float64(bound)/1000)
+	}
+	return buckets
+}
+
+// observeCheck records the result of a check of the test.
+// A result in a maintenance neither counts as a failure in a row nor ends one, like for the alerts.
+func observeCheck(test *models.Test, result *models.TestResult) {
+	labels := []string{test.WebServiceId, test.Id}
+	success := 0.0
+	if result.IsSuccess {
+		success = 1
+	}
+	checkSuccessMetric.With(labels...).Set(success)
+	checkDurationMetric.With(labels...).Observe(float64(result.ResponseTime) / 1000)
+	checkLastRunMetric.With(labels...).Set(float64(result.TestedAt.UnixNano()) / float64(time.Second))
+
+	failures := checkConsecutiveFailuresMetric.With(labels...)
+	switch {
+	case result.InMaintenance:
+	case result.IsSuccess:
+		failures.Set(0)
+	default:
+		failures.Inc()
+	}
+}
+
+// forgetCheck deletes the metrics of a test which is not scheduled or owned by this replica anymore.
+func forgetCheck(testId string) {
+	checkSuccessMetric.DeleteMatching("test_id", testId)
+	checkDurationMetric.DeleteMatching("test_id", testId)
+	checkLastRunMetric.DeleteMatching("test_id", testId)
+	checkConsecutiveFailuresMetric.DeleteMatching("test_id", testId)
+}
+
+func observeAlertDelivery(delivery *models.AlertDelivery) {
+	status := "success"
+	if !delivery.Success {
+		status = "failure"
+	}
+	alertDeliveriesMetric.With(string(delivery.Alert.Type), status).Inc()
+}
+
+// MetricsService exposes the metrics of the process in the Prometheus text format.
+type MetricsService interface {
+	WriteMetrics(w io.Writer) error
+}
+
+// PrometheusMetricsService writes the metrics recorded by the services,
+// followed by those read from the result writer on every scrape.
+// The scheduler has no queue of its own: the results queue up in the result writer,
+// so its length is exposed as the queue depth of the scheduler.
+type PrometheusMetricsService struct {
+	registry *rsmetrics.Registry
+}
+
+var _ MetricsService = &PrometheusMetricsService{}
+
+func (service *PrometheusMetricsService) WriteMetrics(w io.Writer) error {
+	if err := rsmetrics.DefaultRegistry.Write(w); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(service.registry.Write(w))
+}
+
+func NewMetricsService(resultWriter ResultWriter) (MetricsService, error) {
+	if rsvalid.IsZero(resultWriter) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "MetricsService")
+	}
+
+	registry := rsmetrics.NewRegistry()
+	registry.NewGaugeFunc("apimonitor_scheduler_queue_depth", "Results waiting to be stored.", func() float64 {
+		return float64(resultWriter.Stats().QueueLength)
+	})
+	registry.NewGaugeFunc("apimonitor_scheduler_queue_capacity", "Results which can wait to be stored before the checks block.", func() float64 {
+		return float64(resultWriter.Stats().QueueCapacity)
+	})
+	registry.NewGaugeFunc("apimonitor_scheduler_worker_utilization", "Ratio of the scheduled tests being executed.", func() float64 {
+		scheduled := scheduledTestsMetric.Value()
+		if scheduled == 0 {
+			return 0
+		}
+		return runningChecksMetric.Value() / scheduled
+	})
+	registry.NewCounterFunc("apimonitor_result_writer_written_total", "Test results stored.", func() float64 {
+		return float64(resultWriter.Stats().Written)
+	})
+	registry.NewCounterFunc("apimonitor_result_writer_retries_total", "Retries of the batch inserts of test results.", func() float64 {
+		return float64(resultWriter.Stats().Retries)
+	})
+	registry.NewCounterFunc("apimonitor_result_writer_dropped_total", "Test results dropped after the retries.", func() float64 {
+		return float64(resultWriter.Stats().Dropped)
+	})
+	registry.NewCounterFunc("apimonitor_result_writer_blocked_writes_total", "Test results which waited for free space in the queue.", func() float64 {
+		return float64(resultWriter.Stats().BlockedWrites)
+	})
+	return &PrometheusMetricsService{registry: registry}, nil
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rsmetrics"
+)
+
+type statsResultWriter struct {
+	ResultWriter
+	stats ResultWriterStats
+}
+
+func (writer statsResultWriter) Stats() ResultWriterStats { return writer.stats }
+
+func TestPrometheusMetricsService_WriteMetrics(t *testing.T) {
+	test := &models.Test{Id: "metrics-test", WebServiceId: "metrics-web-service"}
+	testedAt := time.Unix(1580515200, 0)
+	for _, result := range []*models.TestResult{
+		{IsSuccess: true, ResponseTime: 80, TestedAt: testedAt},
+		{IsSuccess: false, ResponseTime: 300, TestedAt: testedAt},
+		{IsSuccess: false, ResponseTime: 20, TestedAt: testedAt, InMaintenance: true},
+		{IsSuccess: false, ResponseTime: 40000, TestedAt: testedAt.Add(time.Minute)},
+	} {
+		observeCheck(test, result)
+	}
+
+	service, err := NewMetricsService(statsResultWriter{stats: ResultWriterStats{QueueLength: 3, QueueCapacity: 1000, Written: 42}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if err := service.WriteMetrics(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	labels := `{web_service_id="metrics-web-service",test_id="metrics-test"`
+	for _, line := range []string{
+		"apimonitor_check_success" + labels + "} 0",
+		"apimonitor_check_consecutive_failures" + labels + "} 2",
+		"apimonitor_check_last_run_timestamp_seconds" + labels + "} 1.58051526e+09",
+		"apimonitor_check_duration_seconds_bucket" + labels + `,le="0.1"} 2`,
+		"apimonitor_check_duration_seconds_bucket" + labels + `,le="30"} 3`,
+		"apimonitor_check_duration_seconds_bucket" + labels + `,le="+Inf"} 4`,
+		"apimonitor_check_duration_seconds_count" + labels + "} 4",
+		"apimonitor_scheduler_queue_depth 3",
+		"apimonitor_scheduler_queue_capacity 1000",
+		"apimonitor_result_writer_written_total 42",
+	} {
+		assert.Contains(t, buf.String(), line+"\n")
+	}
+
+	forgetCheck(test.Id)
+	buf.Reset()
+	if err := service.WriteMetrics(buf); err != nil {
+		t.Fatal(err)
+	}
+	assert.NotContains(t, buf.String(), labels)
+}
+
+func TestTestScheduler_SetOwned(t *testing.T) {
+	test := &models.Test{Id: "owned-test", WebServiceId: "owned-web-service"}
+	schedule := &testScheduler{test: test}
+	scheduled := scheduledTestsMetric.Value()
+
+	schedule.setOwned(true)
+	schedule.setOwned(true)
+	assert.Equal(t, scheduled+1, scheduledTestsMetric.Value())
+	observeCheck(test, &models.TestResult{IsSuccess: true, ResponseTime: 80, TestedAt: time.Now()})
+
+	// Another replica took the test over.
+	schedule.setOwned(false)
+	assert.Equal(t, scheduled, scheduledTestsMetric.Value())
+	buf := &bytes.Buffer{}
+	if err := rsmetrics.DefaultRegistry.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	assert.NotContains(t, buf.String(), `test_id="owned-test"`)
+}
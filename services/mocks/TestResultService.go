@@ -2,16 +2,33 @@
 
 package mocks
 
-import amerr "github.com/realsangil/apimonitor/pkg/amerr"
-import mock "github.com/stretchr/testify/mock"
 import models "github.com/realsangil/apimonitor/models"
+import amerr "github.com/realsangil/apimonitor/pkg/amerr"
 import rsmodels "github.com/realsangil/apimonitor/pkg/rsmodels"
+import mock "github.com/stretchr/testify/mock"
+import io "io"
 
 // TestResultService is an autogenerated mock type for the TestResultService type
 type TestResultService struct {
 	mock.Mock
 }
 
+// ExportResults provides a mock function with given fields: request, w
+func (_m *TestResultService) ExportResults(request models.TestResultExportRequest, w io.Writer) *amerr.ErrorWithLanguage {
+	ret := _m.Called(request, w)
+
+	var r0 *amerr.ErrorWithLanguage
+	if rf, ok := ret.Get(0).(func(models.TestResultExportRequest, io.Writer) *amerr.ErrorWithLanguage); ok {
+		r0 = rf(request, w)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*amerr.ErrorWithLanguage)
+		}
+	}
+
+	return r0
+}
+
 // GetResultListByTestId provides a mock function with given fields: test, request
 func (_m *TestResultService) GetResultListByTestId(test *models.Test, request models.TestResultListRequest) (*rsmodels.PaginatedList, *amerr.ErrorWithLanguage) {
 	ret := _m.Called(test, request)
@@ -36,3 +53,28 @@ func (_m *TestResultService) GetResultListByTestId(test *models.Test, request mo
 
 	return r0, r1
 }
+
+// GetResultListByWebServiceId provides a mock function with given fields: webService, request
+func (_m *TestResultService) GetResultListByWebServiceId(webService *models.WebService, request models.TestResultListRequest) (*rsmodels.PaginatedList, *amerr.ErrorWithLanguage) {
+	ret := _m.Called(webService, request)
+
+	var r0 *rsmodels.PaginatedList
+	if rf, ok := ret.Get(0).(func(*models.WebService, models.TestResultListRequest) *rsmodels.PaginatedList); ok {
+		r0 = rf(webService, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*rsmodels.PaginatedList)
+		}
+	}
+
+	var r1 *amerr.ErrorWithLanguage
+	if rf, ok := ret.Get(1).(func(*models.WebService, models.TestResultListRequest) *amerr.ErrorWithLanguage); ok {
+		r1 = rf(webService, request)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*amerr.ErrorWithLanguage)
+		}
+	}
+
+	return r0, r1
+}
@@ -2,10 +2,10 @@
 
 package mocks
 
-import amerr "github.com/realsangil/apimonitor/pkg/amerr"
-import mock "github.com/stretchr/testify/mock"
 import models "github.com/realsangil/apimonitor/models"
+import amerr "github.com/realsangil/apimonitor/pkg/amerr"
 import rsmodels "github.com/realsangil/apimonitor/pkg/rsmodels"
+import mock "github.com/stretchr/testify/mock"
 
 // TestService is an autogenerated mock type for the TestService type
 type TestService struct {
@@ -53,6 +53,47 @@ func (_m *TestService) DeleteTestById(endpoint *models.Test) *amerr.ErrorWithLan
 	return r0
 }
 
+// DryRunTest provides a mock function with given fields: webService, request
+func (_m *TestService) DryRunTest(webService *models.WebService, request models.TestRequest) (*models.TestResult, *amerr.ErrorWithLanguage) {
+	ret := _m.Called(webService, request)
+
+	var r0 *models.TestResult
+	if rf, ok := ret.Get(0).(func(*models.WebService, models.TestRequest) *models.TestResult); ok {
+		r0 = rf(webService, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.TestResult)
+		}
+	}
+
+	var r1 *amerr.ErrorWithLanguage
+	if rf, ok := ret.Get(1).(func(*models.WebService, models.TestRequest) *amerr.ErrorWithLanguage); ok {
+		r1 = rf(webService, request)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*amerr.ErrorWithLanguage)
+		}
+	}
+
+	return r0, r1
+}
+
+// ExecuteTest provides a mock function with given fields: test
+func (_m *TestService) ExecuteTest(test *models.Test) *amerr.ErrorWithLanguage {
+	ret := _m.Called(test)
+
+	var r0 *amerr.ErrorWithLanguage
+	if rf, ok := ret.Get(0).(func(*models.Test) *amerr.ErrorWithLanguage); ok {
+		r0 = rf(test)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*amerr.ErrorWithLanguage)
+		}
+	}
+
+	return r0
+}
+
 // GetTestById provides a mock function with given fields: endpoint
 func (_m *TestService) GetTestById(endpoint *models.Test) *amerr.ErrorWithLanguage {
 	ret := _m.Called(endpoint)
@@ -93,3 +134,44 @@ func (_m *TestService) GetTestList(request models.TestListRequest) (*rsmodels.Pa
 
 	return r0, r1
 }
+
+// RunTest provides a mock function with given fields: test
+func (_m *TestService) RunTest(test *models.Test) (*models.TestResult, *amerr.ErrorWithLanguage) {
+	ret := _m.Called(test)
+
+	var r0 *models.TestResult
+	if rf, ok := ret.Get(0).(func(*models.Test) *models.TestResult); ok {
+		r0 = rf(test)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.TestResult)
+		}
+	}
+
+	var r1 *amerr.ErrorWithLanguage
+	if rf, ok := ret.Get(1).(func(*models.Test) *amerr.ErrorWithLanguage); ok {
+		r1 = rf(test)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*amerr.ErrorWithLanguage)
+		}
+	}
+
+	return r0, r1
+}
+
+// UpdateTestById provides a mock function with given fields: test, request
+func (_m *TestService) UpdateTestById(test *models.Test, request models.TestRequest) *amerr.ErrorWithLanguage {
+	ret := _m.Called(test, request)
+
+	var r0 *amerr.ErrorWithLanguage
+	if rf, ok := ret.Get(0).(func(*models.Test, models.TestRequest) *amerr.ErrorWithLanguage); ok {
+		r0 = rf(test, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*amerr.ErrorWithLanguage)
+		}
+	}
+
+	return r0
+}
@@ -2,10 +2,10 @@
 
 package mocks
 
-import amerr "github.com/realsangil/apimonitor/pkg/amerr"
-import mock "github.com/stretchr/testify/mock"
 import models "github.com/realsangil/apimonitor/models"
+import amerr "github.com/realsangil/apimonitor/pkg/amerr"
 import rsmodels "github.com/realsangil/apimonitor/pkg/rsmodels"
+import mock "github.com/stretchr/testify/mock"
 
 // WebServiceService is an autogenerated mock type for the WebServiceService type
 type WebServiceService struct {
@@ -53,6 +53,22 @@ func (_m *WebServiceService) DeleteWebServiceById(webService *models.WebService)
 	return r0
 }
 
+// ExecuteTests provides a mock function with given fields: webService
+func (_m *WebServiceService) ExecuteTests(webService *models.WebService) *amerr.ErrorWithLanguage {
+	ret := _m.Called(webService)
+
+	var r0 *amerr.ErrorWithLanguage
+	if rf, ok := ret.Get(0).(func(*models.WebService) *amerr.ErrorWithLanguage); ok {
+		r0 = rf(webService)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*amerr.ErrorWithLanguage)
+		}
+	}
+
+	return r0
+}
+
 // GetWebServiceById provides a mock function with given fields: webService
 func (_m *WebServiceService) GetWebServiceById(webService *models.WebService) *amerr.ErrorWithLanguage {
 	ret := _m.Called(webService)
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsmodels"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/repositories"
+)
+
+var _ NotificationChannelService = &NotificationChannelServiceImpl{}
+
+// ChannelResolver resolves the notification channels a test is alerted through when it alerts,
+// so that a change of a channel or a binding applies to the next alert without rescheduling.
+type ChannelResolver interface {
+	ResolveAlerts(test *models.Test) (models.Alerts, error)
+}
+
+type NotificationChannelService interface {
+	ChannelResolver
+	CreateChannel(request models.NotificationChannelRequest) (*models.NotificationChannel, *amerr.ErrorWithLanguage)
+	GetChannel(channel *models.NotificationChannel) *amerr.ErrorWithLanguage
+	GetChannelList(request models.NotificationChannelListRequest) (*rsmodels.PaginatedList, *amerr.ErrorWithLanguage)
+	UpdateChannel(channel *models.NotificationChannel, request models.NotificationChannelRequest) *amerr.ErrorWithLanguage
+	DeleteChannel(channel *models.NotificationChannel) *amerr.ErrorWithLanguage
+	CreateBinding(channel *models.NotificationChannel, request models.NotificationChannelBindingRequest) (*models.NotificationChannelBinding, *amerr.ErrorWithLanguage)
+	GetBinding(binding *models.NotificationChannelBinding) *amerr.ErrorWithLanguage
+	GetBindingList(request models.NotificationChannelBindingListRequest) (*rsmodels.PaginatedList, *amerr.ErrorWithLanguage)
+	UpdateBinding(binding *models.NotificationChannelBinding, request models.NotificationChannelBindingRequest) *amerr.ErrorWithLanguage
+	DeleteBinding(binding *models.NotificationChannelBinding) *amerr.ErrorWithLanguage
+	GetEffectiveBindings(test *models.Test) (models.NotificationChannelBindings, *amerr.ErrorWithLanguage)
+}
+
+type NotificationChannelServiceImpl struct {
+	channelRepository repositories.NotificationChannelRepository
+	bindingRepository repositories.NotificationChannelBindingRepository
+}
+
+func (service *NotificationChannelServiceImpl) CreateChannel(request models.NotificationChannelRequest) (*models.NotificationChannel, *amerr.ErrorWithLanguage) {
+	channel, err := models.NewNotificationChannel(request)
+	if err != nil {
+		rslog.Error(err)
+		return nil, amerr.GetErrorsFromCode(amerr.ErrBadRequest)
+	}
+
+	if err := service.channelRepository.Create(rsdb.GetConnection(), channel); err != nil {
+		switch err {
+		case rsdb.ErrDuplicateData:
+			return nil, amerr.GetErrorsFromCode(amerr.ErrDuplicatedNotificationChannel)
+		case rsdb.ErrInvalidData:
+			return nil, amerr.GetErrorsFromCode(amerr.ErrBadRequest)
+		default:
+			rslog.Error(err)
+			return nil, amerr.GetErrInternalServer()
+		}
+	}
+
+	return channel, nil
+}
+
+func (service *NotificationChannelServiceImpl) GetChannel(channel *models.NotificationChannel) *amerr.ErrorWithLanguage {
+	if rsvalid.IsZero(channel) {
+		rslog.Error(errors.Wrap(rserrors.ErrInvalidParameter, "NotificationChannel"))
+		return amerr.GetErrInternalServer()
+	}
+
+	if rsvalid.IsZero(channel.Id) {
+		return amerr.GetErrorsFromCode(amerr.ErrNotificationChannelNotFound)
+	}
+
+	if err := service.channelRepository.GetById(rsdb.GetConnection(), channel); err != nil {
+		switch err {
+		case rsdb.ErrRecordNotFound:
+			return amerr.GetErrorsFromCode(amerr.ErrNotificationChannelNotFound)
+		default:
+			rslog.Error(err)
+			return amerr.GetErrInternalServer()
+		}
+	}
+
+	return nil
+}
+
+func (service *NotificationChannelServiceImpl) GetChannelList(request models.NotificationChannelListRequest) (*rsmodels.PaginatedList, *amerr.ErrorWithLanguage) {
+	items := make([]*models.NotificationChannel, 0)
+	totalCount, err := service.channelRepository.List(rsdb.GetConnection(), &items, rsdb.ListFilter{
+		Page:    request.Page,
+		NumItem: request.NumItem,
+	}, rsdb.Orders{
+		{
+			Field: "name",
+			IsASC: true,
+		},
+	})
+	if err != nil {
+		rslog.Error(err)
+		return nil, amerr.GetErrInternalServer()
+	}
+
+	return &rsmodels.PaginatedList{
+		CurrentPage: request.Page,
+		NumItem:     request.NumItem,
+		TotalCount:  totalCount,
+		Items:       items,
+	}, nil
+}
+
+func (service *NotificationChannelServiceImpl) UpdateChannel(channel *models.NotificationChannel, request models.NotificationChannelRequest) *amerr.ErrorWithLanguage {
+	if err := service.GetChannel(channel); err != nil {
+		return err
+	}
+
+	if err := channel.UpdateFromRequest(request); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest)
+	}
+
+	if err := service.channelRepository.Save(rsdb.GetConnection(), channel); err != nil {
+		switch err {
+		case rsdb.ErrDuplicateData:
+			return amerr.GetErrorsFromCode(amerr.ErrDuplicatedNotificationChannel)
+		case rsdb.ErrInvalidData:
+			return amerr.GetErrorsFromCode(amerr.ErrBadRequest)
+		default:
+			rslog.Error(err)
+			return amerr.GetErrInternalServer()
+		}
+	}
+
+	return nil
+}
+
+// DeleteChannel deletes the channel with its bindings.
+func (service *NotificationChannelServiceImpl) DeleteChannel(channel *models.NotificationChannel) *amerr.ErrorWithLanguage {
+	if err := service.GetChannel(channel); err != nil {
+		return err
+	}
+
+	if err := service.channelRepository.DeleteById(rsdb.GetConnection(), channel); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrInternalServer()
+	}
+
+	return nil
+}
+
+// CreateBinding binds the channel at the scope of the request.
+// Whether the WebService or the test of the scope exists is checked by the caller.
+func (service *NotificationChannelServiceImpl) CreateBinding(channel *models.NotificationChannel, request models.NotificationChannelBindingRequest) (*models.NotificationChannelBinding, *amerr.ErrorWithLanguage) {
+	if rsvalid.IsZero(channel) {
+		rslog.Error(errors.Wrap(rserrors.ErrInvalidParameter, "NotificationChannel"))
+		return nil, amerr.GetErrInternalServer()
+	}
+
+	binding, err := models.NewNotificationChannelBinding(channel, request)
+	if err != nil {
+		rslog.Error(err)
+		return nil, amerr.GetErrorsFromCode(amerr.ErrBadRequest)
+	}
+
+	if err := service.bindingRepository.Create(rsdb.GetConnection(), binding); err != nil {
+		switch err {
+		case rsdb.ErrDuplicateData:
+			return nil, amerr.GetErrorsFromCode(amerr.ErrDuplicatedNotificationChannelBinding)
+		case rsdb.ErrForeignKeyConstraint:
+			return nil, amerr.GetErrorsFromCode(amerr.ErrNotificationChannelNotFound)
+		case rsdb.ErrInvalidData:
+			return nil, amerr.GetErrorsFromCode(amerr.ErrBadRequest)
+		default:
+			rslog.Error(err)
+			return nil, amerr.GetErrInternalServer()
+		}
+	}
+
+	return binding, nil
+}
+
+func (service *NotificationChannelServiceImpl) GetBinding(binding *models.NotificationChannelBinding) *amerr.ErrorWithLanguage {
+	if rsvalid.IsZero(binding) {
+		rslog.Error(errors.Wrap(rserrors.ErrInvalidParameter, "NotificationChannelBinding"))
+		return amerr.GetErrInternalServer()
+	}
+
+	if rsvalid.IsZero(binding.Id, binding.ChannelId) {
+		return amerr.GetErrorsFromCode(amerr.ErrNotificationChannelBindingNotFound)
+	}
+
+	if err := service.bindingRepository.GetByIdAndChannelId(rsdb.GetConnection(), binding); err != nil {
+		switch err {
+		case rsdb.ErrRecordNotFound:
+			return amerr.GetErrorsFromCode(amerr.ErrNotificationChannelBindingNotFound)
+		default:
+			rslog.Error(err)
+			return amerr.GetErrInternalServer()
+		}
+	}
+
+	return nil
+}
+
+func (service *NotificationChannelServiceImpl) GetBindingList(request models.NotificationChannelBindingListRequest) (*rsmodels.PaginatedList, *amerr.ErrorWithLanguage) {
+	items := make([]*models.NotificationChannelBinding, 0)
+	totalCount, err := service.bindingRepository.List(rsdb.GetConnection(), &items, rsdb.ListFilter{
+		Page:    request.Page,
+		NumItem: request.NumItem,
+		Conditions: map[string]interface{}{
+			"channel_id": request.ChannelId,
+		},
+	}, rsdb.Orders{
+		{
+			Field: "created_at",
+			IsASC: true,
+		},
+	})
+	if err != nil {
+		rslog.Error(err)
+		return nil, amerr.GetErrInternalServer()
+	}
+
+	return &rsmodels.PaginatedList{
+		CurrentPage: request.Page,
+		NumItem:     request.NumItem,
+		TotalCount:  totalCount,
+		Items:       items,
+	}, nil
+}
+
+// UpdateBinding changes the overrides of the binding. Its scope is kept.
+func (service *NotificationChannelServiceImpl) UpdateBinding(binding *models.NotificationChannelBinding, request models.NotificationChannelBindingRequest) *amerr.ErrorWithLanguage {
+	if err := service.GetBinding(binding); err != nil {
+		return err
+	}
+
+	if err := binding.UpdateFromRequest(request); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrorsFromCode(amerr.ErrBadRequest)
+	}
+
+	if err := service.bindingRepository.Save(rsdb.GetConnection(), binding); err != nil {
+		switch err {
+		case rsdb.ErrInvalidData:
+			return amerr.GetErrorsFromCode(amerr.ErrBadRequest)
+		default:
+			rslog.Error(err)
+			return amerr.GetErrInternalServer()
+		}
+	}
+
+	return nil
+}
+
+func (service *NotificationChannelServiceImpl) DeleteBinding(binding *models.NotificationChannelBinding) *amerr.ErrorWithLanguage {
+	if err := service.GetBinding(binding); err != nil {
+		return err
+	}
+
+	if err := service.bindingRepository.DeleteById(rsdb.GetConnection(), binding); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrInternalServer()
+	}
+
+	return nil
+}
+
+// GetEffectiveBindings returns the bindings the test is alerted through.
+func (service *NotificationChannelServiceImpl) GetEffectiveBindings(test *models.Test) (models.NotificationChannelBindings, *amerr.ErrorWithLanguage) {
+	bindings, err := service.bindingRepository.GetListByTest(rsdb.GetConnection(), test)
+	if err != nil {
+		rslog.Error(err)
+		return nil, amerr.GetErrInternalServer()
+	}
+	return bindings.Effective(), nil
+}
+
+func (service *NotificationChannelServiceImpl) ResolveAlerts(test *models.Test) (models.Alerts, error) {
+	bindings, err := service.bindingRepository.GetListByTest(rsdb.GetConnection(), test)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return bindings.Effective().Alerts(), nil
+}
+
+func NewNotificationChannelService(
+	channelRepository repositories.NotificationChannelRepository,
+	bindingRepository repositories.NotificationChannelBindingRepository,
+) (NotificationChannelService, error) {
+	if rsvalid.IsZero(channelRepository, bindingRepository) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "NotificationChannelService")
+	}
+	return &NotificationChannelServiceImpl{
+		channelRepository: channelRepository,
+		bindingRepository: bindingRepository,
+	}, nil
+}
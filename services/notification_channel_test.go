@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rsnotify"
+	"github.com/realsangil/apimonitor/repositories/mocks"
+)
+
+func TestNotificationChannelServiceImpl_ResolveAlerts(t *testing.T) {
+	var (
+		mux      sync.Mutex
+		messages = map[string][]string{}
+	)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]string{}
+		_ = jsoniter.NewDecoder(r.Body).Decode(&body)
+		mux.Lock()
+		messages[r.URL.Path] = append(messages[r.URL.Path], body["text"])
+		mux.Unlock()
+	}))
+	defer webhook.Close()
+
+	channel := func(name string) *models.NotificationChannel {
+		return &models.NotificationChannel{
+			Id:     name,
+			Name:   name,
+			Type:   rsnotify.TypeWebhook,
+			Config: models.NotifierConfig(`{"url": "` + webhook.URL + `/` + name + `"}`),
+		}
+	}
+	ops, dev, qa := channel("ops"), channel("dev"), channel("qa")
+
+	test := &models.Test{
+		Id:           "test",
+		Name:         "health",
+		WebServiceId: "ws",
+		Alerts:       models.Alerts{{Type: rsnotify.TypeWebhook, Config: []byte(`{"url": "` + webhook.URL + `/test"}`)}},
+		AlertPolicy:  models.AlertPolicy{FailureThreshold: 1},
+	}
+
+	// The bindings are read when the test alerts, so the second alert sees the binding added in between.
+	bindings := models.NotificationChannelBindings{
+		{ChannelId: "ops", Channel: ops, Scope: models.ChannelScopeGlobal},
+		{ChannelId: "dev", Channel: dev, Scope: models.ChannelScopeWebService, ScopeId: "ws", Template: "{{.Status}} {{.Test.Name}}"},
+		{ChannelId: "ops", Channel: ops, Scope: models.ChannelScopeTest, ScopeId: "test", Disabled: true},
+	}
+	bindingRepository := &mocks.NotificationChannelBindingRepository{}
+	bindingRepository.On("GetListByTest", mock.Anything, test).Return(func(rsdb.Connection, *models.Test) models.NotificationChannelBindings {
+		return bindings
+	}, nil)
+
+	service, err := NewNotificationChannelService(&mocks.NotificationChannelRepository{}, bindingRepository)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := models.NewAlertState(test.Id)
+	stateRepository := &mocks.AlertStateRepository{}
+	stateRepository.On("GetByTestId", mock.Anything, test.Id).Return(func(rsdb.Connection, string) *models.AlertState {
+		return state
+	}, nil)
+	stateRepository.On("Save", mock.Anything, mock.Anything).Return(nil)
+	deliveryRepository := &mocks.AlertDeliveryRepository{}
+	deliveryRepository.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	manager, err := NewTestAlertManager(stateRepository, deliveryRepository, service, &fakeEscalator{}, &fakeIncidentRecorder{}, newTestResultHub(t), &fakeAlertManagerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manager.HandleResult(test, &models.TestResult{TestId: test.Id, IsSuccess: false, TestedAt: time.Now()})
+	bindings = append(bindings, &models.NotificationChannelBinding{ChannelId: "qa", Channel: qa, Scope: models.ChannelScopeTest, ScopeId: "test"})
+	manager.HandleResult(test, &models.TestResult{TestId: test.Id, IsSuccess: true, TestedAt: time.Now()})
+	assert.NoError(t, manager.Shutdown(context.Background()))
+
+	mux.Lock()
+	defer mux.Unlock()
+	assert.Len(t, messages["/test"], 2)
+	assert.Equal(t, []string{"DOWN health", "RECOVERED health"}, messages["/dev"])
+	assert.Empty(t, messages["/ops"])
+	if assert.Len(t, messages["/qa"], 1) {
+		assert.Contains(t, messages["/qa"][0], "[RECOVERED] health")
+	}
+}
+
+func TestNotificationChannelServiceImpl_CreateChannel(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  models.NotificationChannelRequest
+		createFn error
+		want     *amerr.ErrorWithLanguage
+	}{
+		{
+			name:    "created",
+			request: models.NotificationChannelRequest{Name: "ops", Type: rsnotify.TypeWebhook, Config: models.NotifierConfig(`{"url": "https://ops.example.com"}`)},
+		},
+		{
+			name:    "invalid config",
+			request: models.NotificationChannelRequest{Name: "ops", Type: rsnotify.TypeWebhook, Config: models.NotifierConfig(`{}`)},
+			want:    amerr.GetErrorsFromCode(amerr.ErrBadRequest),
+		},
+		{
+			name:     "duplicated name",
+			request:  models.NotificationChannelRequest{Name: "ops", Type: rsnotify.TypeWebhook, Config: models.NotifierConfig(`{"url": "https://ops.example.com"}`)},
+			createFn: rsdb.ErrDuplicateData,
+			want:     amerr.GetErrorsFromCode(amerr.ErrDuplicatedNotificationChannel),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository := &mocks.NotificationChannelRepository{}
+			repository.On("Create", mock.Anything, mock.Anything).Return(tt.createFn)
+
+			service, err := NewNotificationChannelService(repository, &mocks.NotificationChannelBindingRepository{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			channel, aerr := service.CreateChannel(tt.request)
+			assert.Equal(t, tt.want, aerr)
+			if tt.want == nil && assert.NotNil(t, channel) {
+				assert.NotEmpty(t, channel.Id)
+				assert.Equal(t, tt.request.Name, channel.Name)
+			}
+		})
+	}
+}
@@ -0,0 +1,388 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsmodels"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/repositories"
+)
+
+const (
+	DefaultReportInterval = 15 * time.Minute
+	DefaultReportLimit    = 5
+)
+
+type ReportConfig interface {
+	// GetPeriods are the periods reports are generated for.
+	GetPeriods() []models.ReportPeriod
+	GetInterval() time.Duration
+	GetDelay() time.Duration
+	// GetLimit is how many slowest endpoints, regressions and failing assertions a report lists.
+	GetLimit() int
+}
+
+var _ ReportService = &ReportServiceImpl{}
+
+type ReportService interface {
+	GetReport(report *models.Report) *amerr.ErrorWithLanguage
+	GetReportList(request models.ReportListRequest) (*rsmodels.PaginatedList, *amerr.ErrorWithLanguage)
+}
+
+type ReportServiceImpl struct {
+	reportRepository repositories.ReportRepository
+}
+
+func (service *ReportServiceImpl) GetReport(report *models.Report) *amerr.ErrorWithLanguage {
+	if rsvalid.IsZero(report) {
+		rslog.Error(errors.Wrap(rserrors.ErrInvalidParameter, "Report"))
+		return amerr.GetErrInternalServer()
+	}
+
+	if rsvalid.IsZero(report.Id) {
+		return amerr.GetErrorsFromCode(amerr.ErrReportNotFound)
+	}
+
+	if err := service.reportRepository.GetById(rsdb.GetConnection(), report); err != nil {
+		switch err {
+		case rsdb.ErrRecordNotFound:
+			return amerr.GetErrorsFromCode(amerr.ErrReportNotFound)
+		default:
+			rslog.Error(err)
+			return amerr.GetErrInternalServer()
+		}
+	}
+
+	return nil
+}
+
+func (service *ReportServiceImpl) GetReportList(request models.ReportListRequest) (*rsmodels.PaginatedList, *amerr.ErrorWithLanguage) {
+	conditions := map[string]interface{}{}
+	if request.WebServiceId != "" {
+		conditions["web_service_id"] = request.WebServiceId
+	}
+	if request.Period != "" {
+		conditions["period"] = request.Period
+	}
+
+	items := make([]*models.Report, 0)
+	totalCount, err := service.reportRepository.List(rsdb.GetConnection(), &items, rsdb.ListFilter{
+		Page:       request.Page,
+		NumItem:    request.NumItem,
+		Conditions: conditions,
+	}, rsdb.Orders{
+		{
+			Field: "start",
+			IsASC: false,
+		},
+	})
+	if err != nil {
+		rslog.Error(err)
+		return nil, amerr.GetErrInternalServer()
+	}
+
+	return &rsmodels.PaginatedList{
+		CurrentPage: request.Page,
+		NumItem:     request.NumItem,
+		TotalCount:  totalCount,
+		Items:       items,
+	}, nil
+}
+
+func NewReportService(reportRepository repositories.ReportRepository) (ReportService, error) {
+	if rsvalid.IsZero(reportRepository) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "ReportService")
+	}
+	return &ReportServiceImpl{
+		reportRepository: reportRepository,
+	}, nil
+}
+
+// ReportGenerator generates the digest reports of the web services once their periods are over.
+type ReportGenerator interface {
+	ScheduleRunner
+	ScheduleShutdowner
+}
+
+// WebServiceReportGenerator generates, a delay after each period, the report of every web service
+// which has none for the period yet, and sends it through the channels bound globally or to the web service.
+// Only the leader replica generates, and the unique period of a report keeps it from being generated twice.
+type WebServiceReportGenerator struct {
+	webServiceRepository repositories.WebServiceRepository
+	testRepository       repositories.TestRepository
+	testResultRepository repositories.TestResultRepository
+	incidentRepository   repositories.IncidentRepository
+	reportRepository     repositories.ReportRepository
+	bindingRepository    repositories.NotificationChannelBindingRepository
+	uptimeService        UptimeService
+	statisticsService    StatisticsService
+	coordinator          Coordinator
+	alertManagerConfig   AlertManagerConfig
+	periods              []models.ReportPeriod
+	interval             time.Duration
+	delay                time.Duration
+	limit                int
+
+	mux        sync.Mutex
+	isRunning  bool
+	isShutdown bool
+	closeChan  chan bool
+	doneChan   chan bool
+}
+
+func (generator *WebServiceReportGenerator) Run() error {
+	generator.mux.Lock()
+	if generator.isShutdown {
+		generator.mux.Unlock()
+		return nil
+	}
+	generator.isRunning = true
+	generator.mux.Unlock()
+	defer close(generator.doneChan)
+
+	ticker := time.NewTicker(generator.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			generator.generate(time.Now())
+		case <-generator.closeChan:
+			rslog.Debug("Closed WebServiceReportGenerator")
+			return nil
+		}
+	}
+}
+
+func (generator *WebServiceReportGenerator) Shutdown(ctx context.Context) error {
+	generator.mux.Lock()
+	if generator.isShutdown {
+		generator.mux.Unlock()
+		return nil
+	}
+	generator.isShutdown = true
+	isRunning := generator.isRunning
+	generator.mux.Unlock()
+
+	if !isRunning {
+		return nil
+	}
+
+	close(generator.closeChan)
+	select {
+	case <-generator.doneChan:
+		return nil
+	case <-ctx.Done():
+		return errors.WithStack(ctx.Err())
+	}
+}
+
+func (generator *WebServiceReportGenerator) generate(now time.Time) {
+	if len(generator.periods) == 0 || !generator.coordinator.IsLeader() {
+		return
+	}
+	webServices, err := generator.webServiceRepository.GetAllWebServices(rsdb.GetConnection())
+	if err != nil {
+		rslog.Errorf("failed to get web services to report: error='%v'", err)
+		return
+	}
+	for _, period := range generator.periods {
+		window := period.Window(now.Add(-generator.delay))
+		for _, webService := range webServices {
+			if err := generator.generateReport(webService, period, window); err != nil {
+				rslog.Errorf("failed to generate report: webServiceId='%s', period='%s', start='%s', error='%v'",
+					webService.Id, period, window.Start.Format(time.RFC3339), err)
+			}
+		}
+	}
+}
+
+// generateReport saves the report of the web service for the window and then sends it,
+// unless it was already generated.
+func (generator *WebServiceReportGenerator) generateReport(webService *models.WebService, period models.ReportPeriod, window models.TimeWindow) error {
+	conn := rsdb.GetConnection()
+	exist, err := generator.reportRepository.Exists(conn, webService.Id, period, window.Start)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if exist {
+		return nil
+	}
+
+	summary, err := generator.summarize(webService, period, window)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	report, err := models.NewReport(webService, period, window, summary)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := generator.reportRepository.Create(conn, report); err != nil {
+		if err == rsdb.ErrDuplicateData {
+			return nil
+		}
+		return errors.WithStack(err)
+	}
+
+	generator.deliver(report)
+	return nil
+}
+
+func (generator *WebServiceReportGenerator) summarize(webService *models.WebService, period models.ReportPeriod, window models.TimeWindow) (models.ReportSummary, error) {
+	conn := rsdb.GetConnection()
+	uptime, aerr := generator.uptimeService.GetWebServiceUptime(webService, models.UptimeRequest{From: window.Start, To: window.End})
+	if aerr != nil {
+		return models.ReportSummary{}, errors.New("failed to get uptime")
+	}
+
+	incidents, err := generator.incidentRepository.GetListInRange(conn, webService.Id, window.Start, window.End)
+	if err != nil {
+		return models.ReportSummary{}, errors.WithStack(err)
+	}
+
+	tests := make([]*models.Test, 0)
+	filter := rsdb.ListFilter{
+		Conditions: map[string]interface{}{
+			"web_service_id": webService.Id,
+		},
+	}
+	if _, err := generator.testRepository.GetList(conn, &tests, filter, nil); err != nil {
+		return models.ReportSummary{}, errors.WithStack(err)
+	}
+
+	previous := period.Previous(window)
+	latencies := make([]models.ReportTestLatency, 0, len(tests))
+	testsById := make(map[string]*models.Test, len(tests))
+	for _, test := range tests {
+		testsById[test.Id] = test
+		current, aerr := generator.statisticsService.GetLatencyStatistics(test, models.LatencyStatisticsRequest{From: window.Start, To: window.End, Bucket: "1d"})
+		if aerr != nil {
+			return models.ReportSummary{}, errors.Errorf("failed to get latency statistics: testId='%s'", test.Id)
+		}
+		before, aerr := generator.statisticsService.GetLatencyStatistics(test, models.LatencyStatisticsRequest{From: previous.Start, To: previous.End, Bucket: "1d"})
+		if aerr != nil {
+			return models.ReportSummary{}, errors.Errorf("failed to get latency statistics: testId='%s'", test.Id)
+		}
+		latencies = append(latencies, models.ReportTestLatency{
+			Test:        test,
+			Current:     current.Summary,
+			Previous:    before.Summary,
+			MaxDailyP95: current.MaxBucketP95(),
+		})
+	}
+
+	counter := models.NewReportAssertionCounter()
+	failed := false
+	if err := generator.testResultRepository.ScanExport(conn, models.TestResultExportRequest{
+		WebServiceId: webService.Id,
+		IsSuccess:    &failed,
+		From:         window.Start,
+		To:           window.End,
+	}, func(export *models.TestResultExport) error {
+		counter.Add(testsById, export)
+		return nil
+	}); err != nil {
+		return models.ReportSummary{}, errors.WithStack(err)
+	}
+
+	return models.NewReportSummary(*uptime, incidents, latencies, counter.Failures(), generator.limit), nil
+}
+
+// deliver sends the report through each effective channel of the web service and records the outcomes.
+// The templates of the channels are for alerts, so a report is always sent as is.
+func (generator *WebServiceReportGenerator) deliver(report *models.Report) {
+	conn := rsdb.GetConnection()
+	bindings, err := generator.bindingRepository.GetListByWebService(conn, report.WebServiceId)
+	if err != nil {
+		rslog.Errorf("failed to get channels of report: reportId='%s', error='%v'", report.Id, err)
+		return
+	}
+	effective := bindings.Effective()
+	if len(effective) == 0 {
+		return
+	}
+
+	message, err := report.Message(reportLink(generator.alertManagerConfig, report))
+	if err != nil {
+		rslog.Errorf("failed to render report: reportId='%s', error='%v'", report.Id, err)
+		return
+	}
+	for _, binding := range effective {
+		_, err := binding.Channel.Alert().Send(context.Background(), message)
+		if err != nil {
+			rslog.Errorf("failed to send report: reportId='%s', channelId='%s', error='%v'", report.Id, binding.ChannelId, err)
+		}
+		report.AddDelivery(binding.Channel, err, time.Now())
+	}
+
+	if err := generator.reportRepository.UpdateDeliveries(conn, report); err != nil {
+		rslog.Errorf("failed to save deliveries of report: reportId='%s', error='%v'", report.Id, err)
+	}
+}
+
+// reportLink is the HTML page of the report, or empty if the public URL is not configured.
+func reportLink(config AlertManagerConfig, report *models.Report) string {
+	publicURL := strings.TrimSuffix(config.GetPublicURL(), "/")
+	if publicURL == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/v1/reports/%s/html", publicURL, report.Id)
+}
+
+func NewWebServiceReportGenerator(
+	webServiceRepository repositories.WebServiceRepository,
+	testRepository repositories.TestRepository,
+	testResultRepository repositories.TestResultRepository,
+	incidentRepository repositories.IncidentRepository,
+	reportRepository repositories.ReportRepository,
+	bindingRepository repositories.NotificationChannelBindingRepository,
+	uptimeService UptimeService,
+	statisticsService StatisticsService,
+	coordinator Coordinator,
+	alertManagerConfig AlertManagerConfig,
+	config ReportConfig,
+) (ReportGenerator, error) {
+	if rsvalid.IsZero(
+		webServiceRepository,
+		testRepository,
+		testResultRepository,
+		incidentRepository,
+		reportRepository,
+		bindingRepository,
+		uptimeService,
+		statisticsService,
+		coordinator,
+		alertManagerConfig,
+		config,
+	) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "WebServiceReportGenerator")
+	}
+	return &WebServiceReportGenerator{
+		webServiceRepository: webServiceRepository,
+		testRepository:       testRepository,
+		testResultRepository: testResultRepository,
+		incidentRepository:   incidentRepository,
+		reportRepository:     reportRepository,
+		bindingRepository:    bindingRepository,
+		uptimeService:        uptimeService,
+		statisticsService:    statisticsService,
+		coordinator:          coordinator,
+		alertManagerConfig:   alertManagerConfig,
+		periods:              config.GetPeriods(),
+		interval:             orDefaultDuration(config.GetInterval(), DefaultReportInterval),
+		delay:                config.GetDelay(),
+		limit:                orDefaultInt(config.GetLimit(), DefaultReportLimit),
+		closeChan:            make(chan bool),
+		doneChan:             make(chan bool),
+	}, nil
+}
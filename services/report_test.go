@@ -0,0 +1,154 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rsnotify"
+	"github.com/realsangil/apimonitor/pkg/rsstats"
+	"github.com/realsangil/apimonitor/repositories/mocks"
+)
+
+type reportConfig struct{}
+
+func (reportConfig) GetPeriods() []models.ReportPeriod {
+	return []models.ReportPeriod{models.ReportDaily, models.ReportWeekly}
+}
+func (reportConfig) GetInterval() time.Duration { return 0 }
+func (reportConfig) GetDelay() time.Duration    { return 0 }
+func (reportConfig) GetLimit() int              { return 0 }
+
+type fakeUptimeService struct {
+	uptime models.Uptime
+}
+
+func (service fakeUptimeService) GetTestUptime(*models.Test, models.UptimeRequest) (*models.Uptime, *amerr.ErrorWithLanguage) {
+	return &service.uptime, nil
+}
+
+func (service fakeUptimeService) GetWebServiceUptime(_ *models.WebService, request models.UptimeRequest) (*models.Uptime, *amerr.ErrorWithLanguage) {
+	uptime := service.uptime
+	uptime.From, uptime.To = request.From, request.To
+	return &uptime, nil
+}
+
+func TestWebServiceReportGenerator_generate(t *testing.T) {
+	var (
+		mux      sync.Mutex
+		messages = map[string][]string{}
+	)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]string{}
+		_ = jsoniter.NewDecoder(r.Body).Decode(&body)
+		mux.Lock()
+		messages[r.URL.Path] = append(messages[r.URL.Path], body["text"])
+		mux.Unlock()
+	}))
+	defer webhook.Close()
+
+	now := time.Date(2020, 3, 4, 1, 0, 0, 0, time.UTC)
+	daily := models.ReportDaily.Window(now)
+	weekly := models.ReportWeekly.Window(now)
+	webService := &models.WebService{Id: "ws", Host: "api.example.com"}
+
+	webServiceRepository := &mocks.WebServiceRepository{}
+	webServiceRepository.On("GetAllWebServices", mock.Anything).Return([]*models.WebService{webService}, nil)
+	testRepository := &mocks.TestRepository{}
+	testRepository.On("GetList", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		tests := args.Get(1).(*[]*models.Test)
+		*tests = []*models.Test{{Id: "test", WebServiceId: webService.Id, Name: "users", Method: "GET", Path: "/users"}}
+	}).Return(1, nil)
+	testResultRepository := &mocks.TestResultRepository{}
+	testResultRepository.On("ScanExport", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		request := args.Get(1).(models.TestResultExportRequest)
+		if assert.NotNil(t, request.IsSuccess) {
+			assert.False(t, *request.IsSuccess)
+		}
+		fn := args.Get(2).(func(*models.TestResultExport) error)
+		_ = fn(&models.TestResultExport{TestId: "test", Assertions: models.AssertionResults{{Field: "statusCode"}}})
+	}).Return(nil)
+	incidentRepository := &mocks.IncidentRepository{}
+	incidentRepository.On("GetListInRange", mock.Anything, webService.Id, mock.Anything, mock.Anything).Return([]*models.Incident{}, nil)
+
+	reportRepository := &mocks.ReportRepository{}
+	reportRepository.On("Exists", mock.Anything, webService.Id, models.ReportDaily, daily.Start).Return(false, nil)
+	reportRepository.On("Exists", mock.Anything, webService.Id, models.ReportWeekly, weekly.Start).Return(true, nil)
+	var created *models.Report
+	reportRepository.On("Create", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		created = args.Get(1).(*models.Report)
+	})
+	reportRepository.On("UpdateDeliveries", mock.Anything, mock.Anything).Return(nil)
+
+	channel := func(id string, disabled bool) *models.NotificationChannel {
+		return &models.NotificationChannel{
+			Id:       id,
+			Name:     id,
+			Type:     rsnotify.TypeWebhook,
+			Disabled: disabled,
+			Config:   models.NotifierConfig(`{"url": "` + webhook.URL + `/` + id + `"}`),
+			Template: "{{.Status}} {{.Test.Name}}",
+		}
+	}
+	bindingRepository := &mocks.NotificationChannelBindingRepository{}
+	bindingRepository.On("GetListByWebService", mock.Anything, webService.Id).Return(models.NotificationChannelBindings{
+		{ChannelId: "team", Channel: channel("team", false), Scope: models.ChannelScopeWebService, ScopeId: webService.Id},
+		{ChannelId: "muted", Channel: channel("muted", true), Scope: models.ChannelScopeGlobal},
+	}, nil)
+
+	statisticsService := &fakeStatisticsService{summaries: map[string]rsstats.Summary{
+		"test": {Count: 10, Mean: 300, P95: 500},
+	}}
+	uptimeService := &fakeUptimeService{uptime: models.Uptime{TotalCount: 10, SuccessCount: 9, Availability: 0.9}}
+
+	generator, err := NewWebServiceReportGenerator(
+		webServiceRepository,
+		testRepository,
+		testResultRepository,
+		incidentRepository,
+		reportRepository,
+		bindingRepository,
+		uptimeService,
+		statisticsService,
+		&fakeCoordinator{},
+		&fakeAlertManagerConfig{publicURL: "https://apimonitor.example.com/"},
+		&reportConfig{},
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	generator.(*WebServiceReportGenerator).generate(now)
+
+	reportRepository.AssertNumberOfCalls(t, "Create", 1)
+	if !assert.NotNil(t, created) {
+		return
+	}
+	assert.Equal(t, models.ReportDaily, created.Period)
+	assert.Equal(t, daily.Start, created.Start)
+	assert.Equal(t, 0.9, created.Summary.Uptime.Availability)
+	assert.Len(t, created.Summary.SlowestTests, 1)
+	assert.Equal(t, []models.ReportAssertionFailure{
+		{ReportTest: models.ReportTest{TestId: "test", Name: "users", Method: "GET", Path: "/users"}, Field: "statusCode", Count: 1},
+	}, created.Summary.FailingAssertions)
+
+	if assert.Len(t, created.Deliveries, 1) {
+		assert.Equal(t, "team", created.Deliveries[0].ChannelId)
+		assert.True(t, created.Deliveries[0].Success)
+	}
+	mux.Lock()
+	defer mux.Unlock()
+	assert.Empty(t, messages["/muted"])
+	if assert.Len(t, messages["/team"], 1) {
+		assert.Contains(t, messages["/team"][0], "[daily report] api.example.com 2020-03-03 - 2020-03-04")
+		assert.Contains(t, messages["/team"][0], "https://apimonitor.example.com/v1/reports/"+created.Id+"/html")
+	}
+	reportRepository.AssertCalled(t, "UpdateDeliveries", mock.Anything, created)
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"bufio"
+	"os"
+	"sync"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+)
+
+// resultJournal is a local file of newline-delimited test results
+// which could not be written to the database.
+type resultJournal struct {
+	mux  sync.Mutex
+	path string
+}
+
+func (journal *resultJournal) Append(results []*models.TestResult) error {
+	journal.mux.Lock()
+	defer journal.mux.Unlock()
+
+	file, err := os.OpenFile(journal.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	for _, result := range results {
+		line, err := jsoniter.Marshal(result)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if _, err := writer.Write(append(line, '\n')); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(file.Sync())
+}
+
+// Replay reads the journal in batches and passes them to write.
+// The journal is removed only when every batch was written;
+// otherwise it is kept as is and replayed again later.
+// Results are inserted idempotently, so replaying a batch twice is harmless.
+// Lines which can not be decoded, e.g. one cut off by a crash while appending,
+// are skipped and moved to a ".corrupt" file next to the journal.
+func (journal *resultJournal) Replay(batchSize int, write func(results []*models.TestResult) error) (int, error) {
+	journal.mux.Lock()
+	defer journal.mux.Unlock()
+
+	file, err := os.Open(journal.path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	defer file.Close()
+
+	replayed := 0
+	var corrupt [][]byte
+	batch := make([]*models.TestResult, 0, batchSize)
+	writeBatch := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := write(batch); err != nil {
+			return err
+		}
+		replayed += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		result := &models.TestResult{}
+		if err := jsoniter.Unmarshal(scanner.Bytes(), result); err != nil {
+			rslog.Errorf("skipped corrupt line of result journal: path='%s', error='%v'", journal.path, err)
+			corrupt = append(corrupt, append([]byte(nil), scanner.Bytes()...))
+			continue
+		}
+		result.SetValidated()
+		batch = append(batch, result)
+		if len(batch) >= batchSize {
+			if err := writeBatch(); err != nil {
+				return replayed, errors.WithStack(err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return replayed, errors.WithStack(err)
+	}
+	if err := writeBatch(); err != nil {
+		return replayed, errors.WithStack(err)
+	}
+	if err := journal.moveCorrupt(corrupt); err != nil {
+		return replayed, err
+	}
+
+	return replayed, errors.WithStack(os.Remove(journal.path))
+}
+
+// moveCorrupt appends the lines to the ".corrupt" file of the journal to be inspected by hand.
+func (journal *resultJournal) moveCorrupt(lines [][]byte) error {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	file, err := os.OpenFile(journal.path+".corrupt", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	for _, line := range lines {
+		if _, err := writer.Write(append(line, '\n')); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(file.Sync())
+}
+
+func newResultJournal(path string) *resultJournal {
+	return &resultJournal{path: path}
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/realsangil/apimonitor/models"
+)
+
+func TestResultJournal_Replay_TruncatedLine(t *testing.T) {
+	dir, err := ioutil.TempDir("", "apimonitor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	journalPath := filepath.Join(dir, "results.journal")
+
+	results := newMockTestResults(3)
+	journal := newResultJournal(journalPath)
+	if err := journal.Append(results[:2]); err != nil {
+		t.Fatal(err)
+	}
+
+	line, err := jsoniter.Marshal(results[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	truncated := line[:len(line)/2]
+	file, err := os.OpenFile(journalPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = file.Write(truncated)
+	file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var replayed []*models.TestResult
+	n, err := journal.Replay(10, func(batch []*models.TestResult) error {
+		replayed = append(replayed, batch...)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	if assert.Len(t, replayed, 2) {
+		assert.Equal(t, results[0].Id, replayed[0].Id)
+		assert.Equal(t, results[1].Id, replayed[1].Id)
+	}
+
+	_, err = os.Stat(journalPath)
+	assert.True(t, os.IsNotExist(err))
+	corrupt, err := ioutil.ReadFile(journalPath + ".corrupt")
+	assert.NoError(t, err)
+	assert.Equal(t, string(truncated)+"\n", string(corrupt))
+}
@@ -0,0 +1,354 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/repositories"
+)
+
+const (
+	DefaultResultQueueSize     = 1000
+	DefaultResultBatchSize     = 100
+	DefaultResultFlushInterval = time.Second
+	DefaultResultMaxRetries    = 3
+	DefaultResultRetryInterval = 500 * time.Millisecond
+
+	resultJournalReplayInterval = 30 * time.Second
+)
+
+type ResultWriterConfig interface {
+	GetQueueSize() int
+	GetBatchSize() int
+	GetFlushInterval() time.Duration
+	GetMaxRetries() int
+	GetRetryInterval() time.Duration
+	GetJournalPath() string
+}
+
+type ResultWriter interface {
+	ScheduleRunner
+	ScheduleShutdowner
+	Write(result *models.TestResult)
+	Stats() ResultWriterStats
+}
+
+// ResultWriterStats is a snapshot of the counters of a ResultWriter.
+// BlockedWrites counts the results which had to wait for free space in the queue.
+type ResultWriterStats struct {
+	QueueLength      int           `json:"queueLength"`
+	QueueCapacity    int           `json:"queueCapacity"`
+	BlockedWrites    uint64        `json:"blockedWrites"`
+	BlockedDuration  time.Duration `json:"blockedDuration"`
+	Batches          uint64        `json:"batches"`
+	Written          uint64        `json:"written"`
+	Retries          uint64        `json:"retries"`
+	Dropped          uint64        `json:"dropped"`
+	Journaled        uint64        `json:"journaled"`
+	Replayed         uint64        `json:"replayed"`
+	LastWriteLatency time.Duration `json:"lastWriteLatency"`
+}
+
+// TestResultWriter stores the test results in batches on its own goroutine.
+// A batch is written when it reaches the batch size or the flush interval elapses.
+// Batches which still fail after the retries are appended to the journal,
+// if one is configured, and replayed once the database is reachable again.
+type TestResultWriter struct {
+	testResultRepository repositories.TestResultRepository
+	journal              *resultJournal
+	queue                chan *models.TestResult
+	batchSize            int
+	flushInterval        time.Duration
+	maxRetries           int
+	retryInterval        time.Duration
+	lastReplayedAt       time.Time
+
+	// mux is read-locked by Write while it checks isShutdown, so that no write starts once Shutdown drains the queue.
+	mux        sync.RWMutex
+	isRunning  bool
+	isShutdown bool
+	closeChan  chan bool
+	doneChan   chan bool
+	// writing counts the writes which started before the shutdown and may still wait for free space in the queue.
+	writing sync.WaitGroup
+
+	blockedWrites    uint64
+	blockedDuration  int64
+	batches          uint64
+	written          uint64
+	retries          uint64
+	dropped          uint64
+	journaled        uint64
+	replayed         uint64
+	lastWriteLatency int64
+}
+
+// Write queues the result. Once the writer is shut down, nothing drains the queue anymore,
+// so a late result, e.g. of a test still running, is stored at once instead.
+func (writer *TestResultWriter) Write(result *models.TestResult) {
+	writer.mux.RLock()
+	if writer.isShutdown {
+		writer.mux.RUnlock()
+		rslog.Warnf("result written after shutdown, storing it directly: resultId='%s', testId='%s'", result.Id, result.TestId)
+		writer.writeLate(result)
+		return
+	}
+	writer.writing.Add(1)
+	writer.mux.RUnlock()
+	defer writer.writing.Done()
+
+	select {
+	case writer.queue <- result:
+		return
+	default:
+	}
+
+	startedAt := time.Now()
+	writer.queue <- result
+	atomic.AddUint64(&writer.blockedWrites, 1)
+	atomic.AddInt64(&writer.blockedDuration, int64(time.Since(startedAt)))
+	rslog.Warnf("result queue is full: capacity='%d'", cap(writer.queue))
+}
+
+func (writer *TestResultWriter) Run() error {
+	writer.mux.Lock()
+	if writer.isShutdown {
+		writer.mux.Unlock()
+		return nil
+	}
+	writer.isRunning = true
+	writer.mux.Unlock()
+	defer close(writer.doneChan)
+
+	writer.replayJournal()
+
+	ticker := time.NewTicker(writer.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*models.TestResult, 0, writer.batchSize)
+	for {
+		select {
+		case result := <-writer.queue:
+			batch = append(batch, result)
+			if len(batch) >= writer.batchSize {
+				batch = writer.flush(batch)
+			}
+		case <-ticker.C:
+			batch = writer.flush(batch)
+		case <-writer.closeChan:
+			writer.flush(writer.drainWriting(batch))
+			rslog.Debug("Closed TestResultWriter")
+			return nil
+		}
+	}
+}
+
+// Shutdown stores every queued result and stops the writer.
+func (writer *TestResultWriter) Shutdown(ctx context.Context) error {
+	writer.mux.Lock()
+	if writer.isShutdown {
+		writer.mux.Unlock()
+		return nil
+	}
+	writer.isShutdown = true
+	isRunning := writer.isRunning
+	writer.mux.Unlock()
+
+	if !isRunning {
+		writer.flush(writer.drainWriting(nil))
+		return nil
+	}
+
+	close(writer.closeChan)
+	select {
+	case <-writer.doneChan:
+		return nil
+	case <-ctx.Done():
+		return errors.WithStack(ctx.Err())
+	}
+}
+
+func (writer *TestResultWriter) Stats() ResultWriterStats {
+	return ResultWriterStats{
+		QueueLength:      len(writer.queue),
+		QueueCapacity:    cap(writer.queue),
+		BlockedWrites:    atomic.LoadUint64(&writer.blockedWrites),
+		BlockedDuration:  time.Duration(atomic.LoadInt64(&writer.blockedDuration)),
+		Batches:          atomic.LoadUint64(&writer.batches),
+		Written:          atomic.LoadUint64(&writer.written),
+		Retries:          atomic.LoadUint64(&writer.retries),
+		Dropped:          atomic.LoadUint64(&writer.dropped),
+		Journaled:        atomic.LoadUint64(&writer.journaled),
+		Replayed:         atomic.LoadUint64(&writer.replayed),
+		LastWriteLatency: time.Duration(atomic.LoadInt64(&writer.lastWriteLatency)),
+	}
+}
+
+// drain moves every result left in the queue into the batch.
+func (writer *TestResultWriter) drain(batch []*models.TestResult) []*models.TestResult {
+	for {
+		select {
+		case result := <-writer.queue:
+			batch = append(batch, result)
+		default:
+			return batch
+		}
+	}
+}
+
+// drainWriting moves the results into the batch until the writes started before the shutdown are queued,
+// and then drains the queue.
+func (writer *TestResultWriter) drainWriting(batch []*models.TestResult) []*models.TestResult {
+	written := make(chan bool)
+	go func() {
+		writer.writing.Wait()
+		close(written)
+	}()
+	for {
+		select {
+		case result := <-writer.queue:
+			batch = append(batch, result)
+		case <-written:
+			return writer.drain(batch)
+		}
+	}
+}
+
+// writeLate stores a result written after the shutdown. It may run along with the last flush of Run,
+// so it leaves the replay of the journal to the next start.
+func (writer *TestResultWriter) writeLate(result *models.TestResult) {
+	results := []*models.TestResult{result}
+	if err := writer.createBatch(results); err != nil {
+		rslog.Errorf("failed to write results: count='%d', error='%v'", len(results), err)
+		writer.spill(results)
+		return
+	}
+	atomic.AddUint64(&writer.written, uint64(len(results)))
+}
+
+// flush writes the batch in chunks of the batch size and returns an empty batch to reuse.
+// It also replays the journal from time to time, even when the batch is empty.
+func (writer *TestResultWriter) flush(batch []*models.TestResult) []*models.TestResult {
+	for start := 0; start < len(batch); start += writer.batchSize {
+		end := start + writer.batchSize
+		if end > len(batch) {
+			end = len(batch)
+		}
+		chunk := batch[start:end]
+		if err := writer.createBatch(chunk); err != nil {
+			rslog.Errorf("failed to write results: count='%d', error='%v'", len(chunk), err)
+			writer.spill(chunk)
+			continue
+		}
+		atomic.AddUint64(&writer.written, uint64(len(chunk)))
+	}
+
+	if writer.journal != nil && time.Since(writer.lastReplayedAt) > resultJournalReplayInterval {
+		writer.replayJournal()
+	}
+
+	return batch[:0]
+}
+
+// createBatch retries transient database errors with an exponential backoff.
+func (writer *TestResultWriter) createBatch(results []*models.TestResult) error {
+	var err error
+	backoff := writer.retryInterval
+	for attempt := 0; attempt <= writer.maxRetries; attempt++ {
+		if attempt > 0 {
+			atomic.AddUint64(&writer.retries, 1)
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		startedAt := time.Now()
+		err = writer.testResultRepository.CreateBatch(rsdb.GetConnection(), results)
+		latency := time.Since(startedAt)
+		atomic.StoreInt64(&writer.lastWriteLatency, int64(latency))
+		dbWriteDurationMetric.Observe(latency.Seconds())
+		if err == nil {
+			atomic.AddUint64(&writer.batches, 1)
+			return nil
+		}
+		if !rsdb.IsTransientError(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func (writer *TestResultWriter) spill(results []*models.TestResult) {
+	if writer.journal == nil {
+		atomic.AddUint64(&writer.dropped, uint64(len(results)))
+		return
+	}
+	if err := writer.journal.Append(results); err != nil {
+		rslog.Errorf("failed to write result journal: error='%v'", err)
+		atomic.AddUint64(&writer.dropped, uint64(len(results)))
+		return
+	}
+	atomic.AddUint64(&writer.journaled, uint64(len(results)))
+}
+
+func (writer *TestResultWriter) replayJournal() {
+	if writer.journal == nil {
+		return
+	}
+	writer.lastReplayedAt = time.Now()
+
+	replayed, err := writer.journal.Replay(writer.batchSize, writer.createBatch)
+	atomic.AddUint64(&writer.replayed, uint64(replayed))
+	if err != nil {
+		rslog.Errorf("failed to replay result journal: error='%v'", err)
+		return
+	}
+	if replayed > 0 {
+		rslog.Infof("replayed results from journal: count='%d'", replayed)
+	}
+}
+
+func NewTestResultWriter(testResultRepository repositories.TestResultRepository, config ResultWriterConfig) (ResultWriter, error) {
+	if rsvalid.IsZero(testResultRepository, config) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "TestResultWriter")
+	}
+
+	writer := &TestResultWriter{
+		testResultRepository: testResultRepository,
+		queue:                make(chan *models.TestResult, orDefaultInt(config.GetQueueSize(), DefaultResultQueueSize)),
+		batchSize:            orDefaultInt(config.GetBatchSize(), DefaultResultBatchSize),
+		flushInterval:        orDefaultDuration(config.GetFlushInterval(), DefaultResultFlushInterval),
+		maxRetries:           orDefaultInt(config.GetMaxRetries(), DefaultResultMaxRetries),
+		retryInterval:        orDefaultDuration(config.GetRetryInterval(), DefaultResultRetryInterval),
+		closeChan:            make(chan bool),
+		doneChan:             make(chan bool),
+	}
+
+	if path := config.GetJournalPath(); path != "" {
+		writer.journal = newResultJournal(path)
+	}
+
+	return writer, nil
+}
+
+func orDefaultInt(value, defaultValue int) int {
+	if value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+func orDefaultDuration(value, defaultValue time.Duration) time.Duration {
+	if value <= 0 {
+		return defaultValue
+	}
+	return value
+}
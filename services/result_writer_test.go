@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rsmodels"
+	"github.com/realsangil/apimonitor/pkg/rsstr"
+	"github.com/realsangil/apimonitor/repositories/mocks"
+)
+
+type resultWriterConfig struct {
+	batchSize     int
+	flushInterval time.Duration
+	journalPath   string
+}
+
+func (c resultWriterConfig) GetQueueSize() int               { return 10 }
+func (c resultWriterConfig) GetBatchSize() int               { return c.batchSize }
+func (c resultWriterConfig) GetFlushInterval() time.Duration { return c.flushInterval }
+func (c resultWriterConfig) GetMaxRetries() int              { return 2 }
+func (c resultWriterConfig) GetRetryInterval() time.Duration { return time.Millisecond }
+func (c resultWriterConfig) GetJournalPath() string          { return c.journalPath }
+
+func newMockTestResults(n int) []*models.TestResult {
+	results := make([]*models.TestResult, 0, n)
+	for i := 0; i < n; i++ {
+		results = append(results, &models.TestResult{
+			DefaultValidateChecker: rsmodels.ValidatedDefaultValidateChecker,
+			Id:                     rsstr.NewUUID(),
+			TestId:                 "test",
+			IsSuccess:              true,
+			StatusCode:             200,
+			TestedAt:               time.Now(),
+		})
+	}
+	return results
+}
+
+func TestTestResultWriter_BatchSize(t *testing.T) {
+	repository := &mocks.TestResultRepository{}
+	written := make(chan int, 10)
+	repository.On("CreateBatch", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		written <- len(args.Get(1).([]*models.TestResult))
+	})
+
+	writer, err := NewTestResultWriter(repository, resultWriterConfig{batchSize: 3, flushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go writer.Run()
+
+	for _, result := range newMockTestResults(4) {
+		writer.Write(result)
+	}
+
+	select {
+	case n := <-written:
+		assert.Equal(t, 3, n)
+	case <-time.After(time.Second):
+		t.Fatal("batch was not written")
+	}
+
+	assert.NoError(t, writer.Shutdown(context.Background()))
+	assert.Equal(t, 1, <-written)
+
+	stats := writer.Stats()
+	assert.Equal(t, uint64(4), stats.Written)
+	assert.Equal(t, uint64(2), stats.Batches)
+	assert.Equal(t, 0, stats.QueueLength)
+}
+
+func TestTestResultWriter_Retry(t *testing.T) {
+	repository := &mocks.TestResultRepository{}
+	repository.On("CreateBatch", mock.Anything, mock.Anything).Return(mysql.ErrInvalidConn).Once()
+	repository.On("CreateBatch", mock.Anything, mock.Anything).Return(nil).Once()
+
+	writer, err := NewTestResultWriter(repository, resultWriterConfig{batchSize: 10, flushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range newMockTestResults(2) {
+		writer.Write(result)
+	}
+
+	assert.NoError(t, writer.Shutdown(context.Background()))
+	repository.AssertNumberOfCalls(t, "CreateBatch", 2)
+
+	stats := writer.Stats()
+	assert.Equal(t, uint64(1), stats.Retries)
+	assert.Equal(t, uint64(2), stats.Written)
+	assert.Equal(t, uint64(0), stats.Dropped)
+}
+
+func TestTestResultWriter_WriteAfterShutdown(t *testing.T) {
+	repository := &mocks.TestResultRepository{}
+	repository.On("CreateBatch", mock.Anything, mock.Anything).Return(nil)
+
+	writer, err := NewTestResultWriter(repository, resultWriterConfig{batchSize: 10, flushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go writer.Run()
+	assert.NoError(t, writer.Shutdown(context.Background()))
+
+	result := newMockTestResults(1)[0]
+	writer.Write(result)
+
+	repository.AssertCalled(t, "CreateBatch", mock.Anything, []*models.TestResult{result})
+	stats := writer.Stats()
+	assert.Equal(t, uint64(1), stats.Written)
+	assert.Equal(t, 0, stats.QueueLength)
+}
+
+func TestTestResultWriter_ShutdownWhileWriteBlocked(t *testing.T) {
+	repository := &mocks.TestResultRepository{}
+	repository.On("CreateBatch", mock.Anything, mock.Anything).Return(nil)
+
+	writer, err := NewTestResultWriter(repository, resultWriterConfig{batchSize: 100, flushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing drains the queue, so the last write waits for free space.
+	results := newMockTestResults(11)
+	written := make(chan bool)
+	go func() {
+		for _, result := range results {
+			writer.Write(result)
+		}
+		close(written)
+	}()
+	for writer.Stats().QueueLength < 10 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, writer.Shutdown(ctx))
+	<-written
+	assert.Equal(t, uint64(11), writer.Stats().Written)
+}
+
+func TestTestResultWriter_Journal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "apimonitor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	journalPath := filepath.Join(dir, "results.journal")
+
+	results := newMockTestResults(3)
+
+	unreachable := &mocks.TestResultRepository{}
+	unreachable.On("CreateBatch", mock.Anything, mock.Anything).Return(mysql.ErrInvalidConn)
+
+	writer, err := NewTestResultWriter(unreachable, resultWriterConfig{batchSize: 10, flushInterval: time.Hour, journalPath: journalPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		writer.Write(result)
+	}
+	assert.NoError(t, writer.Shutdown(context.Background()))
+	assert.Equal(t, uint64(3), writer.Stats().Journaled)
+	assert.FileExists(t, journalPath)
+
+	var replayed []*models.TestResult
+	reachable := &mocks.TestResultRepository{}
+	reachable.On("CreateBatch", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		replayed = append(replayed, args.Get(1).([]*models.TestResult)...)
+	})
+
+	writer, err = NewTestResultWriter(reachable, resultWriterConfig{batchSize: 10, flushInterval: time.Hour, journalPath: journalPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go writer.Run()
+	assert.NoError(t, writer.Shutdown(context.Background()))
+
+	assert.Equal(t, uint64(3), writer.Stats().Replayed)
+	if assert.Len(t, replayed, 3) {
+		for i, result := range replayed {
+			assert.Equal(t, results[i].Id, result.Id)
+			assert.True(t, result.IsValidated())
+		}
+	}
+	_, err = os.Stat(journalPath)
+	assert.True(t, os.IsNotExist(err))
+}
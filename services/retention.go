@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/repositories"
+)
+
+const (
+	DefaultRetentionInterval  = time.Hour
+	DefaultRetentionBatchSize = 1000
+)
+
+type RetentionConfig interface {
+	GetInterval() time.Duration
+	GetRawDays() int
+	GetFailureDays() int
+	GetBatchSize() int
+	GetBatchPause() time.Duration
+}
+
+// ResultPurger deletes the results past the retention of their web service.
+type ResultPurger interface {
+	ScheduleRunner
+	ScheduleShutdowner
+	// DryRun reports how many results the purge would delete now, of the web service or of all if it is nil.
+	DryRun(webService *models.WebService) (*models.PurgeReport, *amerr.ErrorWithLanguage)
+}
+
+// TestResultPurger purges the results on the leader replica, in batches with a pause in between
+// so that the writes to test_results are not blocked for long. The results not rolled up yet are kept.
+type TestResultPurger struct {
+	webServiceRepository repositories.WebServiceRepository
+	testResultRepository repositories.TestResultRepository
+	rollupRepository     repositories.TestResultRollupRepository
+	coordinator          Coordinator
+	global               models.RetentionPolicy
+	interval             time.Duration
+	batchSize            int
+	batchPause           time.Duration
+
+	mux        sync.Mutex
+	isRunning  bool
+	isShutdown bool
+	closeChan  chan bool
+	doneChan   chan bool
+}
+
+func (purger *TestResultPurger) Run() error {
+	purger.mux.Lock()
+	if purger.isShutdown {
+		purger.mux.Unlock()
+		return nil
+	}
+	purger.isRunning = true
+	purger.mux.Unlock()
+	defer close(purger.doneChan)
+
+	ticker := time.NewTicker(purger.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			purger.purge(time.Now())
+		case <-purger.closeChan:
+			rslog.Debug("Closed TestResultPurger")
+			return nil
+		}
+	}
+}
+
+func (purger *TestResultPurger) Shutdown(ctx context.Context) error {
+	purger.mux.Lock()
+	if purger.isShutdown {
+		purger.mux.Unlock()
+		return nil
+	}
+	purger.isShutdown = true
+	isRunning := purger.isRunning
+	purger.mux.Unlock()
+
+	if !isRunning {
+		return nil
+	}
+
+	close(purger.closeChan)
+	select {
+	case <-purger.doneChan:
+		return nil
+	case <-ctx.Done():
+		return errors.WithStack(ctx.Err())
+	}
+}
+
+func (purger *TestResultPurger) DryRun(webService *models.WebService) (*models.PurgeReport, *amerr.ErrorWithLanguage) {
+	conn := rsdb.GetConnection()
+	targets, err := purger.targets(conn, webService, time.Now())
+	if err != nil {
+		rslog.Error(err)
+		return nil, amerr.GetErrInternalServer()
+	}
+
+	report := &models.PurgeReport{DryRun: true, Targets: targets}
+	for i := range report.Targets {
+		target := &report.Targets[i]
+		if target.SuccessBefore != nil {
+			if target.SuccessCount, err = purger.testResultRepository.CountPurgeable(conn, target.WebServiceId, true, *target.SuccessBefore); err != nil {
+				rslog.Error(err)
+				return nil, amerr.GetErrInternalServer()
+			}
+		}
+		if target.FailureBefore != nil {
+			if target.FailureCount, err = purger.testResultRepository.CountPurgeable(conn, target.WebServiceId, false, *target.FailureBefore); err != nil {
+				rslog.Error(err)
+				return nil, amerr.GetErrInternalServer()
+			}
+		}
+		report.TotalCount += target.SuccessCount + target.FailureCount
+	}
+	return report, nil
+}
+
+func (purger *TestResultPurger) purge(now time.Time) {
+	if !purger.coordinator.IsLeader() {
+		return
+	}
+	conn := rsdb.GetConnection()
+	targets, err := purger.targets(conn, nil, now)
+	if err != nil {
+		rslog.Errorf("failed to get purge targets: error='%v'", err)
+		return
+	}
+
+	for _, target := range targets {
+		var (
+			successCount, failureCount int64
+			err                        error
+		)
+		if target.SuccessBefore != nil {
+			successCount, err = purger.delete(conn, target.WebServiceId, true, *target.SuccessBefore)
+		}
+		if err == nil && target.FailureBefore != nil {
+			failureCount, err = purger.delete(conn, target.WebServiceId, false, *target.FailureBefore)
+		}
+		if successCount+failureCount > 0 {
+			rslog.Infof("purged results: webServiceId='%s', successCount=%d, failureCount=%d", target.WebServiceId, successCount, failureCount)
+		}
+		switch err {
+		case nil:
+		case context.Canceled:
+			return
+		default:
+			rslog.Errorf("failed to purge results: webServiceId='%s', error='%v'", target.WebServiceId, err)
+		}
+	}
+}
+
+// delete deletes the results in batches until none is left, or returns context.Canceled on shutdown.
+func (purger *TestResultPurger) delete(conn rsdb.Connection, webServiceId string, isSuccess bool, before time.Time) (int64, error) {
+	total := int64(0)
+	for {
+		deleted, err := purger.testResultRepository.DeletePurgeable(conn, webServiceId, isSuccess, before, purger.batchSize)
+		if err != nil {
+			return total, errors.WithStack(err)
+		}
+		total += deleted
+		if deleted < int64(purger.batchSize) {
+			return total, nil
+		}
+		select {
+		case <-time.After(purger.batchPause):
+		case <-purger.closeChan:
+			return total, context.Canceled
+		}
+	}
+}
+
+// targets applies the retention to the web service, or to all if it is nil,
+// up to the oldest watermark of the rollups.
+func (purger *TestResultPurger) targets(conn rsdb.Connection, webService *models.WebService, now time.Time) ([]models.PurgeTarget, error) {
+	var rolledUpTo time.Time
+	for i, granularity := range models.RollupGranularities {
+		watermark, err := purger.rollupRepository.GetWatermark(conn, granularity)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if i == 0 || watermark.Before(rolledUpTo) {
+			rolledUpTo = watermark
+		}
+	}
+
+	webServices := []*models.WebService{webService}
+	if webService == nil {
+		var err error
+		if webServices, err = purger.webServiceRepository.GetAllWebServices(conn); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	targets := make([]models.PurgeTarget, 0, len(webServices))
+	for _, webService := range webServices {
+		targets = append(targets, models.NewPurgeTarget(webService, purger.global, now, rolledUpTo))
+	}
+	return targets, nil
+}
+
+func NewTestResultPurger(
+	webServiceRepository repositories.WebServiceRepository,
+	testResultRepository repositories.TestResultRepository,
+	rollupRepository repositories.TestResultRollupRepository,
+	coordinator Coordinator,
+	config RetentionConfig,
+) (ResultPurger, error) {
+	if rsvalid.IsZero(webServiceRepository, testResultRepository, rollupRepository, coordinator, config) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "TestResultPurger")
+	}
+	batchSize := config.GetBatchSize()
+	if batchSize <= 0 {
+		batchSize = DefaultRetentionBatchSize
+	}
+	return &TestResultPurger{
+		webServiceRepository: webServiceRepository,
+		testResultRepository: testResultRepository,
+		rollupRepository:     rollupRepository,
+		coordinator:          coordinator,
+		global: models.RetentionPolicy{
+			RawDays:     config.GetRawDays(),
+			FailureDays: config.GetFailureDays(),
+		},
+		interval:   orDefaultDuration(config.GetInterval(), DefaultRetentionInterval),
+		batchSize:  batchSize,
+		batchPause: config.GetBatchPause(),
+		closeChan:  make(chan bool),
+		doneChan:   make(chan bool),
+	}, nil
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/repositories/mocks"
+)
+
+type retentionConfig struct{}
+
+func (retentionConfig) GetInterval() time.Duration { return time.Hour }
+
+func (retentionConfig) GetRawDays() int { return 30 }
+
+func (retentionConfig) GetFailureDays() int { return 180 }
+
+func (retentionConfig) GetBatchSize() int { return 2 }
+
+func (retentionConfig) GetBatchPause() time.Duration { return time.Millisecond }
+
+func TestTestResultPurger(t *testing.T) {
+	now := time.Date(2020, 7, 1, 12, 0, 0, 0, time.UTC)
+	rolledUpTo := time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC)
+	webServices := []*models.WebService{
+		{Id: "global"},
+		{Id: "own", Retention: models.RetentionPolicy{RawDays: 7}},
+	}
+
+	webServiceRepository := &mocks.WebServiceRepository{}
+	webServiceRepository.On("GetAllWebServices", mock.Anything).Return(webServices, nil)
+	rollupRepository := &mocks.TestResultRollupRepository{}
+	rollupRepository.On("GetWatermark", mock.Anything, models.RollupHourly).Return(now, nil)
+	rollupRepository.On("GetWatermark", mock.Anything, models.RollupDaily).Return(rolledUpTo, nil)
+
+	testResultRepository := &mocks.TestResultRepository{}
+	testResultRepository.On("DeletePurgeable", mock.Anything, "global", true, now.AddDate(0, 0, -30), 2).Return(int64(2), nil).Once()
+	testResultRepository.On("DeletePurgeable", mock.Anything, "global", true, now.AddDate(0, 0, -30), 2).Return(int64(1), nil).Once()
+	testResultRepository.On("DeletePurgeable", mock.Anything, "global", false, now.AddDate(0, 0, -180), 2).Return(int64(0), nil).Once()
+	testResultRepository.On("DeletePurgeable", mock.Anything, "own", true, now.AddDate(0, 0, -7), 2).Return(int64(1), nil).Once()
+	testResultRepository.On("DeletePurgeable", mock.Anything, "own", false, now.AddDate(0, 0, -180), 2).Return(int64(1), nil).Once()
+
+	purger, err := NewTestResultPurger(webServiceRepository, testResultRepository, rollupRepository, &fakeCoordinator{}, &retentionConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	purger.(*TestResultPurger).purge(now)
+	testResultRepository.AssertNumberOfCalls(t, "DeletePurgeable", 5)
+	testResultRepository.AssertExpectations(t)
+
+	testResultRepository.On("CountPurgeable", mock.Anything, "own", true, mock.Anything).Return(int64(10), nil)
+	testResultRepository.On("CountPurgeable", mock.Anything, "own", false, mock.Anything).Return(int64(3), nil)
+	report, aerr := purger.DryRun(webServices[1])
+	if !assert.Nil(t, aerr) {
+		return
+	}
+	assert.True(t, report.DryRun)
+	assert.Equal(t, int64(13), report.TotalCount)
+	if assert.Len(t, report.Targets, 1) {
+		assert.Equal(t, int64(10), report.Targets[0].SuccessCount)
+		assert.Equal(t, int64(3), report.Targets[0].FailureCount)
+	}
+}
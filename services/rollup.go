@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/repositories"
+)
+
+const (
+	DefaultRollupInterval = 5 * time.Minute
+
+	// rollupMaxPeriods bounds the periods of a granularity rolled up at once, e.g. while catching up on the history.
+	rollupMaxPeriods = 168
+	rollupUpsertSize = 100
+)
+
+type RollupConfig interface {
+	GetInterval() time.Duration
+	GetDelay() time.Duration
+}
+
+// ResultRoller rolls up the results into the hourly and daily rollups.
+type ResultRoller interface {
+	ScheduleRunner
+	ScheduleShutdowner
+}
+
+// TestResultRoller rolls up the periods completed since the watermark of each granularity.
+// Only the leader replica rolls up, and a period rolled up twice is replaced.
+// The results are kept until TestResultPurger deletes those every granularity has rolled up.
+type TestResultRoller struct {
+	testResultRepository repositories.TestResultRepository
+	rollupRepository     repositories.TestResultRollupRepository
+	coordinator          Coordinator
+	interval             time.Duration
+	delay                time.Duration
+
+	mux        sync.Mutex
+	isRunning  bool
+	isShutdown bool
+	closeChan  chan bool
+	doneChan   chan bool
+}
+
+func (roller *TestResultRoller) Run() error {
+	roller.mux.Lock()
+	if roller.isShutdown {
+		roller.mux.Unlock()
+		return nil
+	}
+	roller.isRunning = true
+	roller.mux.Unlock()
+	defer close(roller.doneChan)
+
+	ticker := time.NewTicker(roller.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			roller.rollUp(time.Now())
+		case <-roller.closeChan:
+			rslog.Debug("Closed TestResultRoller")
+			return nil
+		}
+	}
+}
+
+func (roller *TestResultRoller) Shutdown(ctx context.Context) error {
+	roller.mux.Lock()
+	if roller.isShutdown {
+		roller.mux.Unlock()
+		return nil
+	}
+	roller.isShutdown = true
+	isRunning := roller.isRunning
+	roller.mux.Unlock()
+
+	if !isRunning {
+		return nil
+	}
+
+	close(roller.closeChan)
+	select {
+	case <-roller.doneChan:
+		return nil
+	case <-ctx.Done():
+		return errors.WithStack(ctx.Err())
+	}
+}
+
+func (roller *TestResultRoller) rollUp(now time.Time) {
+	if !roller.coordinator.IsLeader() {
+		return
+	}
+	conn := rsdb.GetConnection()
+	for _, granularity := range models.RollupGranularities {
+		if err := roller.rollUpGranularity(conn, granularity, now); err != nil {
+			rslog.Errorf("failed to roll up results: granularity='%s', error='%v'", granularity, err)
+		}
+	}
+}
+
+// rollUpGranularity rolls up the periods which ended a delay ago, starting from the oldest result the first time.
+func (roller *TestResultRoller) rollUpGranularity(conn rsdb.Connection, granularity models.RollupGranularity, now time.Time) error {
+	duration := granularity.Duration()
+	watermark, err := roller.rollupRepository.GetWatermark(conn, granularity)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if watermark.IsZero() {
+		oldest, err := roller.testResultRepository.GetOldestTestedAt(conn)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if oldest.IsZero() {
+			return nil
+		}
+		watermark = oldest.Truncate(duration)
+	}
+
+	until := now.Add(-roller.delay).Truncate(duration)
+	for i := 0; i < rollupMaxPeriods && watermark.Before(until); i++ {
+		period := models.TimeWindow{Start: watermark, End: watermark.Add(duration)}
+		if err := roller.rollUpPeriod(conn, granularity, period); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := roller.rollupRepository.SetWatermark(conn, granularity, period.End); err != nil {
+			return errors.WithStack(err)
+		}
+		watermark = period.End
+	}
+	return nil
+}
+
+func (roller *TestResultRoller) rollUpPeriod(conn rsdb.Connection, granularity models.RollupGranularity, period models.TimeWindow) error {
+	rollups := make([]*models.TestResultRollup, 0)
+	var builder *models.TestResultRollupBuilder
+	if err := roller.testResultRepository.ScanResults(conn, period.Start, period.End, func(result *models.TestResult) {
+		if builder == nil || result.TestId != builder.TestId() {
+			if builder != nil {
+				rollups = append(rollups, builder.Build())
+			}
+			builder = models.NewTestResultRollupBuilder(result.TestId, period.Start)
+		}
+		builder.Add(result)
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	if builder != nil {
+		rollups = append(rollups, builder.Build())
+	}
+
+	for start := 0; start < len(rollups); start += rollupUpsertSize {
+		end := start + rollupUpsertSize
+		if end > len(rollups) {
+			end = len(rollups)
+		}
+		if err := roller.rollupRepository.UpsertBatch(conn, granularity, rollups[start:end]); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+func NewTestResultRoller(
+	testResultRepository repositories.TestResultRepository,
+	rollupRepository repositories.TestResultRollupRepository,
+	coordinator Coordinator,
+	config RollupConfig,
+) (ResultRoller, error) {
+	if rsvalid.IsZero(testResultRepository, rollupRepository, coordinator, config) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "TestResultRoller")
+	}
+	return &TestResultRoller{
+		testResultRepository: testResultRepository,
+		rollupRepository:     rollupRepository,
+		coordinator:          coordinator,
+		interval:             orDefaultDuration(config.GetInterval(), DefaultRollupInterval),
+		delay:                config.GetDelay(),
+		closeChan:            make(chan bool),
+		doneChan:             make(chan bool),
+	}, nil
+}
+
+// splitByRollup splits the window at the watermark of the granularity, see models.SplitByRollup.
+func splitByRollup(
+	rollupRepository repositories.TestResultRollupRepository,
+	window models.TimeWindow,
+	granularity models.RollupGranularity,
+) (rolledUp models.TimeWindow, ok bool, rest []models.TimeWindow, err error) {
+	watermark, err := rollupRepository.GetWatermark(rsdb.GetConnection(), granularity)
+	if err != nil {
+		return models.TimeWindow{}, false, nil, errors.WithStack(err)
+	}
+	rolledUp, ok, rest = models.SplitByRollup(window, granularity, watermark)
+	return rolledUp, ok, rest, nil
+}
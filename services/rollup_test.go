@@ -0,0 +1,73 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/repositories/mocks"
+)
+
+type rollupConfig struct{}
+
+func (rollupConfig) GetInterval() time.Duration { return time.Hour }
+
+func (rollupConfig) GetDelay() time.Duration { return 5 * time.Minute }
+
+func TestTestResultRoller_RollUp(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2020, 2, day, hour, minute, 0, 0, time.UTC)
+	}
+	now := at(2, 12, 10)
+
+	testResultRepository := &mocks.TestResultRepository{}
+	testResultRepository.On("ScanResults", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		from := args.Get(1).(time.Time)
+		fn := args.Get(3).(func(result *models.TestResult))
+		for _, result := range []*models.TestResult{
+			{TestId: "a", IsSuccess: true, StatusCode: 200, ResponseTime: 100, TestedAt: from},
+			{TestId: "a", IsSuccess: false, StatusCode: 500, ResponseTime: 300, TestedAt: from.Add(time.Minute)},
+			{TestId: "b", IsSuccess: true, StatusCode: 200, ResponseTime: 50, TestedAt: from},
+		} {
+			fn(result)
+		}
+	})
+
+	rollupRepository := &mocks.TestResultRollupRepository{}
+	rollupRepository.On("GetWatermark", mock.Anything, models.RollupHourly).Return(at(2, 10, 0), nil)
+	rollupRepository.On("GetWatermark", mock.Anything, models.RollupDaily).Return(at(1, 0, 0), nil)
+	rollupRepository.On("SetWatermark", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	upserted := map[models.RollupGranularity][]*models.TestResultRollup{}
+	rollupRepository.On("UpsertBatch", mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		granularity := args.Get(1).(models.RollupGranularity)
+		upserted[granularity] = append(upserted[granularity], args.Get(2).([]*models.TestResultRollup)...)
+	})
+
+	roller, err := NewTestResultRoller(testResultRepository, rollupRepository, &fakeCoordinator{}, &rollupConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	roller.(*TestResultRoller).rollUp(now)
+
+	rollupRepository.AssertCalled(t, "SetWatermark", mock.Anything, models.RollupHourly, at(2, 11, 0))
+	rollupRepository.AssertCalled(t, "SetWatermark", mock.Anything, models.RollupHourly, at(2, 12, 0))
+	rollupRepository.AssertCalled(t, "SetWatermark", mock.Anything, models.RollupDaily, at(2, 0, 0))
+	rollupRepository.AssertNumberOfCalls(t, "SetWatermark", 3)
+
+	hourly := upserted[models.RollupHourly]
+	if assert.Len(t, hourly, 4) {
+		assert.Equal(t, "a", hourly[0].TestId)
+		assert.Equal(t, at(2, 10, 0), hourly[0].Start)
+		assert.Equal(t, 2, hourly[0].TotalCount)
+		assert.Equal(t, 1, hourly[0].FailureCount)
+		assert.Equal(t, models.StatusCodeCounts{200: 1, 500: 1}, hourly[0].StatusCodes)
+		assert.Equal(t, "b", hourly[1].TestId)
+		assert.Equal(t, at(2, 11, 0), hourly[3].Start)
+	}
+	if daily := upserted[models.RollupDaily]; assert.Len(t, daily, 2) {
+		assert.Equal(t, at(1, 0, 0), daily[0].Start)
+	}
+}
@@ -1,11 +1,11 @@
 package services
 
 import (
+	"context"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
-	"github.com/realsangil/apimonitor/pkg/rsmodels"
-	"github.com/realsangil/apimonitor/pkg/rsstr"
 
 	"github.com/realsangil/apimonitor/models"
 	"github.com/realsangil/apimonitor/pkg/rsdb"
@@ -15,6 +15,17 @@ import (
 	"github.com/realsangil/apimonitor/repositories"
 )
 
+const (
+	// CatchUpPolicyOnce runs a test once right after a restart if it missed one or more runs.
+	CatchUpPolicyOnce = "once"
+	// CatchUpPolicySkip waits for the next run of the schedule.
+	CatchUpPolicySkip = "skip"
+)
+
+type SchedulerConfig interface {
+	GetCatchUpPolicy() string
+}
+
 type ScheduleExecutor interface {
 	Execute() error
 }
@@ -31,6 +42,10 @@ type ScheduleConstructor interface {
 	Init() error
 }
 
+type ScheduleShutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
 type Scheduler interface {
 	ScheduleRunner
 	ScheduleExecutor
@@ -41,23 +56,42 @@ type ScheduleManager interface {
 	ScheduleRunner
 	ScheduleConstructor
 	ScheduleCloser
+	ScheduleShutdowner
 	UpdateSchedule(test *models.Test) error
 	AddSchedule(test *models.Test) error
 	RemoveSchedule(test *models.Test) error
 	ExecuteSchedule(test *models.Test)
+	RunTest(test *models.Test) (*models.TestResult, error)
+	DryRunTest(test *models.Test) (*models.TestResult, error)
 }
 
 type TestScheduleManager struct {
+	mux                  sync.Mutex
 	testSchedulers       map[string]Scheduler
 	testRepository       repositories.TestRepository
 	testResultRepository repositories.TestResultRepository
-	resultChan           chan *models.TestResult
+	maintenanceChecker   MaintenanceChecker
+	alertManager         AlertManager
+	resultWriter         ResultWriter
+	resultHub            ResultHub
+	coordinator          Coordinator
+	catchUpPolicy        string
+	executing            sync.WaitGroup
+	isRunning            bool
+	isShutdown           bool
 	closeChan            chan bool
+	doneChan             chan bool
 	errorChan            chan error
 }
 
 func (manager *TestScheduleManager) Run() error {
 	rslog.Debug("Running WebServiceManager...")
+	manager.mux.Lock()
+	if manager.isShutdown {
+		manager.mux.Unlock()
+		return nil
+	}
+	manager.isRunning = true
 	for _, s := range manager.testSchedulers {
 		go func(s Scheduler, errChan chan<- error) {
 			if err := s.Run(); err != nil {
@@ -65,25 +99,64 @@ func (manager *TestScheduleManager) Run() error {
 			}
 		}(s, manager.errorChan)
 	}
+	manager.mux.Unlock()
+	defer close(manager.doneChan)
+
 	for {
 		select {
 		case err := <-manager.errorChan:
 			rslog.Errorf("error='%v'", err)
 		// 	TODO: 에러 프린팅
-		case result := <-manager.resultChan:
-			rslog.Debugf("result='%+v'", result)
-			if err := manager.testResultRepository.Create(rsdb.GetConnection(), result); err != nil {
-				manager.errorChan <- err
-			}
 		case <-manager.closeChan:
 			rslog.Debug("Closed TestScheduleManager")
-			_ = manager.Close()
 			return nil
 		}
 	}
 }
 
+// Shutdown stops every schedule and waits for the running tests until ctx is done.
+// The result writer is shut down separately, after everything that writes to it has stopped.
+// The manager can not be restarted afterwards.
+func (manager *TestScheduleManager) Shutdown(ctx context.Context) error {
+	rslog.Info("Shutting down TestScheduleManager...")
+	manager.mux.Lock()
+	if manager.isShutdown {
+		manager.mux.Unlock()
+		return nil
+	}
+	manager.isShutdown = true
+	isRunning := manager.isRunning
+	manager.mux.Unlock()
+
+	_ = manager.Close()
+
+	executed := make(chan bool)
+	go func() {
+		manager.executing.Wait()
+		close(executed)
+	}()
+	select {
+	case <-executed:
+	case <-ctx.Done():
+		rslog.Warn("Timed out waiting for running tests")
+	}
+
+	if !isRunning {
+		return nil
+	}
+
+	close(manager.closeChan)
+	select {
+	case <-manager.doneChan:
+		return nil
+	case <-ctx.Done():
+		return errors.WithStack(ctx.Err())
+	}
+}
+
 func (manager *TestScheduleManager) Init() error {
+	// Catching up depends on the ownership, which is known after the first heartbeat.
+	<-manager.coordinator.Ready()
 	if err := manager.Close(); err != nil {
 		return errors.WithStack(err)
 	}
@@ -111,8 +184,10 @@ func (manager *TestScheduleManager) initTests() error {
 	rslog.Debugf("test total count='%d'", totalCount)
 
 	rslog.Debugf("tests='%+v'", tests)
+	manager.mux.Lock()
+	defer manager.mux.Unlock()
 	for _, test := range tests {
-		testScheduler, err := NewTestScheduler(test, manager.resultChan)
+		testScheduler, err := manager.newTestScheduler(test)
 		if err != nil {
 			return errors.WithStack(err)
 		}
@@ -125,14 +200,17 @@ func (manager *TestScheduleManager) initTests() error {
 
 func (manager *TestScheduleManager) Close() error {
 	rslog.Debug("Closing WebServiceManager...")
+	manager.mux.Lock()
+	defer manager.mux.Unlock()
 	for _, s := range manager.testSchedulers {
 		_ = s.Close()
 	}
-	// manager.closeChan <- true
 	return nil
 }
 
 func (manager *TestScheduleManager) UpdateSchedule(test *models.Test) error {
+	manager.mux.Lock()
+	defer manager.mux.Unlock()
 	oldTestScheduler, exist := manager.testSchedulers[test.Id]
 	if !exist {
 		e := errors.Errorf("schedule not exist: '%s'", test.Id)
@@ -154,11 +232,17 @@ func (manager *TestScheduleManager) UpdateSchedule(test *models.Test) error {
 }
 
 func (manager *TestScheduleManager) AddSchedule(test *models.Test) error {
+	manager.mux.Lock()
+	defer manager.mux.Unlock()
 	return manager.addSchedule(test)
 }
 
 func (manager *TestScheduleManager) addSchedule(test *models.Test) error {
-	newTestScheduler, err := NewTestScheduler(test, manager.resultChan)
+	if manager.isShutdown {
+		return errors.Errorf("schedule manager is shut down: '%s'", test.Id)
+	}
+
+	newTestScheduler, err := manager.newTestScheduler(test)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -175,103 +259,300 @@ func (manager *TestScheduleManager) addSchedule(test *models.Test) error {
 }
 
 func (manager *TestScheduleManager) RemoveSchedule(test *models.Test) error {
+	manager.mux.Lock()
+	defer manager.mux.Unlock()
 	testScheduler, exist := manager.testSchedulers[test.Id]
 	if exist {
 		if err := testScheduler.Close(); err != nil {
 			return err
 		}
 		delete(manager.testSchedulers, test.Id)
+		forgetCheck(test.Id)
 	}
 	return nil
 }
 
 func (manager *TestScheduleManager) ExecuteSchedule(test *models.Test) {
+	manager.mux.Lock()
 	testScheduler, exist := manager.testSchedulers[test.Id]
-	if exist {
-		if err := testScheduler.Execute(); err != nil {
-			manager.errorChan <- err
+	manager.mux.Unlock()
+	if !exist {
+		rslog.Warnf("schedule not exist: '%s'", test.Id)
+		return
+	}
+	if err := testScheduler.Execute(); err != nil {
+		manager.errorChan <- err
+	}
+}
+
+// RunTest executes the test immediately, alerts like a scheduled run
+// and stores the result before returning it.
+// A test which could not be executed returns its failed result, whose response is the error,
+// and a result which could not be stored is handed to the ResultWriter, so that the caller
+// always sees why the check failed. Only a test deleted in between is returned as an error.
+func (manager *TestScheduleManager) RunTest(test *models.Test) (*models.TestResult, error) {
+	testScheduler := &testScheduler{
+		test:               test,
+		maintenanceChecker: manager.maintenanceChecker,
+		alertManager:       manager.alertManager,
+		resultHub:          manager.resultHub,
+	}
+	result, err := testScheduler.execute()
+	if err != nil {
+		manager.resultWriter.Write(result)
+		return result, nil
+	}
+	if err := manager.testResultRepository.Create(rsdb.GetConnection(), result); err != nil {
+		if err == rsdb.ErrForeignKeyConstraint {
+			return nil, err
 		}
+		rslog.Errorf("failed to store result of run, retrying in the background: testId='%s', error='%v'", test.Id, err)
+		manager.resultWriter.Write(result)
+	}
+	return result, nil
+}
+
+// DryRunTest executes the test without alerting or storing the result.
+func (manager *TestScheduleManager) DryRunTest(test *models.Test) (*models.TestResult, error) {
+	result, err := runTest(test, manager.maintenanceChecker)
+	if err != nil {
+		return nil, err
 	}
+	return result, nil
+}
+
+func (manager *TestScheduleManager) newTestScheduler(test *models.Test) (Scheduler, error) {
+	return NewTestScheduler(
+		test,
+		manager.testRepository,
+		manager.maintenanceChecker,
+		manager.alertManager,
+		manager.resultWriter,
+		manager.resultHub,
+		manager.coordinator,
+		manager.catchUpPolicy,
+		&manager.executing,
+	)
 }
 
-func NewTestScheduleManager(testRepository repositories.TestRepository, testResultRepository repositories.TestResultRepository) (ScheduleManager, error) {
-	if rsvalid.IsZero(testRepository, testResultRepository) {
+func NewTestScheduleManager(
+	testRepository repositories.TestRepository,
+	testResultRepository repositories.TestResultRepository,
+	maintenanceChecker MaintenanceChecker,
+	alertManager AlertManager,
+	resultWriter ResultWriter,
+	resultHub ResultHub,
+	coordinator Coordinator,
+	config SchedulerConfig,
+) (ScheduleManager, error) {
+	if rsvalid.IsZero(testRepository, testResultRepository, maintenanceChecker, alertManager, resultWriter, resultHub, coordinator, config) {
 		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "Scheduler")
 	}
+	catchUpPolicy := config.GetCatchUpPolicy()
+	switch catchUpPolicy {
+	case CatchUpPolicyOnce, CatchUpPolicySkip:
+	case "":
+		catchUpPolicy = CatchUpPolicyOnce
+	default:
+		return nil, errors.Wrapf(rserrors.ErrInvalidParameter, "catch-up policy: '%s'", catchUpPolicy)
+	}
 	return &TestScheduleManager{
 		testSchedulers:       make(map[string]Scheduler),
 		testRepository:       testRepository,
 		testResultRepository: testResultRepository,
-		resultChan:           make(chan *models.TestResult, 1000),
+		maintenanceChecker:   maintenanceChecker,
+		alertManager:         alertManager,
+		resultWriter:         resultWriter,
+		resultHub:            resultHub,
+		coordinator:          coordinator,
+		catchUpPolicy:        catchUpPolicy,
+		closeChan:            make(chan bool),
+		doneChan:             make(chan bool),
 		errorChan:            make(chan error, 100),
 	}, nil
 }
 
 type testScheduler struct {
-	test       *models.Test
-	closeChan  chan bool
-	resultChan chan<- *models.TestResult
+	test               *models.Test
+	maintenanceChecker MaintenanceChecker
+	alertManager       AlertManager
+	executing          *sync.WaitGroup
+	closeOnce          sync.Once
+	closeChan          chan bool
+	resultWriter       ResultWriter
+	resultHub          ResultHub
+	coordinator        Coordinator
+	testRepository     repositories.TestRepository
+	catchUpPolicy      string
+	// owned is whether the test was owned by this replica at the last run, only used by Run.
+	owned bool
 }
 
+// Run fires the test at the nextRunAt stored with the test, so that the schedule
+// survives restarts. Runs missed while no replica was running are caught up
+// with a single execution according to the catch-up policy.
 func (schedule *testScheduler) Run() error {
-	ticker := schedule.test.Schedule.GetTicker()
-	rslog.Debug("Running...")
+	test := schedule.test
+	interval := test.Schedule.GetDuration()
+	now := time.Now()
+
+	nextRunAt := now.Add(interval)
+	switch {
+	case test.NextRunAt != nil:
+		nextRunAt = *test.NextRunAt
+	case test.LastRunAt != nil:
+		nextRunAt = test.LastRunAt.Add(interval)
+	}
+	if nextRunAt.After(now.Add(interval)) {
+		// The schedule was shortened.
+		nextRunAt = now.Add(interval)
+	}
+	firstRunAt := nextRunAt
+	if nextRunAt.Before(now) {
+		rslog.Infof("test missed its schedule:: id='%v', nextRunAt='%v', policy='%s'", test.Id, nextRunAt, schedule.catchUpPolicy)
+		if schedule.catchUpPolicy == CatchUpPolicyOnce {
+			// The catch-up runs now; the following runs keep the original slots.
+			firstRunAt = now
+		} else {
+			nextRunAt = test.Schedule.NextAfter(nextRunAt, now)
+			firstRunAt = nextRunAt
+			schedule.saveRunAt(nil, nextRunAt)
+		}
+	}
+
+	schedule.setOwned(schedule.coordinator.Owns(test.Id))
+	defer schedule.setOwned(false)
+
+	timer := time.NewTimer(time.Until(firstRunAt))
+	defer timer.Stop()
+	rslog.Debugf("Running...:: id='%v', nextRunAt='%v'", test.Id, nextRunAt)
 	for {
 		select {
-		case <-ticker.C:
+		case <-timer.C:
+			runAt := time.Now()
+			nextRunAt = test.Schedule.NextAfter(nextRunAt, runAt)
+			timer.Reset(time.Until(nextRunAt))
+			owned := schedule.coordinator.Owns(test.Id)
+			schedule.setOwned(owned)
+			if !owned {
+				rslog.Debugf("test is owned by another replica:: \tid='%v'", test.Id)
+				continue
+			}
+			schedule.saveRunAt(&runAt, nextRunAt)
 			if err := schedule.Execute(); err != nil {
-				return errors.WithStack(err)
+				rslog.Errorf("failed to execute test:: id='%v', error='%v'", test.Id, err)
 			}
 		case <-schedule.closeChan:
-			rslog.Debugf("test close:: \tid='%v'", schedule.test.Id)
+			rslog.Debugf("test close:: \tid='%v'", test.Id)
 			return nil
 		}
 	}
 }
 
+// setOwned counts the test as scheduled on this replica while it owns the test,
+// and forgets the metrics of its checks once another replica took it over.
+func (schedule *testScheduler) setOwned(owned bool) {
+	if owned == schedule.owned {
+		return
+	}
+	schedule.owned = owned
+	if owned {
+		scheduledTestsMetric.Inc()
+		return
+	}
+	scheduledTestsMetric.Dec()
+	forgetCheck(schedule.test.Id)
+}
+
+func (schedule *testScheduler) saveRunAt(lastRunAt *time.Time, nextRunAt time.Time) {
+	if !schedule.coordinator.Owns(schedule.test.Id) {
+		return
+	}
+	if err := schedule.testRepository.UpdateRunAt(rsdb.GetConnection(), schedule.test.Id, lastRunAt, nextRunAt); err != nil {
+		rslog.Errorf("failed to save schedule state: id='%v', error='%v'", schedule.test.Id, err)
+	}
+}
+
 func (schedule *testScheduler) Execute() error {
-	test := schedule.test
-	res, err := test.Execute()
+	if schedule.executing != nil {
+		schedule.executing.Add(1)
+		defer schedule.executing.Done()
+	}
+	result, err := schedule.execute()
+	schedule.resultWriter.Write(result)
+	return err
+}
+
+// execute runs the test, records its metrics, publishes the result to the live stream
+// and passes it to the alert manager.
+// The result is returned even if the test could not be executed.
+func (schedule *testScheduler) execute() (*models.TestResult, error) {
+	runningChecksMetric.Inc()
+	result, err := runTest(schedule.test, schedule.maintenanceChecker)
+	runningChecksMetric.Dec()
 	if err != nil {
 		rslog.Error(err)
-		return err
-	}
-	rslog.Debugf("executed test:: id='%v'", test.Id)
-	result := &models.TestResult{
-		DefaultValidateChecker: rsmodels.ValidatedDefaultValidateChecker,
-		Id:                     rsstr.NewUUID(),
-		TestId:                 test.Id,
-		IsSuccess:              test.Assertion.Assert(res),
-		StatusCode:             res.StatusCode,
-		Response:               res.Body,
-		ResponseTime:           res.ResponseTime,
-		TestedAt:               time.Now(),
-	}
-	if !result.IsSuccess {
-		errMessage := result.ErrorMessage()
-		for _, alert := range test.Alerts {
-			if err := alert.Alert(errMessage); err != nil {
-				rslog.Error(err)
-			}
-		}
 	}
-	schedule.resultChan <- result
-	return nil
+	observeCheck(schedule.test, result)
+	schedule.resultHub.Publish(models.NewResultStreamEvent(schedule.test, result))
+	schedule.alertManager.HandleResult(schedule.test, result)
+	return result, err
 }
 
 func (schedule *testScheduler) Close() error {
-	schedule.closeChan <- true
-	close(schedule.closeChan)
+	schedule.closeOnce.Do(func() {
+		schedule.closeChan <- true
+		close(schedule.closeChan)
+	})
 	return nil
 }
 
-func NewTestScheduler(test *models.Test, resultChan chan<- *models.TestResult) (Scheduler, error) {
-	if rsvalid.IsZero(test) {
+// runTest executes the test and returns its result.
+// If the request could not be sent or answered, a failed result is returned with the error.
+// The check is traced with a span, propagated to the web service.
+func runTest(test *models.Test, maintenanceChecker MaintenanceChecker) (*models.TestResult, error) {
+	ctx, span := startCheckSpan(test)
+	res, execErr := test.Execute(ctx)
+	var result *models.TestResult
+	if execErr != nil {
+		result = models.NewErrorTestResult(test, execErr, time.Now())
+	} else {
+		rslog.Debugf("executed test:: id='%v'", test.Id)
+		result = models.NewTestResult(test, res, time.Now())
+	}
+	inMaintenance, err := maintenanceChecker.InMaintenance(test.WebServiceId, result.TestedAt)
+	if err != nil {
+		rslog.Error(err)
+	}
+	result.InMaintenance = inMaintenance
+	endCheckSpan(span, result, execErr)
+	return result, execErr
+}
+
+func NewTestScheduler(
+	test *models.Test,
+	testRepository repositories.TestRepository,
+	maintenanceChecker MaintenanceChecker,
+	alertManager AlertManager,
+	resultWriter ResultWriter,
+	resultHub ResultHub,
+	coordinator Coordinator,
+	catchUpPolicy string,
+	executing *sync.WaitGroup,
+) (Scheduler, error) {
+	if rsvalid.IsZero(test, testRepository, maintenanceChecker, alertManager, resultWriter, resultHub, coordinator) {
 		return nil, rserrors.ErrInvalidParameter
 	}
 	return &testScheduler{
-		test:       test,
-		closeChan:  make(chan bool, 1),
-		resultChan: resultChan,
+		test:               test,
+		maintenanceChecker: maintenanceChecker,
+		alertManager:       alertManager,
+		executing:          executing,
+		closeChan:          make(chan bool, 1),
+		resultWriter:       resultWriter,
+		resultHub:          resultHub,
+		coordinator:        coordinator,
+		testRepository:     testRepository,
+		catchUpPolicy:      catchUpPolicy,
 	}, nil
 }
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/repositories"
+)
+
+var _ StatisticsService = &StatisticsServiceImpl{}
+
+type StatisticsService interface {
+	GetLatencyStatistics(test *models.Test, request models.LatencyStatisticsRequest) (*models.LatencyStatistics, *amerr.ErrorWithLanguage)
+}
+
+type StatisticsServiceImpl struct {
+	testResultRepository repositories.TestResultRepository
+	rollupRepository     repositories.TestResultRollupRepository
+}
+
+// GetLatencyStatistics reads the hourly or the daily buckets already rolled up from the rollups,
+// and the rest from the results.
+func (service *StatisticsServiceImpl) GetLatencyStatistics(test *models.Test, request models.LatencyStatisticsRequest) (*models.LatencyStatistics, *amerr.ErrorWithLanguage) {
+	builder := models.NewLatencyStatisticsBuilder(request)
+	if err := service.buildLatencyStatistics(builder, test, request); err != nil {
+		rslog.Error(err)
+		return nil, amerr.GetErrInternalServer()
+	}
+	return builder.Build(), nil
+}
+
+func (service *StatisticsServiceImpl) buildLatencyStatistics(builder *models.LatencyStatisticsBuilder, test *models.Test, request models.LatencyStatisticsRequest) error {
+	conn := rsdb.GetConnection()
+	window := models.TimeWindow{Start: request.From, End: request.To}
+	granularity, ok := models.RollupGranularityOf(request.Bucket)
+	if !ok {
+		return service.testResultRepository.ScanResponseTimes(conn, test, window.Start, window.End, builder.Add)
+	}
+
+	rolledUp, ok, rest, err := splitByRollup(service.rollupRepository, window, granularity)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !ok {
+		return service.testResultRepository.ScanResponseTimes(conn, test, window.Start, window.End, builder.Add)
+	}
+
+	// The results and the rollups are added in the order they were tested.
+	for _, w := range rest {
+		if !w.Start.Before(rolledUp.Start) {
+			continue
+		}
+		if err := service.testResultRepository.ScanResponseTimes(conn, test, w.Start, w.End, builder.Add); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	rollups, err := service.rollupRepository.GetListByTest(conn, granularity, test.Id, rolledUp.Start, rolledUp.End)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, rollup := range rollups {
+		builder.AddRollup(rollup)
+	}
+	for _, w := range rest {
+		if w.Start.Before(rolledUp.Start) {
+			continue
+		}
+		if err := service.testResultRepository.ScanResponseTimes(conn, test, w.Start, w.End, builder.Add); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+func NewStatisticsService(
+	testResultRepository repositories.TestResultRepository,
+	rollupRepository repositories.TestResultRollupRepository,
+) (StatisticsService, error) {
+	if rsvalid.IsZero(testResultRepository, rollupRepository) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "StatisticsService")
+	}
+	return &StatisticsServiceImpl{
+		testResultRepository: testResultRepository,
+		rollupRepository:     rollupRepository,
+	}, nil
+}
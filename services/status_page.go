@@ -0,0 +1,208 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/repositories"
+)
+
+const (
+	DefaultStatusPageTitle              = "Status"
+	DefaultStatusPageDays               = 90
+	DefaultStatusPageRecentIncidentDays = 7
+	DefaultStatusPageCacheMaxAge        = time.Minute
+)
+
+type StatusPageConfig interface {
+	GetTitle() string
+	GetComponents() []models.StatusComponentDefinition
+	GetDays() int
+	GetRecentIncidentDays() int
+	GetCacheMaxAge() time.Duration
+}
+
+// StatusPageService builds the public status page of the components.
+type StatusPageService interface {
+	GetStatusPage() (*models.StatusPage, *amerr.ErrorWithLanguage)
+	// CacheMaxAge is how long the status page may be cached.
+	CacheMaxAge() time.Duration
+}
+
+// StatusPageServiceImpl reuses the status page for the cache max age, so that the public page
+// costs the database a few queries per web service at most that often, however often it is read.
+type StatusPageServiceImpl struct {
+	webServiceRepository repositories.WebServiceRepository
+	testResultRepository repositories.TestResultRepository
+	rollupRepository     repositories.TestResultRollupRepository
+	alertStateRepository repositories.AlertStateRepository
+	incidentRepository   repositories.IncidentRepository
+	maintenanceChecker   MaintenanceChecker
+	title                string
+	components           []models.StatusComponentDefinition
+	days                 int
+	recentIncidentDays   int
+	cacheMaxAge          time.Duration
+
+	mux       sync.Mutex
+	page      *models.StatusPage
+	expiresAt time.Time
+}
+
+var _ StatusPageService = &StatusPageServiceImpl{}
+
+func (service *StatusPageServiceImpl) GetStatusPage() (*models.StatusPage, *amerr.ErrorWithLanguage) {
+	service.mux.Lock()
+	defer service.mux.Unlock()
+
+	now := time.Now()
+	if service.page != nil && now.Before(service.expiresAt) {
+		return service.page, nil
+	}
+
+	page, err := service.build(now)
+	if err != nil {
+		rslog.Error(err)
+		return nil, amerr.GetErrInternalServer()
+	}
+	service.page = page
+	service.expiresAt = now.Add(service.cacheMaxAge)
+	return page, nil
+}
+
+func (service *StatusPageServiceImpl) CacheMaxAge() time.Duration {
+	return service.cacheMaxAge
+}
+
+func (service *StatusPageServiceImpl) build(now time.Time) (*models.StatusPage, error) {
+	page := models.NewStatusPage(service.title, now)
+	for _, definition := range service.components {
+		component := models.NewStatusComponent(definition.Name, now, service.days)
+		for _, webServiceId := range definition.WebServiceIds {
+			webService := &models.WebService{Id: webServiceId}
+			if err := service.webServiceRepository.GetById(rsdb.GetConnection(), webService); err != nil {
+				if err == rsdb.ErrRecordNotFound {
+					rslog.Warnf("status page component has an unknown web service: component='%s', webServiceId='%s'", definition.Name, webServiceId)
+					continue
+				}
+				return nil, errors.WithStack(err)
+			}
+
+			status, err := service.webServiceStatus(webService, now)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			component.SetWebServiceStatus(status)
+
+			if err := service.addCounts(component, webService, now); err != nil {
+				return nil, errors.WithStack(err)
+			}
+
+			incidents, err := service.incidentRepository.GetListInRange(rsdb.GetConnection(), webService.Id, now.AddDate(0, 0, -service.recentIncidentDays), now)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			for _, incident := range incidents {
+				page.AddIncident(component.Name, incident)
+			}
+		}
+		page.AddComponent(component)
+	}
+	page.SortIncidents()
+	return page, nil
+}
+
+func (service *StatusPageServiceImpl) webServiceStatus(webService *models.WebService, now time.Time) (models.ComponentStatus, error) {
+	alertStatuses, err := service.alertStateRepository.GetStatusesByWebServiceId(rsdb.GetConnection(), webService.Id)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	inMaintenance, err := service.maintenanceChecker.InMaintenance(webService.Id, now)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return models.NewWebServiceStatus(alertStatuses, inMaintenance), nil
+}
+
+// addCounts adds the counts of the days shown, from the daily rollups for the days rolled up,
+// and day by day like the uptime for the others, usually today.
+func (service *StatusPageServiceImpl) addCounts(component *models.StatusComponent, webService *models.WebService, now time.Time) error {
+	window := models.TimeWindow{
+		Start: now.UTC().Truncate(24*time.Hour).AddDate(0, 0, 1-service.days),
+		End:   now,
+	}
+	rolledUp, ok, rest, err := splitByRollup(service.rollupRepository, window, models.RollupDaily)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if ok {
+		counts, err := service.rollupRepository.CountByWebServicePerPeriod(rsdb.GetConnection(), models.RollupDaily, webService.Id, rolledUp.Start, rolledUp.End)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		for _, count := range counts {
+			component.AddCount(count.Start, models.TestResultCount{TotalCount: count.TotalCount, SuccessCount: count.SuccessCount})
+		}
+	}
+
+	for _, window := range rest {
+		for start := window.Start; start.Before(window.End); {
+			end := start.UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+			if end.After(window.End) {
+				end = window.End
+			}
+			count, err := countByRollup(service.rollupRepository, models.TimeWindow{Start: start, End: end}, func(window models.TimeWindow, rolledUp bool) (models.TestResultCount, error) {
+				if rolledUp {
+					return service.rollupRepository.CountByWebService(rsdb.GetConnection(), webService, window.Start, window.End)
+				}
+				return service.testResultRepository.CountByWebService(rsdb.GetConnection(), webService, window.Start, window.End)
+			})
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			component.AddCount(start, count)
+			start = end
+		}
+	}
+	return nil
+}
+
+func NewStatusPageService(
+	webServiceRepository repositories.WebServiceRepository,
+	testResultRepository repositories.TestResultRepository,
+	rollupRepository repositories.TestResultRollupRepository,
+	alertStateRepository repositories.AlertStateRepository,
+	incidentRepository repositories.IncidentRepository,
+	maintenanceChecker MaintenanceChecker,
+	config StatusPageConfig,
+) (StatusPageService, error) {
+	if rsvalid.IsZero(webServiceRepository, testResultRepository, rollupRepository, alertStateRepository, incidentRepository, maintenanceChecker, config) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "StatusPageService")
+	}
+	title := config.GetTitle()
+	if title == "" {
+		title = DefaultStatusPageTitle
+	}
+	return &StatusPageServiceImpl{
+		webServiceRepository: webServiceRepository,
+		testResultRepository: testResultRepository,
+		rollupRepository:     rollupRepository,
+		alertStateRepository: alertStateRepository,
+		incidentRepository:   incidentRepository,
+		maintenanceChecker:   maintenanceChecker,
+		title:                title,
+		components:           config.GetComponents(),
+		days:                 orDefaultInt(config.GetDays(), DefaultStatusPageDays),
+		recentIncidentDays:   orDefaultInt(config.GetRecentIncidentDays(), DefaultStatusPageRecentIncidentDays),
+		cacheMaxAge:          orDefaultDuration(config.GetCacheMaxAge(), DefaultStatusPageCacheMaxAge),
+	}, nil
+}
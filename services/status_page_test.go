@@ -0,0 +1,105 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/repositories/mocks"
+)
+
+type statusPageConfig struct{}
+
+func (statusPageConfig) GetTitle() string { return "" }
+
+func (statusPageConfig) GetComponents() []models.StatusComponentDefinition {
+	return []models.StatusComponentDefinition{{Name: "API", WebServiceIds: []string{"web-service", "unknown"}}}
+}
+
+func (statusPageConfig) GetDays() int { return 3 }
+
+func (statusPageConfig) GetRecentIncidentDays() int { return 0 }
+
+func (statusPageConfig) GetCacheMaxAge() time.Duration { return 0 }
+
+func TestStatusPageServiceImpl_build(t *testing.T) {
+	now := time.Date(2020, 3, 10, 12, 0, 0, 0, time.UTC)
+	today := time.Date(2020, 3, 10, 0, 0, 0, 0, time.UTC)
+	webService := &models.WebService{Id: "web-service"}
+
+	webServiceRepository := &mocks.WebServiceRepository{}
+	webServiceRepository.On("GetById", mock.Anything, mock.MatchedBy(func(webService *models.WebService) bool {
+		return webService.Id == "unknown"
+	})).Return(rsdb.ErrRecordNotFound)
+	webServiceRepository.On("GetById", mock.Anything, webService).Return(nil)
+
+	alertStateRepository := &mocks.AlertStateRepository{}
+	alertStateRepository.On("GetStatusesByWebServiceId", mock.Anything, "web-service").Return(map[string]models.AlertStatus{
+		"a": models.AlertStatusAlerting,
+		"b": models.AlertStatusOk,
+	}, nil)
+
+	// The days before today are rolled up daily, and today hourly up to 11:00.
+	rollupRepository := &mocks.TestResultRollupRepository{}
+	rollupRepository.On("GetWatermark", mock.Anything, models.RollupDaily).Return(today, nil)
+	rollupRepository.On("GetWatermark", mock.Anything, models.RollupHourly).Return(today.Add(11*time.Hour), nil)
+	rollupRepository.On("CountByWebServicePerPeriod", mock.Anything, models.RollupDaily, "web-service", today.AddDate(0, 0, -2), today).
+		Return([]*models.TestResultPeriodCount{{Start: today.AddDate(0, 0, -2), TotalCount: 10, SuccessCount: 5}}, nil)
+	rollupRepository.On("CountByWebService", mock.Anything, webService, today, today.Add(11*time.Hour)).
+		Return(models.TestResultCount{TotalCount: 8, SuccessCount: 8}, nil)
+	testResultRepository := &mocks.TestResultRepository{}
+	testResultRepository.On("CountByWebService", mock.Anything, webService, today.Add(11*time.Hour), now).
+		Return(models.TestResultCount{TotalCount: 2, SuccessCount: 1}, nil)
+
+	endedAt := now.Add(-time.Hour)
+	incidentRepository := &mocks.IncidentRepository{}
+	incidentRepository.On("GetListInRange", mock.Anything, "web-service", now.AddDate(0, 0, -DefaultStatusPageRecentIncidentDays), now).
+		Return([]*models.Incident{
+			{Id: "resolved", StartedAt: now.Add(-2 * time.Hour), EndedAt: &endedAt, Duration: 3600},
+			{Id: "ongoing", StartedAt: now.Add(-30 * time.Minute)},
+		}, nil)
+
+	service, err := NewStatusPageService(
+		webServiceRepository,
+		testResultRepository,
+		rollupRepository,
+		alertStateRepository,
+		incidentRepository,
+		&fakeMaintenanceChecker{},
+		&statusPageConfig{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, DefaultStatusPageCacheMaxAge, service.CacheMaxAge())
+
+	page, err := service.(*StatusPageServiceImpl).build(now)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, DefaultStatusPageTitle, page.Title)
+	assert.Equal(t, models.ComponentDegraded, page.Status)
+	if assert.Len(t, page.Components, 1) {
+		component := page.Components[0]
+		assert.Equal(t, models.ComponentDegraded, component.Status)
+		if assert.Len(t, component.Days, 3) {
+			assert.Equal(t, 0.5, *component.Days[0].Uptime)
+			assert.Nil(t, component.Days[1].Uptime)
+			assert.Equal(t, 0.9, *component.Days[2].Uptime)
+		}
+		assert.Equal(t, 0.7, *component.Uptime)
+	}
+	if assert.Len(t, page.OngoingIncidents, 1) {
+		assert.Equal(t, "ongoing", page.OngoingIncidents[0].Id)
+		assert.Equal(t, "API", page.OngoingIncidents[0].Component)
+	}
+	if assert.Len(t, page.RecentIncidents, 1) {
+		assert.Equal(t, "resolved", page.RecentIncidents[0].Id)
+	}
+	rollupRepository.AssertExpectations(t)
+	testResultRepository.AssertExpectations(t)
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+)
+
+const (
+	DefaultStreamBufferSize        = 64
+	DefaultStreamHeartbeatInterval = 15 * time.Second
+)
+
+type StreamConfig interface {
+	GetBufferSize() int
+	GetHeartbeatInterval() time.Duration
+}
+
+// ResultHub fans the results and the alert state changes out to the clients of the live stream.
+// Only the events of the tests executed by this replica are published.
+type ResultHub interface {
+	Publish(event models.StreamEvent)
+	Subscribe(filter models.StreamFilter) *ResultSubscription
+	// HeartbeatInterval is how often an idle stream is kept alive.
+	HeartbeatInterval() time.Duration
+	// Close closes every subscription, so that the open streams end and the server can shut down.
+	// A subscription made afterwards is closed at once.
+	Close()
+}
+
+// ResultSubscription receives the events matching its filter until it is closed.
+type ResultSubscription struct {
+	hub    *ResultStreamHub
+	filter models.StreamFilter
+	events chan models.StreamEvent
+	lagged bool
+}
+
+// Events is closed when the subscription is closed, or when it lagged behind.
+func (subscription *ResultSubscription) Events() <-chan models.StreamEvent {
+	return subscription.events
+}
+
+// Lagged reports whether the subscription was dropped because its buffer was full.
+// It is only meaningful once Events is closed.
+func (subscription *ResultSubscription) Lagged() bool {
+	subscription.hub.mux.Lock()
+	defer subscription.hub.mux.Unlock()
+	return subscription.lagged
+}
+
+func (subscription *ResultSubscription) Close() {
+	subscription.hub.unsubscribe(subscription, false)
+}
+
+// ResultStreamHub never waits for a client: a subscription whose buffer is full is dropped,
+// so that a slow client neither blocks the checks nor the other clients. It reconnects and
+// catches up from the results endpoints.
+type ResultStreamHub struct {
+	bufferSize        int
+	heartbeatInterval time.Duration
+
+	mux           sync.Mutex
+	subscriptions map[*ResultSubscription]bool
+	isClosed      bool
+}
+
+var _ ResultHub = &ResultStreamHub{}
+
+func (hub *ResultStreamHub) Publish(event models.StreamEvent) {
+	hub.mux.Lock()
+	defer hub.mux.Unlock()
+	for subscription := range hub.subscriptions {
+		if !subscription.filter.Matches(event) {
+			continue
+		}
+		select {
+		case subscription.events <- event:
+		default:
+			rslog.Warnf("dropped lagging stream subscription: filter='%+v', bufferSize=%d", subscription.filter, hub.bufferSize)
+			hub.remove(subscription, true)
+		}
+	}
+}
+
+func (hub *ResultStreamHub) Subscribe(filter models.StreamFilter) *ResultSubscription {
+	subscription := &ResultSubscription{
+		hub:    hub,
+		filter: filter,
+		events: make(chan models.StreamEvent, hub.bufferSize),
+	}
+	hub.mux.Lock()
+	defer hub.mux.Unlock()
+	if hub.isClosed {
+		close(subscription.events)
+		return subscription
+	}
+	hub.subscriptions[subscription] = true
+	streamSubscriptionsMetric.Set(float64(len(hub.subscriptions)))
+	return subscription
+}
+
+func (hub *ResultStreamHub) Close() {
+	hub.mux.Lock()
+	defer hub.mux.Unlock()
+	hub.isClosed = true
+	for subscription := range hub.subscriptions {
+		hub.remove(subscription, false)
+	}
+}
+
+func (hub *ResultStreamHub) HeartbeatInterval() time.Duration {
+	return hub.heartbeatInterval
+}
+
+func (hub *ResultStreamHub) unsubscribe(subscription *ResultSubscription, lagged bool) {
+	hub.mux.Lock()
+	defer hub.mux.Unlock()
+	hub.remove(subscription, lagged)
+}
+
+// remove closes the subscription if it is still subscribed. The caller holds the lock.
+func (hub *ResultStreamHub) remove(subscription *ResultSubscription, lagged bool) {
+	if !hub.subscriptions[subscription] {
+		return
+	}
+	delete(hub.subscriptions, subscription)
+	subscription.lagged = lagged
+	close(subscription.events)
+	streamSubscriptionsMetric.Set(float64(len(hub.subscriptions)))
+	if lagged {
+		streamLaggedMetric.Inc()
+	}
+}
+
+func NewResultStreamHub(config StreamConfig) (ResultHub, error) {
+	if rsvalid.IsZero(config) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "ResultHub")
+	}
+	return &ResultStreamHub{
+		bufferSize:        orDefaultInt(config.GetBufferSize(), DefaultStreamBufferSize),
+		heartbeatInterval: orDefaultDuration(config.GetHeartbeatInterval(), DefaultStreamHeartbeatInterval),
+		subscriptions:     make(map[*ResultSubscription]bool),
+	}, nil
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/realsangil/apimonitor/models"
+)
+
+type streamConfig struct {
+	bufferSize int
+}
+
+func (config streamConfig) GetBufferSize() int { return config.bufferSize }
+
+func (streamConfig) GetHeartbeatInterval() time.Duration { return time.Minute }
+
+func newTestResultHub(t *testing.T) ResultHub {
+	hub, err := NewResultStreamHub(&streamConfig{bufferSize: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hub
+}
+
+func TestResultStreamHub_Publish(t *testing.T) {
+	hub, err := NewResultStreamHub(&streamConfig{bufferSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	all := hub.Subscribe(models.StreamFilter{})
+	defer all.Close()
+	webService := hub.Subscribe(models.StreamFilter{WebServiceId: "web-service"})
+	defer webService.Close()
+	test := hub.Subscribe(models.StreamFilter{WebServiceId: "web-service", TestId: "b"})
+	defer test.Close()
+
+	for _, testId := range []string{"a", "b"} {
+		hub.Publish(models.NewResultStreamEvent(
+			&models.Test{Id: testId, WebServiceId: "web-service"},
+			&models.TestResult{TestId: testId},
+		))
+	}
+	hub.Publish(models.NewResultStreamEvent(&models.Test{Id: "c", WebServiceId: "other"}, &models.TestResult{TestId: "c"}))
+
+	// The subscription to every event lagged on the third event, and was dropped.
+	var testIds []string
+	for event := range all.Events() {
+		testIds = append(testIds, event.TestId)
+	}
+	assert.Equal(t, []string{"a", "b"}, testIds)
+	assert.True(t, all.Lagged())
+
+	assert.Len(t, webService.Events(), 2)
+	if assert.Len(t, test.Events(), 1) {
+		event := <-test.Events()
+		assert.Equal(t, models.StreamEventResult, event.Type)
+		assert.Equal(t, "b", event.Result.TestId)
+	}
+
+	test.Close()
+	test.Close()
+	_, ok := <-test.Events()
+	assert.False(t, ok)
+	assert.False(t, test.Lagged())
+}
+
+func TestResultStreamHub_Close(t *testing.T) {
+	hub := newTestResultHub(t)
+	subscription := hub.Subscribe(models.StreamFilter{})
+
+	hub.Close()
+	_, ok := <-subscription.Events()
+	assert.False(t, ok)
+	assert.False(t, subscription.Lagged())
+	subscription.Close()
+
+	// The stream of a client connecting during the shutdown ends at once.
+	late := hub.Subscribe(models.StreamFilter{})
+	_, ok = <-late.Events()
+	assert.False(t, ok)
+	late.Close()
+	hub.Publish(models.NewResultStreamEvent(&models.Test{Id: "a"}, &models.TestResult{TestId: "a"}))
+}
@@ -21,11 +21,15 @@ type TestService interface {
 	GetTestList(request models.TestListRequest) (*rsmodels.PaginatedList, *amerr.ErrorWithLanguage)
 	UpdateTestById(test *models.Test, request models.TestRequest) *amerr.ErrorWithLanguage
 	ExecuteTest(test *models.Test) *amerr.ErrorWithLanguage
+	RunTest(test *models.Test) (*models.TestResult, *amerr.ErrorWithLanguage)
+	DryRunTest(webService *models.WebService, request models.TestRequest) (*models.TestResult, *amerr.ErrorWithLanguage)
 }
 
 type TestServiceImpl struct {
 	testRepository      repositories.TestRepository
 	testScheduleManager ScheduleManager
+	incidentRecorder    IncidentRecorder
+	bindingRepository   repositories.NotificationChannelBindingRepository
 }
 
 func (service *TestServiceImpl) CreateTest(webService *models.WebService, request models.TestRequest) (*models.Test, *amerr.ErrorWithLanguage) {
@@ -96,6 +100,15 @@ func (service *TestServiceImpl) DeleteTestById(test *models.Test) *amerr.ErrorWi
 		return amerr.GetErrInternalServer()
 	}
 
+	// The test is recovered from its incident first, since deleting it also deletes it from the incident,
+	// which would be left open forever otherwise.
+	service.incidentRecorder.Remove(test)
+
+	if err := service.bindingRepository.DeleteByTest(rsdb.GetConnection(), test.Id); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrInternalServer()
+	}
+
 	if err := service.testRepository.DeleteById(rsdb.GetConnection(), test); err != nil {
 		rslog.Error(err)
 		return amerr.GetErrInternalServer()
@@ -184,12 +197,65 @@ func (service *TestServiceImpl) ExecuteTest(test *models.Test) *amerr.ErrorWithL
 	return nil
 }
 
-func NewTestService(testRepository repositories.TestRepository, testScheduleManager ScheduleManager) (TestService, error) {
-	if rsvalid.IsZero(testRepository, testScheduleManager) {
+func (service *TestServiceImpl) RunTest(test *models.Test) (*models.TestResult, *amerr.ErrorWithLanguage) {
+	if err := service.GetTestById(test); err != nil {
+		return nil, err
+	}
+
+	result, err := service.testScheduleManager.RunTest(test)
+	if err != nil {
+		switch err {
+		case rsdb.ErrForeignKeyConstraint:
+			return nil, amerr.GetErrorsFromCode(amerr.ErrTestNotFound)
+		default:
+			rslog.Error(err)
+			return nil, amerr.GetErrInternalServer()
+		}
+	}
+
+	return result, nil
+}
+
+func (service *TestServiceImpl) DryRunTest(webService *models.WebService, request models.TestRequest) (*models.TestResult, *amerr.ErrorWithLanguage) {
+	if rsvalid.IsZero(webService) {
+		rslog.Error(errors.Wrap(rserrors.ErrInvalidParameter, "WebService"))
+		return nil, amerr.GetErrInternalServer()
+	}
+
+	if rsvalid.IsZero(request.Schedule) {
+		request.Schedule = models.ScheduleDaily
+	}
+
+	test, err := models.NewTest(webService, request)
+	if err != nil {
+		rslog.Error(err)
+		return nil, amerr.GetErrorsFromCode(amerr.ErrBadRequest)
+	}
+	test.Id = ""
+	test.WebService = webService
+
+	result, err := service.testScheduleManager.DryRunTest(test)
+	if err != nil {
+		rslog.Error(err)
+		return nil, amerr.GetErrorsFromCode(amerr.ErrTestExecutionFailed)
+	}
+
+	return result, nil
+}
+
+func NewTestService(
+	testRepository repositories.TestRepository,
+	testScheduleManager ScheduleManager,
+	incidentRecorder IncidentRecorder,
+	bindingRepository repositories.NotificationChannelBindingRepository,
+) (TestService, error) {
+	if rsvalid.IsZero(testRepository, testScheduleManager, incidentRecorder, bindingRepository) {
 		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "TestService")
 	}
 	return &TestServiceImpl{
 		testRepository:      testRepository,
 		testScheduleManager: testScheduleManager,
+		incidentRecorder:    incidentRecorder,
+		bindingRepository:   bindingRepository,
 	}, nil
 }
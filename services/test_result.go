@@ -1,10 +1,17 @@
 package services
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+
 	"github.com/realsangil/apimonitor/models"
 	"github.com/realsangil/apimonitor/pkg/amerr"
 	"github.com/realsangil/apimonitor/pkg/rsdb"
 	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
 	"github.com/realsangil/apimonitor/pkg/rsmodels"
 	"github.com/realsangil/apimonitor/pkg/rsvalid"
 	"github.com/realsangil/apimonitor/repositories"
@@ -13,6 +20,8 @@ import (
 type TestResultService interface {
 	GetResultListByWebServiceId(webService *models.WebService, request models.TestResultListRequest) (*rsmodels.PaginatedList, *amerr.ErrorWithLanguage)
 	GetResultListByTestId(test *models.Test, request models.TestResultListRequest) (*rsmodels.PaginatedList, *amerr.ErrorWithLanguage)
+	// ExportResults writes the results of the request to w as they are read, in the format of the request.
+	ExportResults(request models.TestResultExportRequest, w io.Writer) *amerr.ErrorWithLanguage
 }
 
 type TestResultServiceImpl struct {
@@ -45,6 +54,42 @@ func (service *TestResultServiceImpl) GetResultListByTestId(test *models.Test, r
 	return list, nil
 }
 
+func (service *TestResultServiceImpl) ExportResults(request models.TestResultExportRequest, w io.Writer) *amerr.ErrorWithLanguage {
+	var err error
+	switch request.Format {
+	case models.ExportNDJSON:
+		err = service.exportNDJSON(request, w)
+	default:
+		err = service.exportCSV(request, w)
+	}
+	if err != nil {
+		rslog.Error(err)
+		return amerr.GetErrInternalServer()
+	}
+	return nil
+}
+
+func (service *TestResultServiceImpl) exportCSV(request models.TestResultExportRequest, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(models.TestResultExportHeader); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := service.testResultRepository.ScanExport(rsdb.GetConnection(), request, func(export *models.TestResultExport) error {
+		return writer.Write(export.CSVRecord())
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	writer.Flush()
+	return errors.WithStack(writer.Error())
+}
+
+func (service *TestResultServiceImpl) exportNDJSON(request models.TestResultExportRequest, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	return errors.WithStack(service.testResultRepository.ScanExport(rsdb.GetConnection(), request, func(export *models.TestResultExport) error {
+		return encoder.Encode(export)
+	}))
+}
+
 func NewTestResultService(testResultRepository repositories.TestResultRepository) (TestResultService, error) {
 	if rsvalid.IsZero(testResultRepository) {
 		return nil, rserrors.ErrInvalidParameter
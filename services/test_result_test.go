@@ -0,0 +1,70 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/repositories/mocks"
+)
+
+func TestTestResultServiceImpl_ExportResults(t *testing.T) {
+	testedAt := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	exports := []*models.TestResultExport{
+		{Id: "a", WebServiceId: "web-service", TestId: "test", TestedAt: testedAt, IsSuccess: true, StatusCode: 200, ResponseTime: 10},
+		{Id: "b", WebServiceId: "web-service", TestId: "test", TestedAt: testedAt.Add(time.Minute), StatusCode: 500, ResponseTime: 20},
+	}
+	scan := func(args mock.Arguments) {
+		fn := args.Get(2).(func(export *models.TestResultExport) error)
+		for _, export := range exports {
+			if err := fn(export); err != nil {
+				return
+			}
+		}
+	}
+
+	tests := []struct {
+		format models.ExportFormat
+		want   string
+	}{
+		{
+			format: models.ExportCSV,
+			want: "id,web_service_id,test_id,tested_at,is_success,status_code,response_time,in_maintenance,failed_assertions\n" +
+				"a,web-service,test,2020-01-01T00:00:00Z,true,200,10,false,\n" +
+				"b,web-service,test,2020-01-01T00:01:00Z,false,500,20,false,\n",
+		},
+		{
+			format: models.ExportNDJSON,
+			want: `{"id":"a","webServiceId":"web-service","testId":"test","testedAt":"2020-01-01T00:00:00Z","isSuccess":true,"statusCode":200,"responseTime":10,"inMaintenance":false,"assertions":null}` + "\n" +
+				`{"id":"b","webServiceId":"web-service","testId":"test","testedAt":"2020-01-01T00:01:00Z","isSuccess":false,"statusCode":500,"responseTime":20,"inMaintenance":false,"assertions":null}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			request := models.TestResultExportRequest{Format: tt.format, WebServiceId: "web-service", From: testedAt, To: testedAt.Add(time.Hour)}
+			repository := &mocks.TestResultRepository{}
+			repository.On("ScanExport", mock.Anything, request, mock.Anything).Run(scan).Return(nil)
+			service, err := NewTestResultService(repository)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			buf := &bytes.Buffer{}
+			assert.Nil(t, service.ExportResults(request, buf))
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+
+	repository := &mocks.TestResultRepository{}
+	repository.On("ScanExport", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("connection lost"))
+	service, err := NewTestResultService(repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, service.ExportResults(models.TestResultExportRequest{Format: models.ExportCSV}, &bytes.Buffer{}))
+}
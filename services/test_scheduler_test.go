@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rshttp"
+	"github.com/realsangil/apimonitor/repositories/mocks"
+)
+
+type fakeMaintenanceChecker struct{}
+
+func (fakeMaintenanceChecker) InMaintenance(string, time.Time) (bool, error) { return false, nil }
+func (fakeMaintenanceChecker) GetMaintenanceWindows(string, time.Time, time.Time) ([]models.TimeWindow, error) {
+	return nil, nil
+}
+
+type fakeAlertManager struct{}
+
+func (fakeAlertManager) HandleResult(*models.Test, *models.TestResult) {}
+
+func (fakeAlertManager) Shutdown(context.Context) error { return nil }
+
+type fakeResultWriter struct {
+	ResultWriter
+	written chan *models.TestResult
+}
+
+func (writer fakeResultWriter) Write(result *models.TestResult) { writer.written <- result }
+
+type fakeCoordinator struct {
+	Coordinator
+}
+
+func (fakeCoordinator) Owns(string) bool { return true }
+
+func (fakeCoordinator) IsLeader() bool { return true }
+
+func TestTestScheduler_CatchUp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	serverUrl, _ := url.Parse(server.URL)
+
+	missedAt := time.Now().Add(-90 * time.Minute)
+
+	tests := []struct {
+		name          string
+		catchUpPolicy string
+		wantRun       bool
+		wantNextRunAt time.Time
+	}{
+		{
+			name:          "once",
+			catchUpPolicy: CatchUpPolicyOnce,
+			wantRun:       true,
+			wantNextRunAt: missedAt.Add(2 * time.Hour),
+		},
+		{
+			name:          "skip",
+			catchUpPolicy: CatchUpPolicySkip,
+			wantRun:       false,
+			wantNextRunAt: missedAt.Add(2 * time.Hour),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test := &models.Test{
+				Id:          "test",
+				WebService:  &models.WebService{Schema: serverUrl.Scheme, Host: serverUrl.Host},
+				Path:        "/",
+				Method:      rshttp.MethodGet,
+				ContentType: "application/json",
+				Schedule:    models.ScheduleHourly,
+				NextRunAt:   &missedAt,
+			}
+
+			saved := make(chan time.Time, 2)
+			testRepository := &mocks.TestRepository{}
+			testRepository.On("UpdateRunAt", mock.Anything, "test", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+				saved <- args.Get(3).(time.Time)
+			})
+
+			written := make(chan *models.TestResult, 1)
+			resultWriter := fakeResultWriter{written: written}
+
+			scheduler, err := NewTestScheduler(test, testRepository, &fakeMaintenanceChecker{}, &fakeAlertManager{}, resultWriter, newTestResultHub(t), &fakeCoordinator{}, tt.catchUpPolicy, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			go func() {
+				_ = scheduler.Run()
+			}()
+			defer scheduler.Close()
+
+			select {
+			case nextRunAt := <-saved:
+				assert.True(t, tt.wantNextRunAt.Equal(nextRunAt), "nextRunAt='%v'", nextRunAt)
+			case <-time.After(5 * time.Second):
+				t.Fatal("schedule state was not saved")
+			}
+
+			select {
+			case result := <-written:
+				assert.True(t, tt.wantRun)
+				assert.Equal(t, http.StatusOK, result.StatusCode)
+			case <-time.After(500 * time.Millisecond):
+				assert.False(t, tt.wantRun)
+			}
+		})
+	}
+}
+
+func TestTestScheduleManager_RunTest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	serverUrl, _ := url.Parse(server.URL)
+
+	refused := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	refusedUrl, _ := url.Parse(refused.URL)
+	refused.Close()
+
+	tests := []struct {
+		name        string
+		host        string
+		createErr   error
+		wantCreate  bool
+		wantWritten bool
+		wantErr     error
+	}{
+		{
+			name:       "stores the failed result",
+			host:       serverUrl.Host,
+			wantCreate: true,
+		},
+		{
+			name:        "returns the result of a test which could not be executed",
+			host:        refusedUrl.Host,
+			wantWritten: true,
+		},
+		{
+			name:        "returns the result which could not be stored",
+			host:        serverUrl.Host,
+			createErr:   rsdb.ErrInvalidData,
+			wantCreate:  true,
+			wantWritten: true,
+		},
+		{
+			name:       "test deleted in between",
+			host:       serverUrl.Host,
+			createErr:  rsdb.ErrForeignKeyConstraint,
+			wantCreate: true,
+			wantErr:    rsdb.ErrForeignKeyConstraint,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test := &models.Test{
+				Id:         "test",
+				WebService: &models.WebService{Schema: serverUrl.Scheme, Host: tt.host},
+				Path:       "/",
+				Method:     rshttp.MethodGet,
+				Assertion:  models.AssertionV1{StatusCode: http.StatusOK},
+			}
+			testResultRepository := &mocks.TestResultRepository{}
+			testResultRepository.On("Create", mock.Anything, mock.Anything).Return(tt.createErr)
+			written := make(chan *models.TestResult, 1)
+
+			manager := &TestScheduleManager{
+				testResultRepository: testResultRepository,
+				maintenanceChecker:   &fakeMaintenanceChecker{},
+				alertManager:         &fakeAlertManager{},
+				resultWriter:         fakeResultWriter{written: written},
+				resultHub:            newTestResultHub(t),
+			}
+			result, err := manager.RunTest(test)
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantCreate {
+				testResultRepository.AssertNumberOfCalls(t, "Create", 1)
+			} else {
+				testResultRepository.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+			}
+			if tt.wantErr != nil {
+				assert.Nil(t, result)
+				return
+			}
+			if assert.NotNil(t, result) {
+				assert.False(t, result.IsSuccess)
+			}
+			select {
+			case w := <-written:
+				assert.True(t, tt.wantWritten)
+				assert.Equal(t, result, w)
+			default:
+				assert.False(t, tt.wantWritten)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rstrace"
+)
+
+const (
+	DefaultTracingServiceName = "apimonitor"
+	DefaultTracingBatchSize   = 100
+	DefaultTracingQueueSize   = 2048
+	DefaultTracingInterval    = 5 * time.Second
+)
+
+type TracingConfig interface {
+	GetEndpoint() string
+	GetServiceName() string
+	GetHeaders() map[string]string
+	GetBatchSize() int
+	GetQueueSize() int
+	GetInterval() time.Duration
+	GetTimeout() time.Duration
+}
+
+// NewTracer returns a tracer exporting to the OTLP endpoint of the config,
+// or one which does not record if no endpoint is configured.
+func NewTracer(config TracingConfig) *rstrace.Tracer {
+	endpoint := config.GetEndpoint()
+	if endpoint == "" {
+		return rstrace.NewTracer(nil)
+	}
+	serviceName := config.GetServiceName()
+	if serviceName == "" {
+		serviceName = DefaultTracingServiceName
+	}
+	return rstrace.NewBatchTracer(
+		rstrace.NewOTLPExporter(endpoint, serviceName, config.GetHeaders(), config.GetTimeout()),
+		orDefaultInt(config.GetBatchSize(), DefaultTracingBatchSize),
+		orDefaultInt(config.GetQueueSize(), DefaultTracingQueueSize),
+		orDefaultDuration(config.GetInterval(), DefaultTracingInterval),
+	)
+}
+
+// startCheckSpan starts the span of a check of the test on the default tracer.
+func startCheckSpan(test *models.Test) (context.Context, *rstrace.Span) {
+	return rstrace.DefaultTracer().Start(
+		context.Background(),
+		fmt.Sprintf("check %s", test.Method.String()),
+		rstrace.SpanKindClient,
+		rstrace.String("apimonitor.test.id", test.Id),
+		rstrace.String("apimonitor.web_service.id", test.WebServiceId),
+		rstrace.String("http.method", test.Method.String()),
+	)
+}
+
+// endCheckSpan records the outcome of the check and ends its span.
+// A check which failed its assertions is an error, like one whose request could not be sent.
+func endCheckSpan(span *rstrace.Span, result *models.TestResult, execErr error) {
+	defer span.End()
+
+	span.SetAttributes(
+		rstrace.Bool("apimonitor.check.success", result.IsSuccess),
+		rstrace.Bool("apimonitor.check.in_maintenance", result.InMaintenance),
+		rstrace.Int64("apimonitor.check.response_time_ms", result.ResponseTime),
+	)
+	if result.StatusCode != 0 {
+		span.SetAttributes(rstrace.Int("http.status_code", result.StatusCode))
+	}
+
+	failures := result.Assertions.Failures()
+	if len(result.Assertions) > 0 {
+		fields := make([]string, 0, len(failures))
+		for _, failure := range failures {
+			fields = append(fields, failure.Field)
+		}
+		span.SetAttributes(
+			rstrace.Bool("apimonitor.assertion.passed", len(failures) == 0),
+			rstrace.Int("apimonitor.assertion.count", len(result.Assertions)),
+			rstrace.String("apimonitor.assertion.failed_fields", strings.Join(fields, ",")),
+		)
+	}
+
+	switch {
+	case execErr != nil:
+		span.SetStatus(rstrace.StatusError, execErr.Error())
+	case !result.IsSuccess:
+		span.SetStatus(rstrace.StatusError, fmt.Sprintf("%d assertion(s) failed", len(failures)))
+	default:
+		span.SetStatus(rstrace.StatusOk, "")
+	}
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/rshttp"
+	"github.com/realsangil/apimonitor/pkg/rstrace"
+)
+
+func TestRunTest_Tracing(t *testing.T) {
+	traceparents := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents <- r.Header.Get(rstrace.TraceparentHeader)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	serverUrl, _ := url.Parse(server.URL)
+
+	exporter := rstrace.NewInMemoryExporter()
+	defer rstrace.SetDefaultTracer(rstrace.DefaultTracer())
+	rstrace.SetDefaultTracer(rstrace.NewTracer(exporter))
+
+	test := &models.Test{
+		Id:           "test",
+		WebServiceId: "web-service",
+		WebService:   &models.WebService{Schema: serverUrl.Scheme, Host: serverUrl.Host},
+		Path:         "/health",
+		Method:       rshttp.MethodGet,
+		ContentType:  "application/json",
+		Parameters:   models.Parameters{Header: map[string]string{"Accept": "application/json"}},
+		Assertion:    models.AssertionV1{StatusCode: http.StatusOK},
+	}
+	result, err := runTest(test, &fakeMaintenanceChecker{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, result.IsSuccess)
+	assert.Equal(t, map[string]string{"Accept": "application/json"}, test.Parameters.Header)
+
+	spans := exporter.GetSpans()
+	if !assert.Len(t, spans, 1) {
+		return
+	}
+	span := spans[0]
+	assert.Equal(t, "check GET", span.Name)
+	assert.Equal(t, rstrace.SpanKindClient, span.Kind)
+	assert.Equal(t, span.SpanContext.Traceparent(), <-traceparents)
+	assert.Equal(t, "test", span.Attribute("apimonitor.test.id"))
+	assert.Equal(t, "web-service", span.Attribute("apimonitor.web_service.id"))
+	assert.Equal(t, server.URL+"/health", span.Attribute("http.url"))
+	assert.Equal(t, "GET", span.Attribute("http.method"))
+	assert.Equal(t, int64(http.StatusInternalServerError), span.Attribute("http.status_code"))
+	assert.Equal(t, false, span.Attribute("apimonitor.check.success"))
+	assert.Equal(t, false, span.Attribute("apimonitor.assertion.passed"))
+	assert.Equal(t, "statusCode", span.Attribute("apimonitor.assertion.failed_fields"))
+	assert.Equal(t, rstrace.Status{Code: rstrace.StatusError, Message: "1 assertion(s) failed"}, span.Status)
+}
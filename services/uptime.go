@@ -0,0 +1,141 @@
+package services
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/realsangil/apimonitor/models"
+	"github.com/realsangil/apimonitor/pkg/amerr"
+	"github.com/realsangil/apimonitor/pkg/rsdb"
+	"github.com/realsangil/apimonitor/pkg/rserrors"
+	"github.com/realsangil/apimonitor/pkg/rslog"
+	"github.com/realsangil/apimonitor/pkg/rsvalid"
+	"github.com/realsangil/apimonitor/repositories"
+)
+
+var _ UptimeService = &UptimeServiceImpl{}
+
+// UptimeService reports the availability of the tests and the web services.
+// The results are counted in the database, from the hourly rollups for the hours already rolled up,
+// and the downtime is read from the incidents.
+type UptimeService interface {
+	GetTestUptime(test *models.Test, request models.UptimeRequest) (*models.Uptime, *amerr.ErrorWithLanguage)
+	GetWebServiceUptime(webService *models.WebService, request models.UptimeRequest) (*models.Uptime, *amerr.ErrorWithLanguage)
+}
+
+type UptimeServiceImpl struct {
+	testResultRepository   repositories.TestResultRepository
+	rollupRepository       repositories.TestResultRollupRepository
+	incidentRepository     repositories.IncidentRepository
+	incidentTestRepository repositories.IncidentTestRepository
+	maintenanceChecker     MaintenanceChecker
+}
+
+func (service *UptimeServiceImpl) GetTestUptime(test *models.Test, request models.UptimeRequest) (*models.Uptime, *amerr.ErrorWithLanguage) {
+	count, err := countByRollup(service.rollupRepository, request.Window(), func(window models.TimeWindow, rolledUp bool) (models.TestResultCount, error) {
+		if rolledUp {
+			return service.rollupRepository.CountByTest(rsdb.GetConnection(), test, window.Start, window.End)
+		}
+		return service.testResultRepository.CountByTest(rsdb.GetConnection(), test, window.Start, window.End)
+	})
+	if err != nil {
+		rslog.Error(err)
+		return nil, amerr.GetErrInternalServer()
+	}
+
+	incidentTests, err := service.incidentTestRepository.GetListInRange(rsdb.GetConnection(), test.Id, request.From, request.To)
+	if err != nil {
+		rslog.Error(err)
+		return nil, amerr.GetErrInternalServer()
+	}
+	outages := make([]models.TimeWindow, 0, len(incidentTests))
+	for _, incidentTest := range incidentTests {
+		outages = append(outages, incidentTest.Window())
+	}
+
+	return service.uptime(test.WebServiceId, request, count, outages)
+}
+
+func (service *UptimeServiceImpl) GetWebServiceUptime(webService *models.WebService, request models.UptimeRequest) (*models.Uptime, *amerr.ErrorWithLanguage) {
+	count, err := countByRollup(service.rollupRepository, request.Window(), func(window models.TimeWindow, rolledUp bool) (models.TestResultCount, error) {
+		if rolledUp {
+			return service.rollupRepository.CountByWebService(rsdb.GetConnection(), webService, window.Start, window.End)
+		}
+		return service.testResultRepository.CountByWebService(rsdb.GetConnection(), webService, window.Start, window.End)
+	})
+	if err != nil {
+		rslog.Error(err)
+		return nil, amerr.GetErrInternalServer()
+	}
+
+	incidents, err := service.incidentRepository.GetListInRange(rsdb.GetConnection(), webService.Id, request.From, request.To)
+	if err != nil {
+		rslog.Error(err)
+		return nil, amerr.GetErrInternalServer()
+	}
+	outages := make([]models.TimeWindow, 0, len(incidents))
+	for _, incident := range incidents {
+		outages = append(outages, incident.Window())
+	}
+
+	return service.uptime(webService.Id, request, count, outages)
+}
+
+// countByRollup sums the counts of the hours of the window rolled up and of the results around them.
+func countByRollup(
+	rollupRepository repositories.TestResultRollupRepository,
+	window models.TimeWindow,
+	countWindow func(window models.TimeWindow, rolledUp bool) (models.TestResultCount, error),
+) (models.TestResultCount, error) {
+	var count models.TestResultCount
+	rolledUp, ok, rest, err := splitByRollup(rollupRepository, window, models.RollupHourly)
+	if err != nil {
+		return count, errors.WithStack(err)
+	}
+
+	if ok {
+		if count, err = countWindow(rolledUp, true); err != nil {
+			return count, errors.WithStack(err)
+		}
+	}
+	for _, window := range rest {
+		c, err := countWindow(window, false)
+		if err != nil {
+			return count, errors.WithStack(err)
+		}
+		count.TotalCount += c.TotalCount
+		count.SuccessCount += c.SuccessCount
+	}
+	return count, nil
+}
+
+func (service *UptimeServiceImpl) uptime(webServiceId string, request models.UptimeRequest, count models.TestResultCount, outages []models.TimeWindow) (*models.Uptime, *amerr.ErrorWithLanguage) {
+	maintenances, err := service.maintenanceChecker.GetMaintenanceWindows(webServiceId, request.From, request.To)
+	if err != nil {
+		rslog.Error(err)
+		return nil, amerr.GetErrInternalServer()
+	}
+
+	uptime := models.NewUptime(request, count, outages, maintenances, time.Now())
+	return &uptime, nil
+}
+
+func NewUptimeService(
+	testResultRepository repositories.TestResultRepository,
+	rollupRepository repositories.TestResultRollupRepository,
+	incidentRepository repositories.IncidentRepository,
+	incidentTestRepository repositories.IncidentTestRepository,
+	maintenanceChecker MaintenanceChecker,
+) (UptimeService, error) {
+	if rsvalid.IsZero(testResultRepository, rollupRepository, incidentRepository, incidentTestRepository, maintenanceChecker) {
+		return nil, errors.Wrap(rserrors.ErrInvalidParameter, "UptimeService")
+	}
+	return &UptimeServiceImpl{
+		testResultRepository:   testResultRepository,
+		rollupRepository:       rollupRepository,
+		incidentRepository:     incidentRepository,
+		incidentTestRepository: incidentTestRepository,
+		maintenanceChecker:     maintenanceChecker,
+	}, nil
+}
@@ -24,6 +24,7 @@ type WebServiceServiceImpl struct {
 	webServiceRepository repositories.WebServiceRepository
 	testRepository       repositories.TestRepository
 	testScheduleManager  ScheduleManager
+	bindingRepository    repositories.NotificationChannelBindingRepository
 }
 
 func (service *WebServiceServiceImpl) CreateWebService(request models.WebServiceRequest) (*models.WebService, *amerr.ErrorWithLanguage) {
@@ -77,6 +78,12 @@ func (service *WebServiceServiceImpl) DeleteWebServiceById(webService *models.We
 		return err
 	}
 
+	// The bindings are deleted first, since the tests of the web service are gone once it is deleted.
+	if err := service.bindingRepository.DeleteByWebService(rsdb.GetConnection(), webService.Id); err != nil {
+		rslog.Error(err)
+		return amerr.GetErrInternalServer()
+	}
+
 	if err := service.webServiceRepository.DeleteById(rsdb.GetConnection(), webService); err != nil {
 		switch err {
 		case rsdb.ErrRecordNotFound:
@@ -171,13 +178,15 @@ func NewWebServiceService(
 	webServiceRepository repositories.WebServiceRepository,
 	testRepository repositories.TestRepository,
 	testScheduleManager ScheduleManager,
+	bindingRepository repositories.NotificationChannelBindingRepository,
 ) (WebServiceService, error) {
-	if rsvalid.IsZero(webServiceRepository, testRepository, testScheduleManager) {
+	if rsvalid.IsZero(webServiceRepository, testRepository, testScheduleManager, bindingRepository) {
 		return nil, rserrors.ErrInvalidParameter
 	}
 	return &WebServiceServiceImpl{
 		webServiceRepository: webServiceRepository,
 		testRepository:       testRepository,
 		testScheduleManager:  testScheduleManager,
+		bindingRepository:    bindingRepository,
 	}, nil
 }